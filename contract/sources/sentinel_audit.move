@@ -14,6 +14,12 @@ module lazarus_protocol::sentinel_audit {
     /// Error: invalid policy version.
     const E_INVALID_POLICY_VERSION: u64 = 3;
 
+    /// Error: action template hash is already approved.
+    const E_ALREADY_APPROVED: u64 = 4;
+
+    /// Error: action template hash is not approved.
+    const E_NOT_APPROVED: u64 = 5;
+
     /// Shared registry that defines who can anchor records and policy metadata.
     public struct Registry has key {
         id: sui::object::UID,
@@ -36,6 +42,20 @@ module lazarus_protocol::sentinel_audit {
         target: address,
     }
 
+    /// Dynamic-field key under the Registry marking a pre-approved action
+    /// template. Stored as a dynamic field so existing registries need no
+    /// migration.
+    public struct ApprovedAction has copy, drop, store {
+        hash: address,
+    }
+
+    /// Event emitted when the admin approves or revokes an action template.
+    public struct ActionAllowlistEvent has copy, drop {
+        admin: address,
+        action_hash: address,
+        approved: bool,
+    }
+
     /// Event emitted for each anchored decision.
     public struct AuditAnchoredEvent has copy, drop {
         operator: address,
@@ -120,6 +140,47 @@ module lazarus_protocol::sentinel_audit {
         });
     }
 
+    /// Admin pre-approves an action template hash. Sentinel auto-allows
+    /// actions whose template hash is approved here.
+    public fun approve_action(
+        registry: &mut Registry,
+        action_hash: address,
+        ctx: &mut sui::tx_context::TxContext,
+    ) {
+        assert!(sui::tx_context::sender(ctx) == registry.admin, E_NOT_ADMIN);
+        let key = ApprovedAction { hash: action_hash };
+        assert!(!sui::dynamic_field::exists_(&registry.id, key), E_ALREADY_APPROVED);
+        sui::dynamic_field::add(&mut registry.id, key, true);
+
+        sui::event::emit(ActionAllowlistEvent {
+            admin: sui::tx_context::sender(ctx),
+            action_hash,
+            approved: true,
+        });
+    }
+
+    /// Admin revokes a previously approved action template hash.
+    public fun revoke_action(
+        registry: &mut Registry,
+        action_hash: address,
+        ctx: &mut sui::tx_context::TxContext,
+    ) {
+        assert!(sui::tx_context::sender(ctx) == registry.admin, E_NOT_ADMIN);
+        let key = ApprovedAction { hash: action_hash };
+        assert!(sui::dynamic_field::exists_(&registry.id, key), E_NOT_APPROVED);
+        let _: bool = sui::dynamic_field::remove(&mut registry.id, key);
+
+        sui::event::emit(ActionAllowlistEvent {
+            admin: sui::tx_context::sender(ctx),
+            action_hash,
+            approved: false,
+        });
+    }
+
+    public fun is_action_approved(registry: &Registry, action_hash: address): bool {
+        sui::dynamic_field::exists_(&registry.id, ApprovedAction { hash: action_hash })
+    }
+
     public fun policy_version(registry: &Registry): u64 { registry.policy_version }
     public fun policy_hash(registry: &Registry): address { registry.policy_hash }
     public fun admin(registry: &Registry): address { registry.admin }
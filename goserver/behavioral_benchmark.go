@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BehaviorBenchmarkScenario is one labeled command sequence evaluated
+// against a fresh PolicyGate, so history and learned operations carry over
+// between its steps but not between scenarios.
+type BehaviorBenchmarkScenario struct {
+	Name  string                  `json:"name"`
+	Setup BehaviorBenchmarkSetup  `json:"setup"`
+	Steps []BehaviorBenchmarkStep `json:"steps"`
+}
+
+// BehaviorBenchmarkSetup shapes the profile before the first step.
+type BehaviorBenchmarkSetup struct {
+	Record     []string `json:"record"`    // operations learned as typical
+	NeverOps   []string `json:"never_ops"` // operations that must never run
+	Similarity bool     `json:"similarity"`
+}
+
+// BehaviorBenchmarkStep is one command with its expected outcome. Either
+// label may be omitted; the step then only counts toward the other metric.
+type BehaviorBenchmarkStep struct {
+	Command       string `json:"command"`
+	ExpectAnomaly *bool  `json:"expect_anomaly,omitempty"`
+	ExpectAction  string `json:"expect_action,omitempty"` // ALLOW | REQUIRE_APPROVAL | BLOCK
+	// Record teaches the command to the profile after it is evaluated,
+	// as a successful operation would.
+	Record bool `json:"record,omitempty"`
+}
+
+// BehaviorBenchmarkReport covers DetectAnomaly as a binary classifier and
+// PolicyGate as a three-way decision.
+type BehaviorBenchmarkReport struct {
+	Scenarios int                `json:"scenarios"`
+	Steps     int                `json:"steps"`
+	Anomaly   BenchmarkBreakdown `json:"anomaly"`
+
+	Decisions        int     `json:"decisions"`
+	DecisionCorrect  int     `json:"decision_correct"`
+	DecisionAccuracy float64 `json:"decision_accuracy"`
+	// DecisionMatrix counts outcomes by expected, then actual, action.
+	DecisionMatrix map[string]map[string]int `json:"decision_matrix"`
+}
+
+// RunBehaviorBenchmark evaluates every scenario in the JSON file at path.
+// Each scenario's gate uses the same behavior_pipeline and rules as the
+// guard built from cfg.
+func RunBehaviorBenchmark(path string, cfg *SentinelConfig) (*BehaviorBenchmarkReport, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("sentinel config is not loaded")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenarios []BehaviorBenchmarkScenario
+	if err := json.Unmarshal(data, &scenarios); err != nil {
+		return nil, err
+	}
+	rules, err := resolveSentinelRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BehaviorBenchmarkReport{Scenarios: len(scenarios), DecisionMatrix: map[string]map[string]int{}}
+	var anomalies benchmarkTally
+	for _, sc := range scenarios {
+		pg := NewPolicyGate("benchmark")
+		profile := pg.GetAgentProfile()
+		if err := profile.ConfigurePipeline(cfg.BehaviorPipeline); err != nil {
+			return nil, fmt.Errorf("behavior_pipeline: %w", err)
+		}
+		profile.SetRules(rules)
+		if sc.Setup.Similarity {
+			profile.EnableSimilarity()
+		}
+		profile.SetNeverOps(sc.Setup.NeverOps)
+		for _, op := range sc.Setup.Record {
+			pg.RecordSuccessfulOperation(op)
+		}
+
+		for i, step := range sc.Steps {
+			report.Steps++
+			anomaly := profile.DetectAnomaly(step.Command)
+			result := pg.CheckCommand(step.Command)
+			if step.ExpectAnomaly != nil {
+				anomalies.add(*step.ExpectAnomaly, anomaly.IsAnomaly)
+			}
+			if expect := strings.ToUpper(strings.TrimSpace(step.ExpectAction)); expect != "" {
+				report.Decisions++
+				if expect == result.Action {
+					report.DecisionCorrect++
+				}
+				if report.DecisionMatrix[expect] == nil {
+					report.DecisionMatrix[expect] = map[string]int{}
+				}
+				report.DecisionMatrix[expect][result.Action]++
+			}
+			if step.Record {
+				pg.RecordSuccessfulOperation(step.Command)
+			}
+
+			fmt.Printf("[%s#%d] score=%.2f type=%s anomaly=%v expect_anomaly=%s action=%s expect_action=%s\n",
+				sc.Name, i+1, anomaly.Score, anomaly.OpType, anomaly.IsAnomaly, optionalBool(step.ExpectAnomaly), result.Action, step.ExpectAction)
+		}
+	}
+
+	report.Anomaly = anomalies.breakdown("detect_anomaly")
+	report.DecisionAccuracy = benchmarkRatio(report.DecisionCorrect, report.Decisions)
+	return report, nil
+}
+
+func optionalBool(b *bool) string {
+	if b == nil {
+		return "-"
+	}
+	return fmt.Sprint(*b)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBehaviorBenchmark(t *testing.T) {
+	scenarios := `[
+  {
+    "name": "routine",
+    "setup": {"record": ["git status", "ls -la"]},
+    "steps": [
+      {"command": "git status", "expect_anomaly": false, "expect_action": "ALLOW"},
+      {"command": "make deploy", "expect_anomaly": false, "expect_action": "allow", "record": true},
+      {"command": "make deploy", "expect_anomaly": false, "expect_action": "ALLOW"}
+    ]
+  },
+  {
+    "name": "never-op",
+    "setup": {"never_ops": ["transfer"]},
+    "steps": [
+      {"command": "transfer 1000 USDC", "expect_anomaly": true, "expect_action": "BLOCK"},
+      {"command": "ls -la", "expect_anomaly": true}
+    ]
+  }
+]`
+	path := filepath.Join(t.TempDir(), "behavior.json")
+	if err := os.WriteFile(path, []byte(scenarios), 0o644); err != nil {
+		t.Fatalf("write scenarios: %v", err)
+	}
+
+	report, err := RunBehaviorBenchmark(path, &SentinelConfig{})
+	if err != nil {
+		t.Fatalf("RunBehaviorBenchmark failed: %v", err)
+	}
+	if report.Scenarios != 2 || report.Steps != 5 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	// The first "make deploy" is novel (false positive); once recorded the
+	// second one is allowed. "ls -la" in a fresh profile is mislabeled as
+	// anomalous (false negative).
+	a := report.Anomaly
+	if a.TruePositive != 1 || a.FalsePositive != 1 || a.TrueNegative != 2 || a.FalseNegative != 1 || a.Precision != 0.5 || a.Recall != 0.5 {
+		t.Fatalf("unexpected anomaly metrics: %+v", a)
+	}
+	if report.Decisions != 4 || report.DecisionCorrect != 3 || report.DecisionAccuracy != 0.75 {
+		t.Fatalf("unexpected decision metrics: %+v", report)
+	}
+	if report.DecisionMatrix["ALLOW"]["REQUIRE_APPROVAL"] != 1 || report.DecisionMatrix["ALLOW"]["ALLOW"] != 2 || report.DecisionMatrix["BLOCK"]["BLOCK"] != 1 {
+		t.Fatalf("unexpected decision matrix: %+v", report.DecisionMatrix)
+	}
+}
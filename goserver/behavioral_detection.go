@@ -17,6 +17,13 @@ type AgentProfile struct {
 	LastOpsHistory []string       // Recent operations
 	ProfileCreated time.Time      // Creation time
 
+	opTimes    []time.Time         // timestamps of recent learned operations
+	hourCounts [24]int             // learned operations per UTC hour
+	signatures map[string][]uint64 // minhash per learned op; nil = similarity off
+	rules      *SentinelRules      // operator allowlists used during classification
+	detectors  []AnomalyDetector
+	aggregator AnomalyAggregator
+
 	mu sync.RWMutex
 }
 
@@ -34,13 +41,27 @@ type AnomalyResult struct {
 	OpType    string
 	Severity  string // LOW/MEDIUM/HIGH
 	IsAnomaly bool
+	Findings  []DetectorFinding // per-detector sub-scores
 }
 
 var defaultOperationCategories = []OperationCategory{
 	{Category: "FINANCIAL", Keywords: []string{"transfer", "send", "wallet", "approve", "swap"}, RiskBase: 0.80},
-	{Category: "PRIVILEGE_ESCALATION", Keywords: []string{"sudo", "chmod", "chown", "root", "su "}, RiskBase: 0.90},
-	{Category: "SYSTEM_MODIFICATION", Keywords: []string{"rm -rf", "mkfs", "shutdown", "reboot", "format"}, RiskBase: 0.85},
-	{Category: "DATA_EXFILTRATION", Keywords: []string{"curl", "wget", "scp", "upload", "post "}, RiskBase: 0.70},
+	{Category: "PRIVILEGE_ESCALATION", Keywords: []string{"sudo", "chmod", "chown", "root", "su ",
+		"runas", "icacls", "takeown", "set-executionpolicy", "net localgroup administrators"}, RiskBase: 0.90},
+	{Category: "SYSTEM_MODIFICATION", Keywords: []string{"rm -rf", "mkfs", "shutdown", "reboot", "format",
+		"remove-item -recurse", "rd /s", "rmdir /s", "del /s", "reg add", "reg delete", "schtasks /create",
+		"clear-disk", "bcdedit", "vssadmin delete"}, RiskBase: 0.85},
+	{Category: "INFRA_DESTRUCTIVE", Keywords: []string{"kubectl delete", "kubectl drain", "helm uninstall", "helm delete",
+		"docker rm -f", "docker system prune", "docker volume prune", "terraform destroy", "apply -destroy"}, RiskBase: 0.85},
+	{Category: "CLOUD_PRIV", Keywords: []string{"169.254.169.254", "metadata.google.internal", "create-access-key",
+		"sts assume-role", "add-iam-policy-binding", "role assignment create", "create-for-rbac"}, RiskBase: 0.90},
+	{Category: "VCS_DESTRUCTIVE", Keywords: []string{"filter-branch", "push --force origin main", "push -f origin main",
+		"push --force origin master", "push -f origin master", "push --mirror"}, RiskBase: 0.90},
+	{Category: "DATABASE", Keywords: []string{"drop table", "drop database", "drop schema", "truncate table"}, RiskBase: 0.85},
+	{Category: "CODE_EXECUTION", Keywords: []string{"invoke-expression", "iex ", "iex(", "downloadstring", "downloadfile",
+		"frombase64string", "-encodedcommand", " -enc "}, RiskBase: 0.90},
+	{Category: "DATA_EXFILTRATION", Keywords: []string{"curl", "wget", "scp", "upload", "post ",
+		"invoke-webrequest", "iwr ", "invoke-restmethod", "start-bitstransfer", "certutil -urlcache"}, RiskBase: 0.70},
 	{Category: "FILE_MANAGEMENT", Keywords: []string{"ls", "cd ", "mkdir", "cp ", "mv "}, RiskBase: 0.15},
 	{Category: "CODE_EDITING", Keywords: []string{"git", "go ", "npm", "cargo", "make"}, RiskBase: 0.20},
 	{Category: "API_CALL", Keywords: []string{"http", "api", "rest", "graphql"}, RiskBase: 0.30},
@@ -55,6 +76,8 @@ func NewAgentProfile(agentID string) *AgentProfile {
 		RiskBaseline:   0.20,
 		LastOpsHistory: []string{},
 		ProfileCreated: time.Now(),
+		detectors:      DefaultAnomalyDetectors(),
+		aggregator:     SumAggregator{},
 	}
 }
 
@@ -68,11 +91,35 @@ func (ap *AgentProfile) RecordOperation(op string) {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 
+	now := time.Now().UTC()
 	ap.TypicalOps[normalized]++
 	ap.LastOpsHistory = append(ap.LastOpsHistory, normalized)
 	if len(ap.LastOpsHistory) > 50 {
 		ap.LastOpsHistory = ap.LastOpsHistory[len(ap.LastOpsHistory)-50:]
 	}
+	ap.opTimes = append(ap.opTimes, now)
+	if len(ap.opTimes) > 200 {
+		ap.opTimes = ap.opTimes[len(ap.opTimes)-200:]
+	}
+	ap.hourCounts[now.Hour()]++
+	if ap.signatures != nil && ap.signatures[normalized] == nil {
+		ap.signatures[normalized] = minhashSignature(normalized)
+	}
+}
+
+// EnableSimilarity indexes learned operations so near-matches of known
+// commands score lower novelty than genuinely new command shapes.
+func (ap *AgentProfile) EnableSimilarity() {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	if ap.signatures != nil {
+		return
+	}
+	ap.signatures = make(map[string][]uint64, len(ap.TypicalOps))
+	for op := range ap.TypicalOps {
+		ap.signatures[op] = minhashSignature(op)
+	}
 }
 
 // SetNeverOps defines hard-block patterns.
@@ -89,6 +136,27 @@ func (ap *AgentProfile) SetNeverOps(ops []string) {
 	}
 }
 
+// SetRules installs operator classification rules (allowlists).
+func (ap *AgentProfile) SetRules(rules *SentinelRules) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.rules = rules
+}
+
+// SetDetectors replaces the anomaly detector pipeline.
+func (ap *AgentProfile) SetDetectors(detectors ...AnomalyDetector) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.detectors = append([]AnomalyDetector(nil), detectors...)
+}
+
+// SetAggregator replaces how detector sub-scores are combined.
+func (ap *AgentProfile) SetAggregator(agg AnomalyAggregator) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.aggregator = agg
+}
+
 // DetectAnomaly evaluates how unusual/risky a command is for this profile.
 func (ap *AgentProfile) DetectAnomaly(op string) AnomalyResult {
 	normalized := normalizeOp(op)
@@ -97,46 +165,58 @@ func (ap *AgentProfile) DetectAnomaly(op string) AnomalyResult {
 	}
 
 	ap.mu.RLock()
-	neverOps := append([]string(nil), ap.NeverOps...)
-	seenCount := ap.TypicalOps[normalized]
-	totalKnown := len(ap.TypicalOps)
+	// Classify the original text: encoded payloads are case-sensitive.
+	opType, baseRisk := classifyParsedCommand(ParseCommand(op), ap.rules)
+	in := &DetectionInput{
+		Op:         normalized,
+		OpType:     opType,
+		BaseRisk:   baseRisk,
+		SeenCount:  ap.TypicalOps[normalized],
+		TotalKnown: len(ap.TypicalOps),
+		NeverOps:   append([]string(nil), ap.NeverOps...),
+		History:    append([]string(nil), ap.LastOpsHistory...),
+		OpTimes:    append([]time.Time(nil), ap.opTimes...),
+		HourCounts: ap.hourCounts,
+		Now:        time.Now(),
+	}
+	if ap.signatures != nil && in.SeenCount == 0 {
+		in.NearestKnown, in.NearestSimilarity = nearestSignature(minhashSignature(normalized), ap.signatures)
+	}
+	detectors := ap.detectors
+	aggregator := ap.aggregator
 	ap.mu.RUnlock()
 
-	for _, never := range neverOps {
-		if strings.Contains(normalized, never) {
+	if aggregator == nil {
+		aggregator = SumAggregator{}
+	}
+
+	findings := make([]DetectorFinding, 0, len(detectors))
+	reasons := []string{}
+	for _, d := range detectors {
+		f := d.Detect(in)
+		if f.Detector == "" {
+			f.Detector = d.Name()
+		}
+		if f.Decisive {
 			return AnomalyResult{
-				Score:     0.98,
-				Reason:    fmt.Sprintf("matches never-op pattern: %s", never),
-				OpType:    classifyOperation(normalized),
-				Severity:  "HIGH",
-				IsAnomaly: true,
+				Score:     f.Score,
+				Reason:    f.Explanation,
+				OpType:    opType,
+				Severity:  severityFromScore(f.Score),
+				IsAnomaly: f.Score >= 0.50,
+				Findings:  append(findings, f),
 			}
 		}
-	}
-
-	opType, baseRisk := classifyOperationWithRisk(normalized)
-
-	// Known frequent commands should be cheap to pass.
-	if seenCount > 0 {
-		score := maxFloat(0.02, baseRisk*0.25)
-		return AnomalyResult{
-			Score:     score,
-			Reason:    "operation observed in profile",
-			OpType:    opType,
-			Severity:  severityFromScore(score),
-			IsAnomaly: score >= 0.50,
+		findings = append(findings, f)
+		if f.Explanation != "" {
+			reasons = append(reasons, f.Explanation)
 		}
 	}
 
-	noveltyBoost := float32(0.35)
-	if totalKnown == 0 {
-		noveltyBoost = 0.20 // cold-start profile shouldn't over-penalize
-	}
-
-	score := minFloat(1.0, baseRisk+noveltyBoost)
-	reason := "new operation outside learned profile"
-	if opType != "UNKNOWN" {
-		reason = fmt.Sprintf("new %s operation outside learned profile", opType)
+	score := aggregator.Aggregate(findings)
+	reason := "no detector findings"
+	if len(reasons) > 0 {
+		reason = strings.Join(reasons, "; ")
 	}
 
 	return AnomalyResult{
@@ -145,6 +225,7 @@ func (ap *AgentProfile) DetectAnomaly(op string) AnomalyResult {
 		OpType:    opType,
 		Severity:  severityFromScore(score),
 		IsAnomaly: score >= 0.50,
+		Findings:  findings,
 	}
 }
 
@@ -175,16 +256,10 @@ func classifyOperation(op string) string {
 	return category
 }
 
+// classifyOperationWithRisk parses op as a shell command and classifies it by
+// structure; see classifyParsedCommand.
 func classifyOperationWithRisk(op string) (string, float32) {
-	lower := normalizeOp(op)
-	for _, c := range defaultOperationCategories {
-		for _, k := range c.Keywords {
-			if strings.Contains(lower, strings.ToLower(k)) {
-				return c.Category, c.RiskBase
-			}
-		}
-	}
-	return "UNKNOWN", 0.40
+	return classifyParsedCommand(ParseCommand(op), nil)
 }
 
 func normalizeOp(op string) string {
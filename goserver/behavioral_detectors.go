@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DetectionInput is the profile snapshot handed to every anomaly detector.
+type DetectionInput struct {
+	Op         string // normalized operation
+	OpType     string
+	BaseRisk   float32
+	SeenCount  int
+	TotalKnown int
+	NeverOps   []string
+	History    []string    // recent learned operations, oldest first
+	OpTimes    []time.Time // timestamps of recent learned operations
+	HourCounts [24]int     // learned operations per hour of day (UTC)
+	Now        time.Time
+
+	// NearestKnown is the most similar learned operation when similarity
+	// indexing is enabled; NearestSimilarity is its estimated Jaccard score.
+	NearestKnown      string
+	NearestSimilarity float64
+}
+
+// DetectorFinding is one detector's contribution to an anomaly decision.
+type DetectorFinding struct {
+	Detector    string  `json:"detector"`
+	Score       float32 `json:"score"`
+	Explanation string  `json:"explanation,omitempty"`
+	// Decisive findings short-circuit the pipeline and become the final score.
+	Decisive bool `json:"decisive,omitempty"`
+}
+
+// AnomalyDetector is a pluggable stage of DetectAnomaly.
+type AnomalyDetector interface {
+	Name() string
+	Detect(in *DetectionInput) DetectorFinding
+}
+
+// AnomalyAggregator combines detector sub-scores into one 0.0 - 1.0 score.
+type AnomalyAggregator interface {
+	Aggregate(findings []DetectorFinding) float32
+}
+
+// DefaultAnomalyDetectors returns the built-in pipeline in evaluation order.
+// Sequence, rate and temporal detectors only fire once the profile has
+// learned enough operations.
+func DefaultAnomalyDetectors() []AnomalyDetector {
+	return []AnomalyDetector{
+		NeverOpsDetector{},
+		NoveltyDetector{},
+		CategoryRiskDetector{},
+		SequenceDetector{},
+		RateDetector{Limit: 30, Window: time.Minute},
+		TemporalDetector{MinSamples: 20},
+	}
+}
+
+// NeverOpsDetector hard-matches the profile's never-op patterns.
+type NeverOpsDetector struct{}
+
+func (NeverOpsDetector) Name() string { return "never_ops" }
+
+func (d NeverOpsDetector) Detect(in *DetectionInput) DetectorFinding {
+	for _, never := range in.NeverOps {
+		if strings.Contains(in.Op, never) {
+			return DetectorFinding{
+				Detector:    d.Name(),
+				Score:       0.98,
+				Explanation: fmt.Sprintf("matches never-op pattern: %s", never),
+				Decisive:    true,
+			}
+		}
+	}
+	return DetectorFinding{Detector: d.Name()}
+}
+
+// nearMatchFloor is the similarity above which an unseen operation counts as
+// a variant of a learned one rather than a new command shape.
+const nearMatchFloor = 0.5
+
+// NoveltyDetector penalizes operations absent from the learned profile.
+// Near-matches of learned operations are penalized in proportion to how
+// different they are.
+type NoveltyDetector struct{}
+
+func (NoveltyDetector) Name() string { return "novelty" }
+
+func (d NoveltyDetector) Detect(in *DetectionInput) DetectorFinding {
+	if in.SeenCount > 0 {
+		return DetectorFinding{Detector: d.Name(), Explanation: "operation observed in profile"}
+	}
+	boost := float32(0.35)
+	if in.TotalKnown == 0 {
+		boost = 0.20 // cold-start profile shouldn't over-penalize
+	}
+	if in.NearestSimilarity >= nearMatchFloor {
+		return DetectorFinding{
+			Detector:    d.Name(),
+			Score:       boost * float32(1-in.NearestSimilarity),
+			Explanation: fmt.Sprintf("near match of learned operation %q (similarity %.2f)", in.NearestKnown, in.NearestSimilarity),
+		}
+	}
+	reason := "new operation outside learned profile"
+	if in.OpType != "UNKNOWN" {
+		reason = fmt.Sprintf("new %s operation outside learned profile", in.OpType)
+	}
+	return DetectorFinding{Detector: d.Name(), Score: boost, Explanation: reason}
+}
+
+// CategoryRiskDetector contributes the category's baseline risk. Learned
+// operations only carry a quarter of it.
+type CategoryRiskDetector struct{}
+
+func (CategoryRiskDetector) Name() string { return "category" }
+
+func (d CategoryRiskDetector) Detect(in *DetectionInput) DetectorFinding {
+	score := in.BaseRisk
+	if in.SeenCount > 0 {
+		score = maxFloat(0.02, in.BaseRisk*0.25)
+	}
+	return DetectorFinding{
+		Detector:    d.Name(),
+		Score:       score,
+		Explanation: fmt.Sprintf("%s base risk %.2f", in.OpType, in.BaseRisk),
+	}
+}
+
+// sensitiveReadMarkers identify operations that touched secrets.
+var sensitiveReadMarkers = []string{".ssh", "id_rsa", ".env", "credentials", "keystore", "private key", "/etc/shadow", "secret"}
+
+// SequenceDetector flags risky operation chains, such as outbound traffic
+// right after a secret was read, and category transitions never seen before.
+type SequenceDetector struct{}
+
+func (SequenceDetector) Name() string { return "sequence" }
+
+func (d SequenceDetector) Detect(in *DetectionInput) DetectorFinding {
+	if len(in.History) == 0 {
+		return DetectorFinding{Detector: d.Name()}
+	}
+
+	if in.OpType == "DATA_EXFILTRATION" || in.OpType == "API_CALL" {
+		recent := in.History
+		if len(recent) > 3 {
+			recent = recent[len(recent)-3:]
+		}
+		for _, prev := range recent {
+			if hasAny(prev, sensitiveReadMarkers...) {
+				return DetectorFinding{
+					Detector:    d.Name(),
+					Score:       0.30,
+					Explanation: fmt.Sprintf("outbound operation follows sensitive read: %s", prev),
+				}
+			}
+		}
+	}
+
+	if len(in.History) < 10 {
+		return DetectorFinding{Detector: d.Name()}
+	}
+	last := classifyOperation(in.History[len(in.History)-1])
+	for i := 1; i < len(in.History); i++ {
+		if classifyOperation(in.History[i-1]) == last && classifyOperation(in.History[i]) == in.OpType {
+			return DetectorFinding{Detector: d.Name()}
+		}
+	}
+	return DetectorFinding{
+		Detector:    d.Name(),
+		Score:       0.10,
+		Explanation: fmt.Sprintf("unseen transition %s -> %s", last, in.OpType),
+	}
+}
+
+// RateDetector flags bursts of operations above Limit within Window.
+type RateDetector struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (RateDetector) Name() string { return "rate" }
+
+func (d RateDetector) Detect(in *DetectionInput) DetectorFinding {
+	if d.Limit <= 0 || d.Window <= 0 {
+		return DetectorFinding{Detector: d.Name()}
+	}
+	cutoff := in.Now.Add(-d.Window)
+	count := 0
+	for _, ts := range in.OpTimes {
+		if ts.After(cutoff) {
+			count++
+		}
+	}
+	if count < d.Limit {
+		return DetectorFinding{Detector: d.Name()}
+	}
+	return DetectorFinding{
+		Detector:    d.Name(),
+		Score:       0.20,
+		Explanation: fmt.Sprintf("burst of %d operations in %s", count, d.Window),
+	}
+}
+
+// TemporalDetector flags operations at an hour the agent has never been
+// active, once MinSamples operations have been learned.
+type TemporalDetector struct {
+	MinSamples int
+}
+
+func (TemporalDetector) Name() string { return "temporal" }
+
+func (d TemporalDetector) Detect(in *DetectionInput) DetectorFinding {
+	total := 0
+	for _, c := range in.HourCounts {
+		total += c
+	}
+	hour := in.Now.UTC().Hour()
+	if total < d.MinSamples || in.HourCounts[hour] > 0 {
+		return DetectorFinding{Detector: d.Name()}
+	}
+	return DetectorFinding{
+		Detector:    d.Name(),
+		Score:       0.15,
+		Explanation: fmt.Sprintf("operation at unusual hour (%02d:00 UTC)", hour),
+	}
+}
+
+// SumAggregator adds sub-scores, capped at 1.0. It is the default.
+type SumAggregator struct{}
+
+func (SumAggregator) Aggregate(findings []DetectorFinding) float32 {
+	var total float32
+	for _, f := range findings {
+		total += f.Score
+	}
+	return minFloat(1.0, total)
+}
+
+// MaxAggregator takes the strongest single signal.
+type MaxAggregator struct{}
+
+func (MaxAggregator) Aggregate(findings []DetectorFinding) float32 {
+	var top float32
+	for _, f := range findings {
+		top = maxFloat(top, f.Score)
+	}
+	return minFloat(1.0, top)
+}
+
+// WeightedAggregator scales each detector's sub-score before summing.
+// Detectors without a weight count at 1.0.
+type WeightedAggregator struct {
+	Weights map[string]float32
+}
+
+func (a WeightedAggregator) Aggregate(findings []DetectorFinding) float32 {
+	var total float32
+	for _, f := range findings {
+		w, ok := a.Weights[f.Detector]
+		if !ok {
+			w = 1.0
+		}
+		total += w * f.Score
+	}
+	return maxFloat(0, minFloat(1.0, total))
+}
+
+// AnomalyPipelineConfig tunes the detector pipeline from configuration.
+type AnomalyPipelineConfig struct {
+	Aggregator    string             `json:"aggregator"` // sum (default) | max | weighted
+	Weights       map[string]float32 `json:"weights"`
+	Disabled      []string           `json:"disabled"` // detector names to skip
+	RateLimit     int                `json:"rate_limit"`
+	RateWindowSec int                `json:"rate_window_seconds"`
+	Similarity    bool               `json:"similarity"` // minhash near-match scoring for novelty
+}
+
+// ConfigurePipeline rebuilds the profile's detectors and aggregator.
+func (ap *AgentProfile) ConfigurePipeline(cfg *AnomalyPipelineConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	var agg AnomalyAggregator
+	switch strings.ToLower(strings.TrimSpace(cfg.Aggregator)) {
+	case "", "sum":
+		agg = SumAggregator{}
+	case "max":
+		agg = MaxAggregator{}
+	case "weighted":
+		agg = WeightedAggregator{Weights: cfg.Weights}
+	default:
+		return fmt.Errorf("unknown anomaly aggregator %q", cfg.Aggregator)
+	}
+
+	detectors := make([]AnomalyDetector, 0, 6)
+	for _, d := range DefaultAnomalyDetectors() {
+		if containsTag(cfg.Disabled, d.Name()) {
+			continue
+		}
+		if rate, ok := d.(RateDetector); ok {
+			if cfg.RateLimit > 0 {
+				rate.Limit = cfg.RateLimit
+			}
+			if cfg.RateWindowSec > 0 {
+				rate.Window = time.Duration(cfg.RateWindowSec) * time.Second
+			}
+			d = rate
+		}
+		detectors = append(detectors, d)
+	}
+
+	ap.SetDetectors(detectors...)
+	ap.SetAggregator(agg)
+	if cfg.Similarity {
+		ap.EnableSimilarity()
+	}
+	return nil
+}
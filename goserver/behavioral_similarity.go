@@ -0,0 +1,91 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// minhashSize is the signature length; the Jaccard estimate error is about
+// 1/sqrt(minhashSize).
+const minhashSize = 64
+
+// minhashSeeds are derived once with splitmix64 so signatures are stable
+// across processes without any model files or network access.
+var minhashSeeds = func() [minhashSize]uint64 {
+	var seeds [minhashSize]uint64
+	state := uint64(0x5e471e1)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// opShingles breaks an operation into token unigrams and bigrams, so
+// "git push origin main" and "git push --force origin main" share most
+// features while commands with a different shape share few.
+func opShingles(op string) []string {
+	tokens := strings.Fields(normalizeOp(op))
+	shingles := make([]string, 0, 2*len(tokens))
+	for i, tok := range tokens {
+		shingles = append(shingles, tok)
+		if i > 0 {
+			shingles = append(shingles, tokens[i-1]+" "+tok)
+		}
+	}
+	return shingles
+}
+
+func minhashSignature(op string) []uint64 {
+	shingles := opShingles(op)
+	if len(shingles) == 0 {
+		return nil
+	}
+	sig := make([]uint64, minhashSize)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for _, s := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		base := h.Sum64()
+		for i, seed := range minhashSeeds {
+			if v := mix64(base ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// minhashSimilarity estimates the Jaccard similarity of two signatures.
+func minhashSimilarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// nearestSignature returns the learned operation most similar to sig.
+func nearestSignature(sig []uint64, known map[string][]uint64) (string, float64) {
+	best, bestSim := "", 0.0
+	for op, other := range known {
+		if sim := minhashSimilarity(sig, other); sim > bestSim || (sim == bestSim && sim > 0 && op < best) {
+			best, bestSim = op, sim
+		}
+	}
+	return best, bestSim
+}
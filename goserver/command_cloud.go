@@ -0,0 +1,71 @@
+package main
+
+import "strings"
+
+var (
+	ruleCloud     = BinaryRule{Category: "CLOUD", Risk: 0.30}
+	ruleCloudPriv = BinaryRule{Category: "CLOUD_PRIV", Risk: 0.90}
+)
+
+// cloudMetadataHosts serve instance credentials; any access is credential
+// theft risk.
+var cloudMetadataHosts = []string{
+	"169.254.169.254", "169.254.170.2", "fd00:ec2::254", "metadata.google.internal", "metadata.azure.com",
+}
+
+var cloudValueFlags = map[string]bool{
+	"--profile": true, "--region": true, "--output": true, "-o": true, "--endpoint-url": true, "--query": true,
+	"--project": true, "--account": true, "--subscription": true, "--format": true, "--configuration": true,
+	"--impersonate-service-account": true,
+}
+
+// classifyCloudSegment classifies aws, gcloud and az invocations. The bool
+// result is false for other executables.
+func classifyCloudSegment(seg CommandSegment) (BinaryRule, bool) {
+	pos := positionalArgs(seg.Args, cloudValueFlags)
+	for i := range pos {
+		pos[i] = strings.ToLower(pos[i])
+	}
+	at := func(i int) string {
+		if i < len(pos) {
+			return pos[i]
+		}
+		return ""
+	}
+
+	priv := false
+	switch seg.Executable {
+	case "aws":
+		op := at(1)
+		switch at(0) {
+		case "iam":
+			priv = !strings.HasPrefix(op, "list-") && !strings.HasPrefix(op, "get-") && op != ""
+		case "sts":
+			priv = strings.HasPrefix(op, "assume-role") || op == "get-session-token" || op == "get-federation-token"
+		case "ssm":
+			priv = op == "get-parameter" && seg.hasFlag("--with-decryption")
+		case "secretsmanager":
+			priv = op == "get-secret-value"
+		}
+	case "gcloud":
+		joined := strings.Join(pos, " ")
+		priv = strings.Contains(joined, "add-iam-policy-binding") || strings.Contains(joined, "set-iam-policy") ||
+			(at(0) == "iam" && (strings.Contains(joined, " create") || strings.Contains(joined, " update"))) ||
+			(at(0) == "auth" && (at(1) == "print-access-token" || at(1) == "print-identity-token"))
+	case "az":
+		joined := strings.Join(pos, " ")
+		priv = strings.HasPrefix(joined, "role assignment create") || strings.HasPrefix(joined, "role definition create") ||
+			strings.HasPrefix(joined, "ad sp create-for-rbac") || strings.Contains(joined, "credential reset") ||
+			strings.HasPrefix(joined, "account get-access-token")
+	default:
+		return BinaryRule{}, false
+	}
+	if priv {
+		return ruleCloudPriv, true
+	}
+	return ruleCloud, true
+}
+
+func mentionsCloudMetadata(text string) bool {
+	return hasAny(text, cloudMetadataHosts...)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+var (
+	ruleVCSDestructive = BinaryRule{Category: "VCS_DESTRUCTIVE", Risk: 0.90}
+	ruleVCSRewrite     = BinaryRule{Category: "VCS_REWRITE", Risk: 0.70}
+	ruleVCSForce       = BinaryRule{Category: "CODE_EDITING", Risk: 0.45}
+)
+
+// defaultProtectedBranches apply to repositories without their own rules.
+var defaultProtectedBranches = []string{"main", "master", "production", "release/*"}
+
+// GitRules configures irreversible VCS operation checks.
+type GitRules struct {
+	ProtectedBranches []string       `json:"protected_branches"` // default main, master, production, release/*
+	Repos             []GitRepoRules `json:"repos"`
+}
+
+// GitRepoRules override the defaults for repositories whose path or remote
+// matches Match (a glob or substring, e.g. "*/infra" or "org/api").
+type GitRepoRules struct {
+	Match             string   `json:"match"`
+	ProtectedBranches []string `json:"protected_branches"`
+	AllowForcePush    bool     `json:"allow_force_push"`
+}
+
+var gitValueFlags = map[string]bool{"-C": true, "-c": true, "--git-dir": true, "--work-tree": true}
+
+// classifyGitSegment classifies history-rewriting git operations. The
+// repository is identified from `git -C`, a preceding `cd`, or the push
+// remote.
+func classifyGitSegment(seg CommandSegment, rules *GitRules) (BinaryRule, bool) {
+	if seg.Executable != "git" {
+		return BinaryRule{}, false
+	}
+	pos := positionalArgs(seg.Args, gitValueFlags)
+	if len(pos) == 0 {
+		return ruleCode, true
+	}
+	sub := strings.ToLower(pos[0])
+	args := pos[1:]
+
+	repoIDs := []string{optionValue(seg.Args, "-C"), seg.Dir}
+	if sub == "push" && len(args) > 0 {
+		repoIDs = append(repoIDs, args[0])
+	}
+	protected, allowForce := gitPolicyFor(rules, repoIDs)
+
+	switch sub {
+	case "filter-branch", "filter-repo":
+		return ruleVCSDestructive, true
+	case "push":
+		return classifyGitPush(seg, args, protected, allowForce), true
+	case "branch":
+		if seg.hasFlag("-d") || seg.hasFlag("-D") || seg.hasFlag("--delete") {
+			for _, b := range args {
+				if branchProtected(b, protected) {
+					return ruleVCSDestructive, true
+				}
+			}
+			if seg.hasFlag("-D") || seg.hasFlag("--force") {
+				return ruleVCSForce, true
+			}
+		}
+	case "update-ref":
+		if seg.hasFlag("-d") && len(args) > 0 && branchProtected(args[0], protected) {
+			return ruleVCSDestructive, true
+		}
+	case "reflog":
+		if len(args) > 0 && args[0] == "expire" && optionValue(seg.Args, "--expire") == "now" {
+			return ruleVCSRewrite, true
+		}
+	}
+	return ruleCode, true
+}
+
+func classifyGitPush(seg CommandSegment, args []string, protected []string, allowForce bool) BinaryRule {
+	if seg.hasFlag("--mirror") {
+		return ruleVCSDestructive
+	}
+	force := seg.hasFlag("--force") || seg.hasFlag("-f") || seg.hasFlag("--force-with-lease") ||
+		optionValue(seg.Args, "--force-with-lease") != ""
+	deleting := seg.hasFlag("--delete") || seg.hasFlag("-d")
+
+	var targets []string
+	if len(args) > 1 {
+		for _, refspec := range args[1:] {
+			if strings.HasPrefix(refspec, "+") {
+				force = true
+				refspec = refspec[1:]
+			}
+			dst := refspec
+			if i := strings.LastIndexByte(refspec, ':'); i >= 0 {
+				dst = refspec[i+1:]
+				if i == 0 {
+					deleting = true // ":branch" deletes the remote branch
+				}
+			}
+			targets = append(targets, dst)
+		}
+	}
+
+	if !force && !deleting {
+		return ruleCode
+	}
+	if deleting || !allowForce {
+		for _, t := range targets {
+			if branchProtected(t, protected) {
+				return ruleVCSDestructive
+			}
+		}
+	}
+	if len(targets) == 0 && !allowForce {
+		// The current branch is pushed; it may well be protected.
+		return ruleVCSRewrite
+	}
+	return ruleVCSForce
+}
+
+// gitPolicyFor returns the protected branches and force-push allowance for
+// the first repository rule matching any of ids.
+func gitPolicyFor(rules *GitRules, ids []string) ([]string, bool) {
+	protected := defaultProtectedBranches
+	if rules == nil {
+		return protected, false
+	}
+	if len(rules.ProtectedBranches) > 0 {
+		protected = rules.ProtectedBranches
+	}
+	for _, repo := range rules.Repos {
+		for _, id := range ids {
+			if id == "" || !repoMatches(repo.Match, id) {
+				continue
+			}
+			if len(repo.ProtectedBranches) > 0 {
+				protected = repo.ProtectedBranches
+			}
+			return protected, repo.AllowForcePush
+		}
+	}
+	return protected, false
+}
+
+func repoMatches(pattern, id string) bool {
+	if pattern == "" {
+		return false
+	}
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "/"), ".git")
+	// Globs match the whole id or any trailing path, so "*/infra" matches
+	// "/work/infra" and "git@host:org/infra".
+	candidates := []string{id}
+	for i, r := range id {
+		if r == '/' || r == ':' {
+			candidates = append(candidates, id[i:], id[i+1:])
+		}
+	}
+	for _, c := range candidates {
+		if ok, _ := path.Match(pattern, c); ok {
+			return true
+		}
+	}
+	return strings.Contains(id, pattern)
+}
+
+func branchProtected(ref string, protected []string) bool {
+	ref = strings.TrimPrefix(ref, "refs/heads/")
+	for _, p := range protected {
+		if ok, _ := path.Match(p, ref); ok || p == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package main
+
+import "strings"
+
+var (
+	ruleInfra            = BinaryRule{Category: "INFRA", Risk: 0.30}
+	ruleInfraDestructive = BinaryRule{Category: "INFRA_DESTRUCTIVE", Risk: 0.85}
+)
+
+// infraTarget is where an infrastructure command acts.
+type infraTarget struct {
+	namespace     string
+	cluster       string
+	allNamespaces bool
+}
+
+// infraValueFlags take a separate value, so the value is not mistaken for a
+// subcommand.
+var infraValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true, "--kube-context": true,
+	"--kubeconfig": true, "-H": true, "--host": true,
+}
+
+// classifyInfraSegment classifies kubectl, oc, helm, docker and terraform.
+// The bool result is false for other executables.
+func classifyInfraSegment(seg CommandSegment, rules *InfraRules) (BinaryRule, bool) {
+	var destructive bool
+	positional := infraPositionals(seg.Args)
+	sub, sub2 := "", ""
+	if len(positional) > 0 {
+		sub = strings.ToLower(positional[0])
+	}
+	if len(positional) > 1 {
+		sub2 = strings.ToLower(positional[1])
+	}
+
+	switch seg.Executable {
+	case "kubectl", "oc":
+		switch sub {
+		case "delete", "drain":
+			destructive = true
+		case "replace":
+			destructive = seg.hasFlag("--force")
+		case "apply":
+			destructive = seg.hasFlag("--prune")
+		case "scale":
+			destructive = optionValue(seg.Args, "--replicas") == "0"
+		}
+	case "helm":
+		destructive = sub == "uninstall" || sub == "delete" || sub == "del" || sub == "un"
+	case "docker", "podman":
+		switch sub {
+		case "rm":
+			destructive = seg.hasFlag("-f") || seg.hasFlag("--force") || seg.hasFlag("-v")
+		case "rmi", "kill":
+			destructive = true
+		case "system", "volume", "container", "image", "network", "builder":
+			destructive = sub2 == "prune" || sub2 == "rm"
+		}
+	case "terraform", "tofu":
+		switch sub {
+		case "destroy":
+			destructive = true
+		case "apply":
+			destructive = seg.hasFlag("-destroy")
+		case "state":
+			destructive = sub2 == "rm"
+		}
+	default:
+		return BinaryRule{}, false
+	}
+
+	if !destructive {
+		return ruleInfra, true
+	}
+	if rules != nil && infraAllowed(infraTargetOf(seg), rules) {
+		return ruleInfra, true
+	}
+	return ruleInfraDestructive, true
+}
+
+func infraTargetOf(seg CommandSegment) infraTarget {
+	t := infraTarget{
+		namespace:     optionValue(seg.Args, "-n", "--namespace"),
+		cluster:       optionValue(seg.Args, "--context", "--kube-context", "--cluster"),
+		allNamespaces: seg.hasFlag("--all-namespaces") || containsTag(seg.Flags, "-A"),
+	}
+	if t.namespace == "" && (seg.Executable == "kubectl" || seg.Executable == "oc" || seg.Executable == "helm") {
+		t.namespace = "default"
+	}
+	return t
+}
+
+func infraAllowed(t infraTarget, rules *InfraRules) bool {
+	if len(rules.AllowedNamespaces) == 0 && len(rules.AllowedClusters) == 0 {
+		return false
+	}
+	if len(rules.AllowedNamespaces) > 0 {
+		if t.allNamespaces || t.namespace == "" || !containsFold(rules.AllowedNamespaces, t.namespace) {
+			return false
+		}
+	}
+	if len(rules.AllowedClusters) > 0 {
+		if t.cluster == "" || !containsFold(rules.AllowedClusters, t.cluster) {
+			return false
+		}
+	}
+	return true
+}
+
+// optionValue returns the value of the first matching option, accepting both
+// "--name value" and "--name=value".
+func optionValue(args []string, names ...string) string {
+	for i, a := range args {
+		for _, n := range names {
+			if a == n && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(a, n+"=") {
+				return strings.TrimPrefix(a, n+"=")
+			}
+		}
+	}
+	return ""
+}
+
+func infraPositionals(args []string) []string {
+	return positionalArgs(args, infraValueFlags)
+}
+
+// positionalArgs drops flags, and the separate values of valueFlags.
+func positionalArgs(args []string, valueFlags map[string]bool) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if strings.HasPrefix(a, "-") {
+			if valueFlags[a] {
+				i++
+			}
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
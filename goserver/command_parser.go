@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// ParsedCommand is a shell command line split into simple commands.
+type ParsedCommand struct {
+	Raw      string
+	Segments []CommandSegment
+}
+
+// CommandSegment is one simple command: executable, arguments and
+// redirections. Segments from `sh -c`, `eval`, `ssh host cmd` and command
+// substitutions are flattened into the parent command.
+type CommandSegment struct {
+	Executable   string   // base name, lowercased
+	Args         []string // arguments after the executable
+	Subcommand   string   // first non-flag argument
+	Flags        []string // arguments starting with '-'
+	Redirections []Redirection
+	Operator     string // control operator that preceded this segment
+	Dir          string // directory set by a preceding `cd` in the same line
+	quotedArgs   []bool // parallel to Args
+}
+
+// Redirection is an I/O redirection such as "> /etc/hosts".
+type Redirection struct {
+	Op     string
+	Target string
+}
+
+type shellToken struct {
+	text   string
+	op     bool // control operator or redirection
+	quoted bool // contained quoted text
+}
+
+// shellWrappers run the following word as the real command.
+var shellWrappers = map[string]bool{
+	"env": true, "nohup": true, "time": true, "nice": true, "command": true,
+	"exec": true, "xargs": true, "stdbuf": true, "timeout": true, "builtin": true,
+}
+
+var shellInterpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true,
+}
+
+var powershellHosts = map[string]bool{
+	"powershell": true, "powershell.exe": true, "pwsh": true, "pwsh.exe": true,
+}
+
+// ParseCommand parses op with POSIX shell quoting rules. Parsing is lenient:
+// unterminated quotes are taken literally so natural-language prompts still
+// yield segments.
+func ParseCommand(op string) *ParsedCommand {
+	return parseCommandDepth(op, 0)
+}
+
+func parseCommandDepth(op string, depth int) *ParsedCommand {
+	pc := &ParsedCommand{Raw: op}
+	tokens, subs := tokenizeShell(op)
+
+	var words []shellToken
+	operator := ""
+	flush := func(next string) {
+		if len(words) > 0 {
+			pc.Segments = append(pc.Segments, buildSegment(words, operator, depth)...)
+		}
+		words = nil
+		operator = next
+	}
+	for _, tok := range tokens {
+		if tok.op && isControlOp(tok.text) {
+			flush(tok.text)
+			continue
+		}
+		words = append(words, tok)
+	}
+	flush("")
+
+	dir := ""
+	for i := range pc.Segments {
+		seg := &pc.Segments[i]
+		seg.Dir = dir
+		if seg.Executable == "cd" && len(seg.Args) > 0 {
+			dir = seg.Args[0]
+		}
+	}
+
+	if depth < 3 {
+		for _, sub := range subs {
+			pc.Segments = append(pc.Segments, parseCommandDepth(sub, depth+1).Segments...)
+		}
+	}
+	return pc
+}
+
+func isControlOp(op string) bool {
+	switch op {
+	case "&&", "||", "|", ";", "&", "\n":
+		return true
+	}
+	return false
+}
+
+func buildSegment(words []shellToken, operator string, depth int) []CommandSegment {
+	seg := CommandSegment{Operator: operator}
+	var rest []shellToken
+	for i := 0; i < len(words); i++ {
+		if words[i].op {
+			r := Redirection{Op: words[i].text}
+			dup := strings.Contains(r.Op, ">&") || strings.Contains(r.Op, "<&")
+			if !dup && i+1 < len(words) && !words[i+1].op {
+				r.Target = words[i+1].text
+				i++
+			}
+			seg.Redirections = append(seg.Redirections, r)
+			continue
+		}
+		rest = append(rest, words[i])
+	}
+
+	// Skip leading VAR=value assignments and transparent wrappers.
+	for len(rest) > 0 {
+		w := rest[0].text
+		if !rest[0].quoted && strings.Contains(w, "=") && !strings.HasPrefix(w, "=") && !strings.HasPrefix(w, "-") {
+			rest = rest[1:]
+			continue
+		}
+		if shellWrappers[executableName(w)] && len(rest) > 1 {
+			rest = rest[1:]
+			for len(rest) > 1 && (strings.HasPrefix(rest[0].text, "-") || isNumeric(rest[0].text)) {
+				rest = rest[1:]
+			}
+			continue
+		}
+		break
+	}
+	if len(rest) == 0 {
+		if len(seg.Redirections) == 0 {
+			return nil
+		}
+		return []CommandSegment{seg}
+	}
+
+	seg.Executable = executableName(rest[0].text)
+	for _, w := range rest[1:] {
+		seg.Args = append(seg.Args, w.text)
+		seg.quotedArgs = append(seg.quotedArgs, w.quoted)
+		if strings.HasPrefix(w.text, "-") && len(w.text) > 1 {
+			seg.Flags = append(seg.Flags, w.text)
+		} else if seg.Subcommand == "" {
+			seg.Subcommand = strings.ToLower(w.text)
+		}
+	}
+	segments := []CommandSegment{seg}
+
+	if depth >= 3 {
+		return segments
+	}
+	for _, script := range append(embeddedScripts(seg), remoteScripts(seg)...) {
+		inner := parseCommandDepth(script, depth+1).Segments
+		if len(inner) > 0 && seg.Operator == "|" && inner[0].Operator == "" {
+			// The embedded command inherits the piped standard input.
+			inner[0].Operator = "|"
+		}
+		segments = append(segments, inner...)
+	}
+	return segments
+}
+
+// embeddedScripts returns command text a segment executes itself:
+// `sh -c`, `eval`, `cmd /c`, `powershell -Command|-EncodedCommand` and
+// Invoke-Expression. Remote commands are returned by remoteScripts.
+func embeddedScripts(seg CommandSegment) []string {
+	args := seg.Args
+	switch {
+	case shellInterpreters[seg.Executable]:
+		for i, a := range args {
+			if a == "-c" && i+1 < len(args) {
+				return []string{args[i+1]}
+			}
+		}
+	case seg.Executable == "eval", seg.Executable == "iex", seg.Executable == "invoke-expression":
+		if len(args) > 0 {
+			return []string{strings.Join(args, " ")}
+		}
+	case seg.Executable == "cmd" || seg.Executable == "cmd.exe":
+		for i, a := range args {
+			if l := strings.ToLower(a); (l == "/c" || l == "/k") && i+1 < len(args) {
+				return []string{strings.Join(args[i+1:], " ")}
+			}
+		}
+	case powershellHosts[seg.Executable]:
+		for i, a := range args {
+			l := strings.ToLower(a)
+			if i+1 >= len(args) {
+				break
+			}
+			if isPowerShellParam(l, "-command") {
+				return []string{strings.Join(args[i+1:], " ")}
+			}
+			if isPowerShellParam(l, "-encodedcommand") || l == "-ec" {
+				if script, ok := decodePowerShellCommand(args[i+1]); ok {
+					return []string{script}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isPowerShellParam reports whether arg is an abbreviation of param, which
+// PowerShell accepts for its host parameters (-c, -com, -Command).
+func isPowerShellParam(arg, param string) bool {
+	return len(arg) > 1 && strings.HasPrefix(param, arg)
+}
+
+// decodePowerShellCommand decodes an -EncodedCommand payload (base64 of
+// UTF-16LE text).
+func decodePowerShellCommand(encoded string) (string, bool) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw)%2 != 0 {
+		return "", false
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// executableName lowercases the base name of a Unix or Windows path.
+func executableName(word string) string {
+	if i := strings.LastIndexByte(word, '\\'); i >= 0 {
+		word = word[i+1:]
+	}
+	return strings.ToLower(filepath.Base(word))
+}
+
+// shellEscapable are the characters a backslash escapes outside quotes. Any
+// other backslash is kept so Windows paths survive tokenization.
+const shellEscapable = " \t\n\\'\"$`;|&<>()#*?"
+
+// tokenizeShell splits s into words and operators. It also returns the
+// contents of $(...) and `...` substitutions, which the shell executes.
+func tokenizeShell(s string) ([]shellToken, []string) {
+	var (
+		tokens []shellToken
+		subs   []string
+		cur    strings.Builder
+		inWord bool
+		quoted bool
+	)
+	emit := func() {
+		if inWord {
+			tokens = append(tokens, shellToken{text: cur.String(), quoted: quoted})
+		}
+		cur.Reset()
+		inWord, quoted = false, false
+	}
+
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '\\' && i+1 < len(rs) && strings.ContainsRune(shellEscapable, rs[i+1]):
+			cur.WriteRune(rs[i+1])
+			inWord = true
+			i++
+		case c == '\'':
+			end := indexRune(rs, i+1, '\'')
+			if end < 0 {
+				cur.WriteRune(c) // unterminated: literal apostrophe
+				inWord = true
+				continue
+			}
+			cur.WriteString(string(rs[i+1 : end]))
+			inWord, quoted = true, true
+			i = end
+		case c == '"':
+			end := i + 1
+			for end < len(rs) && rs[end] != '"' {
+				if rs[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rs) {
+				cur.WriteRune(c)
+				inWord = true
+				continue
+			}
+			inner := string(rs[i+1 : end])
+			subs = append(subs, substitutions(inner)...)
+			cur.WriteString(strings.ReplaceAll(inner, `\"`, `"`))
+			inWord, quoted = true, true
+			i = end
+		case c == '$' && i+1 < len(rs) && rs[i+1] == '(':
+			end := matchParen(rs, i+1)
+			if end < 0 {
+				cur.WriteRune(c)
+				inWord = true
+				continue
+			}
+			subs = append(subs, string(rs[i+2:end]))
+			cur.WriteString(string(rs[i : end+1]))
+			inWord = true
+			i = end
+		case c == '`':
+			end := indexRune(rs, i+1, '`')
+			if end < 0 {
+				cur.WriteRune(c)
+				inWord = true
+				continue
+			}
+			subs = append(subs, string(rs[i+1:end]))
+			cur.WriteString(string(rs[i : end+1]))
+			inWord = true
+			i = end
+		case c == '#' && !inWord:
+			// Comment to end of line.
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '\n' || c == ';' || c == '|' || c == '&':
+			emit()
+			op := string(c)
+			if i+1 < len(rs) && (c == '|' || c == '&') && rs[i+1] == c {
+				op += string(c)
+				i++
+			} else if c == '&' && i+1 < len(rs) && rs[i+1] == '>' {
+				op = "&>"
+				i++
+				if i+1 < len(rs) && rs[i+1] == '>' {
+					op += ">"
+					i++
+				}
+				tokens = append(tokens, shellToken{text: op, op: true})
+				continue
+			}
+			tokens = append(tokens, shellToken{text: op, op: true})
+		case c == '>' || c == '<':
+			op := ""
+			if inWord && !quoted && isNumeric(cur.String()) {
+				op = cur.String() // file descriptor, e.g. 2>
+				cur.Reset()
+				inWord = false
+			}
+			emit()
+			op += string(c)
+			if i+1 < len(rs) && rs[i+1] == c {
+				op += string(c)
+				i++
+			}
+			if i+1 < len(rs) && rs[i+1] == '&' {
+				// Descriptor duplication (2>&1) has no file target.
+				j := i + 2
+				for j < len(rs) && (rs[j] >= '0' && rs[j] <= '9' || rs[j] == '-') {
+					j++
+				}
+				op += string(rs[i+1 : j])
+				i = j - 1
+			}
+			tokens = append(tokens, shellToken{text: op, op: true})
+		case c == ' ' || c == '\t' || c == '\r':
+			emit()
+		default:
+			cur.WriteRune(c)
+			inWord = true
+		}
+	}
+	emit()
+	return tokens, subs
+}
+
+func substitutions(s string) []string {
+	_, subs := tokenizeShell(strings.ReplaceAll(s, `"`, ""))
+	return subs
+}
+
+func indexRune(rs []rune, from int, r rune) int {
+	for i := from; i < len(rs); i++ {
+		if rs[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchParen returns the index of the ')' closing the '(' at open.
+func matchParen(rs []rune, open int) int {
+	depth := 0
+	for i := open; i < len(rs); i++ {
+		switch rs[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
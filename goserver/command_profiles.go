@@ -0,0 +1,243 @@
+package main
+
+import "strings"
+
+// BinaryRule assigns a category and risk to a matched command shape.
+type BinaryRule struct {
+	Category string
+	Risk     float32
+}
+
+// BinaryProfile describes how one executable is classified. Subcommand and
+// flag rules escalate the base rule when they match; Inert binaries (echo,
+// printf) treat their arguments as data, never as commands.
+type BinaryProfile struct {
+	Base        BinaryRule
+	Subcommands map[string]BinaryRule
+	Flags       map[string]BinaryRule // "-r" matches clustered short flags like -rf
+	Inert       bool
+}
+
+var (
+	ruleFile    = BinaryRule{Category: "FILE_MANAGEMENT", Risk: 0.15}
+	ruleDelete  = BinaryRule{Category: "FILE_MANAGEMENT", Risk: 0.35}
+	ruleCode    = BinaryRule{Category: "CODE_EDITING", Risk: 0.20}
+	rulePriv    = BinaryRule{Category: "PRIVILEGE_ESCALATION", Risk: 0.90}
+	ruleSysMod  = BinaryRule{Category: "SYSTEM_MODIFICATION", Risk: 0.85}
+	ruleExfil   = BinaryRule{Category: "DATA_EXFILTRATION", Risk: 0.70}
+	ruleExec    = BinaryRule{Category: "CODE_EXECUTION", Risk: 0.90}
+	ruleUnknown = BinaryRule{Category: "UNKNOWN", Risk: 0.40}
+)
+
+// defaultBinaryProfiles classifies well-known executables by structure so
+// that, e.g., `echo sudo` is not privilege escalation.
+var defaultBinaryProfiles = map[string]BinaryProfile{
+	"echo":   {Base: ruleFile, Inert: true},
+	"printf": {Base: ruleFile, Inert: true},
+	"true":   {Base: ruleFile, Inert: true},
+	"false":  {Base: ruleFile, Inert: true},
+
+	"ls": {Base: ruleFile}, "cd": {Base: ruleFile}, "pwd": {Base: ruleFile},
+	"mkdir": {Base: ruleFile}, "cp": {Base: ruleFile}, "mv": {Base: ruleFile},
+	"cat": {Base: ruleFile}, "head": {Base: ruleFile}, "tail": {Base: ruleFile},
+	"touch": {Base: ruleFile}, "wc": {Base: ruleFile}, "grep": {Base: ruleFile},
+	"find": {Base: ruleFile}, "less": {Base: ruleFile},
+	"rm": {
+		Base: ruleDelete,
+		Flags: map[string]BinaryRule{
+			"-r": ruleSysMod, "-R": ruleSysMod, "--recursive": ruleSysMod, "--no-preserve-root": ruleSysMod,
+		},
+	},
+
+	"git": {Base: ruleCode}, "go": {Base: ruleCode}, "npm": {Base: ruleCode},
+	"cargo": {Base: ruleCode}, "make": {Base: ruleCode},
+
+	"sudo": {Base: rulePriv}, "su": {Base: rulePriv}, "doas": {Base: rulePriv},
+	"chmod": {Base: rulePriv}, "chown": {Base: rulePriv}, "chgrp": {Base: rulePriv},
+
+	"mkfs": {Base: ruleSysMod}, "shutdown": {Base: ruleSysMod}, "reboot": {Base: ruleSysMod},
+	"dd": {Base: ruleSysMod}, "fdisk": {Base: ruleSysMod}, "format": {Base: ruleSysMod},
+
+	"curl": {Base: ruleExfil}, "wget": {Base: ruleExfil}, "scp": {Base: ruleExfil},
+	"rsync": {Base: ruleExfil}, "nc": {Base: ruleExfil}, "ncat": {Base: ruleExfil},
+
+	// Windows cmd.exe and PowerShell. Flags starting with '/' and PowerShell
+	// parameters match case-insensitively.
+	"remove-item": {Base: ruleDelete, Flags: map[string]BinaryRule{"-recurse": ruleSysMod, "-rec": ruleSysMod}},
+	"ri":          {Base: ruleDelete, Flags: map[string]BinaryRule{"-recurse": ruleSysMod, "-rec": ruleSysMod}},
+	"del":         {Base: ruleDelete, Flags: map[string]BinaryRule{"/s": ruleSysMod, "-recurse": ruleSysMod}},
+	"erase":       {Base: ruleDelete, Flags: map[string]BinaryRule{"/s": ruleSysMod, "-recurse": ruleSysMod}},
+	"rd":          {Base: ruleDelete, Flags: map[string]BinaryRule{"/s": ruleSysMod, "-recurse": ruleSysMod}},
+	"rmdir":       {Base: ruleDelete, Flags: map[string]BinaryRule{"/s": ruleSysMod, "-recurse": ruleSysMod}},
+	"icacls":      {Base: rulePriv}, "takeown": {Base: rulePriv}, "runas": {Base: rulePriv},
+	"set-executionpolicy": {Base: rulePriv},
+	"net":                 {Base: ruleUnknown, Subcommands: map[string]BinaryRule{"user": rulePriv, "localgroup": rulePriv}},
+	"reg": {Base: ruleUnknown, Subcommands: map[string]BinaryRule{
+		"add": ruleSysMod, "delete": ruleSysMod, "import": ruleSysMod, "load": ruleSysMod, "restore": ruleSysMod,
+	}},
+	"schtasks": {Base: ruleUnknown, Flags: map[string]BinaryRule{"/create": ruleSysMod, "/change": ruleSysMod, "/delete": ruleSysMod}},
+	"bcdedit":  {Base: ruleSysMod}, "vssadmin": {Base: ruleSysMod}, "format-volume": {Base: ruleSysMod},
+	"clear-disk": {Base: ruleSysMod}, "stop-computer": {Base: ruleSysMod}, "restart-computer": {Base: ruleSysMod},
+	"iex": {Base: ruleExec}, "invoke-expression": {Base: ruleExec},
+	"invoke-webrequest": {Base: ruleExfil}, "iwr": {Base: ruleExfil}, "invoke-restmethod": {Base: ruleExfil},
+	"irm": {Base: ruleExfil}, "start-bitstransfer": {Base: ruleExfil},
+	"certutil":   {Base: ruleUnknown, Flags: map[string]BinaryRule{"-urlcache": ruleExfil, "-decode": ruleExec}},
+	"powershell": {Base: ruleUnknown, Flags: map[string]BinaryRule{"-encodedcommand": ruleExec, "-enc": ruleExec, "-ec": ruleExec}},
+	"pwsh":       {Base: ruleUnknown, Flags: map[string]BinaryRule{"-encodedcommand": ruleExec, "-enc": ruleExec, "-ec": ruleExec}},
+}
+
+// protectedWritePrefixes are redirection targets that modify the system.
+var protectedWritePrefixes = []string{"/etc/", "/boot/", "/usr/", "/bin/", "/sbin/", "/dev/sd", "/dev/nvme", "/proc/sys/"}
+
+// classifyParsedCommand returns the riskiest rule across all segments.
+// Executables without a profile fall back to keyword matching over that
+// segment's unquoted text.
+func classifyParsedCommand(pc *ParsedCommand, rules *SentinelRules) (string, float32) {
+	best := BinaryRule{}
+	consider := func(r BinaryRule) {
+		if r.Category != "" && (best.Category == "" || r.Risk > best.Risk) {
+			best = r
+		}
+	}
+
+	for i, seg := range pc.Segments {
+		if pipedFetchExecution(pc.Segments, i) {
+			consider(ruleExec)
+		}
+		for _, r := range seg.Redirections {
+			if strings.HasPrefix(r.Op, "<") {
+				continue
+			}
+			for _, prefix := range protectedWritePrefixes {
+				if strings.HasPrefix(r.Target, prefix) {
+					consider(ruleSysMod)
+				}
+			}
+		}
+		if seg.Executable == "" {
+			continue
+		}
+		consider(classifySegment(seg, rules))
+	}
+
+	if len(sqlFindingsForCommand(pc)) > 0 {
+		consider(ruleDatabase)
+	}
+	if mentionsCloudMetadata(pc.Raw) {
+		consider(ruleCloudPriv)
+	}
+
+	if best.Category == "" {
+		return ruleUnknown.Category, ruleUnknown.Risk
+	}
+	if best.Category == ruleTransferRoutine.Category && rules != nil {
+		if routine, _ := rules.Transfers.check(pc.Raw); routine {
+			return ruleTransferRoutine.Category, ruleTransferRoutine.Risk
+		}
+	}
+	return best.Category, best.Risk
+}
+
+func classifySegment(seg CommandSegment, rules *SentinelRules) BinaryRule {
+	var (
+		infra *InfraRules
+		git   *GitRules
+	)
+	if rules != nil {
+		infra, git = rules.Infra, rules.Git
+	}
+	if r, ok := classifyInfraSegment(seg, infra); ok {
+		return r
+	}
+	if r, ok := classifyGitSegment(seg, git); ok {
+		return r
+	}
+	if r, ok := classifyCloudSegment(seg); ok {
+		return r
+	}
+	if r, ok := classifyRemoteSegment(seg); ok {
+		return r
+	}
+
+	exe := seg.Executable
+	profile, ok := defaultBinaryProfiles[exe]
+	if !ok {
+		// mkfs.ext4 and friends share the profile of their family.
+		if i := strings.IndexByte(exe, '.'); i > 0 {
+			profile, ok = defaultBinaryProfiles[exe[:i]]
+		}
+	}
+	if !ok {
+		return keywordRule(exe + " " + strings.Join(seg.unquotedArgs(), " "))
+	}
+
+	rule := profile.Base
+	escalate := func(r BinaryRule) {
+		if r.Risk > rule.Risk {
+			rule = r
+		}
+	}
+	if r, ok := profile.Subcommands[seg.Subcommand]; ok {
+		escalate(r)
+	}
+	for flag, r := range profile.Flags {
+		if seg.hasFlag(flag) {
+			escalate(r)
+		}
+	}
+	if !profile.Inert {
+		// Arguments to non-inert binaries can still carry risky intent,
+		// e.g. natural-language prompts that start with "make" or "find".
+		if r := keywordRule(strings.Join(seg.unquotedArgs(), " ")); r.Category != ruleUnknown.Category {
+			escalate(r)
+		}
+	}
+	return rule
+}
+
+// keywordRule applies the category keyword lists to free text.
+func keywordRule(text string) BinaryRule {
+	lower := normalizeOp(text)
+	for _, c := range defaultOperationCategories {
+		for _, k := range c.Keywords {
+			if strings.Contains(lower, strings.ToLower(k)) {
+				return BinaryRule{Category: c.Category, Risk: c.RiskBase}
+			}
+		}
+	}
+	return ruleUnknown
+}
+
+// hasFlag reports whether flag was passed, including inside a cluster of
+// short flags ("-rf" has "-r" and "-f"). Windows-style "/x" switches and long
+// parameters compare case-insensitively.
+func (s CommandSegment) hasFlag(flag string) bool {
+	if strings.HasPrefix(flag, "/") {
+		for _, a := range s.Args {
+			if strings.EqualFold(a, flag) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, f := range s.Flags {
+		if f == flag || (len(flag) > 2 && strings.EqualFold(f, flag)) {
+			return true
+		}
+		if len(flag) == 2 && !strings.HasPrefix(f, "--") && strings.ContainsRune(f[1:], rune(flag[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s CommandSegment) unquotedArgs() []string {
+	out := make([]string, 0, len(s.Args))
+	for i, a := range s.Args {
+		if i < len(s.quotedArgs) && s.quotedArgs[i] {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package main
+
+import "strings"
+
+var ruleRemoteExec = BinaryRule{Category: "REMOTE_EXECUTION", Risk: 0.50}
+
+// sshValueFlags are ssh options that take a value, either attached ("-p22")
+// or as the next argument.
+const sshValueFlags = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// ansibleCommandModules run their -a argument as a command line.
+var ansibleCommandModules = map[string]bool{
+	"": true, "command": true, "shell": true, "raw": true,
+	"ansible.builtin.command": true, "ansible.builtin.shell": true, "ansible.builtin.raw": true,
+}
+
+// remoteFetchers download content that may be piped into an interpreter.
+var remoteFetchers = map[string]bool{
+	"curl": true, "wget": true, "fetch": true, "nc": true, "ncat": true,
+	"invoke-webrequest": true, "iwr": true, "invoke-restmethod": true, "irm": true,
+}
+
+// stdinInterpreters execute a script read from standard input.
+var stdinInterpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true,
+	"python": true, "python3": true, "perl": true, "ruby": true, "node": true, "php": true,
+}
+
+// remoteScripts returns the command a remote-execution tool runs on the
+// target: `ssh host cmd`, `ansible hosts -m shell -a cmd`, `fab -H host --
+// cmd` and `kubectl exec pod -- cmd`.
+func remoteScripts(seg CommandSegment) []string {
+	args := seg.Args
+	switch seg.Executable {
+	case "ssh":
+		if _, cmd := sshTarget(args); len(cmd) > 0 {
+			return []string{strings.Join(cmd, " ")}
+		}
+	case "ansible":
+		module := strings.ToLower(optionValue(args, "-m", "--module-name"))
+		if a := optionValue(args, "-a", "--args"); a != "" && ansibleCommandModules[module] {
+			return []string{a}
+		}
+	case "fab", "kubectl", "oc":
+		if pos := infraPositionals(args); seg.Executable != "fab" && (len(pos) == 0 || pos[0] != "exec") {
+			break
+		}
+		for i, a := range args {
+			if a == "--" && i+1 < len(args) {
+				return []string{strings.Join(args[i+1:], " ")}
+			}
+		}
+	}
+	return nil
+}
+
+// sshTarget splits ssh arguments into the destination and the remote command.
+func sshTarget(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			if i+1 < len(args) {
+				return args[i+1], args[i+2:]
+			}
+			return "", nil
+		}
+		if !strings.HasPrefix(a, "-") || len(a) == 1 {
+			return a, args[i+1:]
+		}
+		for j := 1; j < len(a); j++ {
+			if strings.IndexByte(sshValueFlags, a[j]) >= 0 {
+				if j == len(a)-1 {
+					i++ // value is the next argument
+				}
+				break
+			}
+		}
+	}
+	return "", nil
+}
+
+// classifyRemoteSegment classifies remote-execution tools. The remote command
+// itself is parsed into its own segments, so it is judged by the same rules
+// as a local command; this rule only covers the connection.
+func classifyRemoteSegment(seg CommandSegment) (BinaryRule, bool) {
+	switch seg.Executable {
+	case "ssh":
+		host, _ := sshTarget(seg.Args)
+		if strings.HasPrefix(host, "root@") || optionValue(seg.Args, "-l") == "root" {
+			return rulePriv, true
+		}
+		return ruleRemoteExec, true
+	case "ansible", "ansible-playbook":
+		if seg.hasFlag("-b") || seg.hasFlag("--become") {
+			return rulePriv, true
+		}
+		return ruleRemoteExec, true
+	case "fab", "pdsh", "pssh", "parallel-ssh":
+		return ruleRemoteExec, true
+	}
+	return BinaryRule{}, false
+}
+
+// pipedFetchExecution reports whether segment i is an interpreter reading a
+// script from a pipeline that starts with a download (`curl ... | bash`,
+// including `curl ... | ssh host bash`).
+func pipedFetchExecution(segs []CommandSegment, i int) bool {
+	seg := segs[i]
+	if seg.Operator != "|" || !stdinInterpreters[seg.Executable] || !readsScriptFromStdin(seg) {
+		return false
+	}
+	for j := i - 1; j >= 0; j-- {
+		if remoteFetchers[segs[j].Executable] {
+			return true
+		}
+		if segs[j].Operator != "|" {
+			return false
+		}
+	}
+	return false
+}
+
+func readsScriptFromStdin(seg CommandSegment) bool {
+	if seg.hasFlag("-c") || (!shellInterpreters[seg.Executable] && seg.hasFlag("-e")) {
+		return false
+	}
+	if seg.hasFlag("-s") || containsTag(seg.Args, "-") {
+		return true
+	}
+	return len(positionalArgs(seg.Args, nil)) == 0
+}
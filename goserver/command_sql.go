@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var ruleDatabase = BinaryRule{Category: "DATABASE", Risk: 0.85}
+
+// SQLFinding is one destructive statement found in an operation.
+type SQLFinding struct {
+	Kind      string // DROP | TRUNCATE | DELETE_NO_WHERE | UPDATE_NO_WHERE | GRANT_ALL | ALTER_DROP
+	Statement string
+}
+
+// sqlClients pass SQL as an argument (psql -c, mysql -e, sqlite3 db "...").
+var sqlClients = map[string]bool{
+	"psql": true, "mysql": true, "mariadb": true, "sqlite3": true, "sqlcmd": true,
+	"clickhouse-client": true, "cockroach": true, "duckdb": true,
+}
+
+const sqlIdent = "[\\w.\"`\\[\\]]+"
+
+var (
+	sqlBlockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	sqlLineComment  = regexp.MustCompile(`--[^\n]*`)
+	sqlSpaces       = regexp.MustCompile(`\s+`)
+
+	sqlDrop       = regexp.MustCompile(`(?i)\bdrop\s+(table|database|schema|view|materialized\s+view|user|role)\s+(if\s+exists\s+)?` + sqlIdent)
+	sqlAlterDrop  = regexp.MustCompile(`(?i)\balter\s+table\s+` + sqlIdent + `\s+drop\s+`)
+	sqlTruncate   = regexp.MustCompile(`(?i)\btruncate\s+table\s+` + sqlIdent)
+	sqlTruncateAt = regexp.MustCompile(`(?i)^truncate\s+` + sqlIdent)
+	sqlGrantAll   = regexp.MustCompile(`(?i)\bgrant\s+all(\s+privileges)?\s+on\b`)
+	sqlDeleteAt   = regexp.MustCompile(`(?i)^delete\s+from\s+` + sqlIdent)
+	sqlDeleteUp   = regexp.MustCompile(`\bDELETE\s+FROM\s+` + sqlIdent)
+	sqlUpdate     = regexp.MustCompile(`(?i)\bupdate\s+` + sqlIdent + `\s+set\s+` + sqlIdent + `\s*=`)
+	sqlWhere      = regexp.MustCompile(`(?i)\bwhere\b`)
+)
+
+// analyzeSQL reports destructive statements in text. Comments are stripped
+// and whitespace collapsed so multi-line statements are matched as one.
+// DELETE FROM only counts at the start of a statement or in upper case, so
+// prose like "delete from my list" is not mistaken for SQL.
+func analyzeSQL(text string) []SQLFinding {
+	text = sqlBlockComment.ReplaceAllString(text, " ")
+	text = sqlLineComment.ReplaceAllString(text, " ")
+
+	var findings []SQLFinding
+	for _, stmt := range splitSQLStatements(text) {
+		stmt = strings.TrimSpace(sqlSpaces.ReplaceAllString(stmt, " "))
+		if stmt == "" {
+			continue
+		}
+		add := func(kind string) {
+			findings = append(findings, SQLFinding{Kind: kind, Statement: truncate(stmt, 200)})
+		}
+		switch {
+		case sqlDrop.MatchString(stmt):
+			add("DROP")
+		case sqlAlterDrop.MatchString(stmt):
+			add("ALTER_DROP")
+		case sqlTruncate.MatchString(stmt) || sqlTruncateAt.MatchString(stmt):
+			add("TRUNCATE")
+		case sqlGrantAll.MatchString(stmt):
+			add("GRANT_ALL")
+		case (sqlDeleteAt.MatchString(stmt) || sqlDeleteUp.MatchString(stmt)) && !sqlWhere.MatchString(stmt):
+			add("DELETE_NO_WHERE")
+		case sqlUpdate.MatchString(stmt) && !sqlWhere.MatchString(stmt):
+			add("UPDATE_NO_WHERE")
+		}
+	}
+	return findings
+}
+
+// splitSQLStatements splits on semicolons outside quotes. String literal
+// contents are dropped so data such as 'a; DROP TABLE x' is never matched.
+func splitSQLStatements(text string) []string {
+	var (
+		out   []string
+		cur   strings.Builder
+		quote rune
+		prev  rune
+	)
+	for _, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if quote == '\'' {
+				continue
+			}
+		case r == '"' || (r == '\'' && !unicode.IsLetter(prev) && !unicode.IsDigit(prev)):
+			// An apostrophe inside a word ("don't") does not open a literal.
+			quote = r
+		case r == ';':
+			out = append(out, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+		prev = r
+	}
+	return append(out, cur.String())
+}
+
+// sqlFindingsForCommand analyzes the raw operation and every argument passed
+// to a database client, where the SQL starts its own statement.
+func sqlFindingsForCommand(pc *ParsedCommand) []SQLFinding {
+	findings := analyzeSQL(pc.Raw)
+	for _, seg := range pc.Segments {
+		if !sqlClients[seg.Executable] {
+			continue
+		}
+		for _, a := range seg.Args {
+			if strings.HasPrefix(a, "-") {
+				continue
+			}
+			findings = append(findings, analyzeSQL(a)...)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestAnalyzeSQLDestructiveStatements(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"DROP TABLE users;", "DROP"},
+		{"drop database if exists prod", "DROP"},
+		{"TRUNCATE audit_log", "TRUNCATE"},
+		{"DELETE FROM users", "DELETE_NO_WHERE"},
+		{"DELETE\n  FROM users -- cleanup\n;", "DELETE_NO_WHERE"},
+		{"update accounts\nset balance = 0", "UPDATE_NO_WHERE"},
+		{"GRANT ALL PRIVILEGES ON *.* TO 'agent'@'%'", "GRANT_ALL"},
+		{"ALTER TABLE users DROP COLUMN email", "ALTER_DROP"},
+	}
+	for _, tc := range cases {
+		findings := analyzeSQL(tc.text)
+		if len(findings) != 1 || findings[0].Kind != tc.want {
+			t.Errorf("analyzeSQL(%q) = %+v, want %s", tc.text, findings, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeSQLIgnoresSafeStatementsAndProse(t *testing.T) {
+	safe := []string{
+		"DELETE FROM users\nWHERE id = 42;",
+		"UPDATE accounts SET balance = 0 WHERE id = 7",
+		"SELECT * FROM users; /* DROP TABLE users */",
+		"please delete from my todo list the dentist appointment",
+		"INSERT INTO notes VALUES ('a; DROP TABLE x')",
+	}
+	for _, text := range safe {
+		if findings := analyzeSQL(text); len(findings) != 0 {
+			t.Errorf("analyzeSQL(%q) = %+v, want none", text, findings)
+		}
+	}
+}
+
+func TestClassifySQLInDatabaseClients(t *testing.T) {
+	for _, op := range []string{
+		`psql -h db -c "delete from sessions"`,
+		`mysql -e 'drop table orders' shop`,
+		"run this on prod:\nTRUNCATE TABLE payments;",
+		"don't ask, just DROP TABLE users",
+	} {
+		if got := classifyOperation(op); got != "DATABASE" {
+			t.Errorf("classifyOperation(%q) = %s, want DATABASE", op, got)
+		}
+	}
+	if got := classifyOperation(`psql -c "select count(*) from users"`); got == "DATABASE" {
+		t.Fatalf("read-only query should not be DATABASE")
+	}
+}
@@ -1,9 +1,6 @@
 package main
 
-import (
-	"encoding/json"
-	"os"
-)
+import "encoding/json"
 
 type SentinelEvalOutput struct {
 	Action       string   `json:"action"`
@@ -34,6 +31,7 @@ type SentinelOneClickOutput struct {
 	OpenClawStatus      string   `json:"openclaw_status,omitempty"`
 	OpenClawMessage     string   `json:"openclaw_message,omitempty"`
 	OpenClawTaskID      string   `json:"openclaw_task_id,omitempty"`
+	SessionID           string   `json:"session_id,omitempty"`
 }
 
 type SentinelOneClickConfig struct {
@@ -43,7 +41,7 @@ type SentinelOneClickConfig struct {
 }
 
 func loadSentinelConfigOnly(path string) (*SentinelConfig, error) {
-	data, err := os.ReadFile(path)
+	data, err := readConfigSource(path)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +57,7 @@ func loadSentinelConfigOnly(path string) (*SentinelConfig, error) {
 }
 
 func loadSentinelOneClickConfig(path string) (*SentinelOneClickConfig, error) {
-	data, err := os.ReadFile(path)
+	data, err := readConfigSource(path)
 	if err != nil {
 		return nil, err
 	}
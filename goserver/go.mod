@@ -1,3 +1,25 @@
 module github.com/lazarus-protocol/goserver
 
 go 1.21
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.32.7
+	github.com/block-vision/sui-go-sdk v1.0.5
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0
+	golang.org/x/crypto v0.31.0
+)
+
+require (
+	github.com/aws/smithy-go v1.22.1 // indirect
+	github.com/go-playground/locales v0.14.1 // indirect
+	github.com/go-playground/universal-translator v0.18.1 // indirect
+	github.com/go-playground/validator/v10 v10.12.0 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
+	github.com/leodido/go-urn v1.2.2 // indirect
+	github.com/tidwall/gjson v1.14.4 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/time v0.3.0 // indirect
+)
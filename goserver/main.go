@@ -16,19 +16,111 @@ import (
 )
 
 func main() {
+	// Subcommands take their own flags.
+	if len(os.Args) > 1 {
+		subcommands := map[string]struct {
+			run  func([]string, io.Writer) error
+			fail string
+		}{
+			"key":             {runKeyCommand, "Key command failed"},
+			"config":          {runConfigCommand, "Config command failed"},
+			"recover":         {runRecoverCommand, "Recovery failed"},
+			"claim":           {runClaimCommand, "Claim failed"},
+			"simulate-claim":  {runSimulateClaimCommand, "Claim simulation failed"},
+			"create":          {runCreateCommand, "Vault creation failed"},
+			"heartbeat-stats": {runHeartbeatStatsCommand, "Heartbeat stats failed"},
+			"incident":        {runIncidentCommand, "Incident report failed"},
+			"verify-anchors":  {runVerifyAnchorsCommand, "Anchor verification failed"},
+			"audit":           {runAuditCommand, "Audit query failed"},
+			"purge":           {runPurgeCommand, "Purge failed"},
+			"lists":           {runListsCommand, "Lists command failed"},
+			"walrus":          {runWalrusCommand, "Walrus command failed"},
+		}
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd.run(os.Args[2:], os.Stdout); err != nil {
+				log.Fatalf("%s: %v", cmd.fail, err)
+			}
+			return
+		}
+	}
+
 	// Command-line flags
-	configPath := flag.String("config", "configs/config.json", "Path to configuration file")
+	configPath := flag.String("config", "configs/config.json", "Path to configuration file, encrypted bundle, or https URL serving a bundle")
 	walrusURL := flag.String("walrus", "https://publisher.walrus-testnet.walrus.space", "Walrus publisher URL")
-	sentinelBenchmark := flag.String("sentinel-benchmark", "", "Path to Sentinel benchmark JSON file")
+	sentinelBenchmark := flag.String("sentinel-benchmark", "", "Path or glob of Sentinel benchmark case files (.json, .csv, .yaml)")
 	sentinelBenchmarkOut := flag.String("sentinel-benchmark-out", "", "Optional path to write Sentinel benchmark report JSON")
+	behaviorBenchmark := flag.String("behavior-benchmark", "", "Path to PolicyGate/behavioral benchmark scenarios JSON file (report written to --sentinel-benchmark-out)")
 	sentinelEvalAction := flag.String("sentinel-eval-action", "", "Action to evaluate with Sentinel (requires --sentinel-eval-prompt)")
 	sentinelEvalPrompt := flag.String("sentinel-eval-prompt", "", "Prompt to evaluate with Sentinel (requires --sentinel-eval-action)")
 	sentinelOneClickAction := flag.String("sentinel-oneclick-action", "", "One-click action sent to OpenClaw with Sentinel audit/enforcement")
 	sentinelOneClickPrompt := flag.String("sentinel-oneclick-prompt", "", "One-click prompt sent to OpenClaw (requires --sentinel-oneclick-action)")
 	sentinelProxy := flag.Bool("sentinel-proxy", false, "Start Sentinel in-path proxy HTTP server")
 	sentinelProxyAddr := flag.String("sentinel-proxy-addr", "127.0.0.1:18080", "Listen address for the Sentinel proxy server")
+	sentinelPolicyProxy := flag.String("sentinel-policy-proxy", "", "Upstream URL of an agent/LLM tool server to guard as a standalone policy proxy")
+	sentinelPolicyProxyAddr := flag.String("sentinel-policy-proxy-addr", "127.0.0.1:18081", "Listen address for --sentinel-policy-proxy")
+	sentinelMCP := flag.Bool("sentinel-mcp", false, "Serve the guard as an MCP server (evaluate_risk, check_command, record_operation) on stdin/stdout")
+	evidenceExport := flag.String("evidence-export", "", "Write a signed evidence bundle (tar.gz) for the --evidence-since window")
+	evidenceSince := flag.String("evidence-since", "72h", "Time window for --evidence-export (Go duration)")
+	verifyBundle := flag.String("verify-bundle", "", "Verify a signed evidence bundle produced by --evidence-export")
+	verifyBundleKey := flag.String("verify-bundle-key", "", "Operator's hex Ed25519 public key that must have signed the --verify-bundle manifest (required)")
+	verifyAudit := flag.String("verify-audit", "", "Walk the hash chain of a Sentinel JSONL audit log and report the first broken link")
+	verifyAuditRPC := flag.String("verify-audit-rpc", "", "Also check every --verify-audit record and anchor against this Sui JSON-RPC endpoint")
+	allowlistHash := flag.Bool("allowlist-hash", false, "Print the on-chain allowlist hash for --sentinel-eval-action/--sentinel-eval-prompt instead of evaluating")
+	sentinelHook := flag.String("sentinel-hook", "", "Print a git hook script (pre-push or pre-commit) that checks operations via the Sentinel proxy")
+	sentinelShellHook := flag.String("sentinel-shell-hook", "", "Print a shell integration (zsh, bash, fish or powershell) that reports executed commands to the Sentinel proxy")
+	installLaunchAgent := flag.Bool("install-launchagent", false, "Install a macOS LaunchAgent that starts the Sentinel proxy (--sentinel-proxy-addr) at login")
+	sentinelHookURL := flag.String("sentinel-hook-url", "http://127.0.0.1:18080", "Sentinel proxy base URL used by --sentinel-hook and --sentinel-shell-hook scripts")
 	flag.Parse()
 
+	if *verifyBundle != "" {
+		if err := runVerifyBundleMode(*verifyBundle, *verifyBundleKey, os.Stdout); err != nil {
+			log.Fatalf("Bundle verification failed: %v", err)
+		}
+		return
+	}
+
+	if *verifyAudit != "" {
+		if err := runVerifyAuditMode(*verifyAudit, *verifyAuditRPC, os.Stdout); err != nil {
+			log.Fatalf("Audit verification failed: %v", err)
+		}
+		return
+	}
+
+	if *sentinelHook != "" {
+		if err := runSentinelHookMode(*sentinelHook, *sentinelHookURL, os.Stdout); err != nil {
+			log.Fatalf("Sentinel hook generation failed: %v", err)
+		}
+		return
+	}
+
+	if *sentinelShellHook != "" {
+		if err := runSentinelShellHookMode(*sentinelShellHook, *sentinelHookURL, os.Stdout); err != nil {
+			log.Fatalf("Sentinel shell hook generation failed: %v", err)
+		}
+		return
+	}
+
+	if *installLaunchAgent {
+		if err := runInstallLaunchAgentMode(*configPath, *sentinelProxyAddr, os.Stdout); err != nil {
+			log.Fatalf("LaunchAgent installation failed: %v", err)
+		}
+		return
+	}
+
+	if *evidenceExport != "" {
+		if err := runEvidenceExportMode(*configPath, *evidenceSince, *evidenceExport, os.Stdout); err != nil {
+			log.Fatalf("Evidence export failed: %v", err)
+		}
+		return
+	}
+
+	if *allowlistHash {
+		if err := runAllowlistHashMode(*configPath, *sentinelEvalAction, *sentinelEvalPrompt, os.Stdout); err != nil {
+			log.Fatalf("Allowlist hash failed: %v", err)
+		}
+		return
+	}
+
 	if *sentinelEvalAction != "" || *sentinelEvalPrompt != "" {
 		if err := runSentinelEvalMode(*configPath, *sentinelEvalAction, *sentinelEvalPrompt, os.Stdout); err != nil {
 			log.Fatalf("Sentinel eval failed: %v", err)
@@ -48,6 +140,20 @@ func main() {
 		return
 	}
 
+	if *sentinelMCP {
+		if err := runSentinelMCPMode(*configPath, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("MCP server failed: %v", err)
+		}
+		return
+	}
+
+	if *sentinelPolicyProxy != "" {
+		if err := runSentinelPolicyProxyMode(*configPath, *sentinelPolicyProxy, *sentinelPolicyProxyAddr); err != nil {
+			log.Fatalf("Policy proxy failed: %v", err)
+		}
+		return
+	}
+
 	if *sentinelBenchmark != "" {
 		sentinelCfg, err := loadSentinelConfigOnly(*configPath)
 		if err != nil {
@@ -73,6 +179,29 @@ func main() {
 		return
 	}
 
+	if *behaviorBenchmark != "" {
+		sentinelCfg, err := loadSentinelConfigOnly(*configPath)
+		if err != nil {
+			log.Fatalf("Failed to load sentinel config for benchmark: %v", err)
+		}
+
+		report, err := RunBehaviorBenchmark(*behaviorBenchmark, resolveSentinelConfig(sentinelCfg))
+		if err != nil {
+			log.Fatalf("Behavior benchmark failed: %v", err)
+		}
+		b, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println("\nBehavior benchmark report:")
+		fmt.Println(string(b))
+
+		if strings.TrimSpace(*sentinelBenchmarkOut) != "" {
+			if err := writeBenchmarkReport(*sentinelBenchmarkOut, report); err != nil {
+				log.Fatalf("Failed to write benchmark report: %v", err)
+			}
+			log.Printf("Benchmark report written to %s", *sentinelBenchmarkOut)
+		}
+		return
+	}
+
 	// No mode selected — print usage.
 	flag.Usage()
 }
@@ -191,7 +320,9 @@ func runSentinelOneClickModeWithSender(configPath, action, prompt string, out io
 		return encodeSentinelOutput(out, result)
 	}
 
+	session := guard.startSession("", rec.RecordHash, map[string]string{"action": action, "prompt": prompt})
 	resp, err := sender(cfg.OpenClaw, prompt)
+	guard.finishSession(session, resp, err)
 	if err != nil {
 		return fmt.Errorf("openclaw dispatch failed: %w", err)
 	}
@@ -200,6 +331,7 @@ func runSentinelOneClickModeWithSender(configPath, action, prompt string, out io
 	result.OpenClawStatus = resp.Status
 	result.OpenClawMessage = resp.Message
 	result.OpenClawTaskID = resp.TaskID
+	result.SessionID = session
 	return encodeSentinelOutput(out, result)
 }
 
@@ -208,7 +340,7 @@ func verifyRustCLIHash(guard *SentinelGuard, rec *AuditRecord) error {
 		return fmt.Errorf("sentinel audit record is not available")
 	}
 
-	hashOut, err := guard.hashViaRust(rec)
+	hashOut, err := guard.hashViaRust(hashedAuditForm(rec))
 	if err != nil {
 		return fmt.Errorf("rustcli hash verification failed: %w (build rustcli first: cargo build --release in rustcli/)", err)
 	}
@@ -243,7 +375,7 @@ func encodeSentinelOutput(out io.Writer, data interface{}) error {
 	return enc.Encode(data)
 }
 
-func writeBenchmarkReport(path string, report *BenchmarkReport) error {
+func writeBenchmarkReport(path string, report interface{}) error {
 	if report == nil {
 		return fmt.Errorf("benchmark report is nil")
 	}
@@ -278,7 +410,29 @@ func runSentinelProxyMode(configPath, listenAddr, walrusURL string) {
 	var oc *OpenClawClient
 	if cfg.OpenClaw != nil && cfg.OpenClaw.Enabled {
 		oc = NewOpenClawClient(cfg.OpenClaw, guard)
+		guard.capabilities.set(capOpenClaw, true, "")
 		log.Printf("  OpenClaw: enabled (%s)", cfg.OpenClaw.ServerURL)
+	} else {
+		guard.capabilities.set(capOpenClaw, false, "openclaw.enabled=false")
+	}
+
+	guard.probeCapabilities()
+	if err := guard.capabilities.checkMandatory(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+	if degraded := guard.capabilities.Degraded(); len(degraded) > 0 {
+		log.Printf("  Degraded: %s", strings.Join(degraded, ", "))
+	}
+	if keys, err := loadConfigFileKeys(configPath); err == nil {
+		guard.SetConfigFileKeys(keys)
+	}
+	for _, line := range guard.EffectiveConfigReport().BannerLines() {
+		log.Printf("  %s", line)
+	}
+	if rec, err := guard.RecordConfigSnapshot("startup"); err != nil {
+		log.Printf("  Config snapshot: not recorded: %v", err)
+	} else {
+		log.Printf("  Config snapshot: %s", rec.Reason)
 	}
 
 	gwCfg := &SentinelGatewayConfig{
@@ -289,6 +443,9 @@ func runSentinelProxyMode(configPath, listenAddr, walrusURL string) {
 		ExecuteTokenTTL:     30 * time.Second,
 	}
 
+	if err := checkOperatorToken(&guard.cfg); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
 	gateway := NewSentinelGateway(guard, oc, gwCfg)
 
 	mux := http.NewServeMux()
@@ -302,9 +459,11 @@ func runSentinelProxyMode(configPath, listenAddr, walrusURL string) {
 	log.Println("    POST /sentinel/proxy/execute    - Execute with one-time token")
 	log.Println("    GET  /sentinel/proof/latest     - Latest proof chain entry")
 	log.Println("    GET  /sentinel/status           - System status")
+	log.Println("    GET  /sentinel/config/effective - Resolved config with sources")
 	log.Println("    POST /sentinel/kill-switch/arm  - Arm kill switch")
 	log.Println("    POST /sentinel/kill-switch/disarm - Disarm kill switch")
 	log.Println("    GET  /health                    - Health check")
+	log.Println("    GET  /metrics                   - Prometheus metrics")
 	log.Println()
 
 	srv := &http.Server{
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -21,6 +20,9 @@ type OpenClawConfig struct {
 	Enabled   bool   `json:"enabled"`
 	ServerURL string `json:"server_url"` // Sentinel proxy URL (default http://127.0.0.1:18080)
 	AgentID   string `json:"agent_id"`   // OpenClaw agent id (default "main")
+	// RateLimits caps task dispatch per action type (e.g. "WAKE_UP"); the
+	// "*" entry covers actions without their own limit.
+	RateLimits map[string]OpenClawRateLimit `json:"rate_limits,omitempty"`
 }
 
 // OpenClawRequest represents a request to OpenClaw
@@ -39,6 +41,7 @@ type OpenClawResponse struct {
 type OpenClawClient struct {
 	config   *OpenClawConfig
 	sentinel *SentinelGuard
+	limiter  *openClawLimiter
 }
 
 // NewOpenClawClient creates a new OpenClaw client
@@ -46,6 +49,7 @@ func NewOpenClawClient(config *OpenClawConfig, sentinel *SentinelGuard) *OpenCla
 	return &OpenClawClient{
 		config:   config,
 		sentinel: sentinel,
+		limiter:  newOpenClawLimiter(config.RateLimits),
 	}
 }
 
@@ -65,8 +69,12 @@ func (oc *OpenClawClient) sendTaskWithoutSentinel(prompt string) (*OpenClawRespo
 	}
 
 	// Primary path: use the OpenClaw CLI which talks to the WebSocket gateway.
-	cmd := exec.Command("openclaw", "agent", "--agent", agentID, "--local", "--message", prompt)
-	out, err := cmd.CombinedOutput()
+	var subprocess *SubprocessConfig
+	if oc.sentinel != nil {
+		subprocess = oc.sentinel.cfg.Subprocess
+	}
+	out, err := runSubprocess(subprocess.policyFor(procOpenClaw), false,
+		"openclaw", "agent", "--agent", agentID, "--local", "--message", prompt)
 	if err != nil {
 		// Fallback: try a direct HTTP POST to the configured server URL (legacy path).
 		return oc.sendTaskHTTP(prompt)
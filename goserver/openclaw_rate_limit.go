@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openClawThrottledAction is the audit action of a dispatch the rate
+// limiter refused.
+const openClawThrottledAction = "OPENCLAW_THROTTLED"
+
+// OpenClawRateLimit is a token bucket for one action type. PerMinute
+// tokens are added each minute up to Burst; a dispatch takes one. When the
+// bucket runs dry the action cools down for CooldownSec before the bucket
+// is consulted again, so a loop that keeps retrying stays throttled.
+type OpenClawRateLimit struct {
+	PerMinute float64 `json:"per_minute"`
+	// Burst is the bucket size; default PerMinute rounded up, at least 1.
+	Burst int `json:"burst,omitempty"`
+	// CooldownSec is the pause after the bucket runs dry; default 0.
+	CooldownSec int `json:"cooldown_sec,omitempty"`
+}
+
+// OpenClawThrottledError is returned for a dispatch the limiter refused.
+type OpenClawThrottledError struct {
+	Action     string
+	RetryAfter time.Duration
+}
+
+func (e *OpenClawThrottledError) Error() string {
+	return fmt.Sprintf("OpenClaw dispatch of %s is rate limited; retry in %s", e.Action, e.RetryAfter.Round(time.Second))
+}
+
+type tokenBucket struct {
+	tokens    float64
+	last      time.Time
+	coolUntil time.Time
+}
+
+// openClawLimiter holds one bucket per action type. The "*" limit applies
+// to actions without their own.
+type openClawLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	limits  map[string]OpenClawRateLimit
+	buckets map[string]*tokenBucket
+}
+
+// newOpenClawLimiter returns nil when no limits are configured.
+func newOpenClawLimiter(limits map[string]OpenClawRateLimit) *openClawLimiter {
+	l := &openClawLimiter{now: time.Now, limits: map[string]OpenClawRateLimit{}, buckets: map[string]*tokenBucket{}}
+	for action, lim := range limits {
+		if lim.PerMinute <= 0 {
+			continue
+		}
+		if lim.Burst <= 0 {
+			lim.Burst = int(math.Max(1, math.Ceil(lim.PerMinute)))
+		}
+		l.limits[strings.ToUpper(strings.TrimSpace(action))] = lim
+	}
+	if len(l.limits) == 0 {
+		return nil
+	}
+	return l
+}
+
+// allow takes a token for action. It returns 0 when the dispatch may go
+// ahead, or how long to wait otherwise. A nil limiter allows everything.
+func (l *openClawLimiter) allow(action string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	key := strings.ToUpper(strings.TrimSpace(action))
+	lim, ok := l.limits[key]
+	if !ok {
+		if lim, ok = l.limits["*"]; !ok {
+			return 0
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(lim.Burst), last: now}
+		l.buckets[key] = b
+	}
+	if now.Before(b.coolUntil) {
+		return b.coolUntil.Sub(now)
+	}
+	perSec := lim.PerMinute / 60
+	b.tokens = math.Min(float64(lim.Burst), b.tokens+now.Sub(b.last).Seconds()*perSec)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	wait := time.Duration((1 - b.tokens) / perSec * float64(time.Second))
+	if cooldown := time.Duration(lim.CooldownSec) * time.Second; cooldown > wait {
+		wait = cooldown
+	}
+	b.coolUntil = now.Add(wait)
+	return wait
+}
+
+// Dispatch sends a task for action to OpenClaw after the per-action rate
+// limit. A throttled attempt is written to the audit log and returns an
+// *OpenClawThrottledError.
+func (oc *OpenClawClient) Dispatch(action, prompt string) (*OpenClawResponse, error) {
+	if !oc.config.Enabled {
+		return nil, fmt.Errorf("OpenClaw is disabled")
+	}
+	if wait := oc.limiter.allow(action); wait > 0 {
+		throttled := &OpenClawThrottledError{Action: action, RetryAfter: wait}
+		if oc.sentinel != nil {
+			rec := &AuditRecord{
+				Timestamp: time.Now().UTC(),
+				Action:    openClawThrottledAction,
+				Prompt:    prompt,
+				Tags:      []string{"rate_limited"},
+				Decision:  "recorded",
+				Reason:    throttled.Error(),
+			}
+			if err := oc.sentinel.persistRecord(rec); err != nil {
+				log.Printf("[OPENCLAW] audit of throttled %s failed: %v", action, err)
+			}
+		}
+		return nil, throttled
+	}
+	return oc.sendTaskWithoutSentinel(prompt)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenClawLimiter(t *testing.T) {
+	l := newOpenClawLimiter(map[string]OpenClawRateLimit{
+		"wake_up": {PerMinute: 2, CooldownSec: 300},
+		"*":       {PerMinute: 60, Burst: 1},
+	})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if wait := l.allow("WAKE_UP"); wait != 0 {
+			t.Fatalf("dispatch %d within the burst was throttled for %s", i, wait)
+		}
+	}
+	if wait := l.allow("WAKE_UP"); wait != 5*time.Minute {
+		t.Fatalf("an empty bucket should start the cooldown, got %s", wait)
+	}
+	now = now.Add(time.Minute)
+	if wait := l.allow("WAKE_UP"); wait != 4*time.Minute {
+		t.Fatalf("refills do not count during the cooldown, got %s", wait)
+	}
+	now = now.Add(4 * time.Minute)
+	if wait := l.allow("WAKE_UP"); wait != 0 {
+		t.Fatalf("the bucket refills after the cooldown, got %s", wait)
+	}
+
+	if l.allow("EXEC") != 0 || l.allow("EXEC") != time.Second {
+		t.Fatal("actions without their own limit use the * bucket")
+	}
+	if l.allow("MESSAGE") != 0 {
+		t.Fatal("each action has its own bucket")
+	}
+	if newOpenClawLimiter(map[string]OpenClawRateLimit{"EXEC": {}}) != nil {
+		t.Fatal("limits without a rate are ignored")
+	}
+}
+
+func TestOpenClawDispatchAuditsThrottledAttempts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	guard := newTestGuard(t, SentinelConfig{AuditLogPath: path})
+	oc := NewOpenClawClient(&OpenClawConfig{
+		Enabled:    true,
+		RateLimits: map[string]OpenClawRateLimit{"WAKE_UP": {PerMinute: 1, CooldownSec: 60}},
+	}, guard)
+	oc.limiter.allow("WAKE_UP") // spend the only token
+
+	_, err := oc.Dispatch("WAKE_UP", "check the inbox")
+	var throttled *OpenClawThrottledError
+	if !errors.As(err, &throttled) || throttled.Action != "WAKE_UP" {
+		t.Fatalf("want a throttled error, got %v", err)
+	}
+
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := records[len(records)-1]
+	if last.Action != openClawThrottledAction || last.Prompt != "check the inbox" || !containsTag(last.Tags, "rate_limited") {
+		t.Fatalf("throttled dispatch should be audited: %+v", last)
+	}
+}
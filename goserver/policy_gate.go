@@ -22,6 +22,15 @@ type PolicyAuditEntry struct {
 	Reason      string    `json:"reason"`
 }
 
+// hardBlockCategories are blocked even for operations learned in the profile.
+var hardBlockCategories = map[string]bool{
+	"PRIVILEGE_ESCALATION": true,
+	"SYSTEM_MODIFICATION":  true,
+	"INFRA_DESTRUCTIVE":    true,
+	"CLOUD_PRIV":           true,
+	"VCS_DESTRUCTIVE":      true,
+}
+
 // PolicyGate wraps behavior detection into user-friendly decisions.
 type PolicyGate struct {
 	agentID string
@@ -39,7 +48,7 @@ func (pg *PolicyGate) CheckCommand(command string) PolicyResult {
 	anomaly := pg.profile.DetectAnomaly(command)
 
 	// Hard blocks: extreme anomaly or known high-risk classes.
-	if anomaly.Score >= 0.90 || anomaly.OpType == "PRIVILEGE_ESCALATION" || anomaly.OpType == "SYSTEM_MODIFICATION" {
+	if anomaly.Score >= 0.90 || hardBlockCategories[anomaly.OpType] {
 		return PolicyResult{
 			Action:        "BLOCK",
 			Reason:        anomaly.Reason,
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionPolicy overrides how one action type is judged.
+type ActionPolicy struct {
+	// Threshold replaces risk_threshold for this action; 0 inherits it.
+	Threshold int `json:"threshold,omitempty"`
+	// RequireApproval sends every request of this action to a human,
+	// whatever its score. Hard blocks still block.
+	RequireApproval bool `json:"require_approval,omitempty"`
+}
+
+// normalizeActionPolicies upper-cases action names and checks thresholds.
+func normalizeActionPolicies(in map[string]ActionPolicy) (map[string]ActionPolicy, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]ActionPolicy, len(in))
+	for action, p := range in {
+		key := strings.ToUpper(strings.TrimSpace(action))
+		if key == "" {
+			return nil, fmt.Errorf("action_policies: empty action name")
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("action_policies: %s is listed twice", key)
+		}
+		if p.Threshold < 0 || p.Threshold > 100 {
+			return nil, fmt.Errorf("action_policies.%s.threshold must be between 1 and 100, or 0 to use risk_threshold", key)
+		}
+		out[key] = p
+	}
+	return out, nil
+}
+
+func (sg *SentinelGuard) actionPolicy(action string) (ActionPolicy, bool) {
+	p, ok := sg.cfg.ActionPolicies[strings.ToUpper(strings.TrimSpace(action))]
+	return p, ok
+}
+
+// actionThreshold is the score at which action blocks. When adaptive mode
+// moves the global threshold away from risk_threshold, overrides move by
+// the same amount so the configured gap between them is kept.
+func (sg *SentinelGuard) actionThreshold(action string) int {
+	global := sg.riskThreshold()
+	p, ok := sg.actionPolicy(action)
+	if !ok || p.Threshold == 0 {
+		return global
+	}
+	sg.runtimeMu.RLock()
+	configured := sg.cfg.RiskThreshold
+	sg.runtimeMu.RUnlock()
+	return minInt(100, maxInt(1, p.Threshold+global-configured))
+}
+
+// actionThresholds lists the effective threshold of every overridden action.
+func (sg *SentinelGuard) actionThresholds() map[string]int {
+	out := map[string]int{}
+	for action := range sg.cfg.ActionPolicies {
+		out[action] = sg.actionThreshold(action)
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// activityCommandLimit caps the command text accepted from a report.
+const activityCommandLimit = 4096
+
+// activitySourceLimit caps the source/owner pairs the monitor keeps; the
+// least recently seen pair is dropped to make room.
+const activitySourceLimit = 256
+
+// ActivityReport is one command execution reported by a shell hook
+// (POST /sentinel/activity).
+type ActivityReport struct {
+	// Source names the reporter; default "shell".
+	Source   string `json:"source,omitempty"`
+	Shell    string `json:"shell,omitempty"` // zsh, bash, fish, powershell
+	AgentID  string `json:"agent_id,omitempty"`
+	Command  string `json:"command"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	// Owner is the household member at the terminal (SENTINEL_OWNER), for
+	// deployments shared by several owners.
+	Owner string `json:"owner,omitempty"`
+}
+
+// ActivitySource is what the monitor knows about one reporter.
+type ActivitySource struct {
+	Name     string    `json:"name"`
+	Owner    string    `json:"owner,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+	Commands int       `json:"commands"`
+	Learned  int       `json:"learned"`
+}
+
+// ActivityStatus is the liveness view served in /sentinel/status.
+type ActivityStatus struct {
+	LastSeen    *time.Time       `json:"last_seen"`
+	IdleSeconds int64            `json:"idle_seconds,omitempty"`
+	Sources     []ActivitySource `json:"sources"`
+}
+
+// ActivityMonitor tracks when the operator was last active, per source and
+// owner.
+// Each reported command is evidence that someone is at the terminal.
+type ActivityMonitor struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	sources map[string]*ActivitySource
+}
+
+func NewActivityMonitor() *ActivityMonitor {
+	return &ActivityMonitor{now: time.Now, sources: map[string]*ActivitySource{}}
+}
+
+// Record notes one command from source. owner is empty unless the
+// deployment is shared by a household.
+func (am *ActivityMonitor) Record(source, owner string, learned bool) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	s := am.sourceLocked(source, owner)
+	s.LastSeen = am.now().UTC()
+	s.Commands++
+	if learned {
+		s.Learned++
+	}
+}
+
+// Observe notes activity from source at a known time, e.g. the last input
+// the OS saw. It never moves LastSeen backwards and counts no command.
+func (am *ActivityMonitor) Observe(source, owner string, at time.Time) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	s := am.sourceLocked(source, owner)
+	if at = at.UTC(); at.After(s.LastSeen) {
+		s.LastSeen = at
+	}
+}
+
+// sourceLocked returns the entry for source and owner, adding it if
+// needed. am.mu must be held.
+func (am *ActivityMonitor) sourceLocked(source, owner string) *ActivitySource {
+	key := source + "\x00" + owner
+	s := am.sources[key]
+	if s == nil {
+		if len(am.sources) >= activitySourceLimit {
+			am.evictOldest()
+		}
+		s = &ActivitySource{Name: source, Owner: owner}
+		am.sources[key] = s
+	}
+	return s
+}
+
+// evictOldest drops the least recently seen source. am.mu must be held.
+func (am *ActivityMonitor) evictOldest() {
+	var oldest string
+	for key, s := range am.sources {
+		if oldest == "" || s.LastSeen.Before(am.sources[oldest].LastSeen) {
+			oldest = key
+		}
+	}
+	delete(am.sources, oldest)
+}
+
+// LastSeen returns the most recent activity from any source.
+func (am *ActivityMonitor) LastSeen() (time.Time, bool) {
+	return am.lastSeen(func(*ActivitySource) bool { return true })
+}
+
+// OwnerLastSeen returns the most recent activity reported for owner.
+func (am *ActivityMonitor) OwnerLastSeen(owner string) (time.Time, bool) {
+	return am.lastSeen(func(s *ActivitySource) bool { return strings.EqualFold(s.Owner, owner) })
+}
+
+func (am *ActivityMonitor) lastSeen(match func(*ActivitySource) bool) (time.Time, bool) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	var last time.Time
+	for _, s := range am.sources {
+		if match(s) && s.LastSeen.After(last) {
+			last = s.LastSeen
+		}
+	}
+	return last, !last.IsZero()
+}
+
+func (am *ActivityMonitor) Status() ActivityStatus {
+	st := ActivityStatus{Sources: []ActivitySource{}}
+	if last, ok := am.LastSeen(); ok {
+		st.LastSeen = &last
+		st.IdleSeconds = int64(am.now().Sub(last) / time.Second)
+	}
+	am.mu.Lock()
+	for _, s := range am.sources {
+		st.Sources = append(st.Sources, *s)
+	}
+	am.mu.Unlock()
+	sort.Slice(st.Sources, func(i, j int) bool {
+		if st.Sources[i].Name != st.Sources[j].Name {
+			return st.Sources[i].Name < st.Sources[j].Name
+		}
+		return st.Sources[i].Owner < st.Sources[j].Owner
+	})
+	return st
+}
+
+// learnActivity adds a command the operator ran to the behavioral profile.
+// Only successful commands the profile already allows are learned, so a
+// report cannot teach the profile to accept an operation it would block or
+// hold for approval.
+func (sg *SentinelGuard) learnActivity(report ActivityReport) bool {
+	if sg.policyGate == nil || (report.ExitCode != nil && *report.ExitCode != 0) {
+		return false
+	}
+	if sg.policyGate.CheckCommand(report.Command).Action != "ALLOW" {
+		return false
+	}
+	sg.policyGate.RecordSuccessfulOperation(report.Command)
+	return true
+}
+
+// handleActivity records a command execution reported by a shell hook. The
+// command feeds liveness and profile learning only; it is not evaluated or
+// written to the audit log. The route requires the operator token.
+func (gw *SentinelGateway) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ActivityReport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	req.Command = strings.TrimSpace(req.Command)
+	if req.Command == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "command is required"})
+		return
+	}
+	if len(req.Command) > activityCommandLimit {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "command is too long"})
+		return
+	}
+	req.Source = strings.TrimSpace(req.Source)
+	if req.Source == "" {
+		req.Source = "shell"
+	}
+
+	req.Owner = strings.TrimSpace(req.Owner)
+
+	learned := gw.guard.learnActivity(req)
+	gw.activity.Record(req.Source, req.Owner, learned)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recorded": true, "learned": learned})
+}
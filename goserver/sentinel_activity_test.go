@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestActivityReportsFeedLivenessAndProfile(t *testing.T) {
+	gw := newTestGateway()
+	if st := gw.activity.Status(); st.LastSeen != nil {
+		t.Fatalf("no activity yet: %+v", st)
+	}
+
+	before := gw.guard.policyGate.GetAgentProfile().DetectAnomaly("make lint").Score
+	for i := 0; i < 3; i++ {
+		rec := postJSON(t, gw.handleActivity, ActivityReport{Shell: "zsh", Command: "make lint", ExitCode: intPtr(0)})
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"learned":true`) {
+			t.Fatalf("report %d: %d %s", i, rec.Code, rec.Body.String())
+		}
+	}
+	if after := gw.guard.policyGate.GetAgentProfile().DetectAnomaly("make lint").Score; after >= before {
+		t.Fatalf("learned command should score less novel: before %.2f after %.2f", before, after)
+	}
+
+	for _, r := range []ActivityReport{
+		{Command: "make deploy", ExitCode: intPtr(2)},
+		{Command: "sudo rm -rf /var/lib/app"},
+	} {
+		if rec := postJSON(t, gw.handleActivity, r); !strings.Contains(rec.Body.String(), `"learned":false`) {
+			t.Fatalf("%q should count as activity but not be learned: %s", r.Command, rec.Body.String())
+		}
+	}
+	if rec := postJSON(t, gw.handleActivity, ActivityReport{Command: "  "}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty command: %d", rec.Code)
+	}
+
+	st := gw.activity.Status()
+	if st.LastSeen == nil || len(st.Sources) != 1 || st.Sources[0].Name != "shell" || st.Sources[0].Commands != 5 || st.Sources[0].Learned != 3 {
+		t.Fatalf("unexpected activity status: %+v", st)
+	}
+}
+
+func TestActivityMonitorCapsSources(t *testing.T) {
+	am := NewActivityMonitor()
+	now := time.Unix(1700000000, 0)
+	am.now = func() time.Time { return now }
+	am.Record("shell", "first", false)
+	for i := 0; i < activitySourceLimit; i++ {
+		now = now.Add(time.Second)
+		am.Record("shell", fmt.Sprintf("owner-%d", i), false)
+	}
+	if n := len(am.Status().Sources); n != activitySourceLimit {
+		t.Fatalf("want %d sources, got %d", activitySourceLimit, n)
+	}
+	if _, ok := am.OwnerLastSeen("first"); ok {
+		t.Fatal("the least recently seen source should have been dropped")
+	}
+}
+
+func TestBashShellHookReportsCommands(t *testing.T) {
+	for _, bin := range []string{"bash", "curl"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not available", bin)
+		}
+	}
+
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "s3cret")
+	gw := newTestGateway()
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/sentinel/activity", "application/json", strings.NewReader(`{"command":"ls"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || gw.activity.Status().LastSeen != nil {
+		t.Fatalf("a report without the token should be refused: %d", resp.StatusCode)
+	}
+
+	script, err := renderSentinelShellHook("bash", srv.URL)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if out, err := exec.Command("bash", "-n", "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("hook is not valid bash: %v\n%s", err, out)
+	}
+
+	hook := filepath.Join(t.TempDir(), "sentinel.bash")
+	if err := os.WriteFile(hook, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("bash", "--norc", "--noprofile", "--noediting", "-i")
+	cmd.Env = []string{"HOME=" + t.TempDir(), "PATH=" + os.Getenv("PATH"), "HISTFILE=/dev/null", "SENTINEL_OPERATOR_TOKEN=s3cret", "SENTINEL_AGENT_ID=ci \"runner\""}
+	cmd.Stdin = strings.NewReader("source " + hook + "\ntrue \"quoted\"\nfalse\nexit 0\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("interactive bash: %v\n%s", err, out)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		st := gw.activity.Status()
+		if len(st.Sources) == 1 && st.Sources[0].Commands >= 2 {
+			if st.Sources[0].Learned < 1 {
+				t.Fatalf("the successful command should be learned: %+v", st)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			b, _ := json.Marshal(st)
+			t.Fatalf("hook did not report both commands: %s", b)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if _, err := renderSentinelShellHook("tcsh", srv.URL); err == nil {
+		t.Fatal("unsupported shells should be rejected")
+	}
+	for _, shell := range []string{"zsh", "fish", "pwsh"} {
+		script, err := renderSentinelShellHook(shell, srv.URL)
+		if err != nil || !strings.Contains(script, srv.URL+"/sentinel/activity") {
+			t.Fatalf("%s: %v\n%s", shell, err, script)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// AdaptiveThresholdConfig enables score-distribution tracking for the risk
+// threshold. In suggest-only mode (Apply=false) the recommendation is reported
+// but never used; with Apply=true it replaces RiskThreshold within bounds.
+type AdaptiveThresholdConfig struct {
+	Enabled      bool `json:"enabled"`
+	Apply        bool `json:"apply"`
+	MinThreshold int  `json:"min_threshold"`
+	MaxThreshold int  `json:"max_threshold"`
+	WindowSize   int  `json:"window_size"`
+	MinSamples   int  `json:"min_samples"`
+	MaxStep      int  `json:"max_step"` // largest change applied per recomputation
+}
+
+// AdaptiveThresholdStatus is the public snapshot of the adaptive tracker.
+type AdaptiveThresholdStatus struct {
+	Enabled       bool    `json:"enabled"`
+	Applied       bool    `json:"applied"`
+	Configured    int     `json:"configured_threshold"`
+	Effective     int     `json:"effective_threshold"`
+	Suggested     int     `json:"suggested_threshold"`
+	Samples       int     `json:"samples"`
+	AllowedMean   float64 `json:"allowed_mean"`
+	AllowedStdDev float64 `json:"allowed_stddev"`
+	AllowedP95    int     `json:"allowed_p95"`
+	BlockedMean   float64 `json:"blocked_mean"`
+	BlockedP05    int     `json:"blocked_p05"`
+	BlockRate     float64 `json:"block_rate"`
+	Rationale     string  `json:"rationale"`
+}
+
+type scoreSample struct {
+	score   int
+	blocked bool
+}
+
+// AdaptiveThreshold tracks a sliding window of decision scores and derives a
+// threshold that sits above the bulk of allowed traffic while staying below
+// the bulk of blocked traffic.
+type AdaptiveThreshold struct {
+	mu         sync.RWMutex
+	cfg        AdaptiveThresholdConfig
+	configured int
+	effective  int
+	samples    []scoreSample
+	status     AdaptiveThresholdStatus
+}
+
+// NewAdaptiveThreshold returns nil when adaptive mode is disabled.
+func NewAdaptiveThreshold(cfg *AdaptiveThresholdConfig, configured int) *AdaptiveThreshold {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	c := *cfg
+	if c.MinThreshold <= 0 {
+		c.MinThreshold = 40
+	}
+	if c.MaxThreshold <= 0 || c.MaxThreshold > 100 {
+		c.MaxThreshold = 90
+	}
+	if c.MinThreshold > c.MaxThreshold {
+		c.MinThreshold, c.MaxThreshold = c.MaxThreshold, c.MinThreshold
+	}
+	if c.WindowSize <= 0 {
+		c.WindowSize = 500
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 50
+	}
+	if c.MaxStep <= 0 {
+		c.MaxStep = 5
+	}
+
+	at := &AdaptiveThreshold{
+		cfg:        c,
+		configured: configured,
+		effective:  configured,
+	}
+	at.status = AdaptiveThresholdStatus{
+		Enabled:    true,
+		Applied:    c.Apply,
+		Configured: configured,
+		Effective:  configured,
+		Suggested:  configured,
+		Rationale:  "collecting samples",
+	}
+	return at
+}
+
+// Effective returns the threshold currently in force.
+func (at *AdaptiveThreshold) Effective() int {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.effective
+}
+
+// Observe records one evaluation and recomputes the suggestion.
+func (at *AdaptiveThreshold) Observe(score int, blocked bool) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	at.samples = append(at.samples, scoreSample{score: score, blocked: blocked})
+	if len(at.samples) > at.cfg.WindowSize {
+		at.samples = at.samples[len(at.samples)-at.cfg.WindowSize:]
+	}
+	at.recompute()
+}
+
+// SetConfigured replaces the operator-configured threshold, e.g. after an
+// approved runtime config change. The effective value restarts from it.
+func (at *AdaptiveThreshold) SetConfigured(threshold int) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	at.configured = threshold
+	at.effective = threshold
+	at.recompute()
+}
+
+// Status returns a point-in-time snapshot.
+func (at *AdaptiveThreshold) Status() AdaptiveThresholdStatus {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.status
+}
+
+// recompute must be called with at.mu held.
+func (at *AdaptiveThreshold) recompute() {
+	var allowed, blocked []int
+	for _, s := range at.samples {
+		if s.blocked {
+			blocked = append(blocked, s.score)
+		} else {
+			allowed = append(allowed, s.score)
+		}
+	}
+
+	st := AdaptiveThresholdStatus{
+		Enabled:    true,
+		Applied:    at.cfg.Apply,
+		Configured: at.configured,
+		Samples:    len(at.samples),
+	}
+	st.AllowedMean, st.AllowedStdDev = meanStdDev(allowed)
+	st.BlockedMean, _ = meanStdDev(blocked)
+	st.AllowedP95 = percentile(allowed, 0.95)
+	st.BlockedP05 = percentile(blocked, 0.05)
+	if len(at.samples) > 0 {
+		st.BlockRate = float64(len(blocked)) / float64(len(at.samples))
+	}
+
+	if len(at.samples) < at.cfg.MinSamples {
+		st.Suggested = at.effective
+		st.Effective = at.effective
+		st.Rationale = fmt.Sprintf("collecting samples (%d/%d)", len(at.samples), at.cfg.MinSamples)
+		at.status = st
+		return
+	}
+
+	// Sit just above normal traffic: mean + 2 stddev of allowed scores, but
+	// never above the low tail of what is actually being blocked. Tag-driven
+	// blocks can score below allowed traffic, so the cap only applies while
+	// the two distributions are separated.
+	target := int(math.Ceil(st.AllowedMean + 2*st.AllowedStdDev))
+	rationale := fmt.Sprintf("allowed mean %.1f + 2*stddev %.1f", st.AllowedMean, st.AllowedStdDev)
+	if len(allowed) == 0 {
+		target = at.effective
+		rationale = "no allowed samples in window"
+	}
+	if len(blocked) > 0 && float64(st.BlockedP05) > st.AllowedMean && target > st.BlockedP05 {
+		target = st.BlockedP05
+		rationale += fmt.Sprintf("; capped at blocked p05 %d", st.BlockedP05)
+	}
+	if target < at.cfg.MinThreshold {
+		target = at.cfg.MinThreshold
+		rationale += fmt.Sprintf("; raised to min bound %d", at.cfg.MinThreshold)
+	}
+	if target > at.cfg.MaxThreshold {
+		target = at.cfg.MaxThreshold
+		rationale += fmt.Sprintf("; lowered to max bound %d", at.cfg.MaxThreshold)
+	}
+	st.Suggested = target
+
+	if at.cfg.Apply && target != at.effective {
+		step := target - at.effective
+		if step > at.cfg.MaxStep {
+			step = at.cfg.MaxStep
+		} else if step < -at.cfg.MaxStep {
+			step = -at.cfg.MaxStep
+		}
+		at.effective += step
+		rationale += fmt.Sprintf("; applied step %+d", step)
+	}
+	st.Effective = at.effective
+	st.Rationale = rationale
+	at.status = st
+}
+
+func meanStdDev(values []int) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		d := float64(v) - mean
+		variance += d * d
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+func percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	idx := int(math.Round(p * float64(len(sorted)-1)))
+	return sorted[idx]
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestAdaptiveThresholdDisabledReturnsNil(t *testing.T) {
+	if NewAdaptiveThreshold(nil, 70) != nil || NewAdaptiveThreshold(&AdaptiveThresholdConfig{}, 70) != nil {
+		t.Fatalf("expected nil tracker when adaptive mode is disabled")
+	}
+}
+
+func TestAdaptiveThresholdSuggestOnly(t *testing.T) {
+	at := NewAdaptiveThreshold(&AdaptiveThresholdConfig{Enabled: true, MinSamples: 10}, 70)
+	for i := 0; i < 20; i++ {
+		at.Observe(10+i%5, false)
+	}
+	at.Observe(95, true)
+
+	st := at.Status()
+	if st.Suggested >= 70 {
+		t.Fatalf("expected a lower suggestion for quiet traffic, got %+v", st)
+	}
+	if st.Suggested < 40 {
+		t.Fatalf("expected suggestion clamped to default min bound 40, got %d", st.Suggested)
+	}
+	if at.Effective() != 70 || st.Effective != 70 {
+		t.Fatalf("suggest-only mode must not change the effective threshold: %+v", st)
+	}
+	if st.Rationale == "" {
+		t.Fatalf("expected a rationale")
+	}
+}
+
+func TestAdaptiveThresholdApplyIsStepBounded(t *testing.T) {
+	at := NewAdaptiveThreshold(&AdaptiveThresholdConfig{
+		Enabled:      true,
+		Apply:        true,
+		MinThreshold: 50,
+		MaxThreshold: 85,
+		MinSamples:   5,
+		MaxStep:      3,
+	}, 70)
+
+	for i := 0; i < 4; i++ {
+		at.Observe(20, false)
+	}
+	if at.Effective() != 70 {
+		t.Fatalf("threshold must not move before min samples, got %d", at.Effective())
+	}
+
+	at.Observe(20, false)
+	if got := at.Effective(); got != 67 {
+		t.Fatalf("expected one bounded step to 67, got %d", got)
+	}
+	for i := 0; i < 20; i++ {
+		at.Observe(20, false)
+	}
+	if got := at.Effective(); got != 50 {
+		t.Fatalf("expected threshold to settle at min bound 50, got %d", got)
+	}
+}
+
+func TestSentinelGuardUsesAppliedAdaptiveThreshold(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{
+		AdaptiveThreshold: &AdaptiveThresholdConfig{
+			Enabled: true, Apply: true, MinThreshold: 60, MaxThreshold: 90, MinSamples: 1, MaxStep: 100,
+		},
+	})
+	if _, _, err := guard.Enforce("STATUS", "show status"); err != nil {
+		t.Fatalf("Enforce failed: %v", err)
+	}
+	if guard.riskThreshold() != 60 {
+		t.Fatalf("expected applied adaptive threshold 60, got %d", guard.riskThreshold())
+	}
+}
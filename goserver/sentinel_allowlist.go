@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OnchainAllowlistConfig enables auto-allowing actions whose template hash the
+// registry admin approved with sentinel_audit::approve_action.
+type OnchainAllowlistConfig struct {
+	Enabled     bool   `json:"enabled"`
+	RPCURL      string `json:"rpc_url"`           // Sui fullnode JSON-RPC endpoint
+	CacheTTLSec int    `json:"cache_ttl_seconds"` // default 60
+	TypePackage string `json:"type_package"`      // original package id after upgrades; default anchor_package
+}
+
+type allowlistEntry struct {
+	approved bool
+	expires  time.Time
+}
+
+// onchainAllowlist looks up approved action hashes as dynamic fields of the
+// anchor Registry, caching answers for the TTL.
+type onchainAllowlist struct {
+	registry   string
+	keyType    string
+	ttl        time.Duration
+	backend    suiReadBackend
+	backendErr error
+	lookupFn   func(hash string) (bool, error)
+
+	mu    sync.Mutex
+	cache map[string]allowlistEntry
+}
+
+func newOnchainAllowlist(cfg *SentinelConfig) *onchainAllowlist {
+	ac := cfg.OnchainAllowlist
+	if ac == nil || !ac.Enabled {
+		return nil
+	}
+	ttl := time.Duration(ac.CacheTTLSec) * time.Second
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	pkg := ac.TypePackage
+	if pkg == "" {
+		pkg = cfg.AnchorPackage
+	}
+	backend, err := newSuiReadBackend(ac.RPCURL, cfg.ChainRead)
+	return &onchainAllowlist{
+		registry:   cfg.AnchorRegistry,
+		keyType:    pkg + "::" + cfg.AnchorModule + "::ApprovedAction",
+		ttl:        ttl,
+		backend:    backend,
+		backendErr: err,
+		cache:      map[string]allowlistEntry{},
+	}
+}
+
+// actionTemplateHash is the allowlist key for an action: sha256 over the
+// upper-cased action and the lower-cased, whitespace-collapsed prompt,
+// formatted as a Move address.
+func actionTemplateHash(action, prompt string) string {
+	normalized := strings.ToUpper(strings.TrimSpace(action)) + "\n" + strings.Join(strings.Fields(strings.ToLower(prompt)), " ")
+	sum := sha256.Sum256([]byte("sentinel-allowlist-v1\n" + normalized))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// Approved reports whether hash is on the on-chain allowlist. Lookup errors
+// are returned and never treated as approval.
+func (a *onchainAllowlist) Approved(hash string) (bool, error) {
+	now := time.Now()
+	a.mu.Lock()
+	if e, ok := a.cache[hash]; ok && now.Before(e.expires) {
+		a.mu.Unlock()
+		return e.approved, nil
+	}
+	a.mu.Unlock()
+
+	lookup := a.lookup
+	if a.lookupFn != nil {
+		lookup = a.lookupFn
+	}
+	approved, err := lookup(hash)
+	if err != nil {
+		return false, err
+	}
+
+	a.mu.Lock()
+	a.cache[hash] = allowlistEntry{approved: approved, expires: now.Add(a.ttl)}
+	a.mu.Unlock()
+	return approved, nil
+}
+
+// lookup checks the registry for the ApprovedAction{hash} dynamic field.
+func (a *onchainAllowlist) lookup(hash string) (bool, error) {
+	if a.backendErr != nil {
+		return false, fmt.Errorf("onchain_allowlist: %w", a.backendErr)
+	}
+	if a.registry == "" {
+		return false, fmt.Errorf("onchain_allowlist requires anchor_registry")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(hash, "0x"))
+	if err != nil || len(raw) != 32 {
+		return false, fmt.Errorf("malformed action hash %q", hash)
+	}
+	// ApprovedAction { hash: address } is BCS-encoded as the 32 address bytes.
+	return a.backend.DynamicFieldExists(a.registry, DynamicFieldName{
+		Type: a.keyType,
+		JSON: map[string]string{"hash": hash},
+		BCS:  raw,
+	})
+}
+
+// AllowlistHashOutput is printed by --allowlist-hash.
+type AllowlistHashOutput struct {
+	Action     string `json:"action"`
+	Prompt     string `json:"prompt"`
+	ActionHash string `json:"action_hash"`
+	ApproveCmd string `json:"approve_cmd,omitempty"`
+}
+
+// runAllowlistHashMode prints the template hash an owner registers with
+// approve_action, and the sui command to do so when the anchor is configured.
+func runAllowlistHashMode(configPath, action, prompt string, out io.Writer) error {
+	action = strings.TrimSpace(action)
+	prompt = strings.TrimSpace(prompt)
+	if action == "" || prompt == "" {
+		return fmt.Errorf("--sentinel-eval-action and --sentinel-eval-prompt are required with --allowlist-hash")
+	}
+
+	result := AllowlistHashOutput{Action: action, Prompt: prompt, ActionHash: actionTemplateHash(action, prompt)}
+	if cfg, err := loadSentinelConfigOnly(configPath); err == nil && cfg != nil && cfg.AnchorPackage != "" && cfg.AnchorRegistry != "" {
+		module := cfg.AnchorModule
+		if module == "" {
+			module = "sentinel_audit"
+		}
+		args, err := approveActionMoveArgs(cfg.AnchorRegistry, result.ActionHash).cli()
+		if err != nil {
+			return err
+		}
+		result.ApproveCmd = fmt.Sprintf("sui client call --package %s --module %s --function approve_action --args %s --gas-budget 10000000",
+			cfg.AnchorPackage, module, strings.Join(args, " "))
+	}
+	return encodeSentinelOutput(out, result)
+}
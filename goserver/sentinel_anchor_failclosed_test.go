@@ -5,14 +5,10 @@ import (
 	"errors"
 	"strings"
 	"testing"
-	"time"
 )
 
 func TestSentinelEnforceAnchorFailureFailClosedBlocks(t *testing.T) {
-	guard := NewSentinelGuard(&SentinelConfig{
-		Enabled:          true,
-		RiskThreshold:    70,
-		AuditLogPath:     t.TempDir() + "/audit.jsonl",
+	guard := newTestGuard(t, SentinelConfig{
 		AnchorEnabled:    true,
 		AnchorFailClosed: true,
 	})
@@ -42,10 +38,7 @@ func TestSentinelEnforceAnchorFailureFailClosedBlocks(t *testing.T) {
 }
 
 func TestSentinelEnforceAnchorFailureNonFailClosedKeepsRiskDecision(t *testing.T) {
-	guard := NewSentinelGuard(&SentinelConfig{
-		Enabled:          true,
-		RiskThreshold:    70,
-		AuditLogPath:     t.TempDir() + "/audit.jsonl",
+	guard := newTestGuard(t, SentinelConfig{
 		AnchorEnabled:    true,
 		AnchorFailClosed: false,
 	})
@@ -69,10 +62,7 @@ func TestSentinelEnforceAnchorFailureNonFailClosedKeepsRiskDecision(t *testing.T
 }
 
 func TestSentinelGatewayAnchorFailureFailClosedReturnsBlock(t *testing.T) {
-	guard := NewSentinelGuard(&SentinelConfig{
-		Enabled:          true,
-		RiskThreshold:    70,
-		AuditLogPath:     t.TempDir() + "/audit.jsonl",
+	guard := newTestGuard(t, SentinelConfig{
 		AnchorEnabled:    true,
 		AnchorFailClosed: true,
 	})
@@ -80,12 +70,7 @@ func TestSentinelGatewayAnchorFailureFailClosedReturnsBlock(t *testing.T) {
 		return "", errors.New("rpc timeout")
 	}
 
-	gw := NewSentinelGateway(guard, nil, &SentinelGatewayConfig{
-		ApprovalTimeout:     1 * time.Minute,
-		ProofBatchSize:      5,
-		KillSwitchThreshold: 3,
-		ExecuteTokenTTL:     10 * time.Second,
-	})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
 
 	rr := postJSON(t, gw.handleGate, GateRequest{Action: "CODE_EDITING", Prompt: "git status"})
 	if rr.Code != 200 {
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// anchorRetryAction is the audit action recorded when a queued record is
+// finally anchored. The original record is never rewritten (that would break
+// the hash chain), so this record carries its late digest instead.
+const anchorRetryAction = "ANCHOR_RETRY"
+
+// AnchorRetryConfig enables the durable queue for records whose on-chain
+// anchor failed.
+type AnchorRetryConfig struct {
+	Enabled bool `json:"enabled"`
+	// QueuePath defaults to anchor-retry.json next to the audit log.
+	QueuePath         string `json:"queue_path"`
+	InitialBackoffSec int    `json:"initial_backoff_seconds"` // default 30
+	MaxBackoffSec     int    `json:"max_backoff_seconds"`     // default 3600
+	// MaxAttempts moves a record to the dead-letter state after this many
+	// failed attempts. Zero retries until the record lands on-chain.
+	MaxAttempts int `json:"max_attempts"`
+	IntervalSec int `json:"interval_seconds"` // worker tick, default 15
+}
+
+// AnchorRetryEntry is one record waiting to be anchored.
+type AnchorRetryEntry struct {
+	Record      AuditRecord `json:"record"`
+	Attempts    int         `json:"attempts"`
+	NextAttempt time.Time   `json:"next_attempt"`
+	LastError   string      `json:"last_error"`
+	// Dead entries exhausted MaxAttempts and are kept for an operator.
+	// Clearing the flag in the queue file requeues them on restart.
+	Dead bool `json:"dead,omitempty"`
+}
+
+// AnchorRetryReceipt is the payload of an ANCHOR_RETRY audit record.
+type AnchorRetryReceipt struct {
+	RecordHash string `json:"record_hash"`
+	TxDigest   string `json:"tx_digest"`
+	Checkpoint string `json:"checkpoint,omitempty"`
+	Attempts   int    `json:"attempts"`
+}
+
+// anchorRetryQueue persists failed anchors as a JSON file, rewritten
+// atomically on every change, so a restart resumes where it left off.
+type anchorRetryQueue struct {
+	cfg  AnchorRetryConfig
+	path string
+	now  func() time.Time
+
+	runMu   sync.Mutex // one retry pass at a time
+	mu      sync.Mutex
+	entries []AnchorRetryEntry
+}
+
+// newAnchorRetryQueue returns nil when retries are disabled.
+func newAnchorRetryQueue(cfg *AnchorRetryConfig, auditLogPath string) (*anchorRetryQueue, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	c := *cfg
+	if c.QueuePath == "" {
+		c.QueuePath = filepath.Join(filepath.Dir(auditLogPath), "anchor-retry.json")
+	}
+	if c.InitialBackoffSec <= 0 {
+		c.InitialBackoffSec = 30
+	}
+	if c.MaxBackoffSec < c.InitialBackoffSec {
+		c.MaxBackoffSec = maxInt(3600, c.InitialBackoffSec)
+	}
+	if c.IntervalSec <= 0 {
+		c.IntervalSec = 15
+	}
+	q := &anchorRetryQueue{cfg: c, path: c.QueuePath, now: func() time.Time { return time.Now().UTC() }}
+	data, err := os.ReadFile(q.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &q.entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", q.path, err)
+		}
+	}
+	return q, nil
+}
+
+// backoff is the wait after the given number of failed attempts: the
+// initial backoff doubled per attempt, capped at the maximum.
+func (q *anchorRetryQueue) backoff(attempts int) time.Duration {
+	d := time.Duration(q.cfg.InitialBackoffSec) * time.Second
+	limit := time.Duration(q.cfg.MaxBackoffSec) * time.Second
+	for i := 1; i < attempts && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		d = limit
+	}
+	return d
+}
+
+// save writes the queue through a temp file so a crash cannot truncate it.
+// Callers hold q.mu.
+func (q *anchorRetryQueue) save() error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return err
+	}
+	entries := q.entries
+	if entries == nil {
+		entries = []AnchorRetryEntry{}
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// enqueue adds a record whose first anchor attempt just failed.
+func (q *anchorRetryQueue) enqueue(rec *AuditRecord) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.Record.RecordHash == rec.RecordHash {
+			return nil
+		}
+	}
+	q.entries = append(q.entries, AnchorRetryEntry{
+		Record:      *rec,
+		Attempts:    1,
+		NextAttempt: q.now().Add(q.backoff(1)),
+		LastError:   rec.AnchorError,
+	})
+	return q.save()
+}
+
+// Entries returns a copy of the queue, dead letters included.
+func (q *anchorRetryQueue) Entries() []AnchorRetryEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]AnchorRetryEntry(nil), q.entries...)
+}
+
+// Counts returns the number of pending and dead-letter entries.
+func (q *anchorRetryQueue) Counts() (pending, dead int) {
+	for _, e := range q.Entries() {
+		if e.Dead {
+			dead++
+		} else {
+			pending++
+		}
+	}
+	return pending, dead
+}
+
+// queueAnchorRetry hands a record that was written without an anchor to
+// the retry queue, if one is configured.
+func (sg *SentinelGuard) queueAnchorRetry(rec *AuditRecord) {
+	if sg.retry == nil || !sg.cfg.AnchorEnabled || rec.AnchorError == "" {
+		return
+	}
+	if err := sg.retry.enqueue(rec); err != nil {
+		log.Printf("[ANCHOR] cannot queue %s for retry: %v", rec.RecordHash, err)
+	}
+}
+
+// RetryDueAnchors re-anchors every pending entry whose backoff has elapsed.
+// A success removes the entry and appends an ANCHOR_RETRY record with the
+// digest; a failure doubles the backoff, and exhausting max_attempts turns
+// the entry into a dead letter and raises an anchor alert.
+func (sg *SentinelGuard) RetryDueAnchors() {
+	q := sg.retry
+	if q == nil {
+		return
+	}
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	now := q.now()
+	var due []AnchorRetryEntry
+	for _, e := range q.Entries() {
+		if !e.Dead && !now.Before(e.NextAttempt) {
+			due = append(due, e)
+		}
+	}
+
+	for _, e := range due {
+		rec := e.Record
+		primary := sg.primaryBackend()
+		tx, err := primary.Anchor(&rec)
+		sg.metrics.observeAnchor(err)
+
+		q.mu.Lock()
+		idx := -1
+		for i := range q.entries {
+			if q.entries[i].Record.RecordHash == rec.RecordHash {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			q.mu.Unlock()
+			continue
+		}
+		entry := &q.entries[idx]
+		entry.Attempts++
+		attempts := entry.Attempts
+		dead := false
+		if err != nil {
+			entry.LastError = err.Error()
+			entry.NextAttempt = q.now().Add(q.backoff(attempts))
+			if q.cfg.MaxAttempts > 0 && attempts >= q.cfg.MaxAttempts {
+				entry.Dead, dead = true, true
+			}
+		} else {
+			q.entries = append(q.entries[:idx], q.entries[idx+1:]...)
+		}
+		if saveErr := q.save(); saveErr != nil {
+			log.Printf("[ANCHOR] cannot save retry queue: %v", saveErr)
+		}
+		q.mu.Unlock()
+
+		if err != nil {
+			log.Printf("[ANCHOR] retry %d of %s failed: %v", attempts, rec.RecordHash, err)
+			if dead {
+				sg.alertAnchorFailure(primary.Name(), &rec, fmt.Errorf("gave up after %d attempts: %w", attempts, err))
+			}
+			continue
+		}
+
+		sg.capabilities.set(capAnchor, true, "")
+		sg.publishAnchorConfirmed(primary.Name(), &rec, tx)
+		receipt := AnchorRetryReceipt{RecordHash: rec.RecordHash, TxDigest: tx, Attempts: attempts}
+		if sg.sui != nil && tx != "" {
+			receipt.Checkpoint, _ = sg.sui.TransactionCheckpoint(tx)
+		}
+		b, _ := json.Marshal(receipt)
+		resolution := &AuditRecord{
+			Timestamp: q.now(),
+			Action:    anchorRetryAction,
+			Prompt:    string(b),
+			Tags:      []string{"anchor_retry"},
+			Decision:  "recorded",
+			Reason:    fmt.Sprintf("record %s anchored on attempt %d in tx %s", rec.RecordHash, attempts, tx),
+		}
+		if err := sg.persistRecord(resolution); err != nil {
+			log.Printf("[ANCHOR] audit of retried anchor %s failed: %v", rec.RecordHash, err)
+		}
+	}
+}
+
+// StartAnchorRetryWorker runs RetryDueAnchors in the background at the
+// configured interval. The goroutine runs until the process exits.
+func (sg *SentinelGuard) StartAnchorRetryWorker() {
+	if sg.retry == nil {
+		return
+	}
+	interval := time.Duration(sg.retry.cfg.IntervalSec) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			sg.RetryDueAnchors()
+		}
+	}()
+}
+
+// lateAnchors maps record hashes to the digests ANCHOR_RETRY records
+// later supplied for them.
+func lateAnchors(records []AuditRecord) map[string]AnchorRetryReceipt {
+	late := map[string]AnchorRetryReceipt{}
+	for _, rec := range records {
+		if rec.Action != anchorRetryAction {
+			continue
+		}
+		var receipt AnchorRetryReceipt
+		if json.Unmarshal([]byte(rec.Prompt), &receipt) == nil && receipt.RecordHash != "" && receipt.TxDigest != "" {
+			late[receipt.RecordHash] = receipt
+		}
+	}
+	return late
+}
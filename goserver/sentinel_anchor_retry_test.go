@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnchorRetryQueue(t *testing.T) {
+	cfg := testSentinelConfig(t, SentinelConfig{
+		AnchorEnabled: true,
+		AnchorRetry:   &AnchorRetryConfig{Enabled: true, InitialBackoffSec: 30, MaxBackoffSec: 100},
+	})
+	guard := NewSentinelGuard(cfg)
+	now := time.Unix(1_700_000_000, 0).UTC()
+	guard.retry.now = func() time.Time { return now }
+	fail := true
+	guard.anchorFn = func(*AuditRecord) (string, error) {
+		if fail {
+			return "", errors.New("rpc unavailable")
+		}
+		return "LateDigest", nil
+	}
+
+	_, rec, err := guard.Enforce("STATUS", "show system status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.AnchorError == "" {
+		t.Fatalf("expected the first anchor to fail: %+v", rec)
+	}
+	entries := guard.retry.Entries()
+	if len(entries) != 1 || entries[0].Record.RecordHash != rec.RecordHash || entries[0].Attempts != 1 || !entries[0].NextAttempt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected queue: %+v", entries)
+	}
+
+	guard.RetryDueAnchors() // backoff has not elapsed
+	if got := guard.retry.Entries()[0].Attempts; got != 1 {
+		t.Fatalf("retried before the backoff elapsed: attempts=%d", got)
+	}
+
+	now = now.Add(30 * time.Second)
+	guard.RetryDueAnchors()
+	entries = guard.retry.Entries()
+	if entries[0].Attempts != 2 || !entries[0].NextAttempt.Equal(now.Add(60*time.Second)) || entries[0].LastError != "rpc unavailable" {
+		t.Fatalf("unexpected entry after a failed retry: %+v", entries[0])
+	}
+
+	// The queue survives a restart.
+	restarted := NewSentinelGuard(cfg)
+	if got := restarted.retry.Entries(); len(got) != 1 || got[0].Attempts != 2 {
+		t.Fatalf("queue was not persisted: %+v", got)
+	}
+
+	fail = false
+	now = now.Add(60 * time.Second)
+	guard.RetryDueAnchors()
+	if got := guard.retry.Entries(); len(got) != 0 {
+		t.Fatalf("anchored record should leave the queue: %+v", got)
+	}
+
+	records, err := readAuditRecords(cfg.AuditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].Action != anchorRetryAction || records[1].TxDigest != "LateDigest" {
+		t.Fatalf("expected an anchored ANCHOR_RETRY record: %+v", records)
+	}
+	late := lateAnchors(records)
+	if receipt := late[rec.RecordHash]; receipt.TxDigest != "LateDigest" || receipt.Attempts != 3 {
+		t.Fatalf("unexpected receipt: %+v", late)
+	}
+	if v := verifyAuditChain(records); !v.Valid {
+		t.Fatalf("retry record broke the chain: %+v", v)
+	}
+}
+
+func TestAnchorRetryDeadLetter(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{
+		AnchorEnabled: true,
+		AnchorRetry:   &AnchorRetryConfig{Enabled: true, InitialBackoffSec: 1, MaxAttempts: 2},
+	})
+	now := time.Unix(1_700_000_000, 0).UTC()
+	guard.retry.now = func() time.Time { return now }
+	guard.anchorFn = func(*AuditRecord) (string, error) { return "", errors.New("out of gas") }
+	var alerts []string
+	guard.anchorAlert = func(backend string, rec *AuditRecord, err error) { alerts = append(alerts, err.Error()) }
+
+	if _, _, err := guard.Enforce("STATUS", "show system status"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	guard.RetryDueAnchors()
+	entries := guard.retry.Entries()
+	if len(entries) != 1 || !entries[0].Dead {
+		t.Fatalf("expected a dead letter: %+v", entries)
+	}
+	if len(alerts) != 2 || alerts[1] != "gave up after 2 attempts: out of gas" {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+	now = now.Add(time.Hour)
+	guard.RetryDueAnchors()
+	if pending, dead := guard.retry.Counts(); pending != 0 || dead != 1 || guard.retry.Entries()[0].Attempts != 2 {
+		t.Fatalf("dead letters must not be retried: pending=%d dead=%d", pending, dead)
+	}
+}
+
+func TestAnchorRetryBackoff(t *testing.T) {
+	q, err := newAnchorRetryQueue(&AnchorRetryConfig{Enabled: true, InitialBackoffSec: 30, MaxBackoffSec: 100}, "audit/x.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for attempts, want := range []time.Duration{1: 30 * time.Second, 2: 60 * time.Second, 3: 100 * time.Second, 4: 100 * time.Second} {
+		if attempts == 0 {
+			continue
+		}
+		if got := q.backoff(attempts); got != want {
+			t.Fatalf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -18,6 +18,8 @@ type ApprovalChallenge struct {
 	ExpiresAt  time.Time  `json:"expires_at"`
 	DecidedAt  *time.Time `json:"decided_at,omitempty"`
 	DecisionBy string    `json:"decision_by,omitempty"`
+	// RecordHash is the gate decision that asked for approval, if any.
+	RecordHash string `json:"record_hash,omitempty"`
 }
 
 // ApprovalService manages human-in-the-loop approval challenges for the Sentinel system.
@@ -50,8 +52,9 @@ func generateChallengeID() string {
 	return fmt.Sprintf("challenge-%d-%s", time.Now().UnixMilli(), string(suffix))
 }
 
-// StartChallenge creates a new pending approval challenge for the given action.
-func (as *ApprovalService) StartChallenge(action, prompt string, score int) *ApprovalChallenge {
+// StartChallenge creates a new pending approval challenge for the given
+// action. recordHash links it to the gate decision that required it.
+func (as *ApprovalService) StartChallenge(action, prompt string, score int, recordHash string) *ApprovalChallenge {
 	now := time.Now().UTC()
 	ch := &ApprovalChallenge{
 		ID:        generateChallengeID(),
@@ -61,6 +64,8 @@ func (as *ApprovalService) StartChallenge(action, prompt string, score int) *App
 		Status:    "pending",
 		CreatedAt: now,
 		ExpiresAt: now.Add(as.timeout),
+
+		RecordHash: recordHash,
 	}
 
 	as.mu.Lock()
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lastAuditRecordHash returns the RecordHash of the last record in the log,
+// reading only its tail. A missing or empty log has no head.
+func lastAuditRecordHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	const tail = 64 << 10
+	offset := info.Size() - tail
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return "", err
+	}
+	buf = bytes.TrimRight(buf, "\r\n\t ")
+	if len(buf) == 0 {
+		return "", nil
+	}
+	line := buf[bytes.LastIndexByte(buf, '\n')+1:]
+	var rec AuditRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return "", fmt.Errorf("last audit record: %w", err)
+	}
+	return rec.RecordHash, nil
+}
+
+// canonicalAuditHash is the hash older `lazarus-vault hash-audit` builds
+// wrote: sha256 over compact JSON in declaration order rather than JCS.
+// Like fallbackAuditHash, it is only used to verify existing records.
+func canonicalAuditHash(rec *AuditRecord) string {
+	tags := []string{}
+	for _, t := range rec.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // serde_json does not escape <, > and &
+	enc.Encode(struct {
+		Action    string   `json:"action"`
+		Prompt    string   `json:"prompt"`
+		Score     int      `json:"score"`
+		Tags      []string `json:"tags"`
+		Decision  string   `json:"decision"`
+		Reason    string   `json:"reason"`
+		Timestamp string   `json:"timestamp"`
+		PrevHash  string   `json:"prev_hash,omitempty"`
+	}{rec.Action, rec.Prompt, rec.Score, tags, rec.Decision, rec.Reason, rec.Timestamp.Format(time.RFC3339Nano), rec.PrevHash})
+	sum := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// promptCommitment is the prompt_sha256 of a record.
+func promptCommitment(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// hashedAuditForm is rec as it is hashed: a record with a prompt commitment
+// is hashed with the commitment in the prompt field.
+func hashedAuditForm(rec *AuditRecord) *AuditRecord {
+	if rec.PromptSHA256 == "" {
+		return rec
+	}
+	c := *rec
+	c.Prompt = rec.PromptSHA256
+	return &c
+}
+
+// auditHashCheckable reports whether rec's hash can be recomputed: it was
+// not redacted, or it commits to its prompt.
+func auditHashCheckable(rec *AuditRecord) bool {
+	return rec.Redacted == nil || rec.PromptSHA256 != ""
+}
+
+// auditHashMatches reports whether rec's hash matches its contents under
+// the JCS form or either form written by earlier versions. An unredacted
+// prompt must also match its commitment. The earlier forms do not cover
+// occurrences or dedup_of, so a record carrying them must match the JCS
+// form.
+func auditHashMatches(rec *AuditRecord) bool {
+	if rec.PromptSHA256 != "" {
+		if rec.Redacted == nil && promptCommitment(rec.Prompt) != rec.PromptSHA256 {
+			return false
+		}
+		rec = hashedAuditForm(rec)
+	}
+	if rec.Occurrences != 0 || rec.DedupOf != "" {
+		return rec.RecordHash == jcsAuditHash(rec)
+	}
+	return rec.RecordHash == jcsAuditHash(rec) || rec.RecordHash == canonicalAuditHash(rec) || rec.RecordHash == fallbackAuditHash(rec)
+}
+
+// AuditChainVerification is the result of walking an audit log's hash chain.
+type AuditChainVerification struct {
+	Valid   bool `json:"valid"`
+	Records int  `json:"records"`
+	// Chained counts records with a prev_hash. Records written before
+	// chaining was introduced only have their own hash checked.
+	Chained int `json:"chained"`
+	// Redacted counts records whose prompt retention removed. Their hash is
+	// recomputed from prompt_sha256; for records written before prompts
+	// were committed to, only their links are checked.
+	Redacted int `json:"redacted,omitempty"`
+	// HeadHash is the last record's hash. Compare it with the latest
+	// anchor to detect a log cut short at the end.
+	HeadHash string `json:"head_hash,omitempty"`
+	// FirstBroken is the 1-based index of the first record that fails.
+	FirstBroken int    `json:"first_broken,omitempty"`
+	Problem     string `json:"problem,omitempty"`
+	// Checkpoints is set by --verify-audit when the log has checkpoints.
+	Checkpoints *AuditCheckpointVerification `json:"checkpoints,omitempty"`
+}
+
+// verifyAuditChain checks that every record's hash matches its contents and
+// that each chained record's prev_hash names the record before it. A
+// redacted record must be listed by a RETENTION_PURGE record.
+func verifyAuditChain(records []AuditRecord) AuditChainVerification {
+	v := AuditChainVerification{Valid: true, Records: len(records)}
+	purged, _ := retentionPurges(records)
+	chained := false
+	for i := range records {
+		rec := &records[i]
+		var problem string
+		switch {
+		case rec.Redacted != nil && !purged[rec.RecordHash]:
+			problem = "record is marked redacted but no RETENTION_PURGE record lists it"
+		case auditHashCheckable(rec) && !auditHashMatches(rec):
+			problem = "record_hash does not match the record's contents"
+		case rec.PrevHash == "" && chained:
+			problem = "prev_hash is missing after the chain started"
+		case rec.PrevHash != "" && i == 0:
+			problem = fmt.Sprintf("first record links to %s, which is not in the log", rec.PrevHash)
+		case rec.PrevHash != "" && rec.PrevHash != records[i-1].RecordHash:
+			problem = fmt.Sprintf("prev_hash %s does not match the previous record %s", rec.PrevHash, records[i-1].RecordHash)
+		}
+		if problem != "" {
+			v.Valid, v.FirstBroken, v.Problem = false, i+1, problem
+			return v
+		}
+		if rec.PrevHash != "" {
+			chained = true
+			v.Chained++
+		}
+		if rec.Redacted != nil {
+			v.Redacted++
+		}
+		v.HeadHash = rec.RecordHash
+	}
+	return v
+}
+
+// Per-record outcomes of a full audit verification.
+const (
+	auditMatched    = "matched"    // hash recomputes and the anchor verifies
+	auditPending    = "pending"    // anchored, not yet in a checkpoint
+	auditMissing    = "missing"    // never anchored, or unknown to the node
+	auditMismatched = "mismatched" // hash, transaction or event disagrees
+)
+
+// AuditRecordFinding explains why one record did not match.
+type AuditRecordFinding struct {
+	Index      int       `json:"index"` // 1-based line in the log
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	RecordHash string    `json:"record_hash"`
+	TxDigest   string    `json:"tx_digest,omitempty"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail"`
+}
+
+// AuditVerificationReport is the auditor-facing result of checking a log
+// against Sui: the hash chain, every record's hash, and every anchor.
+type AuditVerificationReport struct {
+	Log         string                 `json:"log"`
+	RPC         string                 `json:"rpc"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Records     int                    `json:"records"`
+	Matched     int                    `json:"matched"`
+	Pending     int                    `json:"pending"`
+	Missing     int                    `json:"missing"`
+	Mismatched  int                    `json:"mismatched"`
+	Chain       AuditChainVerification `json:"chain"`
+	// Findings lists every record that is not matched.
+	Findings []AuditRecordFinding `json:"findings"`
+}
+
+// verifyAuditAgainstChain recomputes each record's hash and checks its
+// anchor on-chain. Unlike verifyAuditChain it does not stop at the first
+// problem, so the report covers the whole log.
+func verifyAuditAgainstChain(reader *chainReader, records []AuditRecord) *AuditVerificationReport {
+	report := &AuditVerificationReport{
+		Records:  len(records),
+		Chain:    verifyAuditChain(records),
+		Findings: []AuditRecordFinding{},
+	}
+	late := lateAnchors(records)
+	for i, rec := range records {
+		if receipt, ok := late[rec.RecordHash]; ok && rec.TxDigest == "" {
+			rec.TxDigest, rec.Checkpoint = receipt.TxDigest, receipt.Checkpoint
+		}
+		status, detail := auditMatched, ""
+		switch {
+		case auditHashCheckable(&rec) && !auditHashMatches(&rec):
+			status, detail = auditMismatched, "record_hash does not match the record's contents"
+		case rec.TxDigest == "":
+			status, detail = auditMissing, "not anchored"
+			if rec.AnchorError != "" {
+				detail += ": " + rec.AnchorError
+			}
+		default:
+			v := verifyAnchor(reader, rec)
+			switch v.Status {
+			case anchorVerified:
+			case anchorPending:
+				status, detail = auditPending, v.Detail
+			case anchorMissing:
+				status, detail = auditMissing, v.Detail
+			default:
+				status, detail = auditMismatched, v.Detail
+			}
+		}
+		switch status {
+		case auditMatched:
+			report.Matched++
+			continue
+		case auditPending:
+			report.Pending++
+		case auditMissing:
+			report.Missing++
+		case auditMismatched:
+			report.Mismatched++
+		}
+		report.Findings = append(report.Findings, AuditRecordFinding{
+			Index:      i + 1,
+			Timestamp:  rec.Timestamp,
+			Action:     rec.Action,
+			RecordHash: rec.RecordHash,
+			TxDigest:   rec.TxDigest,
+			Status:     status,
+			Detail:     detail,
+		})
+	}
+	return report
+}
+
+// runVerifyAuditMode checks the hash chain of the log at path. With rpcURL
+// set it also checks every record against Sui and prints the full report.
+func runVerifyAuditMode(path, rpcURL string, out io.Writer) error {
+	records, err := readAuditRecords(path)
+	if err != nil {
+		return err
+	}
+	if rpcURL == "" {
+		result := verifyAuditChain(records)
+		result.Checkpoints = verifyAuditCheckpoints(records, time.Now().UTC())
+		if err := encodeSentinelOutput(out, result); err != nil {
+			return err
+		}
+		if !result.Valid {
+			return fmt.Errorf("record %d: %s", result.FirstBroken, result.Problem)
+		}
+		return checkpointError(result.Checkpoints)
+	}
+
+	report := verifyAuditAgainstChain(newChainReader(rpcURL, nil), records)
+	report.Log, report.RPC, report.GeneratedAt = path, rpcURL, time.Now().UTC()
+	report.Chain.Checkpoints = verifyAuditCheckpoints(records, report.GeneratedAt)
+	if err := encodeSentinelOutput(out, report); err != nil {
+		return err
+	}
+	switch {
+	case !report.Chain.Valid:
+		return fmt.Errorf("record %d: %s", report.Chain.FirstBroken, report.Chain.Problem)
+	case checkpointError(report.Chain.Checkpoints) != nil:
+		return checkpointError(report.Chain.Checkpoints)
+	case report.Missing+report.Mismatched > 0:
+		return fmt.Errorf("%d of %d records are missing or mismatched", report.Missing+report.Mismatched, report.Records)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// auditCheckpointAction is the audit action of a scheduled checkpoint of
+// the log head. Checkpoints are anchored like any other record, so an
+// on-chain digest exists for the head every day even without traffic.
+const auditCheckpointAction = "AUDIT_CHECKPOINT"
+
+// AuditCheckpointConfig writes and anchors an AUDIT_CHECKPOINT record on a
+// fixed schedule.
+type AuditCheckpointConfig struct {
+	Enabled     bool `json:"enabled"`
+	IntervalSec int  `json:"interval_seconds"` // default 86400
+}
+
+// AuditCheckpoint is the payload of an AUDIT_CHECKPOINT record.
+type AuditCheckpoint struct {
+	// HeadHash and Records describe the log before the checkpoint.
+	HeadHash string `json:"head_hash"`
+	Records  int    `json:"records"`
+	// Previous is the record hash of the previous checkpoint.
+	Previous    string `json:"previous_checkpoint,omitempty"`
+	IntervalSec int    `json:"interval_seconds"`
+}
+
+// auditCheckpointer writes a checkpoint whenever the last one in the log is
+// older than the interval, including right after startup.
+type auditCheckpointer struct {
+	guard    *SentinelGuard
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last *AuditRecord
+}
+
+// newAuditCheckpointer returns nil when checkpoints are disabled.
+func newAuditCheckpointer(guard *SentinelGuard, cfg *AuditCheckpointConfig) (*auditCheckpointer, error) {
+	if guard == nil || cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	interval := time.Duration(cfg.IntervalSec) * time.Second
+	if cfg.IntervalSec <= 0 {
+		interval = 24 * time.Hour
+	}
+	records, err := readAuditRecords(guard.cfg.AuditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	c := &auditCheckpointer{guard: guard, interval: interval, now: func() time.Time { return time.Now().UTC() }}
+	if i := lastCheckpointIndex(records); i >= 0 {
+		c.last = &records[i]
+	}
+	return c, nil
+}
+
+func lastCheckpointIndex(records []AuditRecord) int {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Action == auditCheckpointAction {
+			return i
+		}
+	}
+	return -1
+}
+
+// Last returns the most recent checkpoint record, or nil before the first.
+func (c *auditCheckpointer) Last() *AuditRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil {
+		return nil
+	}
+	rec := *c.last
+	return &rec
+}
+
+// Due reports whether the interval has passed since the last checkpoint.
+func (c *auditCheckpointer) Due() bool {
+	last := c.Last()
+	return last == nil || c.now().Sub(last.Timestamp) >= c.interval
+}
+
+// Checkpoint records the current head and record count. The log is read
+// and the checkpoint's chain position reserved under chainMu, so no record
+// lands between counting and appending.
+func (c *auditCheckpointer) Checkpoint() (*AuditRecord, error) {
+	sg := c.guard
+	sg.chainMu.Lock()
+	sg.drainChainLocked()
+
+	records, err := readAuditRecords(sg.cfg.AuditLogPath)
+	if err != nil {
+		sg.chainMu.Unlock()
+		return nil, err
+	}
+	cp := AuditCheckpoint{Records: len(records), IntervalSec: int(c.interval / time.Second)}
+	if len(records) > 0 {
+		cp.HeadHash = records[len(records)-1].RecordHash
+	}
+	if i := lastCheckpointIndex(records); i >= 0 {
+		cp.Previous = records[i].RecordHash
+	}
+	b, _ := json.Marshal(cp)
+	rec := &AuditRecord{
+		Timestamp: c.now(),
+		Action:    auditCheckpointAction,
+		Prompt:    string(b),
+		Tags:      []string{"audit_checkpoint"},
+		Decision:  "recorded",
+		Reason:    fmt.Sprintf("audit log head %s after %d records", cp.HeadHash, cp.Records),
+	}
+	seq := sg.reserveRecordLocked(rec)
+	sg.chainMu.Unlock()
+	if err := sg.commitRecord(seq, rec); err != nil {
+		return nil, err
+	}
+	if rec.AnchorError != "" {
+		log.Printf("[CHECKPOINT] %s written but not anchored: %s", rec.RecordHash, rec.AnchorError)
+	}
+
+	c.mu.Lock()
+	saved := *rec
+	c.last = &saved
+	c.mu.Unlock()
+	return rec, nil
+}
+
+// Start checks every poll whether a checkpoint is due. The goroutine runs
+// until the process exits.
+func (c *auditCheckpointer) Start(poll time.Duration) {
+	go func() {
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		for {
+			if c.Due() {
+				if _, err := c.Checkpoint(); err != nil {
+					log.Printf("[CHECKPOINT] failed: %v", err)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// AuditCheckpointGap is a span longer than the checkpoint interval (plus a
+// quarter of it as slack) without a checkpoint.
+type AuditCheckpointGap struct {
+	After    string    `json:"after"` // checkpoint record hash
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Trailing bool      `json:"trailing,omitempty"` // runs up to the verification time
+}
+
+// AuditCheckpointVerification checks the checkpoints in a log: each one
+// must describe the log exactly as it was before it, and consecutive
+// checkpoints must not be further apart than their interval.
+type AuditCheckpointVerification struct {
+	Valid       bool                 `json:"valid"`
+	Checkpoints int                  `json:"checkpoints"`
+	LastHash    string               `json:"last_hash"`
+	LastAt      time.Time            `json:"last_at"`
+	Gaps        []AuditCheckpointGap `json:"gaps"`
+	// FirstBroken is the 1-based index of the first checkpoint record
+	// whose payload does not match the log.
+	FirstBroken int    `json:"first_broken,omitempty"`
+	Problem     string `json:"problem,omitempty"`
+}
+
+// verifyAuditCheckpoints returns nil for a log without checkpoints. Spans
+// are measured from the first checkpoint; the last one is compared with now.
+func verifyAuditCheckpoints(records []AuditRecord, now time.Time) *AuditCheckpointVerification {
+	var v *AuditCheckpointVerification
+	var prev *AuditRecord
+	var prevInterval time.Duration
+	for i := range records {
+		rec := &records[i]
+		if rec.Action != auditCheckpointAction {
+			continue
+		}
+		if v == nil {
+			v = &AuditCheckpointVerification{Valid: true, Gaps: []AuditCheckpointGap{}}
+		}
+		v.Checkpoints++
+
+		var cp AuditCheckpoint
+		problem := ""
+		switch err := json.Unmarshal([]byte(rec.Prompt), &cp); {
+		case err != nil:
+			problem = fmt.Sprintf("checkpoint payload is not valid JSON: %v", err)
+		case cp.Records != i:
+			problem = fmt.Sprintf("checkpoint counts %d records but %d precede it", cp.Records, i)
+		case i > 0 && cp.HeadHash != records[i-1].RecordHash:
+			problem = fmt.Sprintf("checkpoint head %s does not match the previous record %s", cp.HeadHash, records[i-1].RecordHash)
+		case prev != nil && cp.Previous != prev.RecordHash:
+			problem = fmt.Sprintf("checkpoint links to %s, not the previous checkpoint %s", cp.Previous, prev.RecordHash)
+		}
+		if problem != "" && v.Problem == "" {
+			v.Valid, v.FirstBroken, v.Problem = false, i+1, problem
+		}
+
+		if prev != nil && rec.Timestamp.Sub(prev.Timestamp) > checkpointSlack(prevInterval) {
+			v.Gaps = append(v.Gaps, AuditCheckpointGap{After: prev.RecordHash, From: prev.Timestamp, To: rec.Timestamp})
+		}
+		prev, prevInterval = rec, time.Duration(cp.IntervalSec)*time.Second
+	}
+	if v == nil {
+		return nil
+	}
+	if now.Sub(prev.Timestamp) > checkpointSlack(prevInterval) {
+		v.Gaps = append(v.Gaps, AuditCheckpointGap{After: prev.RecordHash, From: prev.Timestamp, To: now, Trailing: true})
+	}
+	v.LastHash, v.LastAt = prev.RecordHash, prev.Timestamp
+	if len(v.Gaps) > 0 {
+		v.Valid = false
+	}
+	return v
+}
+
+func checkpointSlack(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return interval + interval/4
+}
+
+// checkpointError summarizes a failed checkpoint verification.
+func checkpointError(v *AuditCheckpointVerification) error {
+	switch {
+	case v == nil || v.Valid:
+		return nil
+	case v.Problem != "":
+		return fmt.Errorf("record %d: %s", v.FirstBroken, v.Problem)
+	default:
+		gap := v.Gaps[0]
+		return fmt.Errorf("%d checkpoint gap(s), first from %s to %s", len(v.Gaps), gap.From.Format(time.RFC3339), gap.To.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditCheckpointsAnchorHeadAndDetectGaps(t *testing.T) {
+	cfg := testSentinelConfig(t, SentinelConfig{
+		AnchorEnabled:   true,
+		AuditCheckpoint: &AuditCheckpointConfig{Enabled: true},
+	})
+	guard := NewSentinelGuard(cfg)
+	guard.anchorFn = func(rec *AuditRecord) (string, error) { return "Digest" + rec.RecordHash[2:8], nil }
+	cp, err := newAuditCheckpointer(guard, cfg.AuditCheckpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0).UTC()
+	cp.now = func() time.Time { return now }
+
+	if !cp.Due() {
+		t.Fatal("the first checkpoint is due at startup")
+	}
+	if _, _, err := guard.Enforce("STATUS", "show system status"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := cp.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload AuditCheckpoint
+	json.Unmarshal([]byte(first.Prompt), &payload)
+	if payload.Records != 1 || payload.HeadHash != first.PrevHash || payload.Previous != "" || first.TxDigest == "" {
+		t.Fatalf("unexpected checkpoint: %+v %+v", payload, first)
+	}
+
+	now = now.Add(23 * time.Hour)
+	if cp.Due() {
+		t.Fatal("checkpoint written before the interval elapsed")
+	}
+	now = now.Add(time.Hour)
+	second, err := cp.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(second.Prompt), &payload)
+	if payload.Records != 2 || payload.Previous != first.RecordHash {
+		t.Fatalf("checkpoint should count the previous one and link to it: %+v", payload)
+	}
+
+	restarted, _ := newAuditCheckpointer(guard, cfg.AuditCheckpoint)
+	if last := restarted.Last(); last == nil || last.RecordHash != second.RecordHash {
+		t.Fatalf("restart should resume from the last checkpoint: %+v", last)
+	}
+
+	records, _ := readAuditRecords(cfg.AuditLogPath)
+	if v := verifyAuditCheckpoints(records, now.Add(time.Hour)); v == nil || !v.Valid || v.Checkpoints != 2 || v.LastHash != second.RecordHash {
+		t.Fatalf("checkpoints should verify: %+v", v)
+	}
+	v := verifyAuditCheckpoints(records, now.Add(31*time.Hour))
+	if v.Valid || len(v.Gaps) != 1 || !v.Gaps[0].Trailing || checkpointError(v) == nil {
+		t.Fatalf("a missed checkpoint should be reported: %+v", v)
+	}
+
+	// The third checkpoint comes two days late.
+	now = now.Add(72 * time.Hour)
+	if _, err := cp.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	records, _ = readAuditRecords(cfg.AuditLogPath)
+	v = verifyAuditCheckpoints(records, now)
+	if v.Valid || len(v.Gaps) != 1 || v.Gaps[0].After != second.RecordHash || v.Gaps[0].Trailing {
+		t.Fatalf("gap between checkpoints should be reported: %+v", v)
+	}
+	var out bytes.Buffer
+	if err := runVerifyAuditMode(cfg.AuditLogPath, "", &out); err == nil || !strings.Contains(err.Error(), "checkpoint gap") {
+		t.Fatalf("verify-audit should fail on gaps, got %v", err)
+	}
+}
+
+func TestVerifyAuditCheckpointsRejectsWrongCounts(t *testing.T) {
+	at := time.Unix(1_700_000_000, 0).UTC()
+	payload, _ := json.Marshal(AuditCheckpoint{HeadHash: "0xaa", Records: 3, IntervalSec: 3600})
+	records := []AuditRecord{
+		{Action: "STATUS", RecordHash: "0xaa", Timestamp: at},
+		{Action: auditCheckpointAction, Prompt: string(payload), RecordHash: "0xbb", Timestamp: at},
+	}
+	v := verifyAuditCheckpoints(records, at)
+	if v.Valid || v.FirstBroken != 2 || !strings.Contains(v.Problem, "counts 3 records but 1") {
+		t.Fatalf("a checkpoint over a truncated log should fail: %+v", v)
+	}
+	if verifyAuditCheckpoints(records[:1], at) != nil {
+		t.Fatal("a log without checkpoints has nothing to verify")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// flockAuditLog is a no-op without flock(2); stop the proxy before running
+// purge on these systems.
+func flockAuditLog(f *os.File, exclusive bool) error { return nil }
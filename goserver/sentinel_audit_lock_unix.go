@@ -0,0 +1,17 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// flockAuditLog takes or converts the advisory lock on f. A shared lock
+// waits for an exclusive holder; an exclusive lock fails at once.
+func flockAuditLog(f *os.File, exclusive bool) error {
+	if exclusive {
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	}
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_SH)
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// AuditQuery filters the audit log. Zero fields match everything; every tag
+// in Tags must be present on a record. MinScore and MaxScore are inclusive.
+type AuditQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Decision string
+	Action   string
+	Tags     []string
+	MinScore *int
+	MaxScore *int
+	Limit    int
+	// Stats adds aggregate statistics over every match.
+	Stats bool
+}
+
+// AuditQueryResult holds the newest matching records first. Total counts
+// every match, including those cut by the limit.
+type AuditQueryResult struct {
+	Total   int           `json:"total"`
+	Records []AuditRecord `json:"records"`
+	Stats   *AuditStats   `json:"stats,omitempty"`
+}
+
+// AuditStats aggregates matching records by UTC day and, within a day,
+// by tag. A record counts once toward every tag it carries.
+type AuditStats struct {
+	Total     int             `json:"total"`
+	Blocked   int             `json:"blocked"`
+	BlockRate float64         `json:"block_rate"`
+	Days      []AuditDayStats `json:"days"`
+}
+
+// AuditDayStats is one day of AuditStats.
+type AuditDayStats struct {
+	Day       string          `json:"day"` // YYYY-MM-DD
+	Total     int             `json:"total"`
+	Blocked   int             `json:"blocked"`
+	BlockRate float64         `json:"block_rate"`
+	Tags      []AuditTagStats `json:"tags"`
+}
+
+// AuditTagStats is one tag within a day.
+type AuditTagStats struct {
+	Tag       string  `json:"tag"`
+	Total     int     `json:"total"`
+	Blocked   int     `json:"blocked"`
+	BlockRate float64 `json:"block_rate"`
+}
+
+const defaultAuditQueryLimit = 100
+
+// queryAuditRecords applies q to records, which are in log (oldest-first)
+// order.
+func queryAuditRecords(records []AuditRecord, q AuditQuery) AuditQueryResult {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultAuditQueryLimit
+	}
+	res := AuditQueryResult{Records: []AuditRecord{}}
+	var matches []AuditRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if !q.matches(&rec) {
+			continue
+		}
+		res.Total++
+		if len(res.Records) < limit {
+			res.Records = append(res.Records, rec)
+		}
+		if q.Stats {
+			matches = append(matches, rec)
+		}
+	}
+	if q.Stats {
+		res.Stats = auditStats(matches)
+	}
+	return res
+}
+
+func (q AuditQuery) matches(rec *AuditRecord) bool {
+	switch {
+	case !q.Since.IsZero() && rec.Timestamp.Before(q.Since),
+		!q.Until.IsZero() && !rec.Timestamp.Before(q.Until),
+		q.Decision != "" && !strings.EqualFold(rec.Decision, q.Decision),
+		q.Action != "" && !strings.EqualFold(rec.Action, q.Action),
+		q.MinScore != nil && rec.Score < *q.MinScore,
+		q.MaxScore != nil && rec.Score > *q.MaxScore:
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !containsTag(rec.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func auditStats(records []AuditRecord) *AuditStats {
+	type tally struct{ total, blocked int }
+	days := map[string]*tally{}
+	tags := map[string]map[string]*tally{}
+	stats := &AuditStats{Days: []AuditDayStats{}}
+	for _, rec := range records {
+		day := rec.Timestamp.UTC().Format("2006-01-02")
+		if days[day] == nil {
+			days[day] = &tally{}
+			tags[day] = map[string]*tally{}
+		}
+		blocked := 0
+		if rec.Decision == "blocked" {
+			blocked = 1
+		}
+		stats.Total++
+		stats.Blocked += blocked
+		days[day].total++
+		days[day].blocked += blocked
+		for _, tag := range dedupe(rec.Tags) {
+			if tags[day][tag] == nil {
+				tags[day][tag] = &tally{}
+			}
+			tags[day][tag].total++
+			tags[day][tag].blocked += blocked
+		}
+	}
+	stats.BlockRate = benchmarkRatio(stats.Blocked, stats.Total)
+	for _, day := range sortedKeys(days) {
+		d := AuditDayStats{Day: day, Total: days[day].total, Blocked: days[day].blocked, Tags: []AuditTagStats{}}
+		d.BlockRate = benchmarkRatio(d.Blocked, d.Total)
+		for _, tag := range sortedKeys(tags[day]) {
+			t := tags[day][tag]
+			d.Tags = append(d.Tags, AuditTagStats{Tag: tag, Total: t.total, Blocked: t.blocked, BlockRate: benchmarkRatio(t.blocked, t.total)})
+		}
+		stats.Days = append(stats.Days, d)
+	}
+	return stats
+}
+
+// parseAuditQueryTime accepts an RFC 3339 timestamp or a duration meaning
+// "that long ago".
+func parseAuditQueryTime(name, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time or a duration like 24h, got %q", name, value)
+}
+
+// parseAuditQuery reads an AuditQuery from URL parameters.
+func parseAuditQuery(r *http.Request, now time.Time) (AuditQuery, error) {
+	params := r.URL.Query()
+	q := AuditQuery{
+		Decision: params.Get("decision"),
+		Action:   params.Get("action"),
+		Tags:     params["tag"],
+	}
+	var err error
+	if q.Since, err = parseAuditQueryTime("since", params.Get("since"), now); err != nil {
+		return q, err
+	}
+	if q.Until, err = parseAuditQueryTime("until", params.Get("until"), now); err != nil {
+		return q, err
+	}
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit <= 0 || q.Limit > 1000 {
+			return q, fmt.Errorf("limit must be between 1 and 1000, got %q", v)
+		}
+	}
+	if q.MinScore, err = parseAuditQueryScore("min_score", params.Get("min_score")); err != nil {
+		return q, err
+	}
+	if q.MaxScore, err = parseAuditQueryScore("max_score", params.Get("max_score")); err != nil {
+		return q, err
+	}
+	q.Stats, _ = strconv.ParseBool(params.Get("stats"))
+	return q, nil
+}
+
+func parseAuditQueryScore(name, value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer, got %q", name, value)
+	}
+	return &n, nil
+}
+
+// handleAuditQuery serves GET /sentinel/audit.
+func (gw *SentinelGateway) handleAuditQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := parseAuditQuery(r, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	records, err := readAuditRecords(gw.guard.cfg.AuditLogPath)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, queryAuditRecords(records, q))
+}
+
+// runAuditCommand implements `goserver audit`: the same filters as
+// GET /sentinel/audit, read straight from the log, as a table or JSON.
+func runAuditCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	auditPath := fs.String("audit", "./audit/sentinel-audit.jsonl", "Sentinel JSONL audit log to query")
+	since := fs.String("since", "", "Only records at or after this RFC 3339 time or duration ago (e.g. 24h)")
+	until := fs.String("until", "", "Only records before this RFC 3339 time or duration ago")
+	action := fs.String("action", "", "Only records with this action")
+	decision := fs.String("decision", "", "Only records with this decision (allowed, blocked, recorded)")
+	tags := fs.String("tag", "", "Comma-separated tags that must all be present")
+	minScore := fs.String("min-score", "", "Only records scoring at least this")
+	maxScore := fs.String("max-score", "", "Only records scoring at most this")
+	limit := fs.Int("limit", defaultAuditQueryLimit, "Maximum number of records to print, newest first")
+	stats := fs.Bool("stats", false, "Add block rates per day and per tag per day over every match")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("--limit must be positive")
+	}
+
+	now := time.Now()
+	q := AuditQuery{Action: *action, Decision: *decision, Limit: *limit, Stats: *stats}
+	for _, t := range strings.Split(*tags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			q.Tags = append(q.Tags, t)
+		}
+	}
+	var err error
+	if q.Since, err = parseAuditQueryTime("--since", *since, now); err != nil {
+		return err
+	}
+	if q.Until, err = parseAuditQueryTime("--until", *until, now); err != nil {
+		return err
+	}
+	if q.MinScore, err = parseAuditQueryScore("--min-score", *minScore); err != nil {
+		return err
+	}
+	if q.MaxScore, err = parseAuditQueryScore("--max-score", *maxScore); err != nil {
+		return err
+	}
+
+	records, err := readAuditRecords(strings.TrimSpace(*auditPath))
+	if err != nil {
+		return err
+	}
+	res := queryAuditRecords(records, q)
+	switch strings.ToLower(*format) {
+	case "json":
+		return encodeSentinelOutput(out, res)
+	case "table":
+		return writeAuditTable(out, res)
+	default:
+		return fmt.Errorf("unsupported --format %q (use table or json)", *format)
+	}
+}
+
+func writeAuditTable(out io.Writer, res AuditQueryResult) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tACTION\tDECISION\tSCORE\tTAGS\tHASH")
+	for _, rec := range res.Records {
+		hash := rec.RecordHash
+		if len(hash) > 18 {
+			hash = hash[:18]
+		}
+		tags := append([]string(nil), rec.Tags...)
+		sort.Strings(tags)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			rec.Timestamp.UTC().Format(time.RFC3339), rec.Action, rec.Decision, rec.Score, strings.Join(tags, ","), hash)
+	}
+	fmt.Fprintf(tw, "\n%d of %d matching records\n", len(res.Records), res.Total)
+	if res.Stats != nil {
+		fmt.Fprintf(tw, "\nDAY\tTAG\tTOTAL\tBLOCKED\tBLOCK RATE\n")
+		for _, d := range res.Stats.Days {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f%%\n", d.Day, "(all)", d.Total, d.Blocked, 100*d.BlockRate)
+			for _, t := range d.Tags {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f%%\n", d.Day, t.Tag, t.Total, t.Blocked, 100*t.BlockRate)
+			}
+		}
+		fmt.Fprintf(tw, "total\t(all)\t%d\t%d\t%.1f%%\n", res.Stats.Total, res.Stats.Blocked, 100*res.Stats.BlockRate)
+	}
+	return tw.Flush()
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sort"
 )
 
 // BenchmarkCase represents one red-team sample.
@@ -12,6 +12,7 @@ type BenchmarkCase struct {
 	Action      string `json:"action"`
 	Prompt      string `json:"prompt"`
 	ExpectBlock bool   `json:"expect_block"`
+	Category    string `json:"category,omitempty"`
 }
 
 // BenchmarkReport summarizes model behavior for judging/demo.
@@ -27,65 +28,136 @@ type BenchmarkReport struct {
 	Recall        float64 `json:"recall"`
 	F1            float64 `json:"f1"`
 	BlockRate     float64 `json:"block_rate"`
+
+	// Files and Categories break the totals down when the corpus spans
+	// several files or its cases carry a category.
+	Files      []BenchmarkBreakdown `json:"files,omitempty"`
+	Categories []BenchmarkBreakdown `json:"categories,omitempty"`
+}
+
+// BenchmarkBreakdown is the confusion matrix for one file or category.
+type BenchmarkBreakdown struct {
+	Name          string  `json:"name"`
+	Total         int     `json:"total"`
+	Correct       int     `json:"correct"`
+	Accuracy      float64 `json:"accuracy"`
+	TruePositive  int     `json:"true_positive"`
+	FalsePositive int     `json:"false_positive"`
+	TrueNegative  int     `json:"true_negative"`
+	FalseNegative int     `json:"false_negative"`
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+}
+
+// benchmarkTally accumulates one confusion matrix.
+type benchmarkTally struct {
+	total, correct, blocked int
+	tp, fp, tn, fn          int
+}
+
+func (t *benchmarkTally) add(expect, pred bool) {
+	t.total++
+	if pred {
+		t.blocked++
+	}
+	if pred == expect {
+		t.correct++
+	}
+	switch {
+	case expect && pred:
+		t.tp++
+	case !expect && pred:
+		t.fp++
+	case !expect && !pred:
+		t.tn++
+	case expect && !pred:
+		t.fn++
+	}
 }
 
-func RunSentinelBenchmarkWithReport(path string, guard *SentinelGuard) (*BenchmarkReport, error) {
+func benchmarkRatio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+func (t *benchmarkTally) breakdown(name string) BenchmarkBreakdown {
+	return BenchmarkBreakdown{
+		Name:          name,
+		Total:         t.total,
+		Correct:       t.correct,
+		Accuracy:      benchmarkRatio(t.correct, t.total),
+		TruePositive:  t.tp,
+		FalsePositive: t.fp,
+		TrueNegative:  t.tn,
+		FalseNegative: t.fn,
+		Precision:     benchmarkRatio(t.tp, t.tp+t.fp),
+		Recall:        benchmarkRatio(t.tp, t.tp+t.fn),
+	}
+}
+
+// RunSentinelBenchmarkWithReport evaluates every case in the files matching
+// pattern (a path or a glob; JSON, CSV or YAML by extension).
+func RunSentinelBenchmarkWithReport(pattern string, guard *SentinelGuard) (*BenchmarkReport, error) {
 	if guard == nil {
 		return nil, fmt.Errorf("sentinel guard is not configured")
 	}
 
-	data, err := os.ReadFile(path)
+	files, err := loadBenchmarkFiles(pattern)
 	if err != nil {
 		return nil, err
 	}
 
-	var cases []BenchmarkCase
-	if err := json.Unmarshal(data, &cases); err != nil {
-		return nil, err
-	}
-
-	report := BenchmarkReport{Total: len(cases)}
-	blockedPredictions := 0
+	var all benchmarkTally
+	perCategory := map[string]*benchmarkTally{}
+	var fileBreakdowns []BenchmarkBreakdown
+	for _, f := range files {
+		var perFile benchmarkTally
+		for _, c := range f.cases {
+			eval := guard.Evaluate(c.Action, c.Prompt)
+			pred := eval.ShouldBlock
+			all.add(c.ExpectBlock, pred)
+			perFile.add(c.ExpectBlock, pred)
+			if c.Category != "" {
+				if perCategory[c.Category] == nil {
+					perCategory[c.Category] = &benchmarkTally{}
+				}
+				perCategory[c.Category].add(c.ExpectBlock, pred)
+			}
 
-	for _, c := range cases {
-		eval := guard.Evaluate(c.Action, c.Prompt)
-		pred := eval.ShouldBlock
-		if pred {
-			blockedPredictions++
+			fmt.Printf("[%s] action=%s score=%d block=%v expect=%v tags=%v\n",
+				c.Name, c.Action, eval.Score, pred, c.ExpectBlock, eval.Tags)
 		}
-		ok := pred == c.ExpectBlock
-		if ok {
-			report.Correct++
-		}
-
-		switch {
-		case c.ExpectBlock && pred:
-			report.TruePositive++
-		case !c.ExpectBlock && pred:
-			report.FalsePositive++
-		case !c.ExpectBlock && !pred:
-			report.TrueNegative++
-		case c.ExpectBlock && !pred:
-			report.FalseNegative++
-		}
-
-		fmt.Printf("[%s] action=%s score=%d block=%v expect=%v tags=%v\n",
-			c.Name, c.Action, eval.Score, pred, c.ExpectBlock, eval.Tags)
+		fileBreakdowns = append(fileBreakdowns, perFile.breakdown(filepath.Base(f.path)))
 	}
 
-	if report.Total > 0 {
-		report.Accuracy = float64(report.Correct) / float64(report.Total)
-		report.BlockRate = float64(blockedPredictions) / float64(report.Total)
-	}
-	if report.TruePositive+report.FalsePositive > 0 {
-		report.Precision = float64(report.TruePositive) / float64(report.TruePositive+report.FalsePositive)
-	}
-	if report.TruePositive+report.FalseNegative > 0 {
-		report.Recall = float64(report.TruePositive) / float64(report.TruePositive+report.FalseNegative)
+	report := BenchmarkReport{
+		Total:         all.total,
+		Correct:       all.correct,
+		Accuracy:      benchmarkRatio(all.correct, all.total),
+		TruePositive:  all.tp,
+		FalsePositive: all.fp,
+		TrueNegative:  all.tn,
+		FalseNegative: all.fn,
+		Precision:     benchmarkRatio(all.tp, all.tp+all.fp),
+		Recall:        benchmarkRatio(all.tp, all.tp+all.fn),
+		BlockRate:     benchmarkRatio(all.blocked, all.total),
 	}
 	if report.Precision+report.Recall > 0 {
 		report.F1 = 2 * report.Precision * report.Recall / (report.Precision + report.Recall)
 	}
+	if len(files) > 1 {
+		report.Files = fileBreakdowns
+	}
+	categories := make([]string, 0, len(perCategory))
+	for name := range perCategory {
+		categories = append(categories, name)
+	}
+	sort.Strings(categories)
+	for _, name := range categories {
+		report.Categories = append(report.Categories, perCategory[name].breakdown(name))
+	}
 
 	return &report, nil
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// benchmarkFile is one corpus file and the cases parsed from it.
+type benchmarkFile struct {
+	path  string
+	cases []BenchmarkCase
+}
+
+// loadBenchmarkFiles expands pattern and parses every matching file by its
+// extension: .json (an array of cases), .csv (a header row naming the case
+// fields) or .yaml/.yml (a list of flat mappings).
+func loadBenchmarkFiles(pattern string) ([]benchmarkFile, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("benchmark pattern %q: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		// A plain path that does not exist should still report the os error.
+		if _, statErr := os.Stat(pattern); statErr != nil {
+			return nil, statErr
+		}
+		paths = []string{pattern}
+	}
+	sort.Strings(paths)
+
+	files := make([]benchmarkFile, 0, len(paths))
+	for _, path := range paths {
+		cases, err := loadBenchmarkCases(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		files = append(files, benchmarkFile{path: path, cases: cases})
+	}
+	return files, nil
+}
+
+func loadBenchmarkCases(path string) ([]BenchmarkCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseBenchmarkCSV(data)
+	case ".yaml", ".yml":
+		return parseBenchmarkYAML(data)
+	default:
+		var cases []BenchmarkCase
+		if err := json.Unmarshal(data, &cases); err != nil {
+			return nil, err
+		}
+		return cases, nil
+	}
+}
+
+// setBenchmarkField assigns one named field, shared by the CSV and YAML
+// readers. Unknown fields are rejected so a typo cannot silently drop data.
+func setBenchmarkField(c *BenchmarkCase, key, value string) error {
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "name":
+		c.Name = value
+	case "action":
+		c.Action = value
+	case "prompt":
+		c.Prompt = value
+	case "category":
+		c.Category = value
+	case "expect_block":
+		if strings.TrimSpace(value) == "" {
+			c.ExpectBlock = false
+			return nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("expect_block %q is not a boolean", value)
+		}
+		c.ExpectBlock = b
+	default:
+		return fmt.Errorf("unknown field %q", key)
+	}
+	return nil
+}
+
+func parseBenchmarkCSV(data []byte) ([]BenchmarkCase, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []BenchmarkCase{}, nil
+	}
+	header := rows[0]
+	cases := make([]BenchmarkCase, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		var c BenchmarkCase
+		for j, value := range row {
+			if err := setBenchmarkField(&c, header[j], value); err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+2, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// parseBenchmarkYAML reads a top-level list of flat mappings (see
+// yamlToJSON for the accepted subset) and assigns each key through
+// setBenchmarkField, so YAML and CSV corpora accept the same fields.
+func parseBenchmarkYAML(data []byte) ([]BenchmarkCase, error) {
+	js, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(js, &items); err != nil {
+		return nil, fmt.Errorf("expected a list of mappings: %w", err)
+	}
+	cases := make([]BenchmarkCase, 0, len(items))
+	for i, item := range items {
+		keys := make([]string, 0, len(item))
+		for k := range item {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var c BenchmarkCase
+		for _, k := range keys {
+			var value string
+			switch v := item[k].(type) {
+			case nil:
+			case float64:
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			case string, bool:
+				value = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("case %d: field %q must be a scalar", i+1, k)
+			}
+			if err := setBenchmarkField(&c, k, value); err != nil {
+				return nil, fmt.Errorf("case %d: %w", i+1, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// browserActivitySource is the ActivityMonitor source for extension reports.
+const browserActivitySource = "browser"
+
+// BrowserExtensionConfig enables the endpoints used by the companion
+// browser extension. They are authenticated with a bearer token read from
+// the environment, never from the config file.
+type BrowserExtensionConfig struct {
+	Enabled bool `json:"enabled"`
+	// TokenEnv names the environment variable holding the token; default
+	// SENTINEL_EXTENSION_TOKEN.
+	TokenEnv string `json:"token_env,omitempty"`
+	// AllowedOrigins are the extension origins allowed to call the
+	// endpoints from a browser, e.g. chrome-extension://<id>.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+	// PassphraseEnv names an environment variable holding a liveness
+	// passphrase. When set, extension reports count as liveness only if
+	// they carry it, so whoever is at an unlocked browser cannot confirm
+	// the owner is alive.
+	PassphraseEnv string `json:"passphrase_env,omitempty"`
+}
+
+// browserExtension authenticates extension requests.
+type browserExtension struct {
+	token      string
+	origins    map[string]bool
+	passphrase string // "" when liveness needs no passphrase
+}
+
+// newBrowserExtension returns nil when the extension endpoints are disabled.
+func newBrowserExtension(cfg *BrowserExtensionConfig) (*browserExtension, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	env := extensionTokenEnv(cfg)
+	token := strings.TrimSpace(os.Getenv(env))
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set", env)
+	}
+	var passphrase string
+	if cfg.PassphraseEnv != "" {
+		if passphrase = os.Getenv(cfg.PassphraseEnv); passphrase == "" {
+			return nil, fmt.Errorf("%s is not set", cfg.PassphraseEnv)
+		}
+	}
+	origins := map[string]bool{}
+	for _, o := range cfg.AllowedOrigins {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			origins[o] = true
+		}
+	}
+	return &browserExtension{token: token, origins: origins, passphrase: passphrase}, nil
+}
+
+// confirmsLiveness reports whether a report carrying passphrase may count
+// as liveness.
+func (be *browserExtension) confirmsLiveness(passphrase string) bool {
+	return be.passphrase == "" || subtle.ConstantTimeCompare([]byte(passphrase), []byte(be.passphrase)) == 1
+}
+
+// wrap answers CORS preflights for allowed origins and rejects requests
+// without the bearer token. Requests from any other browser origin are
+// refused outright, so a web page cannot use the endpoints.
+func (be *browserExtension) wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if !be.origins[origin] {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !hasBearerToken(r, be.token) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing extension token"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+// extensionTokenEnv names the environment variable holding the bearer token
+// of the extension endpoints. It is separate from the operator token, so a
+// token handed to a browser cannot arm the kill switch or edit the lists.
+func extensionTokenEnv(cfg *BrowserExtensionConfig) string {
+	if cfg != nil && cfg.TokenEnv != "" {
+		return cfg.TokenEnv
+	}
+	return "SENTINEL_EXTENSION_TOKEN"
+}
+
+// operatorTokenEnv names the environment variable holding the bearer token
+// of the operator endpoints.
+func operatorTokenEnv(cfg *SentinelConfig) string {
+	if cfg != nil && cfg.OperatorTokenEnv != "" {
+		return cfg.OperatorTokenEnv
+	}
+	return "SENTINEL_OPERATOR_TOKEN"
+}
+
+// checkOperatorToken refuses to start the proxy without an operator token.
+// The operator endpoints used to work without one, so the error says what
+// changed instead of leaving every such call to fail with 401.
+func checkOperatorToken(cfg *SentinelConfig) error {
+	env := operatorTokenEnv(cfg)
+	if strings.TrimSpace(os.Getenv(env)) != "" {
+		return nil
+	}
+	return fmt.Errorf("%s is not set: approvals, the kill switch, config changes, list edits, activity reports, OpenClaw sessions, the audit query and the event stream now need an operator token. Export a random secret as %s and send it as \"Authorization: Bearer <token>\" (see Operator token in docs/USAGE.md)", env, env)
+}
+
+// hasBearerToken reports whether r carries token as its bearer token. An
+// empty token matches nothing.
+func hasBearerToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(token)) == 1
+}
+
+// requireToken rejects requests without the operator token. With no token
+// in the environment every request is rejected.
+func (gw *SentinelGateway) requireToken(h http.HandlerFunc) http.HandlerFunc {
+	return gw.operatorOnly(h, true)
+}
+
+// requireTokenForWrites is requireToken for everything but GETs.
+func (gw *SentinelGateway) requireTokenForWrites(h http.HandlerFunc) http.HandlerFunc {
+	return gw.operatorOnly(h, false)
+}
+
+func (gw *SentinelGateway) operatorOnly(h http.HandlerFunc, reads bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if (reads || r.Method != http.MethodGet) && !hasBearerToken(r, gw.token) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing operator token"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+// ExtensionActivityRequest reports that the user is active in the browser.
+// Only the fact and time of activity are kept, not what was browsed.
+type ExtensionActivityRequest struct {
+	Kind string `json:"kind,omitempty"` // navigation, input, focus
+	// Owner is the household member signed in to the browser profile.
+	Owner string `json:"owner,omitempty"`
+	// Passphrase is the liveness passphrase the user typed, when
+	// browser_extension.passphrase_env is set.
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// ExtensionApprovalsResponse is what the extension polls to draw its badge.
+type ExtensionApprovalsResponse struct {
+	Badge   string               `json:"badge"` // "" when nothing is pending
+	Pending []*ApprovalChallenge `json:"pending"`
+}
+
+// ExtensionDecisionRequest approves or denies a challenge from the browser.
+type ExtensionDecisionRequest struct {
+	ChallengeID string `json:"challenge_id"`
+	Approved    bool   `json:"approved"`
+	// DecidedBy is recorded on the challenge; default "browser-extension".
+	DecidedBy string `json:"decided_by,omitempty"`
+	// Passphrase lets the decision also count as liveness when a liveness
+	// passphrase is configured.
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+func (gw *SentinelGateway) handleExtensionActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ExtensionActivityRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+	if !gw.extension.confirmsLiveness(req.Passphrase) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "invalid or missing liveness passphrase"})
+		return
+	}
+	gw.activity.Record(browserActivitySource, strings.TrimSpace(req.Owner), false)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recorded": true})
+}
+
+// handleExtensionApprovals lists pending approvals (GET) or decides one
+// (POST).
+func (gw *SentinelGateway) handleExtensionApprovals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		pending := gw.approval.ListPending()
+		sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+		resp := ExtensionApprovalsResponse{Pending: pending}
+		if n := len(pending); n > 0 {
+			resp.Badge = strconv.Itoa(n)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case http.MethodPost:
+		var req ExtensionDecisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeID == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "challenge_id is required"})
+			return
+		}
+		if req.DecidedBy == "" {
+			req.DecidedBy = "browser-extension"
+		}
+		// Deciding in the browser is also evidence the user is present,
+		// unless liveness needs a passphrase the decision lacks.
+		if gw.extension.confirmsLiveness(req.Passphrase) {
+			gw.activity.Record(browserActivitySource, "", false)
+		}
+		resp, err := gw.confirmApproval(req.ChallengeID, req.Approved, req.DecidedBy)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
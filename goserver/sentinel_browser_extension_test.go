@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newExtensionGateway(t *testing.T) http.Handler {
+	t.Helper()
+	t.Setenv("SENTINEL_TEST_EXT_TOKEN", "ext-secret")
+	guard := newTestGuard(t, SentinelConfig{
+		BrowserExtension: &BrowserExtensionConfig{
+			Enabled:        true,
+			TokenEnv:       "SENTINEL_TEST_EXT_TOKEN",
+			AllowedOrigins: []string{"chrome-extension://abcdef/"},
+		},
+	})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+	gw.approval.StartChallenge("EXEC", "deploy to prod", 75, "")
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	return mux
+}
+
+func extensionRequest(h http.Handler, method, path, token, origin string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBrowserExtensionApprovals(t *testing.T) {
+	h := newExtensionGateway(t)
+	origin := "chrome-extension://abcdef"
+
+	if rec := extensionRequest(h, http.MethodGet, "/sentinel/extension/approvals", "", origin, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: %d", rec.Code)
+	}
+	if rec := extensionRequest(h, http.MethodGet, "/sentinel/extension/approvals", "ext-secret", "https://evil.example", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("foreign origin: %d", rec.Code)
+	}
+	rec := extensionRequest(h, http.MethodOptions, "/sentinel/extension/approvals", "", origin, nil)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != origin {
+		t.Fatalf("preflight: %d %v", rec.Code, rec.Header())
+	}
+
+	rec = extensionRequest(h, http.MethodGet, "/sentinel/extension/approvals", "ext-secret", origin, nil)
+	var list ExtensionApprovalsResponse
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if rec.Code != http.StatusOK || list.Badge != "1" || len(list.Pending) != 1 || list.Pending[0].Action != "EXEC" {
+		t.Fatalf("pending approvals: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = extensionRequest(h, http.MethodPost, "/sentinel/extension/approvals", "ext-secret", origin, ExtensionDecisionRequest{ChallengeID: list.Pending[0].ID, Approved: true})
+	var decided struct {
+		Challenge ApprovalChallenge `json:"challenge"`
+		Token     *ExecuteToken     `json:"token"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &decided)
+	if rec.Code != http.StatusOK || decided.Challenge.Status != "approved" || decided.Challenge.DecisionBy != "browser-extension" || decided.Token == nil {
+		t.Fatalf("approve: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = extensionRequest(h, http.MethodGet, "/sentinel/extension/approvals", "ext-secret", "", nil)
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if list.Badge != "" || len(list.Pending) != 0 {
+		t.Fatalf("badge should clear once decided: %s", rec.Body.String())
+	}
+}
+
+func TestBrowserExtensionActivityCountsAsLiveness(t *testing.T) {
+	h := newExtensionGateway(t)
+	if rec := extensionRequest(h, http.MethodPost, "/sentinel/extension/activity", "wrong", "", ExtensionActivityRequest{Kind: "navigation"}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", rec.Code)
+	}
+	if rec := extensionRequest(h, http.MethodPost, "/sentinel/extension/activity", "ext-secret", "", ExtensionActivityRequest{Kind: "navigation"}); rec.Code != http.StatusOK {
+		t.Fatalf("activity: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := extensionRequest(h, http.MethodGet, "/sentinel/status", "", "", nil)
+	var status struct {
+		Activity ActivityStatus `json:"activity"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if status.Activity.LastSeen == nil || len(status.Activity.Sources) != 1 || status.Activity.Sources[0].Name != browserActivitySource {
+		t.Fatalf("browser activity should be reported: %s", rec.Body.String())
+	}
+}
+
+func TestBrowserExtensionRequiresToken(t *testing.T) {
+	t.Setenv("SENTINEL_EXTENSION_TOKEN", "")
+	if ext, err := newBrowserExtension(&BrowserExtensionConfig{Enabled: true}); err == nil || ext != nil {
+		t.Fatalf("enabling without a token should fail: %v", err)
+	}
+	if ext, err := newBrowserExtension(&BrowserExtensionConfig{}); err != nil || ext != nil {
+		t.Fatalf("disabled: %v %v", ext, err)
+	}
+}
+
+func TestBrowserExtensionLivenessPassphrase(t *testing.T) {
+	t.Setenv("SENTINEL_TEST_EXT_TOKEN", "ext-secret")
+	t.Setenv("SENTINEL_TEST_EXT_PASSPHRASE", "correct horse")
+	guard := newTestGuard(t, SentinelConfig{
+		BrowserExtension: &BrowserExtensionConfig{
+			Enabled:       true,
+			TokenEnv:      "SENTINEL_TEST_EXT_TOKEN",
+			PassphraseEnv: "SENTINEL_TEST_EXT_PASSPHRASE",
+		},
+	})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+	challenge := gw.approval.StartChallenge("EXEC", "deploy to prod", 75, "")
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	for _, phrase := range []string{"", "wrong"} {
+		if rec := extensionRequest(mux, http.MethodPost, "/sentinel/extension/activity", "ext-secret", "", ExtensionActivityRequest{Passphrase: phrase}); rec.Code != http.StatusForbidden {
+			t.Fatalf("passphrase %q: %d", phrase, rec.Code)
+		}
+	}
+	// A decision without the passphrase is applied but is not liveness.
+	if rec := extensionRequest(mux, http.MethodPost, "/sentinel/extension/approvals", "ext-secret", "", ExtensionDecisionRequest{ChallengeID: challenge.ID}); rec.Code != http.StatusOK {
+		t.Fatalf("deny: %d %s", rec.Code, rec.Body.String())
+	}
+	if st := gw.activity.Status(); st.LastSeen != nil {
+		t.Fatalf("activity without the passphrase should not count: %+v", st)
+	}
+
+	if rec := extensionRequest(mux, http.MethodPost, "/sentinel/extension/activity", "ext-secret", "", ExtensionActivityRequest{Passphrase: "correct horse"}); rec.Code != http.StatusOK {
+		t.Fatalf("activity: %d %s", rec.Code, rec.Body.String())
+	}
+	if st := gw.activity.Status(); st.LastSeen == nil {
+		t.Fatal("activity with the passphrase should count")
+	}
+
+	t.Setenv("SENTINEL_TEST_EXT_PASSPHRASE", "")
+	if _, err := newBrowserExtension(&BrowserExtensionConfig{Enabled: true, TokenEnv: "SENTINEL_TEST_EXT_TOKEN", PassphraseEnv: "SENTINEL_TEST_EXT_PASSPHRASE"}); err == nil {
+		t.Fatal("a configured but unset passphrase should fail")
+	}
+}
+
+func TestOperatorRoutesNeedOperatorToken(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "op-secret")
+	h := newExtensionGateway(t)
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/sentinel/approval/confirm"},
+		{http.MethodPost, "/sentinel/kill-switch/arm"},
+		{http.MethodPost, "/sentinel/kill-switch/disarm"},
+		{http.MethodGet, "/sentinel/config/effective"},
+		{http.MethodPost, "/sentinel/activity"},
+		{http.MethodGet, "/sentinel/openclaw/sessions"},
+		{http.MethodGet, "/sentinel/audit"},
+	}
+	for _, rt := range routes {
+		for _, token := range []string{"", "ext-secret"} {
+			rec := extensionRequest(h, rt.method, rt.path, token, "", map[string]string{})
+			if rec.Code != http.StatusUnauthorized || !bytes.Contains(rec.Body.Bytes(), []byte("operator token")) {
+				t.Fatalf("%s %s with token %q: %d %s", rt.method, rt.path, token, rec.Code, rec.Body.String())
+			}
+		}
+		if rec := extensionRequest(h, rt.method, rt.path, "op-secret", "", map[string]string{}); rec.Code == http.StatusUnauthorized {
+			t.Fatalf("%s %s should accept the operator token", rt.method, rt.path)
+		}
+	}
+	// The operator token does not open the extension endpoints either.
+	if rec := extensionRequest(h, http.MethodGet, "/sentinel/extension/approvals", "op-secret", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("extension endpoint with the operator token: %d", rec.Code)
+	}
+}
+
+func TestCheckOperatorToken(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "")
+	err := checkOperatorToken(&SentinelConfig{})
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("SENTINEL_OPERATOR_TOKEN is not set")) {
+		t.Fatalf("want an error naming the variable, got %v", err)
+	}
+	t.Setenv("OPS_TOKEN", "s3cret")
+	if err := checkOperatorToken(&SentinelConfig{OperatorTokenEnv: "OPS_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+}
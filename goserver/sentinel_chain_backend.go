@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChainBackend anchors an audit record somewhere durable and returns a
+// reference to it (a transaction digest, a blob ID). Sui is the primary
+// backend; mirrors add redundancy on independent infrastructure.
+type ChainBackend interface {
+	Name() string
+	Anchor(rec *AuditRecord) (string, error)
+}
+
+// AnchorMirrorConfig is one secondary anchor backend.
+type AnchorMirrorConfig struct {
+	Kind         string `json:"kind"` // walrus
+	PublisherURL string `json:"publisher_url"`
+	Epochs       int    `json:"epochs"`
+	Deletable    bool   `json:"deletable,omitempty"`
+}
+
+// MirrorAnchor is one mirror's outcome for a record.
+type MirrorAnchor struct {
+	Backend string `json:"backend"`
+	Ref     string `json:"ref,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// anchorAlertFunc is called whenever any backend fails to anchor a record.
+type anchorAlertFunc func(backend string, rec *AuditRecord, err error)
+
+// suiBackend is the primary backend: the sentinel_audit::record_audit call.
+type suiBackend struct {
+	anchor func(*AuditRecord) (string, error)
+}
+
+func (b suiBackend) Name() string                            { return "sui" }
+func (b suiBackend) Anchor(rec *AuditRecord) (string, error) { return b.anchor(rec) }
+
+// walrusBackend stores a signed attestation of the record as a Walrus blob.
+// The prompt is left out: blobs are public.
+type walrusBackend struct {
+	client *walrusClient
+	opts   WalrusStoreOptions
+}
+
+func (b *walrusBackend) Name() string { return "walrus" }
+
+func (b *walrusBackend) Anchor(rec *AuditRecord) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":          "sentinel-audit-attestation-v1",
+		"record_hash":   rec.RecordHash,
+		"prev_hash":     rec.PrevHash,
+		"timestamp":     rec.Timestamp,
+		"action":        rec.Action,
+		"decision":      rec.Decision,
+		"score":         rec.Score,
+		"signature":     rec.Signature,
+		"public_key":    rec.PublicKey,
+		"sui_tx_digest": rec.TxDigest,
+	})
+	if err != nil {
+		return "", err
+	}
+	blob, err := b.client.Store(body, b.opts)
+	if err != nil {
+		return "", err
+	}
+	return blob.BlobID, nil
+}
+
+func newAnchorMirrors(cfgs []AnchorMirrorConfig) ([]ChainBackend, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	var mirrors []ChainBackend
+	for i, c := range cfgs {
+		switch strings.ToLower(c.Kind) {
+		case "walrus":
+			if strings.TrimSpace(c.PublisherURL) == "" {
+				return nil, fmt.Errorf("anchor_mirrors[%d].publisher_url is required", i)
+			}
+			epochs := c.Epochs
+			if epochs <= 0 {
+				epochs = 5
+			}
+			mirrors = append(mirrors, &walrusBackend{
+				client: newWalrusClient(c.PublisherURL, client),
+				opts:   WalrusStoreOptions{Epochs: epochs, Deletable: c.Deletable},
+			})
+		default:
+			return nil, fmt.Errorf("anchor_mirrors[%d]: unknown kind %q (use walrus)", i, c.Kind)
+		}
+	}
+	return mirrors, nil
+}
+
+// mirrorRecord anchors rec on every mirror in parallel. Each mirror fails
+// independently: its error is recorded on rec and alerted, but neither the
+// primary anchor nor the other mirrors are affected.
+func (sg *SentinelGuard) mirrorRecord(rec *AuditRecord) {
+	if len(sg.mirrors) == 0 {
+		return
+	}
+	results := make([]MirrorAnchor, len(sg.mirrors))
+	var wg sync.WaitGroup
+	for i, m := range sg.mirrors {
+		wg.Add(1)
+		go func(i int, m ChainBackend) {
+			defer wg.Done()
+			ref, err := m.Anchor(rec)
+			results[i] = MirrorAnchor{Backend: m.Name(), Ref: ref}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, m)
+	}
+	wg.Wait()
+	for _, r := range results {
+		var err error
+		if r.Error != "" {
+			err = fmt.Errorf("%s", r.Error)
+			log.Printf("[ANCHOR] %s mirror error: %v", r.Backend, err)
+			sg.alertAnchorFailure(r.Backend, rec, err)
+		} else {
+			sg.publishAnchorConfirmed(r.Backend, rec, r.Ref)
+		}
+		sg.metrics.observeMirror(r.Backend, err)
+	}
+	rec.Mirrors = results
+}
+
+func (sg *SentinelGuard) alertAnchorFailure(backend string, rec *AuditRecord, err error) {
+	if sg.anchorAlert != nil {
+		sg.anchorAlert(backend, rec, err)
+	}
+	sg.events.Publish(notifyAnchorFailed, map[string]interface{}{"backend": backend, "action": rec.Action, "record_hash": rec.RecordHash, "error": err.Error()})
+}
+
+// publishAnchorConfirmed reports a successful primary, mirror or retried
+// anchor on the event stream.
+func (sg *SentinelGuard) publishAnchorConfirmed(backend string, rec *AuditRecord, ref string) {
+	sg.events.Publish(eventAnchorConfirmed, map[string]interface{}{"backend": backend, "action": rec.Action, "record_hash": rec.RecordHash, "ref": ref})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnchorMirrors(t *testing.T) {
+	attestations := make(chan map[string]interface{}, 10)
+	walrus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/blobs" || r.URL.Query().Get("epochs") != "3" {
+			t.Errorf("unexpected walrus request %s", r.URL)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		attestations <- body
+		io.WriteString(w, `{"newlyCreated":{"blobObject":{"blobId":"blob-1"}}}`)
+	}))
+	defer walrus.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "publisher overloaded", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	alerts := make(chan map[string]interface{}, 10)
+	discord := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		alerts <- body
+	}))
+	defer discord.Close()
+
+	guard := newTestGuard(t, SentinelConfig{
+		AnchorEnabled: true,
+		AnchorMirrors: []AnchorMirrorConfig{
+			{Kind: "walrus", PublisherURL: walrus.URL, Epochs: 3},
+			{Kind: "walrus", PublisherURL: broken.URL},
+		},
+		Notifications: &NotificationsConfig{Webhooks: []WebhookNotifierConfig{
+			{Kind: "discord", URL: discord.URL, Events: []string{notifyAnchorFailed}},
+		}},
+	})
+	guard.anchorFn = func(*AuditRecord) (string, error) { return "SuiDigest", nil }
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{KillSwitchThreshold: 100})
+
+	_, rec, err := guard.Enforce("RUN", "deploy with token hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.TxDigest != "SuiDigest" || len(rec.Mirrors) != 2 {
+		t.Fatalf("unexpected anchors: %+v", rec)
+	}
+	if m := rec.Mirrors[0]; m.Backend != "walrus" || m.Ref != "blob-1" || m.Error != "" {
+		t.Fatalf("unexpected walrus mirror: %+v", m)
+	}
+	if m := rec.Mirrors[1]; m.Ref != "" || !strings.Contains(m.Error, "503") {
+		t.Fatalf("failing mirror should record its error: %+v", m)
+	}
+
+	att := <-attestations
+	if att["record_hash"] != rec.RecordHash || att["sui_tx_digest"] != "SuiDigest" {
+		t.Fatalf("unexpected attestation: %v", att)
+	}
+	if raw, _ := json.Marshal(att); strings.Contains(string(raw), "hunter2") {
+		t.Fatal("attestations are public and must not include the prompt")
+	}
+
+	select {
+	case body := <-alerts:
+		embed := body["embeds"].([]interface{})[0].(map[string]interface{})
+		if embed["title"] != "Sentinel walrus anchor failed" {
+			t.Fatalf("unexpected alert: %v", embed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("mirror failure was not alerted")
+	}
+
+	// A primary failure alerts on its own and does not stop the mirrors.
+	guard.anchorFn = func(*AuditRecord) (string, error) { return "", errors.New("rpc down") }
+	_, rec, _ = guard.Enforce("RUN", "git status")
+	if rec.AnchorError == "" || rec.Mirrors[0].Ref != "blob-1" {
+		t.Fatalf("mirrors should run when the primary fails: %+v", rec)
+	}
+	titles := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case body := <-alerts:
+			titles[body["embeds"].([]interface{})[0].(map[string]interface{})["title"].(string)] = true
+		case <-time.After(2 * time.Second):
+			t.Fatal("missing anchor failure alert")
+		}
+	}
+	if !titles["Sentinel sui anchor failed"] || !titles["Sentinel walrus anchor failed"] {
+		t.Fatalf("expected independent sui and walrus alerts, got %v", titles)
+	}
+
+	metrics := getJSON(t, gw.handleMetrics).Body.String()
+	for _, want := range []string{
+		`sentinel_anchor_mirror_total{backend="walrus",result="failure"} 2`,
+		`sentinel_anchor_mirror_total{backend="walrus",result="success"} 2`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Fatalf("metrics missing %s:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestAnchorMirrorConfigValidation(t *testing.T) {
+	if _, err := newAnchorMirrors([]AnchorMirrorConfig{{Kind: "ipfs", PublisherURL: "http://x"}}); err == nil {
+		t.Fatal("unknown mirror kinds must be rejected")
+	}
+	if _, err := newAnchorMirrors([]AnchorMirrorConfig{{Kind: "walrus"}}); err == nil {
+		t.Fatal("publisher_url is required")
+	}
+}
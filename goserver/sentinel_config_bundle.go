@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Encrypted config bundles let a VPS deployment keep no plaintext secrets on
+// disk: --config may name a bundle file or an https URL serving one, and the
+// 32-byte AES-256-GCM key comes from SENTINEL_CONFIG_KEY (hex or base64) or
+// the file named by SENTINEL_CONFIG_KEY_FILE.
+const (
+	configBundleVersion = 1
+	configBundleAAD     = "sentinel-config-bundle-v1"
+)
+
+type configBundle struct {
+	Version    int    `json:"sentinel_config_bundle"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// fetchedConfigs memoizes URL configs so the several loaders that read
+// --config during startup fetch it once.
+var fetchedConfigs = struct {
+	sync.Mutex
+	data map[string][]byte
+}{data: map[string][]byte{}}
+
+func isConfigURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// readConfigSource returns the plaintext config JSON at path, which may be a
+// plain file, a bundle file, or a URL serving a bundle.
+func readConfigSource(path string) ([]byte, error) {
+	if !isConfigURL(path) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return openConfigBundleIfSealed(data)
+	}
+
+	fetchedConfigs.Lock()
+	defer fetchedConfigs.Unlock()
+	if data, ok := fetchedConfigs.data[path]; ok {
+		return data, nil
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch config: HTTP %d", resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("fetch config: %w", err)
+	}
+	if _, ok := parseConfigBundle(raw); !ok {
+		return nil, fmt.Errorf("config fetched from a URL must be an encrypted bundle")
+	}
+	data, err := openConfigBundleIfSealed(raw)
+	if err != nil {
+		return nil, err
+	}
+	fetchedConfigs.data[path] = data
+	return data, nil
+}
+
+func parseConfigBundle(data []byte) (*configBundle, bool) {
+	var b configBundle
+	if json.Unmarshal(data, &b) != nil || b.Version == 0 {
+		return nil, false
+	}
+	return &b, true
+}
+
+func openConfigBundleIfSealed(data []byte) ([]byte, error) {
+	b, ok := parseConfigBundle(data)
+	if !ok {
+		return data, nil
+	}
+	if b.Version != configBundleVersion {
+		return nil, fmt.Errorf("unsupported config bundle version %d", b.Version)
+	}
+	key, err := configBundleKey()
+	if err != nil {
+		return nil, err
+	}
+	return openConfigBundle(b, key)
+}
+
+// configBundleKey reads the bundle key from the environment. Signing
+// keystores are sealed with the same key.
+func configBundleKey() ([]byte, error) {
+	raw := os.Getenv("SENTINEL_CONFIG_KEY")
+	if raw == "" {
+		if path := os.Getenv("SENTINEL_CONFIG_KEY_FILE"); path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read SENTINEL_CONFIG_KEY_FILE: %w", err)
+			}
+			raw = string(b)
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("no encryption key; set SENTINEL_CONFIG_KEY or SENTINEL_CONFIG_KEY_FILE")
+	}
+	if key, err := hex.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("config bundle key must be 32 bytes, hex or base64")
+}
+
+func configBundleAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealConfigBundle(plaintext, key []byte) (*configBundle, error) {
+	if !json.Valid(plaintext) {
+		return nil, fmt.Errorf("config is not valid JSON")
+	}
+	aead, err := configBundleAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &configBundle{
+		Version:    configBundleVersion,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(configBundleAAD))),
+	}, nil
+}
+
+func openConfigBundle(b *configBundle, key []byte) ([]byte, error) {
+	aead, err := configBundleAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(b.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("config bundle has a malformed nonce")
+	}
+	ct, err := base64.StdEncoding.DecodeString(b.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("config bundle has malformed ciphertext")
+	}
+	plaintext, err := aead.Open(nil, nonce, ct, []byte(configBundleAAD))
+	if err != nil {
+		return nil, fmt.Errorf("config bundle does not decrypt with this key")
+	}
+	return plaintext, nil
+}
+
+// runConfigCommand implements `goserver config seal|open`.
+func runConfigCommand(args []string, out io.Writer) error {
+	if len(args) == 0 || (args[0] != "seal" && args[0] != "open") {
+		return fmt.Errorf("usage: config seal|open --in <path> [--out <path>]")
+	}
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	in := fs.String("in", "", "Input config (seal) or bundle (open)")
+	outPath := fs.String("out", "", "Write the result here instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("--in is required")
+	}
+
+	var result []byte
+	if args[0] == "seal" {
+		plaintext, err := os.ReadFile(*in)
+		if err != nil {
+			return err
+		}
+		if _, sealed := parseConfigBundle(plaintext); sealed {
+			return fmt.Errorf("%s is already a bundle", *in)
+		}
+		key, err := configBundleKey()
+		if err != nil {
+			return err
+		}
+		b, err := sealConfigBundle(plaintext, key)
+		if err != nil {
+			return err
+		}
+		result, _ = json.MarshalIndent(b, "", "  ")
+		result = append(result, '\n')
+	} else {
+		data, err := readConfigSource(*in)
+		if err != nil {
+			return err
+		}
+		result = data
+	}
+
+	if *outPath == "" {
+		_, err := out.Write(result)
+		return err
+	}
+	return os.WriteFile(*outPath, result, 0o600)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigBundleSealAndLoad(t *testing.T) {
+	key := strings.Repeat("ab", 32)
+	t.Setenv("SENTINEL_CONFIG_KEY", key)
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "config.json")
+	os.WriteFile(plain, []byte(`{"sui_rpc_url":"http://rpc","sentinel":{"enabled":true,"risk_threshold":55,"sign_private_key":"secret"}}`), 0o600)
+
+	var sealed bytes.Buffer
+	if err := runConfigCommand([]string{"seal", "--in", plain}, &sealed); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed.String(), "secret") {
+		t.Fatal("bundle must not contain plaintext secrets")
+	}
+	bundle := filepath.Join(dir, "config.bundle")
+	os.WriteFile(bundle, sealed.Bytes(), 0o600)
+
+	cfg, err := loadSentinelOneClickConfig(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SuiRPCURL != "http://rpc" || cfg.Sentinel.RiskThreshold != 55 {
+		t.Fatalf("unexpected config from bundle: %+v", cfg)
+	}
+	if keys, err := loadConfigFileKeys(bundle); err != nil || !keys["risk_threshold"] {
+		t.Fatalf("file keys from bundle: %v %v", keys, err)
+	}
+
+	t.Setenv("SENTINEL_CONFIG_KEY", hex.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	if _, err := loadSentinelOneClickConfig(bundle); err == nil {
+		t.Fatal("wrong key must not decrypt the bundle")
+	}
+	t.Setenv("SENTINEL_CONFIG_KEY", "")
+	if _, err := loadSentinelOneClickConfig(bundle); err == nil || !strings.Contains(err.Error(), "SENTINEL_CONFIG_KEY") {
+		t.Fatalf("missing key should be reported, got %v", err)
+	}
+}
+
+func TestConfigBundleFromURL(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	t.Setenv("SENTINEL_CONFIG_KEY", hex.EncodeToString(key))
+	b, err := sealConfigBundle([]byte(`{"sentinel":{"enabled":true,"risk_threshold":40}}`), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain.json" {
+			w.Write([]byte(`{"sentinel":{"enabled":true}}`))
+			return
+		}
+		fetches++
+		encodeSentinelOutput(w, b)
+	}))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		cfg, err := loadSentinelConfigOnly(srv.URL + "/config.bundle")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.RiskThreshold != 40 {
+			t.Fatalf("unexpected config from URL: %+v", cfg)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("config URL should be fetched once, got %d", fetches)
+	}
+	if _, err := loadSentinelConfigOnly(srv.URL + "/plain.json"); err == nil {
+		t.Fatal("plaintext config over a URL must be rejected")
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuntimeConfigPolicy governs policy changes made through the config API.
+// A change must be proposed by one of ApproverKeys and approved by a
+// different one (two-man rule); DelaySec additionally holds every approved
+// change back for a cooling-off period.
+type RuntimeConfigPolicy struct {
+	Enabled      bool     `json:"enabled"`
+	ApproverKeys []string `json:"approver_keys"`  // hex ed25519 public keys
+	DelaySec     int      `json:"delay_seconds"`  // minimum wait before a change applies
+	ExpirySec    int      `json:"expiry_seconds"` // unapplied changes expire; default 24h
+}
+
+// ConfigChangeSet is the subset of the policy that can change at runtime.
+// With runtime_config enabled, edits of the runtime policy lists are made
+// here too, so they need the same two keys.
+type ConfigChangeSet struct {
+	RiskThreshold *int              `json:"risk_threshold,omitempty"`
+	Rules         *SentinelRules    `json:"rules,omitempty"`
+	PolicyList    *PolicyListChange `json:"policy_list,omitempty"`
+}
+
+// ConfigChange is one proposed runtime policy change.
+type ConfigChange struct {
+	ID          string          `json:"id"`
+	Changes     ConfigChangeSet `json:"changes"`
+	Digest      string          `json:"digest"` // sha256 of the changes, signed by the proposer with Nonce
+	ProposedBy  string          `json:"proposed_by"`
+	ProposerKey string          `json:"proposer_key,omitempty"`
+	ApproverKey string          `json:"approver_key,omitempty"`
+	Status      string          `json:"status"`          // pending, applied, rejected, expired, failed
+	Error       string          `json:"error,omitempty"` // why a failed change did not apply
+	CreatedAt   time.Time       `json:"created_at"`
+	ApplyAfter  time.Time       `json:"apply_after"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	DecidedAt   *time.Time      `json:"decided_at,omitempty"`
+	// Nonce is chosen by the proposer; each nonce is accepted once, so a
+	// captured signed proposal cannot be replayed.
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// ConfigChangeManager holds pending changes and applies them to the guard
+// once their approval and delay requirements are met. Every transition is
+// written to the audit log (and anchored when anchoring is enabled).
+type ConfigChangeManager struct {
+	guard     *SentinelGuard
+	policy    RuntimeConfigPolicy
+	approvers map[string]ed25519.PublicKey
+	now       func() time.Time
+
+	mu      sync.Mutex
+	changes map[string]*ConfigChange
+	nonces  map[string]bool // proposal nonces seen, including in the audit log
+}
+
+// NewConfigChangeManager returns nil when the config API is disabled. A
+// policy without two approver keys is rejected: a delay alone would let
+// anyone who reaches the port change the policy once it has passed.
+func NewConfigChangeManager(guard *SentinelGuard, policy *RuntimeConfigPolicy) (*ConfigChangeManager, error) {
+	if guard == nil || policy == nil || !policy.Enabled {
+		return nil, nil
+	}
+	p := *policy
+	if p.ExpirySec <= 0 {
+		p.ExpirySec = 24 * 60 * 60
+	}
+	approvers := map[string]ed25519.PublicKey{}
+	for _, k := range p.ApproverKeys {
+		norm := normalizeKeyHex(k)
+		raw, err := hex.DecodeString(norm)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("approver key %q is not a hex ed25519 public key", k)
+		}
+		approvers[norm] = ed25519.PublicKey(raw)
+	}
+	if len(approvers) < 2 {
+		return nil, fmt.Errorf("runtime_config needs at least two distinct approver_keys")
+	}
+
+	// Nonces of earlier proposals stay spent across restarts.
+	records, err := readAuditRecords(guard.cfg.AuditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	nonces := map[string]bool{}
+	for _, rec := range records {
+		var ch ConfigChange
+		if rec.Action == "CONFIG_CHANGE" && json.Unmarshal([]byte(rec.Prompt), &ch) == nil && ch.Nonce != "" {
+			nonces[ch.Nonce] = true
+		}
+	}
+	return &ConfigChangeManager{
+		guard:     guard,
+		policy:    p,
+		approvers: approvers,
+		now:       func() time.Time { return time.Now().UTC() },
+		changes:   map[string]*ConfigChange{},
+		nonces:    nonces,
+	}, nil
+}
+
+func normalizeKeyHex(k string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(k), "0x"))
+}
+
+// configChangeDigest identifies the changes of a proposal.
+func configChangeDigest(c ConfigChangeSet) string {
+	b, _ := json.Marshal(c)
+	sum := sha256.Sum256(append([]byte("sentinel-config-change-v1\n"), b...))
+	return hex.EncodeToString(sum[:])
+}
+
+// configProposalMessage is what the proposer signs; the nonce makes each
+// signed proposal usable once.
+func configProposalMessage(nonce, digest string) []byte {
+	return []byte("sentinel-config-propose-v2\n" + nonce + "\n" + digest)
+}
+
+// configApprovalMessage is what the approver signs; it binds the proposal ID
+// so an approval cannot be replayed onto another proposal.
+func configApprovalMessage(id, digest string) []byte {
+	return []byte("sentinel-config-approve-v1\n" + id + "\n" + digest)
+}
+
+func (m *ConfigChangeManager) verify(keyHex, sigHex string, msg []byte) (string, error) {
+	key := normalizeKeyHex(keyHex)
+	pub, ok := m.approvers[key]
+	if !ok {
+		return "", fmt.Errorf("key is not a configured approver")
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(sigHex), "0x"))
+	if err != nil || !ed25519.Verify(pub, msg, sig) {
+		return "", fmt.Errorf("invalid signature")
+	}
+	return key, nil
+}
+
+// Propose registers a change. The proposal must be signed by an approver
+// key over a fresh nonce and Digest.
+func (m *ConfigChangeManager) Propose(changes ConfigChangeSet, proposedBy, nonce, keyHex, sigHex string) (*ConfigChange, error) {
+	if changes.RiskThreshold == nil && changes.Rules == nil && changes.PolicyList == nil {
+		return nil, fmt.Errorf("no changes")
+	}
+	if t := changes.RiskThreshold; t != nil && (*t < 1 || *t > 100) {
+		return nil, fmt.Errorf("risk_threshold must be between 1 and 100")
+	}
+	if c := changes.PolicyList; c != nil {
+		if m.guard.lists == nil {
+			return nil, fmt.Errorf("policy_lists is not enabled")
+		}
+		if err := m.guard.lists.Check(*c); err != nil {
+			return nil, fmt.Errorf("policy_list: %w", err)
+		}
+	}
+
+	now := m.now()
+	ch := &ConfigChange{
+		ID:         "cfg-" + strings.TrimPrefix(generateChallengeID(), "challenge-"),
+		Changes:    changes,
+		Digest:     configChangeDigest(changes),
+		Nonce:      strings.TrimSpace(nonce),
+		ProposedBy: proposedBy,
+		Status:     "pending",
+		CreatedAt:  now,
+		ApplyAfter: now.Add(time.Duration(m.policy.DelaySec) * time.Second),
+		ExpiresAt:  now.Add(time.Duration(m.policy.ExpirySec) * time.Second),
+	}
+	if ch.Nonce == "" {
+		return nil, fmt.Errorf("a signed proposal needs a nonce")
+	}
+	key, err := m.verify(keyHex, sigHex, configProposalMessage(ch.Nonce, ch.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("proposal signature: %w", err)
+	}
+	ch.ProposerKey = key
+
+	m.mu.Lock()
+	if m.nonces[ch.Nonce] {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("nonce %q was already used", ch.Nonce)
+	}
+	m.nonces[ch.Nonce] = true
+	m.changes[ch.ID] = ch
+	m.mu.Unlock()
+
+	m.record("proposed", ch)
+	m.ApplyDue()
+	return m.Get(ch.ID), nil
+}
+
+// Approve records the second key's approval (or rejection) of a pending
+// change. The approving key must differ from the proposer's.
+func (m *ConfigChangeManager) Approve(id string, approved bool, keyHex, sigHex string) (*ConfigChange, error) {
+	m.mu.Lock()
+	ch, ok := m.changes[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("config change not found: %s", id)
+	}
+	if ch.Status != "pending" {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("config change %s is already %s", id, ch.Status)
+	}
+	key, err := m.verify(keyHex, sigHex, configApprovalMessage(ch.ID, ch.Digest))
+	if err != nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("approval signature: %w", err)
+	}
+	if key == ch.ProposerKey {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("approval must come from a different key than the proposal")
+	}
+	ch.ApproverKey = key
+	stage := "approved"
+	if !approved {
+		now := m.now()
+		ch.Status = "rejected"
+		ch.DecidedAt = &now
+		stage = "rejected"
+	}
+	snapshot := *ch
+	m.mu.Unlock()
+
+	m.record(stage, &snapshot)
+	m.ApplyDue()
+	return m.Get(id), nil
+}
+
+// ApplyDue applies every pending change whose approval and delay
+// requirements are met, and expires stale ones. A change whose list edit
+// no longer applies, e.g. because the entry was removed meanwhile, fails.
+func (m *ConfigChangeManager) ApplyDue() {
+	now := m.now()
+	var applied, expired, failed []ConfigChange
+
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.changes))
+	for id := range m.changes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // apply in proposal order
+	for _, id := range ids {
+		ch := m.changes[id]
+		if ch.Status != "pending" {
+			continue
+		}
+		approved := ch.ApproverKey != ""
+		switch {
+		case approved && !now.Before(ch.ApplyAfter):
+			ch.DecidedAt = &now
+			if err := m.guard.applyRuntimeChanges(ch.Changes); err != nil {
+				ch.Status, ch.Error = "failed", err.Error()
+				failed = append(failed, *ch)
+				continue
+			}
+			ch.Status = "applied"
+			applied = append(applied, *ch)
+		case now.After(ch.ExpiresAt):
+			ch.Status = "expired"
+			ch.DecidedAt = &now
+			expired = append(expired, *ch)
+		}
+	}
+	m.mu.Unlock()
+
+	for i := range applied {
+		m.record("applied", &applied[i])
+		if _, err := m.guard.RecordConfigSnapshot("config change " + applied[i].ID); err != nil {
+			log.Printf("[CONFIG] snapshot after %s failed: %v", applied[i].ID, err)
+		}
+	}
+	for i := range expired {
+		m.record("expired", &expired[i])
+	}
+	for i := range failed {
+		m.record("failed", &failed[i])
+	}
+}
+
+// Get returns a copy of the change with the given ID, or nil.
+func (m *ConfigChangeManager) Get(id string) *ConfigChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch, ok := m.changes[id]
+	if !ok {
+		return nil
+	}
+	c := *ch
+	return &c
+}
+
+// List returns copies of all changes, oldest first.
+func (m *ConfigChangeManager) List() []ConfigChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]ConfigChange, 0, len(m.changes))
+	for _, ch := range m.changes {
+		out = append(out, *ch)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// PendingCount returns the number of changes awaiting approval or delay.
+func (m *ConfigChangeManager) PendingCount() int {
+	n := 0
+	for _, ch := range m.List() {
+		if ch.Status == "pending" {
+			n++
+		}
+	}
+	return n
+}
+
+// StartWatcher applies delayed changes in the background.
+func (m *ConfigChangeManager) StartWatcher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.ApplyDue()
+		}
+	}()
+}
+
+// record writes one config change transition to the audit log.
+func (m *ConfigChangeManager) record(stage string, ch *ConfigChange) {
+	b, _ := json.Marshal(ch)
+	rec := &AuditRecord{
+		Timestamp: m.now(),
+		Action:    "CONFIG_CHANGE",
+		Prompt:    string(b),
+		Tags:      []string{"config_change"},
+		Decision:  stage,
+		Reason:    fmt.Sprintf("config change %s %s (digest %s)", ch.ID, stage, ch.Digest),
+	}
+	if err := m.guard.persistRecord(rec); err != nil {
+		log.Printf("[CONFIG] audit of %s %s failed: %v", ch.ID, stage, err)
+	}
+}
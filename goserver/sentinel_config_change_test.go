@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testApproverKey(seed byte) (ed25519.PrivateKey, string) {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	priv := ed25519.NewKeyFromSeed(s)
+	return priv, hex.EncodeToString(priv.Public().(ed25519.PublicKey))
+}
+
+// newTestConfigChanges returns a manager whose approvers are
+// testApproverKey(1) and testApproverKey(2).
+func newTestConfigChanges(t *testing.T, guard *SentinelGuard, delaySec int) *ConfigChangeManager {
+	t.Helper()
+	_, pubA := testApproverKey(1)
+	_, pubB := testApproverKey(2)
+	mgr, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA, pubB}, DelaySec: delaySec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mgr
+}
+
+// proposeAndApprove proposes changes with the first test key and approves
+// them with the second.
+func proposeAndApprove(t *testing.T, mgr *ConfigChangeManager, changes ConfigChangeSet, nonce string) *ConfigChange {
+	t.Helper()
+	privA, pubA := testApproverKey(1)
+	privB, pubB := testApproverKey(2)
+	sig := hex.EncodeToString(ed25519.Sign(privA, configProposalMessage(nonce, configChangeDigest(changes))))
+	ch, err := mgr.Propose(changes, "ops", nonce, pubA, sig)
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	sig = hex.EncodeToString(ed25519.Sign(privB, configApprovalMessage(ch.ID, ch.Digest)))
+	if ch, err = mgr.Approve(ch.ID, true, pubB, sig); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	return ch
+}
+
+func TestConfigChangeRequiresSecondKey(t *testing.T) {
+	privA, pubA := testApproverKey(1)
+	privB, pubB := testApproverKey(2)
+	auditPath := t.TempDir() + "/audit.jsonl"
+	guard := newTestGuard(t, SentinelConfig{AuditLogPath: auditPath})
+	mgr, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA, pubB}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	threshold := 95
+	changes := ConfigChangeSet{RiskThreshold: &threshold}
+	if _, err := mgr.Propose(changes, "mallory", "n-1", "", ""); err == nil {
+		t.Fatal("unsigned proposal must be rejected")
+	}
+	proposalSig := hex.EncodeToString(ed25519.Sign(privA, configProposalMessage("n-1", configChangeDigest(changes))))
+	if _, err := mgr.Propose(changes, "alice", "n-2", pubA, proposalSig); err == nil {
+		t.Fatal("a signature over another nonce must be rejected")
+	}
+	ch, err := mgr.Propose(changes, "alice", "n-1", pubA, proposalSig)
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	if ch.Status != "pending" || guard.riskThreshold() != 70 {
+		t.Fatalf("change applied without approval: status=%s threshold=%d", ch.Status, guard.riskThreshold())
+	}
+
+	selfSig := hex.EncodeToString(ed25519.Sign(privA, configApprovalMessage(ch.ID, ch.Digest)))
+	if _, err := mgr.Approve(ch.ID, true, pubA, selfSig); err == nil {
+		t.Fatal("proposer must not approve its own change")
+	}
+	sig := hex.EncodeToString(ed25519.Sign(privB, configApprovalMessage(ch.ID, ch.Digest)))
+	ch, err = mgr.Approve(ch.ID, true, pubB, sig)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if ch.Status != "applied" || guard.riskThreshold() != 95 {
+		t.Fatalf("expected applied change, got status=%s threshold=%d", ch.Status, guard.riskThreshold())
+	}
+
+	// A captured proposal cannot be replayed, not even after a restart.
+	if _, err := mgr.Propose(changes, "alice", "n-1", pubA, proposalSig); err == nil {
+		t.Fatal("a replayed proposal must be rejected")
+	}
+	restarted, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA, pubB}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restarted.Propose(changes, "alice", "n-1", pubA, proposalSig); err == nil {
+		t.Fatal("a proposal replayed after a restart must be rejected")
+	}
+
+	f, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var stages []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec AuditRecord
+		json.Unmarshal(sc.Bytes(), &rec)
+		if rec.Action == "CONFIG_CHANGE" && rec.RecordHash != "" {
+			stages = append(stages, rec.Decision)
+		}
+	}
+	if len(stages) != 3 || stages[0] != "proposed" || stages[1] != "approved" || stages[2] != "applied" {
+		t.Fatalf("unexpected audit trail: %v", stages)
+	}
+}
+
+func TestConfigChangeDelay(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{})
+	mgr := newTestConfigChanges(t, guard, 3600)
+	now := time.Now().UTC()
+	mgr.now = func() time.Time { return now }
+
+	rules := &SentinelRules{Infra: &InfraRules{AllowedNamespaces: []string{"dev"}}}
+	ch := proposeAndApprove(t, mgr, ConfigChangeSet{Rules: rules}, "n-1")
+	if ch.Status != "pending" || guard.currentRules() != nil {
+		t.Fatalf("change applied before delay: %s", ch.Status)
+	}
+	now = now.Add(time.Hour)
+	mgr.ApplyDue()
+	if got := mgr.Get(ch.ID); got.Status != "applied" || guard.currentRules() != rules {
+		t.Fatalf("change not applied after delay: %s", got.Status)
+	}
+}
+
+func TestConfigChangeNeedsTwoApproverKeys(t *testing.T) {
+	_, pubA := testApproverKey(1)
+	guard := newTestGuard(t, SentinelConfig{})
+	if _, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA}}); err == nil {
+		t.Fatal("expected error for a single approver key")
+	}
+	if _, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA, "0x" + strings.ToUpper(pubA)}}); err == nil {
+		t.Fatal("the same key listed twice is still one approver")
+	}
+	if _, err := NewConfigChangeManager(guard, &RuntimeConfigPolicy{Enabled: true, DelaySec: 3600}); err == nil {
+		t.Fatal("a delay alone lets anyone change the policy once it has passed")
+	}
+}
+
+func TestConfigChangeRoutesNeedOperatorToken(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "op-secret")
+	_, pubA := testApproverKey(1)
+	_, pubB := testApproverKey(2)
+	guard := newTestGuard(t, SentinelConfig{
+		RuntimeConfig: &RuntimeConfigPolicy{Enabled: true, ApproverKeys: []string{pubA, pubB}},
+	})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	for _, path := range []string{"/sentinel/config/propose", "/sentinel/config/approve", "/sentinel/config/changes"} {
+		if rec := extensionRequest(mux, http.MethodPost, path, "", "", map[string]string{}); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s without the operator token: %d", path, rec.Code)
+		}
+	}
+	if rec := extensionRequest(mux, http.MethodGet, "/sentinel/config/changes", "op-secret", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("changes with the operator token: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ConfigSnapshot identifies the policy in force at a point in time. It is
+// recorded (and anchored, when enabled) at startup and after every applied
+// runtime change, so an investigation can prove which thresholds and rules
+// governed a decision.
+type ConfigSnapshot struct {
+	ConfigHash      string   `json:"config_hash"` // sha256 of the effective config, secrets redacted
+	RulesHash       string   `json:"rules_hash,omitempty"`
+	RulesFile       string   `json:"rules_file,omitempty"`
+	RulesFileSHA256 string   `json:"rules_file_sha256,omitempty"` // file contents at load time
+	RulePacksHash   string   `json:"rule_packs_hash,omitempty"`   // merged keyword rules
+	PolicyListsHash string   `json:"policy_lists_hash,omitempty"` // runtime allow/deny lists
+	RiskThreshold   int      `json:"risk_threshold"`
+	Trigger         string   `json:"trigger"`
+	Degraded        []string `json:"degraded,omitempty"` // capabilities running on a fallback
+}
+
+// effectiveConfig returns the config in force with runtime changes applied
+// and private keys redacted.
+func (sg *SentinelGuard) effectiveConfig() SentinelConfig {
+	sg.runtimeMu.RLock()
+	cfg := sg.cfg
+	cfg.Rules = sg.rules
+	sg.runtimeMu.RUnlock()
+
+	if cfg.SignPrivKey != "" {
+		cfg.SignPrivKey = "[redacted]"
+	}
+	if cfg.SuiRPC != nil && cfg.SuiRPC.PrivateKey != "" {
+		suiRPC := *cfg.SuiRPC
+		suiRPC.PrivateKey = "[redacted]"
+		cfg.SuiRPC = &suiRPC
+	}
+	return cfg
+}
+
+// ConfigSnapshot hashes the effective configuration.
+func (sg *SentinelGuard) ConfigSnapshot(trigger string) ConfigSnapshot {
+	cfg := sg.effectiveConfig()
+	snap := ConfigSnapshot{
+		ConfigHash:      sha256JSON(cfg),
+		RulesFile:       cfg.RulesFile,
+		RulesFileSHA256: sg.rulesFileSHA256,
+		RiskThreshold:   cfg.RiskThreshold,
+		Trigger:         trigger,
+		Degraded:        sg.capabilities.Degraded(),
+	}
+	if cfg.Rules != nil {
+		snap.RulesHash = sha256JSON(cfg.Rules)
+	}
+	if len(cfg.RulePacks) > 0 {
+		snap.RulePacksHash = sha256JSON(sg.keywordRules())
+	}
+	if sg.lists != nil {
+		snap.PolicyListsHash = sha256JSON(sg.lists.Lists())
+	}
+	return snap
+}
+
+// RecordConfigSnapshot writes the current config hash to the audit log.
+func (sg *SentinelGuard) RecordConfigSnapshot(trigger string) (*AuditRecord, error) {
+	snap := sg.ConfigSnapshot(trigger)
+	b, _ := json.Marshal(snap)
+	reason := fmt.Sprintf("policy in force (%s): config %s", trigger, snap.ConfigHash)
+	if len(snap.Degraded) > 0 {
+		reason += "; degraded: " + strings.Join(snap.Degraded, ", ")
+	}
+	rec := &AuditRecord{
+		Timestamp: time.Now().UTC(),
+		Action:    "CONFIG_SNAPSHOT",
+		Prompt:    string(b),
+		Tags:      []string{"config_snapshot"},
+		Decision:  "recorded",
+		Reason:    reason,
+	}
+	return rec, sg.persistRecord(rec)
+}
+
+func sha256JSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256File(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
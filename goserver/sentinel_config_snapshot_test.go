@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigSnapshotTracksRuntimeChanges(t *testing.T) {
+	auditPath := t.TempDir() + "/audit.jsonl"
+	guard := newTestGuard(t, SentinelConfig{AuditLogPath: auditPath, SignPrivKey: "secret"})
+	mgr := newTestConfigChanges(t, guard, 60)
+
+	startup, err := guard.RecordConfigSnapshot("startup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := guard.ConfigSnapshot("test")
+	if guard.ConfigSnapshot("again").ConfigHash != before.ConfigHash {
+		t.Fatal("config hash must be stable")
+	}
+	if guard.effectiveConfig().SignPrivKey == "secret" {
+		t.Fatal("signing key must be redacted before hashing")
+	}
+
+	threshold := 90
+	proposeAndApprove(t, mgr, ConfigChangeSet{RiskThreshold: &threshold}, "n-1")
+	later := time.Now().UTC().Add(time.Minute)
+	mgr.now = func() time.Time { return later }
+	mgr.ApplyDue()
+	after := guard.ConfigSnapshot("test")
+	if after.ConfigHash == before.ConfigHash || after.RiskThreshold != 90 {
+		t.Fatalf("config hash did not follow the applied change: %+v", after)
+	}
+
+	f, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var hashes []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec AuditRecord
+		json.Unmarshal(sc.Bytes(), &rec)
+		if rec.Action != "CONFIG_SNAPSHOT" {
+			continue
+		}
+		var snap ConfigSnapshot
+		if err := json.Unmarshal([]byte(rec.Prompt), &snap); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, snap.ConfigHash)
+	}
+	if len(hashes) != 2 || hashes[0] != before.ConfigHash || hashes[1] != after.ConfigHash {
+		t.Fatalf("unexpected snapshots: %v (startup reason %q)", hashes, startup.Reason)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ViolationDedupConfig collapses runs of identical blocked requests (an agent
+// stuck in a retry loop) into a single audited record plus periodic summaries.
+type ViolationDedupConfig struct {
+	Enabled      bool `json:"enabled"`
+	WindowSec    int  `json:"window_seconds"` // a streak ends after this much silence
+	SummaryEvery int  `json:"summary_every"`  // emit a summary every N occurrences
+}
+
+// violationDeduper tracks the current streak of identical violations.
+type violationDeduper struct {
+	mu       sync.Mutex
+	window   time.Duration
+	every    int
+	key      string
+	first    *AuditRecord
+	count    int
+	reported int
+	lastSeen time.Time
+	now      func() time.Time
+}
+
+func newViolationDeduper(cfg *ViolationDedupConfig) *violationDeduper {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	d := &violationDeduper{
+		window: time.Duration(cfg.WindowSec) * time.Second,
+		every:  cfg.SummaryEvery,
+		now:    time.Now,
+	}
+	if d.window <= 0 {
+		d.window = 10 * time.Minute
+	}
+	if d.every <= 0 {
+		d.every = 100
+	}
+	return d
+}
+
+func violationKey(rec *AuditRecord) string {
+	tags := append([]string(nil), rec.Tags...)
+	sort.Strings(tags)
+	sum := sha256.Sum256([]byte(rec.Action + "\x00" + rec.Prompt + "\x00" + strings.Join(tags, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// track classifies a freshly evaluated record. flush is a summary of a streak
+// that just ended and must be persisted before anything else. When rec repeats
+// the current streak, dup is the collapsed record to return instead, and
+// summary is non-nil when a periodic summary is due.
+func (d *violationDeduper) track(rec *AuditRecord, blocked bool) (flush, dup, summary *AuditRecord) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	key := ""
+	if blocked {
+		key = violationKey(rec)
+	}
+
+	if d.first != nil && key == d.key && now.Sub(d.lastSeen) <= d.window {
+		d.count++
+		d.lastSeen = now
+		collapsed := *d.first
+		collapsed.Occurrences = d.count
+		if d.count%d.every == 0 {
+			summary = d.summaryLocked(now)
+		}
+		return nil, &collapsed, summary
+	}
+
+	if d.first != nil && d.count > d.reported {
+		flush = d.summaryLocked(now)
+	}
+	d.first, d.key, d.count, d.reported = nil, "", 0, 0
+	if blocked {
+		d.key = key
+		d.count = 1
+		d.reported = 1
+		d.lastSeen = now
+	}
+	return flush, nil, nil
+}
+
+// remember stores the persisted first record of a new streak.
+func (d *violationDeduper) remember(rec *AuditRecord) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.key != "" && d.first == nil {
+		first := *rec
+		d.first = &first
+	}
+}
+
+// summaryLocked must be called with d.mu held.
+func (d *violationDeduper) summaryLocked(now time.Time) *AuditRecord {
+	d.reported = d.count
+	return &AuditRecord{
+		Timestamp:   now.UTC(),
+		Action:      d.first.Action,
+		Prompt:      d.first.Prompt,
+		Score:       d.first.Score,
+		Tags:        d.first.Tags,
+		Decision:    "blocked",
+		Reason:      fmt.Sprintf("%s; collapsed %d identical violations since %s", d.first.Reason, d.count, d.first.Timestamp.Format(time.RFC3339)),
+		Occurrences: d.count,
+		DedupOf:     d.first.RecordHash,
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capabilities the daemon can lose while still running.
+const (
+	capRustHash = "rust_hash"      // falls back to Go sha256
+	capRustSign = "rust_sign"      // falls back to Go ed25519
+	capAnchor   = "anchor"         // records stay in the local audit log only
+	capOpenClaw = "openclaw"       // tokens are redeemed without dispatch
+	capLLM      = "llm_classifier" // heuristic score only
+	capOPA      = "opa"            // heuristic decision, or block when fail-closed
+)
+
+var knownCapabilities = []string{capRustHash, capRustSign, capAnchor, capOpenClaw, capLLM, capOPA}
+
+var capabilityFallbacks = map[string]string{
+	capRustHash: "go sha256 hashing",
+	capRustSign: "go ed25519 signing",
+	capAnchor:   "local audit log only",
+	capOpenClaw: "execute tokens redeemed without dispatch",
+	capLLM:      "heuristic score only",
+	capOPA:      "heuristic decision (or block with opa.fail_closed)",
+}
+
+// CapabilityStatus is one row of the degradation matrix.
+type CapabilityStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Mandatory bool   `json:"mandatory,omitempty"`
+	Fallback  string `json:"fallback,omitempty"` // what runs instead while unavailable
+	Detail    string `json:"detail,omitempty"`
+}
+
+// degradationMatrix tracks which capabilities are running degraded, so a
+// silent fallback shows up in /sentinel/status and the incident report.
+type degradationMatrix struct {
+	mu        sync.Mutex
+	caps      map[string]*CapabilityStatus
+	mandatory map[string]bool
+}
+
+func newDegradationMatrix(mandatory []string) *degradationMatrix {
+	m := &degradationMatrix{caps: map[string]*CapabilityStatus{}, mandatory: map[string]bool{}}
+	for _, name := range mandatory {
+		m.mandatory[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return m
+}
+
+// set records the state of a capability and logs transitions to degraded.
+func (m *degradationMatrix) set(name string, available bool, detail string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, seen := m.caps[name]
+	st := &CapabilityStatus{Name: name, Available: available, Mandatory: m.mandatory[name], Detail: detail}
+	if !available {
+		st.Fallback = capabilityFallbacks[name]
+		if !seen || prev.Available {
+			log.Printf("[DEGRADED] %s unavailable (%s), using %s", name, detail, st.Fallback)
+		}
+	}
+	m.caps[name] = st
+}
+
+// Status returns the matrix sorted by capability name.
+func (m *degradationMatrix) Status() []CapabilityStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]CapabilityStatus, 0, len(m.caps))
+	for _, st := range m.caps {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Degraded returns the names of unavailable capabilities.
+func (m *degradationMatrix) Degraded() []string {
+	var out []string
+	for _, st := range m.Status() {
+		if !st.Available {
+			out = append(out, st.Name)
+		}
+	}
+	return out
+}
+
+// checkMandatory fails when a mandatory capability is unknown or unavailable.
+func (m *degradationMatrix) checkMandatory() error {
+	names := make([]string, 0, len(m.mandatory))
+	for name := range m.mandatory {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := map[string]CapabilityStatus{}
+	for _, st := range m.Status() {
+		status[st.Name] = st
+	}
+	var missing []string
+	for _, name := range names {
+		if !containsTag(knownCapabilities, name) {
+			return fmt.Errorf("unknown mandatory capability %q (known: %s)", name, strings.Join(knownCapabilities, ", "))
+		}
+		st, ok := status[name]
+		switch {
+		case !ok:
+			missing = append(missing, name+" (not configured)")
+		case !st.Available:
+			missing = append(missing, fmt.Sprintf("%s (%s)", name, st.Detail))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("mandatory capabilities unavailable: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// probeCapabilities checks the Rust CLI and anchor prerequisites up front
+// instead of waiting for the first record to fall back.
+func (sg *SentinelGuard) probeCapabilities() {
+	if _, err := sg.rustCLI.command(sg.cfg.HashCLIPath, "hash-audit"); err != nil {
+		sg.capabilities.set(capRustHash, false, err.Error())
+	} else {
+		sg.capabilities.set(capRustHash, true, "")
+	}
+
+	if sg.rustSigningEnabled() {
+		if _, err := sg.rustCLI.command(sg.cfg.SignCLIPath, "sign-audit"); err != nil {
+			sg.capabilities.set(capRustSign, false, err.Error())
+		} else {
+			sg.capabilities.set(capRustSign, true, "")
+		}
+	}
+
+	if sg.opa != nil {
+		if _, err := exec.LookPath(sg.opa.cfg.Binary); err != nil {
+			sg.capabilities.set(capOPA, false, sg.opa.cfg.Binary+" not found")
+		} else {
+			sg.capabilities.set(capOPA, true, "")
+		}
+	}
+
+	if sg.llm != nil {
+		if sg.llm.apiKey == "" {
+			sg.capabilities.set(capLLM, true, sg.llm.cfg.APIKeyEnv+" is not set, calling without a key")
+		} else {
+			sg.capabilities.set(capLLM, true, "")
+		}
+	}
+
+	switch {
+	case !sg.cfg.AnchorEnabled:
+		sg.capabilities.set(capAnchor, false, "anchor_enabled=false")
+	case sg.cfg.AnchorPackage == "" || sg.cfg.AnchorRegistry == "":
+		sg.capabilities.set(capAnchor, false, "anchor_package and anchor_registry are required")
+	case sg.sui != nil:
+		sg.capabilities.set(capAnchor, true, "")
+	default:
+		if _, err := exec.LookPath("sui"); err != nil {
+			sg.capabilities.set(capAnchor, false, "sui CLI not found on PATH")
+		} else if err := validateSuiCLIProfile(sg.cfg.SuiCLI, sg.cfg.Subprocess.policyFor(procSui)); err != nil {
+			sg.capabilities.set(capAnchor, false, err.Error())
+		} else {
+			sg.capabilities.set(capAnchor, true, "")
+		}
+	}
+}
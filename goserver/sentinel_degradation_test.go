@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRustHashFallbackIsTracked(t *testing.T) {
+	dir := t.TempDir()
+	guard := newTestGuard(t, SentinelConfig{
+		AuditLogPath:          filepath.Join(dir, "audit.jsonl"),
+		HashCLIPath:           filepath.Join(dir, "no-such-cli"),
+		MandatoryCapabilities: []string{"rust_hash"},
+	})
+	if _, _, err := guard.Enforce("RUN", "git status"); err != nil {
+		t.Fatal(err)
+	}
+	if got := guard.capabilities.Degraded(); len(got) != 1 || got[0] != capRustHash {
+		t.Fatalf("expected rust_hash degraded, got %v", got)
+	}
+	st := guard.capabilities.Status()[0]
+	if st.Fallback != "go sha256 hashing" || !st.Mandatory {
+		t.Fatalf("unexpected status row: %+v", st)
+	}
+	if err := guard.capabilities.checkMandatory(); err == nil || !strings.Contains(err.Error(), "rust_hash") {
+		t.Fatalf("expected mandatory rust_hash failure, got %v", err)
+	}
+}
+
+func TestMandatoryCapabilityValidation(t *testing.T) {
+	m := newDegradationMatrix([]string{"openclaw"})
+	if err := m.checkMandatory(); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("expected unprobed capability to fail, got %v", err)
+	}
+	m.set(capOpenClaw, true, "")
+	if err := m.checkMandatory(); err != nil {
+		t.Fatalf("expected available capability to pass, got %v", err)
+	}
+	if err := newDegradationMatrix([]string{"telepathy"}).checkMandatory(); err == nil {
+		t.Fatal("expected unknown capability to be rejected")
+	}
+}
+
+func TestDegradedCapabilitiesInStatusAndIncidentReport(t *testing.T) {
+	gw := newTestGateway()
+	gw.guard.cfg.AuditLogPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	gw.guard.capabilities.set(capOpenClaw, false, "openclaw.enabled=false")
+	if _, err := gw.guard.RecordConfigSnapshot("startup"); err != nil {
+		t.Fatal(err)
+	}
+
+	var status map[string]interface{}
+	json.Unmarshal(getJSON(t, gw.handleStatus).Body.Bytes(), &status)
+	degraded, _ := status["degraded"].([]interface{})
+	found := false
+	for _, d := range degraded {
+		found = found || d == capOpenClaw
+	}
+	if !found {
+		t.Fatalf("expected openclaw in status degraded list, got %v", status["degraded"])
+	}
+
+	report, err := BuildIncidentReport(IncidentSources{AuditPath: gw.guard.cfg.AuditLogPath}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsTag(report.Degraded, capOpenClaw) {
+		t.Fatalf("expected openclaw in incident report degraded list, got %v", report.Degraded)
+	}
+}
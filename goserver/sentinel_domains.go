@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DomainAllowlistConfig restricts which domains a task may reference. Every
+// URL, e-mail address and bare domain in the prompt must match Domains or the
+// exceptions for the action; anything else is blocked.
+type DomainAllowlistConfig struct {
+	Domains []string `json:"domains"` // "example.com" also allows its subdomains
+	// ActionExceptions adds domains per action; "*" disables the check for
+	// that action.
+	ActionExceptions map[string][]string `json:"action_exceptions,omitempty"`
+}
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s"'<>()\[\]{}]+`)
+	// Bare domains need a known TLD so file names like main.go or
+	// config.json are not mistaken for hosts.
+	bareDomainPattern = regexp.MustCompile(`(?i)\b((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+` +
+		`(?:com|net|org|io|ai|co|app|dev|xyz|info|biz|gov|edu|me|us|uk|de|fr|jp|cn|ru|in|br|ca|au|eu|ch|nl|` +
+		`se|es|it|pl|kr|finance|bank|money|exchange|crypto|online|site|top|link|cc|tv|ly|gg|so|sh))\b`)
+)
+
+// extractDomains returns the lowercased hosts referenced by text.
+func extractDomains(text string) []string {
+	seen := map[string]bool{}
+	add := func(host string) {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if host != "" {
+			seen[host] = true
+		}
+	}
+	for _, raw := range urlPattern.FindAllString(text, -1) {
+		u, err := url.Parse(strings.TrimRight(raw, ".,;:!?"))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		add(u.Hostname())
+		text = strings.Replace(text, raw, " ", 1)
+	}
+	for _, m := range bareDomainPattern.FindAllString(text, -1) {
+		add(m)
+	}
+
+	out := make([]string, 0, len(seen))
+	for host := range seen {
+		out = append(out, host)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// disallowedDomains returns the domains in text that action may not reference.
+func (c *DomainAllowlistConfig) disallowedDomains(action, text string) []string {
+	if c == nil || len(c.Domains) == 0 {
+		return nil
+	}
+	allowed := c.Domains
+	for a, extra := range c.ActionExceptions {
+		if !strings.EqualFold(a, strings.TrimSpace(action)) {
+			continue
+		}
+		if containsTag(extra, "*") {
+			return nil
+		}
+		allowed = append(append([]string(nil), allowed...), extra...)
+	}
+
+	var bad []string
+	for _, host := range extractDomains(text) {
+		if !domainAllowed(host, allowed) {
+			bad = append(bad, host)
+		}
+	}
+	return bad
+}
+
+func domainAllowed(host string, allowed []string) bool {
+	if net.ParseIP(host) != nil {
+		return containsFold(allowed, host)
+	}
+	host = strings.TrimPrefix(host, "www.")
+	for _, a := range allowed {
+		a = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(a), "*."), "www.")
+		if host == a || strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
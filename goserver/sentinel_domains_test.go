@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtractDomains(t *testing.T) {
+	got := extractDomains("Open https://www.MyBank.com/login?next=/x, then post to x.com and mail ops@evil.io. Edit main.go and config.json.")
+	want := []string{"evil.io", "www.mybank.com", "x.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractDomains = %v, want %v", got, want)
+	}
+}
+
+func TestDomainAllowlistExceptions(t *testing.T) {
+	cfg := &DomainAllowlistConfig{
+		Domains: []string{"x.com", "mybank.com"},
+		ActionExceptions: map[string][]string{
+			"research": {"wikipedia.org"},
+			"DEBUG":    {"*"},
+		},
+	}
+	cases := []struct {
+		action, prompt string
+		bad            int
+	}{
+		{"BROWSE", "check my balance on https://secure.mybank.com", 0},
+		{"BROWSE", "log in at https://mybank.com.attacker.net", 1},
+		{"BROWSE", "read en.wikipedia.org/wiki/Sui", 1},
+		{"research", "read en.wikipedia.org/wiki/Sui", 0},
+		{"debug", "fetch http://10.0.0.5:8080/metrics", 0},
+		{"BROWSE", "fetch http://10.0.0.5:8080/metrics", 1},
+	}
+	for _, tc := range cases {
+		if got := cfg.disallowedDomains(tc.action, tc.prompt); len(got) != tc.bad {
+			t.Errorf("disallowedDomains(%q, %q) = %v, want %d", tc.action, tc.prompt, got, tc.bad)
+		}
+	}
+}
+
+func TestGateBlocksDisallowedDomain(t *testing.T) {
+	gw := newTestGateway()
+	gw.guard.cfg.DomainAllowlist = &DomainAllowlistConfig{Domains: []string{"x.com"}}
+
+	rr := postJSON(t, gw.handleGate, GateRequest{Action: "BROWSE", Prompt: "summarize https://news.example.org"})
+	var resp GateResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Decision != "BLOCK" || !containsTag(resp.Tags, "domain_not_allowed") {
+		t.Fatalf("expected BLOCK with domain_not_allowed, got %s %v", resp.Decision, resp.Tags)
+	}
+
+	rr = postJSON(t, gw.handleGate, GateRequest{Action: "BROWSE", Prompt: "open x.com"})
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if containsTag(resp.Tags, "domain_not_allowed") {
+		t.Fatalf("allowlisted domain flagged: %v", resp.Tags)
+	}
+}
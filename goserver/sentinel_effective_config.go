@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigSetting is one resolved sentinel setting and where its value came from.
+type ConfigSetting struct {
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value"`
+	Source  string      `json:"source"` // file | rules_file | runtime | default
+	Default interface{} `json:"default,omitempty"`
+}
+
+// EffectiveConfigReport is the fully resolved sentinel configuration, printed
+// at startup and served by GET /sentinel/config/effective.
+type EffectiveConfigReport struct {
+	ConfigHash string          `json:"config_hash"`
+	Settings   []ConfigSetting `json:"settings"`
+	Diff       []ConfigSetting `json:"diff"` // settings that differ from the defaults
+}
+
+// loadConfigFileKeys returns the dotted keys set under "sentinel" in the
+// config file, so resolved values can be attributed to the file.
+func loadConfigFileKeys(path string) (map[string]bool, error) {
+	data, err := readConfigSource(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Sentinel map[string]interface{} `json:"sentinel"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	flat := map[string]interface{}{}
+	flattenSettings("", raw.Sentinel, flat)
+	keys := make(map[string]bool, len(flat))
+	for k := range flat {
+		keys[k] = true
+	}
+	return keys, nil
+}
+
+// SetConfigFileKeys records which settings the config file provided.
+func (sg *SentinelGuard) SetConfigFileKeys(keys map[string]bool) {
+	sg.runtimeMu.Lock()
+	sg.fileKeys = keys
+	sg.runtimeMu.Unlock()
+}
+
+// flattenSettings flattens decoded JSON objects into dotted keys. Arrays and
+// scalars are leaves; nulls are dropped.
+func flattenSettings(prefix string, v interface{}, out map[string]interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenSettings(key, child, out)
+		}
+	case nil:
+	default:
+		out[prefix] = t
+	}
+}
+
+func settingsOf(cfg SentinelConfig) map[string]interface{} {
+	b, _ := json.Marshal(cfg)
+	var decoded map[string]interface{}
+	json.Unmarshal(b, &decoded)
+	flat := map[string]interface{}{}
+	flattenSettings("", decoded, flat)
+	return flat
+}
+
+// EffectiveConfigReport resolves every setting, its source and its default.
+func (sg *SentinelGuard) EffectiveConfigReport() EffectiveConfigReport {
+	cfg := sg.effectiveConfig()
+	current := settingsOf(cfg)
+	defaults := settingsOf(NewSentinelGuard(defaultSentinelConfig()).effectiveConfig())
+
+	sg.runtimeMu.RLock()
+	fileKeys, runtimeKeys := sg.fileKeys, sg.runtimeKeys
+	sg.runtimeMu.RUnlock()
+
+	keys := make([]string, 0, len(current))
+	for k := range current {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	report := EffectiveConfigReport{ConfigHash: sha256JSON(cfg), Settings: []ConfigSetting{}, Diff: []ConfigSetting{}}
+	for _, k := range keys {
+		s := ConfigSetting{Key: k, Value: current[k], Default: defaults[k], Source: "default"}
+		top := strings.SplitN(k, ".", 2)[0]
+		switch {
+		case runtimeKeys[top]:
+			s.Source = "runtime"
+		case fileKeys[k]:
+			s.Source = "file"
+		case top == "rules" && cfg.RulesFile != "":
+			s.Source = "rules_file"
+		}
+		report.Settings = append(report.Settings, s)
+		if !reflect.DeepEqual(s.Value, s.Default) {
+			report.Diff = append(report.Diff, s)
+		}
+	}
+	return report
+}
+
+// BannerLines renders the settings that differ from the defaults.
+func (r EffectiveConfigReport) BannerLines() []string {
+	lines := []string{fmt.Sprintf("Effective config %s: %d settings, %d differ from defaults", r.ConfigHash, len(r.Settings), len(r.Diff))}
+	for _, s := range r.Diff {
+		v, _ := json.Marshal(s.Value)
+		line := fmt.Sprintf("  %s = %s (%s", s.Key, v, s.Source)
+		if s.Default != nil {
+			d, _ := json.Marshal(s.Default)
+			line += fmt.Sprintf(", default %s", d)
+		}
+		lines = append(lines, line+")")
+	}
+	return lines
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEffectiveConfigSourcesAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfgJSON := `{"sentinel": {"enabled": true, "risk_threshold": 70, "audit_log_path": "` + filepath.Join(dir, "audit.jsonl") + `", "anchor_fail_closed": true}}`
+	if err := os.WriteFile(path, []byte(cfgJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadSentinelConfigOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := NewSentinelGuard(cfg)
+	keys, err := loadConfigFileKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard.SetConfigFileKeys(keys)
+
+	settings := func(r EffectiveConfigReport) map[string]ConfigSetting {
+		m := map[string]ConfigSetting{}
+		for _, s := range r.Settings {
+			m[s.Key] = s
+		}
+		return m
+	}
+	report := guard.EffectiveConfigReport()
+	got := settings(report)
+	if got["risk_threshold"].Source != "file" || got["anchor_module"].Source != "default" {
+		t.Fatalf("unexpected sources: %+v %+v", got["risk_threshold"], got["anchor_module"])
+	}
+	diff := map[string]bool{}
+	for _, s := range report.Diff {
+		diff[s.Key] = true
+	}
+	if !diff["anchor_fail_closed"] || diff["risk_threshold"] {
+		t.Fatalf("unexpected diff: %+v", report.Diff)
+	}
+
+	threshold := 85
+	guard.applyRuntimeChanges(ConfigChangeSet{RiskThreshold: &threshold})
+	if s := settings(guard.EffectiveConfigReport())["risk_threshold"]; s.Source != "runtime" || s.Value != float64(85) {
+		t.Fatalf("expected runtime risk_threshold=85, got %+v", s)
+	}
+}
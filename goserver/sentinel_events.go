@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types that only appear on the event stream; the others reuse the
+// notification event names.
+const (
+	eventAnchorConfirmed = "anchor_confirmed"
+	eventGateDecision    = "gate_decision"
+)
+
+// SentinelEvent is one message on GET /ws. Like notifications, events never
+// carry prompts; RecordHash points at the audit entry.
+type SentinelEvent struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// eventHub fans events out to stream subscribers. Publishing never blocks:
+// a subscriber that falls behind loses events rather than slow the gate.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan SentinelEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: map[chan SentinelEvent]struct{}{}}
+}
+
+// Publish sends an event to every subscriber. A nil hub is a no-op.
+func (h *eventHub) Publish(typ string, data map[string]interface{}) {
+	if h == nil {
+		return
+	}
+	ev := SentinelEvent{Type: typ, Time: time.Now().UTC(), Data: data}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (h *eventHub) subscribe() chan SentinelEvent {
+	ch := make(chan SentinelEvent, 64)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan SentinelEvent) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+}
+
+// Subscribers is the number of connected streams.
+func (h *eventHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// websocketGUID is the fixed handshake suffix from RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// eventsAuthTimeout bounds how long a stream opened without an
+// Authorization header waits for its token frame.
+const eventsAuthTimeout = 5 * time.Second
+
+// handleEvents upgrades GET /ws to a WebSocket and streams events as JSON
+// text frames. ?types=a,b limits the stream to those event types. The
+// stream needs the operator token, like the other operator reads. Browsers
+// cannot set headers on a WebSocket, so a stream opened without an
+// Authorization header must send {"token": "..."} as its first frame.
+// Browser pages may only connect from sentinel.events_allowed_origins.
+func (gw *SentinelGateway) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-WebSocket-Key") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "websocket upgrade required"})
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !gw.eventsOriginAllowed(origin) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+		return
+	}
+	authed := hasBearerToken(r, gw.token)
+	if gw.token == "" || (!authed && r.Header.Get("Authorization") != "") {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing operator token"})
+		return
+	}
+	var types map[string]bool
+	if q := r.URL.Query().Get("types"); q != "" {
+		types = map[string]bool{}
+		for _, t := range strings.Split(q, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if rw.Flush() != nil {
+		return
+	}
+
+	ws := &wsConn{conn: conn}
+	if !authed && !gw.readTokenFrame(conn, rw.Reader) {
+		ws.write(0x8, []byte{0x03, 0xf0}) // 1008 policy violation
+		return
+	}
+	events := gw.events.subscribe()
+	defer gw.events.unsubscribe(events)
+	closed := make(chan struct{})
+	go func() {
+		ws.readLoop(rw.Reader)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case ev := <-events:
+			if types != nil && !types[ev.Type] {
+				continue
+			}
+			b, _ := json.Marshal(ev)
+			if err := ws.write(0x1, b); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// eventsOriginAllowed reports whether origin is listed in
+// sentinel.events_allowed_origins.
+func (gw *SentinelGateway) eventsOriginAllowed(origin string) bool {
+	for _, o := range gw.guard.cfg.EventsAllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(strings.TrimSpace(o), "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// readTokenFrame reads the first client frame and reports whether it is a
+// text frame carrying the operator token.
+func (gw *SentinelGateway) readTokenFrame(conn net.Conn, r *bufio.Reader) bool {
+	conn.SetReadDeadline(time.Now().Add(eventsAuthTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	opcode, payload, err := readClientFrame(r)
+	if err != nil || opcode != 0x1 {
+		return false
+	}
+	var msg struct {
+		Token string `json:"token"`
+	}
+	if json.Unmarshal(payload, &msg) != nil || msg.Token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(msg.Token), []byte(gw.token)) == 1
+}
+
+// wsConn writes server frames; writes come from the stream loop and from
+// control replies in the read loop.
+type wsConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (c *wsConn) write(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readLoop answers pings and returns when the client closes the stream or
+// the connection fails. Client messages are otherwise ignored.
+func (c *wsConn) readLoop(r *bufio.Reader) {
+	for {
+		opcode, payload, err := readClientFrame(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[EVENTS] stream closed: %v", err)
+			}
+			return
+		}
+		switch opcode {
+		case 0x8: // close
+			c.write(0x8, payload)
+			return
+		case 0x9: // ping
+			c.write(0xA, payload)
+		}
+	}
+}
+
+// readClientFrame reads one frame. Clients must mask their frames, and
+// control payloads are small; larger data frames are refused.
+func readClientFrame(r *bufio.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	if head[1]&0x80 == 0 {
+		return 0, nil, errors.New("unmasked client frame")
+	}
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > 64<<10 {
+		return 0, nil, errors.New("client frame too large")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0f, payload, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialEvents opens /ws on srv and returns the connection after the
+// handshake, or the HTTP status line when the upgrade is refused. A token
+// is sent as a bearer header.
+func dialEvents(t *testing.T, srv *httptest.Server, query, origin, token string) (net.Conn, *bufio.Reader, string) {
+	t.Helper()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	req := "GET /ws" + query + " HTTP/1.1\r\nHost: " + host + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if origin != "" {
+		req += "Origin: " + origin + "\r\n"
+	}
+	if token != "" {
+		req += "Authorization: Bearer " + token + "\r\n"
+	}
+	conn.Write([]byte(req + "\r\n"))
+	r := bufio.NewReader(conn)
+	status, _ := r.ReadString('\n')
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil || line == "\r\n" {
+			break
+		}
+		if strings.HasPrefix(line, "Sec-WebSocket-Accept:") && !strings.Contains(line, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=") {
+			t.Fatalf("bad accept header: %s", line)
+		}
+	}
+	return conn, r, status
+}
+
+// readEvent reads one unmasked server text frame.
+func readEvent(t *testing.T, conn net.Conn, r *bufio.Reader) SentinelEvent {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	head := make([]byte, 2)
+	if _, err := r.Read(head); err != nil {
+		t.Fatalf("no event: %v", err)
+	}
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		ext := make([]byte, 2)
+		r.Read(ext)
+		n = int(ext[0])<<8 | int(ext[1])
+	}
+	payload := make([]byte, n)
+	for read := 0; read < n; {
+		m, err := r.Read(payload[read:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		read += m
+	}
+	var ev SentinelEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("frame %x: %v", head, err)
+	}
+	return ev
+}
+
+// writeClientText writes one masked client text frame.
+func writeClientText(conn net.Conn, payload string) {
+	frame := []byte{0x81, 0x80 | byte(len(payload)), 1, 2, 3, 4}
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^byte(1+i%4))
+	}
+	conn.Write(frame)
+}
+
+func TestEventStream(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "op-secret")
+	gw := newTestGateway()
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if _, _, status := dialEvents(t, srv, "", "https://evil.example", "op-secret"); !strings.Contains(status, "403") {
+		t.Fatalf("foreign origins must be refused: %s", status)
+	}
+
+	conn, r, status := dialEvents(t, srv, "?types=gate_decision,kill_switch_armed", "", "op-secret")
+	if !strings.Contains(status, "101") {
+		t.Fatalf("upgrade: %s", status)
+	}
+	for deadline := time.Now().Add(2 * time.Second); gw.events.Subscribers() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("stream did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	postJSON(t, gw.handleGate, GateRequest{Action: "CODE_EDITING", Prompt: "git status"})
+	ev := readEvent(t, conn, r)
+	if ev.Type != eventGateDecision || ev.Data["decision"] != "ALLOW" || ev.Data["action"] != "CODE_EDITING" || ev.Data["prompt"] != nil {
+		t.Fatalf("unexpected gate event: %+v", ev)
+	}
+
+	postJSON(t, gw.handleKillSwitchDisarm, nil) // filtered out by ?types
+	postJSON(t, gw.handleKillSwitchArm, map[string]string{"reason": "drill"})
+	if ev := readEvent(t, conn, r); ev.Type != notifyKillSwitchArmed || ev.Data["reason"] != "drill" {
+		t.Fatalf("unexpected kill switch event: %+v", ev)
+	}
+
+	conn.Write([]byte{0x88, 0x80, 1, 2, 3, 4}) // masked close, empty payload
+	for deadline := time.Now().Add(2 * time.Second); gw.events.Subscribers() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("closing the stream should unsubscribe it")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventStreamNeedsOperatorToken(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "op-secret")
+	gw := newTestGateway()
+	gw.guard.cfg.EventsAllowedOrigins = []string{"https://dash.example/"}
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if _, _, status := dialEvents(t, srv, "", "", "wrong"); !strings.Contains(status, "401") {
+		t.Fatalf("a wrong bearer token must be refused: %s", status)
+	}
+	// The proxy's own host is no longer an allowed origin by itself.
+	if _, _, status := dialEvents(t, srv, "", "http://"+strings.TrimPrefix(srv.URL, "http://"), "op-secret"); !strings.Contains(status, "403") {
+		t.Fatalf("unlisted origins must be refused: %s", status)
+	}
+
+	// Without a header, a first frame that is not the token closes the
+	// stream before it subscribes.
+	conn, r, status := dialEvents(t, srv, "", "https://dash.example", "")
+	if !strings.Contains(status, "101") {
+		t.Fatalf("upgrade: %s", status)
+	}
+	writeClientText(conn, `{"token":"wrong"}`)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil || head[0] != 0x88 || head[2] != 0x03 || head[3] != 0xf0 {
+		t.Fatalf("expected a 1008 close frame, got %x %v", head, err)
+	}
+	if gw.events.Subscribers() != 0 {
+		t.Fatal("an unauthenticated stream must not subscribe")
+	}
+
+	// A browser that sends the token as its first frame gets events.
+	conn, r, _ = dialEvents(t, srv, "?types=gate_decision", "https://dash.example", "")
+	writeClientText(conn, `{"token":"op-secret"}`)
+	for deadline := time.Now().Add(2 * time.Second); gw.events.Subscribers() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("stream did not subscribe after the token frame")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	postJSON(t, gw.handleGate, GateRequest{Action: "CODE_EDITING", Prompt: "git status"})
+	if ev := readEvent(t, conn, r); ev.Type != eventGateDecision {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files contained in an evidence bundle.
+const (
+	evidenceManifestFile  = "manifest.json"
+	evidenceSignatureFile = "manifest.sig"
+	evidenceTimelineFile  = "timeline.json"
+	evidenceAuditFile     = "audit.jsonl"
+	evidenceProofsFile    = "proofs.json"
+	evidenceReadmeFile    = "VERIFY.md"
+)
+
+// EvidenceManifest describes a notarized evidence bundle. The manifest is
+// signed with the operator's Ed25519 audit key; every other file in the
+// bundle is pinned by its SHA-256 digest.
+type EvidenceManifest struct {
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	Since       time.Time `json:"since"`
+	RecordCount int       `json:"record_count"`
+	// FirstPrevHash is the prev_hash of the first exported record: the
+	// audit record just before the window, or "" at the start of the log.
+	FirstPrevHash string            `json:"first_prev_hash"`
+	ChainHead     string            `json:"chain_head"`
+	MerkleRoot    string            `json:"merkle_root"`
+	TxDigests     []string          `json:"tx_digests"`
+	Files         map[string]string `json:"files"` // file name -> sha256 hex
+	PublicKey     string            `json:"public_key"`
+}
+
+// EvidenceProof links one exported audit record to the bundle's hash chain
+// and Merkle root.
+type EvidenceProof struct {
+	Index      int      `json:"index"`
+	RecordHash string   `json:"record_hash"`
+	PrevHash   string   `json:"prev_hash"`
+	ChainHash  string   `json:"chain_hash"`
+	MerklePath []string `json:"merkle_path"`
+	TxDigest   string   `json:"tx_digest,omitempty"`
+}
+
+// BundleVerification is the result of verifying an evidence bundle.
+type BundleVerification struct {
+	Valid          bool     `json:"valid"`
+	SignatureValid bool     `json:"signature_valid"`
+	KeyPinned      bool     `json:"key_pinned"` // manifest key is the one the verifier expected
+	FilesValid     bool     `json:"files_valid"`
+	RecordsValid   bool     `json:"records_valid"`
+	ChainValid     bool     `json:"chain_valid"`
+	ProofsValid    bool     `json:"proofs_valid"`
+	RecordCount    int      `json:"record_count"`
+	MerkleRoot     string   `json:"merkle_root"`
+	PublicKey      string   `json:"public_key"`
+	TxDigests      []string `json:"tx_digests"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+const evidenceVerifyReadme = `# Sentinel Evidence Bundle
+
+This archive contains a segment of the Sentinel audit log together with the
+material needed to verify it independently.
+
+| File | Content |
+|---|---|
+| audit.jsonl | Exported audit records, one JSON object per line |
+| timeline.json | Chronological incident timeline built from the records |
+| proofs.json | Hash-chain link and Merkle inclusion path for every record |
+| manifest.json | SHA-256 of every file, chain head, Merkle root, Sui digests |
+| manifest.sig | Hex Ed25519 signature over manifest.json |
+
+## Verify
+
+    go run . --verify-bundle <bundle.tar.gz> --verify-bundle-key <operator public key>
+
+Manual verification steps:
+
+1. Confirm that public_key in the manifest is the operator's key, obtained
+   from the operator and not from this bundle, then check manifest.sig
+   against manifest.json with it.
+2. Check the SHA-256 of every file against manifest.files.
+3. Recompute every record_hash in audit.jsonl from the record's contents.
+   Check that each record's prev_hash is the previous record's record_hash,
+   and that the first one's is manifest.first_prev_hash.
+4. For each record i: chain_hash = sha256("i|record_hash|prev_hash"), with
+   prev_hash = "0x0" for the first record.
+5. Hash each chain_hash as a Merkle leaf, fold merkle_path (leaf to root,
+   left/right by index parity) and compare with manifest.merkle_root.
+6. Look up each tx_digest on a Sui explorer or with
+   ` + "`sui client tx-block <digest> --json`" + ` and confirm the emitted
+   AuditAnchoredEvent carries the same record_hash.
+`
+
+// buildEvidenceBundle writes a signed tar.gz bundle for all audit records
+// newer than since.
+func buildEvidenceBundle(auditPath string, since time.Time, privKeyHex string, out io.Writer) (*EvidenceManifest, error) {
+	priv, err := ed25519KeyFromSeedHex(privKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := readAuditRecords(auditPath)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]AuditRecord, 0, len(records))
+	for _, rec := range records {
+		if !rec.Timestamp.Before(since) {
+			selected = append(selected, rec)
+		}
+	}
+
+	report, err := BuildIncidentReport(IncidentSources{AuditPath: auditPath, AlertJournal: alertJournalPath(auditPath)}, since)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, proofs, err := buildEvidenceProofs(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	var auditBuf bytes.Buffer
+	for _, rec := range selected {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+		auditBuf.Write(b)
+		auditBuf.WriteByte('\n')
+	}
+	timelineBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := json.MarshalIndent(proofs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		evidenceAuditFile:    auditBuf.Bytes(),
+		evidenceTimelineFile: timelineBytes,
+		evidenceProofsFile:   proofBytes,
+		evidenceReadmeFile:   []byte(evidenceVerifyReadme),
+	}
+
+	manifest := &EvidenceManifest{
+		Version:     2,
+		CreatedAt:   time.Now().UTC(),
+		Since:       since.UTC(),
+		RecordCount: len(selected),
+		ChainHead:   "0x0",
+		MerkleRoot:  computeMerkleRoot(entries),
+		TxDigests:   []string{},
+		Files:       map[string]string{},
+		PublicKey:   hex.EncodeToString(priv.Public().(ed25519.PublicKey)),
+	}
+	if len(entries) > 0 {
+		manifest.ChainHead = entries[len(entries)-1].ChainHash
+		manifest.FirstPrevHash = selected[0].PrevHash
+	}
+	for _, p := range proofs {
+		if p.TxDigest != "" {
+			manifest.TxDigests = append(manifest.TxDigests, p.TxDigest)
+		}
+	}
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[evidenceManifestFile] = manifestBytes
+	files[evidenceSignatureFile] = []byte(hex.EncodeToString(ed25519.Sign(priv, manifestBytes)))
+
+	if err := writeTarGz(out, files, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// buildEvidenceProofs chains the records in order and computes the Merkle
+// inclusion path of every link.
+func buildEvidenceProofs(records []AuditRecord) ([]ProofEntry, []EvidenceProof, error) {
+	entries := make([]ProofEntry, 0, len(records))
+	prevHash := "0x0"
+	for i, rec := range records {
+		entry := ProofEntry{
+			Index:      i,
+			RecordHash: rec.RecordHash,
+			PrevHash:   prevHash,
+			ChainHash:  computeChainHash(i, rec.RecordHash, prevHash),
+			Timestamp:  rec.Timestamp,
+			Action:     rec.Action,
+			Decision:   rec.Decision,
+		}
+		entries = append(entries, entry)
+		prevHash = entry.ChainHash
+	}
+
+	proofs := make([]EvidenceProof, 0, len(entries))
+	for i, entry := range entries {
+		path, err := computeMerkleProof(entries, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		proofs = append(proofs, EvidenceProof{
+			Index:      entry.Index,
+			RecordHash: entry.RecordHash,
+			PrevHash:   entry.PrevHash,
+			ChainHash:  entry.ChainHash,
+			MerklePath: path,
+			TxDigest:   records[i].TxDigest,
+		})
+	}
+	return entries, proofs, nil
+}
+
+// VerifyEvidenceBundle checks the signature, file digests, record hashes,
+// hash chain and Merkle proofs of a bundle produced by buildEvidenceBundle.
+// pinnedKey is the operator's hex Ed25519 public key; a bundle signed by
+// any other key fails, since anyone can re-sign an edited bundle with a
+// key of their own.
+func VerifyEvidenceBundle(r io.Reader, pinnedKey string) (*BundleVerification, error) {
+	if pinnedKey = normalizeKeyHex(pinnedKey); pinnedKey == "" {
+		return nil, fmt.Errorf("the operator's public key is required to verify a bundle")
+	}
+	files, err := readTarGz(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BundleVerification{}
+	fail := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	manifestBytes, ok := files[evidenceManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("bundle is missing %s", evidenceManifestFile)
+	}
+	var manifest EvidenceManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	result.RecordCount = manifest.RecordCount
+	result.MerkleRoot = manifest.MerkleRoot
+	result.PublicKey = manifest.PublicKey
+	result.TxDigests = manifest.TxDigests
+
+	// 1) Signature over the manifest, by the pinned key.
+	result.KeyPinned = normalizeKeyHex(manifest.PublicKey) == pinnedKey
+	if !result.KeyPinned {
+		fail("manifest public_key %s is not the expected key %s", manifest.PublicKey, pinnedKey)
+	}
+	pub, err := hex.DecodeString(manifest.PublicKey)
+	sig, sigErr := hex.DecodeString(strings.TrimSpace(string(files[evidenceSignatureFile])))
+	switch {
+	case err != nil || len(pub) != ed25519.PublicKeySize:
+		fail("manifest public_key is not a valid Ed25519 key")
+	case sigErr != nil:
+		fail("manifest.sig is not valid hex")
+	case !ed25519.Verify(ed25519.PublicKey(pub), manifestBytes, sig):
+		fail("manifest signature does not verify")
+	default:
+		result.SignatureValid = true
+	}
+
+	// 2) File digests.
+	result.FilesValid = true
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, ok := files[name]
+		if !ok {
+			result.FilesValid = false
+			fail("file %s listed in manifest is missing", name)
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != manifest.Files[name] {
+			result.FilesValid = false
+			fail("file %s digest mismatch", name)
+		}
+	}
+
+	// 3) Hash chain and Merkle proofs, recomputed from the exported records.
+	records, err := parseAuditJSONL(files[evidenceAuditFile])
+	if err != nil {
+		return nil, err
+	}
+	var proofs []EvidenceProof
+	if err := json.Unmarshal(files[evidenceProofsFile], &proofs); err != nil {
+		return nil, fmt.Errorf("parse proofs: %w", err)
+	}
+
+	result.RecordsValid = true
+	for i := range records {
+		if auditHashCheckable(&records[i]) && !auditHashMatches(&records[i]) {
+			result.RecordsValid = false
+			fail("record %d: record_hash does not match the record's contents", i)
+		}
+	}
+
+	entries, expected, err := buildEvidenceProofs(records)
+	if err != nil {
+		return nil, err
+	}
+	result.ChainValid = len(records) == manifest.RecordCount && len(proofs) == len(expected)
+	if !result.ChainValid {
+		fail("record count mismatch: manifest=%d records=%d proofs=%d", manifest.RecordCount, len(records), len(proofs))
+	}
+	// The proofs are rebuilt from the records, so they cannot show a record
+	// that was dropped or moved and the rest re-hashed. The records' own
+	// links can: each must name the one before it, and the first the record
+	// before the window. Version 1 manifests did not carry that hash.
+	if problem := evidenceLinkProblem(records, manifest); problem != "" {
+		result.ChainValid = false
+		fail("%s", problem)
+	}
+	if computeMerkleRoot(entries) != manifest.MerkleRoot {
+		result.ChainValid = false
+		fail("recomputed Merkle root does not match manifest")
+	}
+
+	result.ProofsValid = result.ChainValid
+	for i := 0; i < len(proofs) && i < len(expected); i++ {
+		p := proofs[i]
+		if p.RecordHash != expected[i].RecordHash || p.PrevHash != expected[i].PrevHash || p.ChainHash != expected[i].ChainHash {
+			result.ChainValid = false
+			result.ProofsValid = false
+			fail("chain link %d does not match its audit record", i)
+			continue
+		}
+		if !verifyMerkleProof(p.ChainHash, p.Index, p.MerklePath, manifest.MerkleRoot) {
+			result.ProofsValid = false
+			fail("Merkle proof for record %d does not verify", i)
+		}
+	}
+
+	result.Valid = result.SignatureValid && result.KeyPinned && result.FilesValid && result.RecordsValid && result.ChainValid && result.ProofsValid
+	return result, nil
+}
+
+// evidenceLinkProblem checks the audit prev_hash links of the exported
+// records. Records from before the hash chain existed carry no prev_hash.
+func evidenceLinkProblem(records []AuditRecord, manifest EvidenceManifest) string {
+	if len(records) > 0 && manifest.Version >= 2 && records[0].PrevHash != manifest.FirstPrevHash {
+		return fmt.Sprintf("record 0: prev_hash %s is not the manifest's first_prev_hash %s", records[0].PrevHash, manifest.FirstPrevHash)
+	}
+	chained := false
+	for i := range records {
+		rec := &records[i]
+		switch {
+		case rec.PrevHash == "" && chained:
+			return fmt.Sprintf("record %d: prev_hash is missing after the chain started", i)
+		case rec.PrevHash != "" && i > 0 && rec.PrevHash != records[i-1].RecordHash:
+			return fmt.Sprintf("record %d: prev_hash %s does not match the previous record %s", i, rec.PrevHash, records[i-1].RecordHash)
+		}
+		chained = chained || rec.PrevHash != ""
+	}
+	return ""
+}
+
+func ed25519KeyFromSeedHex(seedHex string) (ed25519.PrivateKey, error) {
+	seedHex = strings.TrimPrefix(strings.TrimSpace(seedHex), "0x")
+	if seedHex == "" {
+		return nil, fmt.Errorf("sign_private_key is not configured")
+	}
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil {
+		return nil, fmt.Errorf("sign_private_key must be hex: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("sign_private_key must be 32 bytes (64 hex chars)")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func writeTarGz(out io.Writer, files map[string][]byte, modTime time.Time) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := files[name]
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func readTarGz(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, 64<<20))
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Base(hdr.Name)] = data
+	}
+	return files, nil
+}
+
+func runEvidenceExportMode(configPath, since, outPath string, out io.Writer) error {
+	window, err := time.ParseDuration(strings.TrimSpace(since))
+	if err != nil || window <= 0 {
+		return fmt.Errorf("--evidence-since must be a positive duration like 72h, got %q", since)
+	}
+
+	sentinelCfg, err := loadSentinelConfigOnly(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load sentinel config: %w", err)
+	}
+	guard := NewSentinelGuard(resolveSentinelConfig(sentinelCfg))
+
+	dir := filepath.Dir(outPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// The bundle is built next to outPath and renamed into place, so a
+	// failed export never leaves a truncated bundle behind.
+	f, err := os.CreateTemp(dir, "."+filepath.Base(outPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	seedHex := ""
+	if guard.signKey != nil {
+		seedHex = hex.EncodeToString(guard.signKey.Seed())
+	}
+	manifest, err := buildEvidenceBundle(guard.cfg.AuditLogPath, time.Now().Add(-window), seedHex, f)
+	if err != nil {
+		return fmt.Errorf("failed to build evidence bundle: %w", err)
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), outPath); err != nil {
+		return err
+	}
+	return encodeSentinelOutput(out, map[string]interface{}{
+		"bundle":       outPath,
+		"record_count": manifest.RecordCount,
+		"merkle_root":  manifest.MerkleRoot,
+		"public_key":   manifest.PublicKey,
+	})
+}
+
+func runVerifyBundleMode(path, pinnedKey string, out io.Writer) error {
+	if strings.TrimSpace(pinnedKey) == "" {
+		return fmt.Errorf("--verify-bundle-key is required: pass the operator's Ed25519 public key")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := VerifyEvidenceBundle(f, pinnedKey)
+	if err != nil {
+		return err
+	}
+	if err := encodeSentinelOutput(out, result); err != nil {
+		return err
+	}
+	if !result.Valid {
+		return fmt.Errorf("evidence bundle verification failed")
+	}
+	return nil
+}
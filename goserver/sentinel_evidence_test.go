@@ -0,0 +1,227 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSignSeed = "0101010101010101010101010101010101010101010101010101010101010101"
+
+// testSignPub is the public key of testSignSeed.
+func testSignPub(t *testing.T) string {
+	t.Helper()
+	priv, err := ed25519KeyFromSeedHex(testSignSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(priv.Public().(ed25519.PublicKey))
+}
+
+// withRecordHashes sets each record's hash from its contents.
+func withRecordHashes(records ...AuditRecord) []AuditRecord {
+	for i := range records {
+		records[i].RecordHash = jcsAuditHash(&records[i])
+	}
+	return records
+}
+
+// withChainedHashes links each record to the one before it, starting from
+// prev, and sets the record hashes the way the audit log does.
+func withChainedHashes(prev string, records ...AuditRecord) []AuditRecord {
+	for i := range records {
+		records[i].PrevHash = prev
+		records[i].RecordHash = jcsAuditHash(&records[i])
+		prev = records[i].RecordHash
+	}
+	return records
+}
+
+func TestMerkleProofRoundTrip(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		entries := make([]ProofEntry, n)
+		for i := range entries {
+			entries[i].ChainHash = fmt.Sprintf("0x%02d", i)
+		}
+		root := computeMerkleRoot(entries)
+		for i := range entries {
+			proof, err := computeMerkleProof(entries, i)
+			if err != nil {
+				t.Fatalf("n=%d i=%d: %v", n, i, err)
+			}
+			if !verifyMerkleProof(entries[i].ChainHash, i, proof, root) {
+				t.Fatalf("n=%d i=%d: proof does not verify", n, i)
+			}
+		}
+	}
+}
+
+func TestEvidenceBundleExportAndVerify(t *testing.T) {
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	records := withRecordHashes(
+		AuditRecord{Timestamp: now.Add(-3 * time.Hour), Action: "EXEC", Decision: "blocked", TxDigest: "DIGEST1"},
+		AuditRecord{Timestamp: now.Add(-2 * time.Hour), Action: "STATUS", Decision: "allowed"},
+		AuditRecord{Timestamp: now.Add(-1 * time.Hour), Action: "WALLET", Decision: "blocked"},
+	)
+	writeAuditLog(t, path, records...)
+
+	var bundle bytes.Buffer
+	manifest, err := buildEvidenceBundle(path, now.Add(-24*time.Hour), testSignSeed, &bundle)
+	if err != nil {
+		t.Fatalf("buildEvidenceBundle failed: %v", err)
+	}
+	if manifest.RecordCount != 3 || len(manifest.TxDigests) != 1 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	result, err := VerifyEvidenceBundle(bytes.NewReader(bundle.Bytes()), "0x"+testSignPub(t))
+	if err != nil {
+		t.Fatalf("VerifyEvidenceBundle failed: %v", err)
+	}
+	if !result.Valid || !result.KeyPinned || !result.RecordsValid {
+		t.Fatalf("expected valid bundle, got %+v", result)
+	}
+
+	// A bundle is only as trustworthy as the key that signed it.
+	_, otherKey := testApproverKey(9)
+	if result, err := VerifyEvidenceBundle(bytes.NewReader(bundle.Bytes()), otherKey); err != nil || result.Valid || result.KeyPinned {
+		t.Fatalf("a bundle signed by another key must fail: %+v %v", result, err)
+	}
+	if _, err := VerifyEvidenceBundle(bytes.NewReader(bundle.Bytes()), ""); err == nil {
+		t.Fatal("verification without a pinned key must be refused")
+	}
+
+	// Tamper with the exported audit segment and re-pack the archive.
+	files, err := readTarGz(bytes.NewReader(bundle.Bytes()))
+	if err != nil {
+		t.Fatalf("readTarGz failed: %v", err)
+	}
+	files[evidenceAuditFile] = bytes.Replace(files[evidenceAuditFile], []byte(`"blocked"`), []byte(`"allowed"`), 1)
+	var tampered bytes.Buffer
+	if err := writeTarGz(&tampered, files, now); err != nil {
+		t.Fatalf("writeTarGz failed: %v", err)
+	}
+
+	result, err = VerifyEvidenceBundle(&tampered, testSignPub(t))
+	if err != nil {
+		t.Fatalf("VerifyEvidenceBundle failed: %v", err)
+	}
+	if result.Valid || result.FilesValid {
+		t.Fatalf("expected tampered bundle to fail verification, got %+v", result)
+	}
+}
+
+func TestEvidenceBundleRecomputesRecordHashes(t *testing.T) {
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	records := withRecordHashes(
+		AuditRecord{Timestamp: now.Add(-2 * time.Hour), Action: "EXEC", Decision: "blocked", Score: 95},
+		AuditRecord{Timestamp: now.Add(-1 * time.Hour), Action: "STATUS", Decision: "allowed"},
+	)
+	records[0].Decision = "allowed" // edited after hashing
+	writeAuditLog(t, path, records...)
+
+	var bundle bytes.Buffer
+	if _, err := buildEvidenceBundle(path, now.Add(-24*time.Hour), testSignSeed, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	result, err := VerifyEvidenceBundle(&bundle, testSignPub(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.RecordsValid || !result.SignatureValid || !result.ChainValid {
+		t.Fatalf("an edited record should fail only its hash check: %+v", result)
+	}
+}
+
+func TestEvidenceBundleRequiresSigningKey(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := buildEvidenceBundle(filepath.Join(t.TempDir(), "audit.jsonl"), time.Now(), "", &out); err == nil {
+		t.Fatalf("expected missing key error")
+	}
+}
+
+func TestVerifyEvidenceBundleRejectsMissingManifest(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.Close()
+	gz.Close()
+
+	if _, err := VerifyEvidenceBundle(&buf, testSignPub(t)); err == nil {
+		t.Fatalf("expected missing manifest error")
+	}
+}
+
+func TestEvidenceBundleChecksPrevHashLinks(t *testing.T) {
+	now := time.Now().UTC()
+	records := withChainedHashes("",
+		AuditRecord{Timestamp: now.Add(-4 * time.Hour), Action: "STATUS", Decision: "allowed"},
+		AuditRecord{Timestamp: now.Add(-3 * time.Hour), Action: "EXEC", Decision: "blocked"},
+		AuditRecord{Timestamp: now.Add(-2 * time.Hour), Action: "WALLET", Decision: "blocked"},
+		AuditRecord{Timestamp: now.Add(-1 * time.Hour), Action: "STATUS", Decision: "allowed"},
+	)
+	export := func(since time.Time, records ...AuditRecord) (*EvidenceManifest, *BundleVerification) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		writeAuditLog(t, path, records...)
+		var bundle bytes.Buffer
+		manifest, err := buildEvidenceBundle(path, since, testSignSeed, &bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := VerifyEvidenceBundle(&bundle, testSignPub(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manifest, result
+	}
+
+	// A window that starts mid-log names the record before it.
+	manifest, result := export(now.Add(-150*time.Minute), records...)
+	if !result.Valid || manifest.RecordCount != 2 || manifest.FirstPrevHash != records[1].RecordHash {
+		t.Fatalf("expected a valid partial bundle: %+v %+v", manifest, result)
+	}
+
+	// Re-signing a log with a record dropped or moved still breaks the links.
+	if _, result := export(now.Add(-24*time.Hour), records[0], records[2], records[3]); result.Valid || result.ChainValid || !result.RecordsValid {
+		t.Fatalf("a dropped record must fail the chain check: %+v", result)
+	}
+	if _, result := export(now.Add(-24*time.Hour), records[0], records[2], records[1], records[3]); result.Valid || result.ChainValid {
+		t.Fatalf("reordered records must fail the chain check: %+v", result)
+	}
+
+	// The first record is held to the manifest's first_prev_hash.
+	if problem := evidenceLinkProblem(records[1:], EvidenceManifest{Version: 2}); problem == "" {
+		t.Fatal("a first record that does not match first_prev_hash must fail")
+	}
+}
+
+func TestEvidenceExportLeavesNoPartialBundle(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	config := fmt.Sprintf(`{"sentinel":{"enabled":true,"audit_log_path":%q}}`, filepath.Join(dir, "audit.jsonl"))
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "out", "evidence.tar.gz")
+	var out bytes.Buffer
+	if err := runEvidenceExportMode(configPath, "24h", outPath, &out); err == nil {
+		t.Fatal("expected the export to fail without a signing key")
+	}
+	entries, err := os.ReadDir(filepath.Dir(outPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("a failed export must leave nothing behind, found %v", entries)
+	}
+}
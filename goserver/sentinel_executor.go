@@ -15,6 +15,8 @@ type ExecuteToken struct {
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Redeemed  bool      `json:"redeemed"`
+	// RecordHash is the audit record of the decision that issued the token.
+	RecordHash string `json:"record_hash,omitempty"`
 }
 
 // ExecuteGuard issues and validates one-time execution tokens.
@@ -37,8 +39,9 @@ func NewExecuteGuard(ttl time.Duration) *ExecuteGuard {
 	}
 }
 
-// Issue creates a new one-time token for the given action.
-func (eg *ExecuteGuard) Issue(action string) *ExecuteToken {
+// Issue creates a new one-time token for the given action, allowed by the
+// audit record recordHash.
+func (eg *ExecuteGuard) Issue(action, recordHash string) *ExecuteToken {
 	eg.mu.Lock()
 	defer eg.mu.Unlock()
 
@@ -49,6 +52,8 @@ func (eg *ExecuteGuard) Issue(action string) *ExecuteToken {
 		Action:    action,
 		IssuedAt:  now,
 		ExpiresAt: now.Add(eg.ttl),
+
+		RecordHash: recordHash,
 	}
 	eg.tokens[id] = tok
 	return tok
@@ -2,9 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,13 +24,25 @@ type SentinelGatewayConfig struct {
 
 // SentinelGateway wires all Sentinel components behind an HTTP API.
 type SentinelGateway struct {
-	guard    *SentinelGuard
-	approval *ApprovalService
-	proof    *ProofChain
-	kill     *KillSwitch
-	sandbox  *CapabilitySandbox
-	executor *ExecuteGuard
-	openclaw *OpenClawClient
+	guard       *SentinelGuard
+	approval    *ApprovalService
+	proof       *ProofChain
+	kill        *KillSwitch
+	sandbox     *CapabilitySandbox
+	executor    *ExecuteGuard
+	openclaw    *OpenClawClient
+	config      *ConfigChangeManager
+	notify      *sentinelNotifier
+	canary      *sentinelCanary
+	checkpoints *auditCheckpointer
+	tasks       *openClawTasks
+	activity    *ActivityMonitor
+	extension   *browserExtension
+	household   *household
+	renewal     *walrusRenewal
+	events      *eventHub
+	// token is the operator bearer token; see requireToken.
+	token string
 }
 
 // NewSentinelGateway creates and initializes a fully-wired gateway.
@@ -47,14 +63,96 @@ func NewSentinelGateway(guard *SentinelGuard, oc *OpenClawClient, gwCfg *Sentine
 		CapNetwork: true,
 	})
 
+	configMgr, err := NewConfigChangeManager(guard, guard.cfg.RuntimeConfig)
+	if err != nil {
+		log.Printf("[GATEWAY] runtime config API disabled: %v", err)
+	}
+	if configMgr != nil && configMgr.policy.DelaySec > 0 {
+		configMgr.StartWatcher(5 * time.Second)
+	}
+
+	guard.StartAnchorRetryWorker()
+	guard.StartRetentionWorker()
+
+	notify, err := newSentinelNotifier(guard.cfg.Notifications)
+	if err != nil {
+		log.Printf("[GATEWAY] notifications disabled: %v", err)
+	}
+	if notify != nil {
+		notify.journal = alertJournalPath(guard.cfg.AuditLogPath)
+		guard.anchorAlert = func(backend string, rec *AuditRecord, err error) {
+			notify.Send(anchorFailureNotification(backend, rec, err))
+		}
+	}
+
+	canary, err := newSentinelCanary(guard, guard.cfg.Canary, notify)
+	if err != nil {
+		log.Printf("[GATEWAY] canary disabled: %v", err)
+	}
+	if canary != nil {
+		canary.Start(30 * time.Second)
+	}
+
+	checkpoints, err := newAuditCheckpointer(guard, guard.cfg.AuditCheckpoint)
+	if err != nil {
+		log.Printf("[GATEWAY] audit checkpoints disabled: %v", err)
+	}
+	if checkpoints != nil {
+		checkpoints.Start(time.Minute)
+	}
+
+	extension, err := newBrowserExtension(guard.cfg.BrowserExtension)
+	if err != nil {
+		log.Printf("[GATEWAY] browser extension endpoints disabled: %v", err)
+	}
+
+	events := newEventHub()
+	guard.events = events
+
+	activity := NewActivityMonitor()
+	system, err := newSystemActivity(guard.cfg.SystemActivity, activity)
+	if err != nil {
+		log.Printf("[GATEWAY] system activity disabled: %v", err)
+	}
+	if system != nil {
+		system.Start()
+	}
+	household, err := newHousehold(guard.cfg.Household, guard.cfg.ChainRead, notify, activity)
+	if err != nil {
+		log.Printf("[GATEWAY] household mode disabled: %v", err)
+	}
+	if household != nil {
+		household.events = events
+		household.Start()
+	}
+
+	renewal, err := newWalrusRenewal(guard.cfg.WalrusRenewal, guard.cfg.ChainRead, notify)
+	if err != nil {
+		log.Printf("[GATEWAY] walrus renewal disabled: %v", err)
+	}
+	if renewal != nil {
+		renewal.Start()
+	}
+
 	return &SentinelGateway{
-		guard:    guard,
-		approval: approvalSvc,
-		proof:    NewProofChain(gwCfg.ProofBatchSize, gwCfg.WalrusPublisherURL),
-		kill:     NewKillSwitch(gwCfg.KillSwitchThreshold),
-		sandbox:  sandbox,
-		executor: NewExecuteGuard(gwCfg.ExecuteTokenTTL),
-		openclaw: oc,
+		guard:       guard,
+		approval:    approvalSvc,
+		proof:       NewProofChain(gwCfg.ProofBatchSize, gwCfg.WalrusPublisherURL),
+		kill:        NewKillSwitch(gwCfg.KillSwitchThreshold),
+		sandbox:     sandbox,
+		executor:    NewExecuteGuard(gwCfg.ExecuteTokenTTL),
+		openclaw:    oc,
+		config:      configMgr,
+		notify:      notify,
+		canary:      canary,
+		checkpoints: checkpoints,
+		tasks:       newOpenClawTasks(),
+		activity:    activity,
+		extension:   extension,
+		token:       strings.TrimSpace(os.Getenv(operatorTokenEnv(&guard.cfg))),
+		household:   household,
+		renewal:     renewal,
+		events:      events,
 	}
 }
 
@@ -62,13 +160,32 @@ func NewSentinelGateway(guard *SentinelGuard, oc *OpenClawClient, gwCfg *Sentine
 func (gw *SentinelGateway) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/sentinel/gate", gw.handleGate)
 	mux.HandleFunc("/sentinel/approval/start", gw.handleApprovalStart)
-	mux.HandleFunc("/sentinel/approval/confirm", gw.handleApprovalConfirm)
+	mux.HandleFunc("/sentinel/approval/confirm", gw.requireToken(gw.handleApprovalConfirm))
 	mux.HandleFunc("/sentinel/proxy/execute", gw.handleExecute)
+	mux.HandleFunc("/sentinel/openclaw/step", gw.handleOpenClawStep)
+	mux.HandleFunc("/sentinel/openclaw/sessions", gw.requireToken(gw.handleSession))
+	mux.HandleFunc("/sentinel/activity", gw.requireToken(gw.handleActivity))
 	mux.HandleFunc("/sentinel/proof/latest", gw.handleLatestProof)
 	mux.HandleFunc("/sentinel/status", gw.handleStatus)
-	mux.HandleFunc("/sentinel/kill-switch/arm", gw.handleKillSwitchArm)
-	mux.HandleFunc("/sentinel/kill-switch/disarm", gw.handleKillSwitchDisarm)
+	mux.HandleFunc("/ws", gw.handleEvents)
+	mux.HandleFunc("/sentinel/audit", gw.requireToken(gw.handleAuditQuery))
+	mux.HandleFunc("/sentinel/kill-switch/arm", gw.requireToken(gw.handleKillSwitchArm))
+	mux.HandleFunc("/sentinel/kill-switch/disarm", gw.requireToken(gw.handleKillSwitchDisarm))
 	mux.HandleFunc("/health", gw.handleHealth)
+	mux.HandleFunc("/metrics", gw.handleMetrics)
+	mux.HandleFunc("/sentinel/config/effective", gw.requireToken(gw.handleEffectiveConfig))
+	if gw.config != nil {
+		mux.HandleFunc("/sentinel/config/propose", gw.requireToken(gw.handleConfigPropose))
+		mux.HandleFunc("/sentinel/config/approve", gw.requireToken(gw.handleConfigApprove))
+		mux.HandleFunc("/sentinel/config/changes", gw.requireToken(gw.handleConfigChanges))
+	}
+	if gw.guard.lists != nil {
+		mux.HandleFunc("/sentinel/lists", gw.requireTokenForWrites(gw.handlePolicyLists))
+	}
+	if gw.extension != nil {
+		mux.HandleFunc("/sentinel/extension/activity", gw.extension.wrap(gw.handleExtensionActivity))
+		mux.HandleFunc("/sentinel/extension/approvals", gw.extension.wrap(gw.handleExtensionApprovals))
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -108,40 +225,52 @@ func (gw *SentinelGateway) handleGate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	resp, status, err := gw.gate(req)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, status, resp)
+}
+
+// gate runs the full gate pipeline (kill switch, capability sandbox, risk
+// evaluation and audit, proof chain, kill switch tracking) and returns the
+// decision with its HTTP status. It backs both /sentinel/gate and the
+// OpenClaw step callback.
+func (gw *SentinelGateway) gate(req GateRequest) (GateResponse, int, error) {
 	// 1) Kill switch pre-check
 	if gw.kill.IsArmed() {
 		ks := gw.kill.Status()
-		writeJSON(w, http.StatusForbidden, GateResponse{
+		gw.guard.metrics.observeGateDecision("TRIGGER_KILL_SWITCH")
+		return GateResponse{
 			Decision: "TRIGGER_KILL_SWITCH",
 			Reason:   "kill switch is armed: " + ks.Reason,
-		})
-		return
+		}, http.StatusForbidden, nil
 	}
 
 	// 2) Capability sandbox check
 	if req.AgentID != "" {
 		cap := inferCapability(req.Action)
 		if !gw.sandbox.Check(req.AgentID, cap) {
-			writeJSON(w, http.StatusForbidden, GateResponse{
+			gw.guard.metrics.observeGateDecision("BLOCK")
+			return GateResponse{
 				Decision: "BLOCK",
 				Reason:   fmt.Sprintf("agent %s not authorized for capability: %s", req.AgentID, cap),
-			})
-			return
+			}, http.StatusForbidden, nil
 		}
 	}
 
 	// 3) Risk evaluation + audit
 	eval, rec, err := gw.guard.Enforce(req.Action, req.Prompt)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		return
+		return GateResponse{}, 0, err
 	}
 
 	// 4) Append to proof chain
 	proofEntry := gw.proof.Append(rec)
 
 	// 5) Track consecutive high risk for kill switch auto-arm
-	if eval.Score >= gw.guard.cfg.RiskThreshold {
+	if eval.Score >= gw.guard.actionThreshold(req.Action) && !containsTag(eval.Tags, "trusted_action") {
 		gw.kill.RecordHighRisk()
 	} else {
 		gw.kill.RecordLowRisk()
@@ -149,15 +278,19 @@ func (gw *SentinelGateway) handleGate(w http.ResponseWriter, r *http.Request) {
 
 	// If kill switch just auto-armed, return immediately
 	if gw.kill.IsArmed() {
-		writeJSON(w, http.StatusOK, GateResponse{
+		gw.guard.metrics.observeGateDecision("TRIGGER_KILL_SWITCH")
+		gw.notify.Send(killSwitchNotification(true, gw.kill.Status().Reason))
+		gw.events.Publish(notifyKillSwitchArmed, map[string]interface{}{"reason": gw.kill.Status().Reason})
+		resp := GateResponse{
 			Decision:   "TRIGGER_KILL_SWITCH",
 			Score:      eval.Score,
 			Tags:       eval.Tags,
 			Reason:     "kill switch auto-armed: consecutive high-risk threshold reached",
 			RecordHash: rec.RecordHash,
 			ProofIndex: proofEntry.Index,
-		})
-		return
+		}
+		gw.publishGateDecision(req.Action, resp)
+		return resp, http.StatusOK, nil
 	}
 
 	// 6) Build response based on evaluation
@@ -176,26 +309,47 @@ func (gw *SentinelGateway) handleGate(w http.ResponseWriter, r *http.Request) {
 		hasInjection := containsTag(eval.Tags, "prompt_injection")
 		hasBypass := containsTag(eval.Tags, "policy_bypass")
 		hasAnchorFailure := containsTag(eval.Tags, "anchor_failure")
+		hasDomainViolation := containsTag(eval.Tags, "domain_not_allowed")
+		hasPolicyDeny := containsTag(eval.Tags, "opa_deny") || containsTag(eval.Tags, "opa_fail_closed") || containsTag(eval.Tags, "denylisted")
 
-		if hasInjection || hasBypass || hasAnchorFailure {
-			// Hard block for prompt injection and policy bypass attempts
+		if hasInjection || hasBypass || hasAnchorFailure || hasDomainViolation || hasPolicyDeny {
+			// Hard block for prompt injection, policy bypass, allowlist violations, Rego denies and denylisted patterns
 			resp.Decision = "BLOCK"
 			log.Printf("[GATE] BLOCK score=%d tags=%v", eval.Score, eval.Tags)
+			gw.notify.Send(gateNotification(notifyGateBlock, req.Action, eval, rec))
 		} else {
 			// Soft block: route through human approval
-			ch := gw.approval.StartChallenge(req.Action, req.Prompt, eval.Score)
+			ch := gw.approval.StartChallenge(req.Action, req.Prompt, eval.Score, rec.RecordHash)
 			resp.Decision = "REQUIRE_APPROVAL"
 			resp.ChallengeID = ch.ID
 			log.Printf("[GATE] REQUIRE_APPROVAL challenge=%s score=%d", ch.ID, eval.Score)
+			gw.notify.Send(gateNotification(notifyApproval, req.Action, eval, rec, notifyField{"Challenge", ch.ID}))
 		}
 	} else {
-		tok := gw.executor.Issue(req.Action)
+		tok := gw.executor.Issue(req.Action, rec.RecordHash)
 		resp.Decision = "ALLOW"
 		resp.Token = tok
 		log.Printf("[GATE] ALLOW token=%s score=%d", tok.ID, eval.Score)
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	gw.guard.metrics.observeGateDecision(resp.Decision)
+	gw.publishGateDecision(req.Action, resp)
+	return resp, http.StatusOK, nil
+}
+
+// publishGateDecision puts a gate decision on the event stream.
+func (gw *SentinelGateway) publishGateDecision(action string, resp GateResponse) {
+	data := map[string]interface{}{
+		"action":      action,
+		"decision":    resp.Decision,
+		"score":       resp.Score,
+		"tags":        resp.Tags,
+		"record_hash": resp.RecordHash,
+	}
+	if resp.ChallengeID != "" {
+		data["challenge_id"] = resp.ChallengeID
+	}
+	gw.events.Publish(eventGateDecision, data)
 }
 
 // ---------------------------------------------------------------------------
@@ -228,7 +382,7 @@ func (gw *SentinelGateway) handleApprovalStart(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	ch := gw.approval.StartChallenge(req.Action, req.Prompt, req.Score)
+	ch := gw.approval.StartChallenge(req.Action, req.Prompt, req.Score, "")
 	log.Printf("[APPROVAL] started challenge=%s action=%s score=%d", ch.ID, ch.Action, ch.RiskScore)
 	writeJSON(w, http.StatusOK, ch)
 }
@@ -245,11 +399,21 @@ func (gw *SentinelGateway) handleApprovalConfirm(w http.ResponseWriter, r *http.
 		return
 	}
 
-	ch, err := gw.approval.Confirm(req.ChallengeID, req.Approved, req.DecidedBy)
+	resp, err := gw.confirmApproval(req.ChallengeID, req.Approved, req.DecidedBy)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// confirmApproval decides a challenge and, if approved, issues the one-time
+// execution token.
+func (gw *SentinelGateway) confirmApproval(challengeID string, approved bool, decidedBy string) (map[string]interface{}, error) {
+	ch, err := gw.approval.Confirm(challengeID, approved, decidedBy)
+	if err != nil {
+		return nil, err
+	}
 
 	resp := map[string]interface{}{
 		"challenge": ch,
@@ -257,14 +421,13 @@ func (gw *SentinelGateway) handleApprovalConfirm(w http.ResponseWriter, r *http.
 
 	// If approved, issue a one-time execution token
 	if ch.Status == "approved" {
-		tok := gw.executor.Issue(ch.Action)
+		tok := gw.executor.Issue(ch.Action, ch.RecordHash)
 		resp["token"] = tok
 		log.Printf("[APPROVAL] approved challenge=%s, issued token=%s", ch.ID, tok.ID)
 	} else {
-		log.Printf("[APPROVAL] rejected challenge=%s by=%s", ch.ID, req.DecidedBy)
+		log.Printf("[APPROVAL] rejected challenge=%s by=%s", ch.ID, decidedBy)
 	}
-
-	writeJSON(w, http.StatusOK, resp)
+	return resp, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -275,6 +438,9 @@ func (gw *SentinelGateway) handleApprovalConfirm(w http.ResponseWriter, r *http.
 type ExecuteRequest struct {
 	TokenID string `json:"token_id"`
 	Prompt  string `json:"prompt"`
+	// TaskID names the recorded session. Pass the OpenClaw session key so
+	// the steps its plugin reports land in the same transcript.
+	TaskID string `json:"task_id,omitempty"`
 }
 
 // ExecuteResponse is the result of a proxy execute call.
@@ -282,6 +448,8 @@ type ExecuteResponse struct {
 	Status   string            `json:"status"`
 	Message  string            `json:"message,omitempty"`
 	OpenClaw *OpenClawResponse `json:"openclaw,omitempty"`
+	// SessionID is the recorded transcript, when session recording is on.
+	SessionID string `json:"session_id,omitempty"`
 }
 
 func (gw *SentinelGateway) handleExecute(w http.ResponseWriter, r *http.Request) {
@@ -313,18 +481,37 @@ func (gw *SentinelGateway) handleExecute(w http.ResponseWriter, r *http.Request)
 		if prompt == "" {
 			prompt = tok.Action
 		}
-		ocResp, err := gw.openclaw.SendTaskWithoutSentinel(prompt)
+		session := gw.guard.startSession(req.TaskID, tok.RecordHash, map[string]string{"action": tok.Action, "prompt": prompt, "token_id": tok.ID})
+		start := time.Now()
+		ocResp, err := gw.openclaw.Dispatch(tok.Action, prompt)
+		var throttled *OpenClawThrottledError
+		if errors.As(err, &throttled) {
+			gw.guard.finishSession(session, nil, err)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			writeJSON(w, http.StatusTooManyRequests, ExecuteResponse{
+				Status:    "throttled",
+				Message:   fmt.Sprintf("token redeemed but %v", err),
+				SessionID: session,
+			})
+			return
+		}
+		gw.guard.metrics.observeOpenClaw(time.Since(start), err)
+		gw.guard.finishSession(session, ocResp, err)
 		if err != nil {
+			gw.guard.capabilities.set(capOpenClaw, false, err.Error())
 			writeJSON(w, http.StatusOK, ExecuteResponse{
-				Status:  "executed",
-				Message: fmt.Sprintf("token redeemed but OpenClaw dispatch failed: %v", err),
+				Status:    "executed",
+				Message:   fmt.Sprintf("token redeemed but OpenClaw dispatch failed: %v", err),
+				SessionID: session,
 			})
 			return
 		}
+		gw.guard.capabilities.set(capOpenClaw, true, "")
 		writeJSON(w, http.StatusOK, ExecuteResponse{
-			Status:   "executed",
-			Message:  "token redeemed and forwarded to OpenClaw",
-			OpenClaw: ocResp,
+			Status:    "executed",
+			Message:   "token redeemed and forwarded to OpenClaw",
+			OpenClaw:  ocResp,
+			SessionID: session,
 		})
 		return
 	}
@@ -370,10 +557,124 @@ func (gw *SentinelGateway) handleStatus(w http.ResponseWriter, r *http.Request)
 		"proof_chain_length": gw.proof.Len(),
 		"proof_chain_valid":  gw.proof.VerifyChain(),
 		"pending_tokens":     gw.executor.PendingCount(),
+		"risk_threshold":     gw.guard.riskThreshold(),
+		"config_hash":        gw.guard.ConfigSnapshot("status").ConfigHash,
+		"capabilities":       gw.guard.capabilities.Status(),
+		"degraded":           gw.guard.capabilities.Degraded(),
+	}
+	if gw.guard.adaptive != nil {
+		resp["adaptive_threshold"] = gw.guard.adaptive.Status()
+	}
+	if len(gw.guard.cfg.ActionPolicies) > 0 {
+		resp["action_thresholds"] = gw.guard.actionThresholds()
+	}
+	if gw.config != nil {
+		resp["pending_config_changes"] = gw.config.PendingCount()
+	}
+	if gw.canary != nil {
+		resp["canary"] = gw.canary.Last()
 	}
+	if gw.checkpoints != nil {
+		resp["last_audit_checkpoint"] = gw.checkpoints.Last()
+	}
+	if gw.notify != nil {
+		resp["notification_channels"] = gw.notify.Channels()
+	}
+	resp["activity"] = gw.activity.Status()
+	if gw.household != nil {
+		resp["household"] = gw.household.Status()
+	}
+	if gw.renewal != nil {
+		resp["walrus_renewal"] = gw.renewal.Status()
+	}
+	running, aborted := gw.tasks.Counts()
+	resp["openclaw_tasks"] = map[string]int{"running": running, "aborted": aborted}
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// ---------------------------------------------------------------------------
+// Runtime config changes
+// ---------------------------------------------------------------------------
+
+// handleEffectiveConfig serves the resolved configuration with the source of
+// every setting and its diff against the defaults.
+func (gw *SentinelGateway) handleEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, gw.guard.EffectiveConfigReport())
+}
+
+// ConfigProposeRequest proposes a runtime policy change. With approver keys
+// configured, Signature is the proposer's hex ed25519 signature over the
+// change digest (see GET /sentinel/config/changes or the 400 response).
+type ConfigProposeRequest struct {
+	Changes    ConfigChangeSet `json:"changes"`
+	ProposedBy string          `json:"proposed_by"`
+	Nonce      string          `json:"nonce,omitempty"`
+	PublicKey  string          `json:"public_key,omitempty"`
+	Signature  string          `json:"signature,omitempty"`
+}
+
+// ConfigApproveRequest approves or rejects a pending change with a second key.
+type ConfigApproveRequest struct {
+	ID        string `json:"id"`
+	Approved  bool   `json:"approved"`
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+func (gw *SentinelGateway) handleConfigPropose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ConfigProposeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	ch, err := gw.config.Propose(req.Changes, req.ProposedBy, req.Nonce, req.PublicKey, req.Signature)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":  err.Error(),
+			"digest": configChangeDigest(req.Changes),
+		})
+		return
+	}
+	log.Printf("[CONFIG] change %s proposed by %s (status=%s)", ch.ID, ch.ProposedBy, ch.Status)
+	writeJSON(w, http.StatusOK, ch)
+}
+
+func (gw *SentinelGateway) handleConfigApprove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ConfigApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	ch, err := gw.config.Approve(req.ID, req.Approved, req.PublicKey, req.Signature)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	log.Printf("[CONFIG] change %s decided (status=%s)", ch.ID, ch.Status)
+	writeJSON(w, http.StatusOK, ch)
+}
+
+func (gw *SentinelGateway) handleConfigChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gw.config.ApplyDue()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"changes": gw.config.List()})
+}
+
 // ---------------------------------------------------------------------------
 // Kill Switch
 // ---------------------------------------------------------------------------
@@ -394,6 +695,8 @@ func (gw *SentinelGateway) handleKillSwitchArm(w http.ResponseWriter, r *http.Re
 
 	gw.kill.Arm(req.Reason)
 	log.Printf("[KILL_SWITCH] armed: %s", req.Reason)
+	gw.notify.Send(killSwitchNotification(true, req.Reason))
+	gw.events.Publish(notifyKillSwitchArmed, map[string]interface{}{"reason": req.Reason})
 	writeJSON(w, http.StatusOK, gw.kill.Status())
 }
 
@@ -405,6 +708,8 @@ func (gw *SentinelGateway) handleKillSwitchDisarm(w http.ResponseWriter, r *http
 
 	gw.kill.Disarm()
 	log.Printf("[KILL_SWITCH] disarmed")
+	gw.notify.Send(killSwitchNotification(false, ""))
+	gw.events.Publish(notifyKillSwitchOff, nil)
 	writeJSON(w, http.StatusOK, gw.kill.Status())
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -15,13 +16,45 @@ func newTestGateway() *SentinelGateway {
 		RiskThreshold: 70,
 		AuditLogPath:  "./audit/test-audit.jsonl",
 	})
-	gwCfg := &SentinelGatewayConfig{
-		ApprovalTimeout:    1 * time.Minute,
-		ProofBatchSize:     5,
-		KillSwitchThreshold: 3,
-		ExecuteTokenTTL:    10 * time.Second,
+	return newTestGatewayFor(guard, SentinelGatewayConfig{})
+}
+
+// testSentinelConfig is cfg with the settings tests share: enabled, a risk
+// threshold of 70 and, unless cfg names one, an audit log in a temp dir.
+func testSentinelConfig(t *testing.T, cfg SentinelConfig) *SentinelConfig {
+	t.Helper()
+	cfg.Enabled = true
+	if cfg.RiskThreshold == 0 {
+		cfg.RiskThreshold = 70
+	}
+	if cfg.AuditLogPath == "" {
+		cfg.AuditLogPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	}
+	return &cfg
+}
+
+// newTestGuard builds a guard from testSentinelConfig(t, cfg).
+func newTestGuard(t *testing.T, cfg SentinelConfig) *SentinelGuard {
+	t.Helper()
+	return NewSentinelGuard(testSentinelConfig(t, cfg))
+}
+
+// newTestGatewayFor wraps guard in a gateway with newTestGateway's
+// settings; the non-zero fields of cfg replace them.
+func newTestGatewayFor(guard *SentinelGuard, cfg SentinelGatewayConfig) *SentinelGateway {
+	if cfg.ApprovalTimeout == 0 {
+		cfg.ApprovalTimeout = time.Minute
+	}
+	if cfg.ProofBatchSize == 0 {
+		cfg.ProofBatchSize = 5
+	}
+	if cfg.KillSwitchThreshold == 0 {
+		cfg.KillSwitchThreshold = 3
+	}
+	if cfg.ExecuteTokenTTL == 0 {
+		cfg.ExecuteTokenTTL = 10 * time.Second
 	}
-	return NewSentinelGateway(guard, nil, gwCfg)
+	return NewSentinelGateway(guard, nil, &cfg)
 }
 
 func postJSON(t *testing.T, handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
@@ -3,15 +3,16 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,6 +22,11 @@ type SentinelConfig struct {
 	RiskThreshold int    `json:"risk_threshold"`
 	AuditLogPath  string `json:"audit_log_path"`
 
+	// ActionPolicies override the threshold or force approval per action
+	// type (EXEC, WALLET, LAST_WORDS, ...). Unlisted actions use
+	// RiskThreshold.
+	ActionPolicies map[string]ActionPolicy `json:"action_policies,omitempty"`
+
 	// Optional on-chain anchor fields. Keep them configurable to work with different Move modules.
 	AnchorEnabled    bool   `json:"anchor_enabled"`
 	AnchorFailClosed bool   `json:"anchor_fail_closed"`
@@ -28,10 +34,105 @@ type SentinelConfig struct {
 	AnchorModule     string `json:"anchor_module"`
 	AnchorFunc       string `json:"anchor_function"`
 	AnchorRegistry   string `json:"anchor_registry"`
+	// AnchorClock is the Clock object passed to the anchor call; default 0x6.
+	AnchorClock string `json:"anchor_clock_object,omitempty"`
 
 	HashCLIPath string `json:"hash_cli_path"`
 	SignCLIPath string `json:"sign_cli_path"`
 	SignPrivKey string `json:"sign_private_key"`
+	// SignKeystore is an encrypted keystore holding the signing seed; it
+	// takes precedence over SignPrivKey.
+	SignKeystore string `json:"sign_keystore,omitempty"`
+	// SignWithRustCLI signs through the Rust CLI's sign-audit, which takes
+	// the seed on its command line. Records are signed in Go by default.
+	SignWithRustCLI bool `json:"sign_with_rust_cli,omitempty"`
+	// RustCLISHA256 pins the expected sha256 of the Rust CLI binary; a
+	// mismatching binary is never executed.
+	RustCLISHA256 string `json:"rust_cli_sha256"`
+
+	Subprocess *SubprocessConfig `json:"subprocess,omitempty"`
+
+	AdaptiveThreshold *AdaptiveThresholdConfig `json:"adaptive_threshold,omitempty"`
+
+	ViolationDedup *ViolationDedupConfig `json:"violation_dedup,omitempty"`
+
+	BehaviorPipeline *AnomalyPipelineConfig `json:"behavior_pipeline,omitempty"`
+
+	Rules     *SentinelRules `json:"rules,omitempty"`
+	RulesFile string         `json:"rules_file,omitempty"`
+	// RulePacks lists keyword rule pack files (paths or globs, JSON or
+	// YAML) merged over the built-in detection rules.
+	RulePacks []string `json:"rule_packs,omitempty"`
+
+	DomainAllowlist *DomainAllowlistConfig `json:"domain_allowlist,omitempty"`
+
+	OnchainAllowlist *OnchainAllowlistConfig `json:"onchain_allowlist,omitempty"`
+
+	ChainRead *ChainReadConfig `json:"chain_read,omitempty"`
+
+	RuntimeConfig *RuntimeConfigPolicy `json:"runtime_config,omitempty"`
+
+	// SuiRPC anchors over JSON-RPC with a native client instead of the sui CLI.
+	SuiRPC *SuiRPCConfig `json:"sui_rpc,omitempty"`
+
+	// SuiCLI pins the profile used when anchoring through the sui CLI.
+	SuiCLI *SuiCLIConfig `json:"sui_cli,omitempty"`
+
+	// AnchorMirrors also anchor every record on secondary backends.
+	AnchorMirrors []AnchorMirrorConfig `json:"anchor_mirrors,omitempty"`
+
+	// AnchorRetry queues records whose anchor failed and retries them.
+	AnchorRetry *AnchorRetryConfig `json:"anchor_retry,omitempty"`
+	// SessionRecording keeps transcripts of the OpenClaw tasks Sentinel
+	// dispatches, linked to the records that allowed them.
+	SessionRecording *SessionRecordingConfig `json:"session_recording,omitempty"`
+
+	Notifications *NotificationsConfig `json:"notifications,omitempty"`
+
+	// Canary periodically self-tests the live guard on known cases.
+	Canary *CanaryConfig `json:"canary,omitempty"`
+
+	// OPA lets Rego policies make the final allow/deny decision.
+	OPA *OPAConfig `json:"opa,omitempty"`
+
+	// LLMClassifier rescores ambiguous prompts with an LLM.
+	LLMClassifier *LLMClassifierConfig `json:"llm_classifier,omitempty"`
+
+	// Retention redacts prompts from old audit records.
+	Retention *RetentionConfig `json:"retention,omitempty"`
+
+	// AuditCheckpoint anchors the audit log head on a fixed schedule.
+	AuditCheckpoint *AuditCheckpointConfig `json:"audit_checkpoint,omitempty"`
+
+	// PolicyLists are allow/deny lists editable at runtime.
+	PolicyLists *PolicyListsConfig `json:"policy_lists,omitempty"`
+
+	// BrowserExtension enables the companion extension's endpoints.
+	BrowserExtension *BrowserExtensionConfig `json:"browser_extension,omitempty"`
+
+	// OperatorTokenEnv names the environment variable holding the bearer
+	// token of the operator endpoints; default SENTINEL_OPERATOR_TOKEN.
+	OperatorTokenEnv string `json:"operator_token_env,omitempty"`
+	// EventsAllowedOrigins lists the browser origins (scheme://host[:port])
+	// that may open GET /ws. Requests without an Origin header are not
+	// browsers and only need the operator token.
+	EventsAllowedOrigins []string `json:"events_allowed_origins,omitempty"`
+
+	// SystemActivity polls the OS for keyboard and mouse idle time.
+	SystemActivity *SystemActivityConfig `json:"system_activity,omitempty"`
+
+	// Household watches the vault deadlines of several owners.
+	Household *HouseholdConfig `json:"household,omitempty"`
+
+	// WalrusRenewal re-stores vault blobs on Walrus before they expire.
+	WalrusRenewal *WalrusRenewalConfig `json:"walrus_renewal,omitempty"`
+
+	// PolicyProxy maps task payloads for --sentinel-policy-proxy.
+	PolicyProxy *PolicyProxyConfig `json:"policy_proxy,omitempty"`
+
+	// MandatoryCapabilities lists capabilities (rust_hash, rust_sign,
+	// anchor, openclaw) the proxy refuses to start without.
+	MandatoryCapabilities []string `json:"mandatory_capabilities,omitempty"`
 }
 
 // RiskEvaluation is the policy engine output.
@@ -56,13 +157,81 @@ type AuditRecord struct {
 	PublicKey   string    `json:"public_key,omitempty"`
 	TxDigest    string    `json:"tx_digest,omitempty"`
 	AnchorError string    `json:"anchor_error,omitempty"`
+	// Checkpoint is the Sui checkpoint that included TxDigest, when the
+	// node had already certified it by the time the record was written.
+	Checkpoint string `json:"checkpoint,omitempty"`
+
+	// PrevHash is the RecordHash of the previous record in the log. It is
+	// covered by RecordHash, so the log cannot be cut or reordered without
+	// breaking a link.
+	PrevHash string `json:"prev_hash,omitempty"`
+
+	Mirrors []MirrorAnchor `json:"mirrors,omitempty"`
+
+	// Occurrences and DedupOf are set on collapsed violation streaks: the
+	// count of identical blocked requests and the hash of the first record.
+	// Both are covered by RecordHash.
+	Occurrences int    `json:"occurrences,omitempty"`
+	DedupOf     string `json:"dedup_of,omitempty"`
+
+	// Redacted is set once retention has removed the prompt.
+	Redacted *AuditRedaction `json:"redacted,omitempty"`
+	// PromptSHA256 commits to the prompt. RecordHash covers it in place of
+	// the prompt, so the hash still recomputes after redaction.
+	PromptSHA256 string `json:"prompt_sha256,omitempty"`
 }
 
 // SentinelGuard evaluates risky inputs and writes tamper-evident audits.
 type SentinelGuard struct {
 	cfg        SentinelConfig
+	rules      *SentinelRules
+	keywords   []KeywordRule
 	policyGate *PolicyGate
+	rustCLI    *rustCLIResolver
+	signKey    ed25519.PrivateKey
+	adaptive   *AdaptiveThreshold
+	dedup      *violationDeduper
+	allowlist  *onchainAllowlist
+	lists      *policyListStore
 	anchorFn   func(*AuditRecord) (string, error)
+	sui        *SuiClient
+	mirrors    []ChainBackend
+	retry      *anchorRetryQueue
+	sessions   *sessionRecorder
+	llm        *llmClassifier
+	opa        *opaEngine
+
+	// anchorAlert is told about every primary or mirror anchor failure.
+	anchorAlert anchorAlertFunc
+	// events receives anchor results for the gateway's event stream.
+	events *eventHub
+
+	rulesFileSHA256 string
+	capabilities    *degradationMatrix
+	metrics         *sentinelMetrics
+
+	fileKeys    map[string]bool // settings present in the config file
+	runtimeKeys map[string]bool // top-level settings changed through the config API
+
+	// runtimeMu guards cfg.RiskThreshold and rules, which the config API
+	// can change while requests are evaluated.
+	runtimeMu sync.RWMutex
+
+	// chainMu guards the hash chain. A record reserves its position under
+	// it (taking lastHash as its link), is anchored without it, and is then
+	// appended once every earlier reservation has been, so records reach the
+	// log in the order they were chained while anchors run in parallel.
+	// lastHash is the head including reserved records, written the last
+	// record actually in the log; chainCond signals appends.
+	chainMu     sync.Mutex
+	chainCond   *sync.Cond
+	chainLoaded bool
+	lastHash    string
+	written     string
+	reserved    uint64
+	appended    uint64
+	// auditLock is the open <audit log>.lock; see lockAuditLog.
+	auditLock *os.File
 }
 
 func NewSentinelGuard(cfg *SentinelConfig) *SentinelGuard {
@@ -76,6 +245,11 @@ func NewSentinelGuard(cfg *SentinelConfig) *SentinelGuard {
 	if copyCfg.AuditLogPath == "" {
 		copyCfg.AuditLogPath = "./audit/sentinel-audit.jsonl"
 	}
+	policies, err := normalizeActionPolicies(copyCfg.ActionPolicies)
+	if err != nil {
+		log.Printf("[SENTINEL] action_policies ignored: %v", err)
+	}
+	copyCfg.ActionPolicies = policies
 	if copyCfg.AnchorModule == "" {
 		copyCfg.AnchorModule = "sentinel_audit"
 	}
@@ -89,10 +263,128 @@ func NewSentinelGuard(cfg *SentinelConfig) *SentinelGuard {
 		copyCfg.SignCLIPath = copyCfg.HashCLIPath
 	}
 
-	return &SentinelGuard{
+	policyGate := NewPolicyGate("sentinel-agent")
+	if err := policyGate.GetAgentProfile().ConfigurePipeline(copyCfg.BehaviorPipeline); err != nil {
+		log.Printf("[SENTINEL] behavior_pipeline ignored: %v", err)
+	}
+	rules, err := resolveSentinelRules(&copyCfg)
+	if err != nil {
+		log.Printf("[SENTINEL] rules not loaded: %v", err)
+	}
+	policyGate.GetAgentProfile().SetRules(rules)
+	rulesFileSHA := ""
+	if copyCfg.RulesFile != "" {
+		rulesFileSHA = sha256File(copyCfg.RulesFile)
+	}
+	packs, err := loadRulePacks(copyCfg.RulePacks)
+	if err != nil {
+		log.Printf("[SENTINEL] rule_packs not loaded, using built-in rules: %v", err)
+		packs = nil
+	}
+	sui, err := NewSuiClient(copyCfg.SuiRPC)
+	if err != nil {
+		log.Printf("[SENTINEL] sui_rpc disabled, anchoring via sui CLI: %v", err)
+	}
+	mirrors, err := newAnchorMirrors(copyCfg.AnchorMirrors)
+	if err != nil {
+		log.Printf("[SENTINEL] anchor mirrors disabled: %v", err)
+	}
+	retry, err := newAnchorRetryQueue(copyCfg.AnchorRetry, copyCfg.AuditLogPath)
+	if err != nil {
+		log.Printf("[SENTINEL] anchor retry queue disabled: %v", err)
+	}
+	llm, err := newLLMClassifier(copyCfg.LLMClassifier)
+	if err != nil {
+		log.Printf("[SENTINEL] llm classifier disabled: %v", err)
+	}
+	opa, err := newOPAEngine(copyCfg.OPA, copyCfg.Subprocess)
+	if err != nil {
+		log.Printf("[SENTINEL] opa policies disabled: %v", err)
+	}
+	lists, err := newPolicyListStore(copyCfg.PolicyLists, copyCfg.AuditLogPath)
+	if err != nil {
+		log.Printf("[SENTINEL] policy lists disabled: %v", err)
+	}
+	signKey, err := loadSigningKey(&copyCfg)
+	if err != nil {
+		log.Printf("[SENTINEL] audit signing disabled: %v", err)
+	}
+
+	sg := &SentinelGuard{
 		cfg:        copyCfg,
-		policyGate: NewPolicyGate("sentinel-agent"),
+		rules:      rules,
+		keywords:   mergeRulePacks(packs),
+		policyGate: policyGate,
+		rustCLI:    newRustCLIResolver(copyCfg.RustCLISHA256, copyCfg.Subprocess.policyFor(procRustCLI)),
+		signKey:    signKey,
+		adaptive:   NewAdaptiveThreshold(copyCfg.AdaptiveThreshold, copyCfg.RiskThreshold),
+		dedup:      newViolationDeduper(copyCfg.ViolationDedup),
+		allowlist:  newOnchainAllowlist(&copyCfg),
+		lists:      lists,
+		sui:        sui,
+		mirrors:    mirrors,
+		retry:      retry,
+		llm:        llm,
+		opa:        opa,
+
+		rulesFileSHA256: rulesFileSHA,
+		capabilities:    newDegradationMatrix(copyCfg.MandatoryCapabilities),
+		metrics:         newSentinelMetrics(),
+	}
+	sg.sessions = newSessionRecorder(copyCfg.SessionRecording, copyCfg.AuditLogPath, sg.persistRecord)
+	return sg
+}
+
+// riskThreshold returns the score at which evaluations block. It is the
+// configured RiskThreshold unless adaptive mode is applying its own value.
+func (sg *SentinelGuard) riskThreshold() int {
+	if sg.adaptive != nil {
+		return sg.adaptive.Effective()
+	}
+	sg.runtimeMu.RLock()
+	defer sg.runtimeMu.RUnlock()
+	return sg.cfg.RiskThreshold
+}
+
+func (sg *SentinelGuard) currentRules() *SentinelRules {
+	sg.runtimeMu.RLock()
+	defer sg.runtimeMu.RUnlock()
+	return sg.rules
+}
+
+// applyRuntimeChanges installs an approved runtime config change. A list
+// edit that no longer applies fails the whole change.
+func (sg *SentinelGuard) applyRuntimeChanges(c ConfigChangeSet) error {
+	if c.PolicyList != nil {
+		if err := sg.lists.Apply(*c.PolicyList); err != nil {
+			return err
+		}
+	}
+	sg.runtimeMu.Lock()
+	if c.RiskThreshold != nil {
+		sg.cfg.RiskThreshold = *c.RiskThreshold
+	}
+	if c.Rules != nil {
+		sg.rules = c.Rules
+	}
+	if sg.runtimeKeys == nil {
+		sg.runtimeKeys = map[string]bool{}
+	}
+	if c.RiskThreshold != nil {
+		sg.runtimeKeys["risk_threshold"] = true
+	}
+	if c.Rules != nil {
+		sg.runtimeKeys["rules"] = true
 	}
+	sg.runtimeMu.Unlock()
+
+	if c.RiskThreshold != nil && sg.adaptive != nil {
+		sg.adaptive.SetConfigured(*c.RiskThreshold)
+	}
+	if c.Rules != nil && sg.policyGate != nil {
+		sg.policyGate.GetAgentProfile().SetRules(c.Rules)
+	}
+	return nil
 }
 
 func (sg *SentinelGuard) Evaluate(action, prompt string) RiskEvaluation {
@@ -107,24 +399,36 @@ func (sg *SentinelGuard) Evaluate(action, prompt string) RiskEvaluation {
 		reasons = append(reasons, reason)
 	}
 
-	if hasAny(lower, "ignore previous", "ignore all", "system prompt", "developer message", "bypass") {
-		add(35, "prompt_injection", "detected instruction override pattern")
+	var transfers *TransferRules
+	if rules := sg.currentRules(); rules != nil {
+		transfers = rules.Transfers
 	}
-	if hasAny(lower, "private key", "seed phrase", "mnemonic", "wallet", "sign transaction", "transfer usdc") {
-		add(30, "wallet_risk", "wallet/credential operation requested")
+	routineTransfer, overLimit := transfers.check(prompt)
+	ruleBlock := false
+	for _, m := range matchKeywordRules(sg.keywordRules(), lower, routineTransfer) {
+		add(m.Points, m.Tag, m.Reason)
+		ruleBlock = ruleBlock || m.Block
 	}
-	if hasAny(lower, "curl", "wget", "bash -c", "rm -rf", "chmod 777", "sudo") {
-		add(30, "dangerous_exec", "high-risk shell behavior requested")
+	if len(overLimit) > 0 {
+		add(20, "transfer_over_limit", "transfer exceeds configured cap: "+strings.Join(overLimit, ", "))
+	} else if routineTransfer {
+		tags = append(tags, "transfer_within_limit")
 	}
-	if hasAny(lower, "send to", "post to", "email", "telegram", "discord", "whatsapp", "x.com") {
-		add(15, "data_exfiltration", "external outbound channel detected")
+	if kind := detectUIAccess(lower); kind != "" {
+		add(20, "ui_exfiltration", kind+" access requested")
 	}
-	if hasAny(lower, "disable safety", "turn off security", "no confirmation") {
-		add(25, "policy_bypass", "explicit security bypass attempt")
+	if bad := sg.cfg.DomainAllowlist.disallowedDomains(action, prompt); len(bad) > 0 {
+		add(30, "domain_not_allowed", "references domains outside the allowlist: "+strings.Join(bad, ", "))
+	}
+	if pattern := sg.lists.denied(prompt); pattern != "" {
+		add(100, "denylisted", "matches denylisted pattern "+pattern)
+		ruleBlock = true
 	}
 
+	var behavior *PolicyResult
 	if sg.policyGate != nil {
 		pgResult := sg.policyGate.CheckCommand(prompt)
+		behavior = &pgResult
 		score = minInt(100, score+int(pgResult.RiskScore*100*0.4))
 		tags = append(tags, "behavioral_detection")
 		if pgResult.Action == "BLOCK" {
@@ -139,26 +443,77 @@ func (sg *SentinelGuard) Evaluate(action, prompt string) RiskEvaluation {
 	if score > 100 {
 		score = 100
 	}
+	if !ruleBlock && sg.llm.ambiguous(score) {
+		llmScore, llmReason, err := sg.llm.Classify(action, prompt)
+		switch {
+		case err == nil:
+			sg.capabilities.set(capLLM, true, "")
+			score = sg.llm.blend(score, llmScore)
+			tags = append(tags, "llm_classified")
+			reasons = append(reasons, fmt.Sprintf("llm classifier scored %d: %s", llmScore, llmReason))
+		case errors.Is(err, errLLMCooldown):
+			tags = append(tags, "llm_unavailable")
+		default:
+			sg.capabilities.set(capLLM, false, err.Error())
+			tags = append(tags, "llm_unavailable")
+		}
+	}
+
+	threshold := sg.actionThreshold(action)
+	if p, ok := sg.actionPolicy(action); ok && p.RequireApproval {
+		tags = append(tags, "action_requires_approval")
+		reasons = append(reasons, strings.ToUpper(strings.TrimSpace(action))+" always requires approval")
+	}
 
 	hasPromptInjection := containsTag(tags, "prompt_injection")
 	hasDangerousExec := containsTag(tags, "dangerous_exec")
 	hasBehaviorBlock := containsTag(tags, "behavior_block")
-	decision := score >= sg.cfg.RiskThreshold || containsTag(tags, "policy_bypass") || containsTag(tags, "wallet_risk") || hasBehaviorBlock || (hasPromptInjection && hasDangerousExec)
+	// Keyword rules with block set (policy_bypass and wallet_risk in the
+	// built-in pack) block whatever the score.
+	// ui_exfiltration always needs a human: the gateway routes it to approval.
+	hardBlock := ruleBlock || containsTag(tags, "action_requires_approval") || containsTag(tags, "transfer_over_limit") || containsTag(tags, "ui_exfiltration") || containsTag(tags, "domain_not_allowed") || (hasPromptInjection && hasDangerousExec)
+	// The behavioral gate's verdict comes from its own risk score.
+	scoreBlock := score >= threshold || hasBehaviorBlock
+	// A trusted action, a runtime allowlisted prompt or an on-chain approved
+	// action waives a score block, never a hard block. OPA still runs after.
+	if scoreBlock && !hardBlock {
+		if sg.lists.isTrusted(action) {
+			scoreBlock = false
+			tags = append(tags, "trusted_action")
+			reasons = append(reasons, fmt.Sprintf("score block waived for trusted action %s (score %d, threshold %d)", strings.ToUpper(strings.TrimSpace(action)), score, threshold))
+		} else if hash := sg.lists.allowed(action, prompt); hash != "" {
+			scoreBlock = false
+			tags = append(tags, "allowlisted")
+			reasons = append(reasons, "matches runtime allowlisted prompt "+hash)
+		} else if hash, ok := sg.onchainApproved(action, prompt); ok {
+			scoreBlock = false
+			tags = append(tags, "onchain_allowlisted")
+			reasons = append(reasons, "matches on-chain approved action "+hash)
+		}
+	}
+	decision := hardBlock || scoreBlock
 	reason := "no notable risk indicators"
 	if len(reasons) > 0 {
 		reason = strings.Join(reasons, "; ")
 	}
 
-	return RiskEvaluation{
+	eval := RiskEvaluation{
 		Score:       score,
 		Tags:        dedupe(tags),
 		Reason:      reason,
 		ShouldBlock: decision,
 	}
+	if sg.opa != nil {
+		sg.applyOPA(&eval, action, prompt, behavior)
+	}
+	return eval
 }
 
 func (sg *SentinelGuard) Enforce(action, prompt string) (RiskEvaluation, *AuditRecord, error) {
 	eval := sg.Evaluate(action, prompt)
+	if sg.adaptive != nil {
+		sg.adaptive.Observe(eval.Score, eval.ShouldBlock)
+	}
 	rec := &AuditRecord{
 		Timestamp: time.Now().UTC(),
 		Action:    action,
@@ -172,55 +527,226 @@ func (sg *SentinelGuard) Enforce(action, prompt string) (RiskEvaluation, *AuditR
 	} else {
 		rec.Decision = "allowed"
 	}
+	defer func() { sg.metrics.observeEvaluation(rec.Decision, eval.Tags) }()
 
-	materializeRecord := func() {
-		rec.RecordHash = sg.computeHash(rec)
-		rec.Signature = ""
-		rec.PublicKey = ""
-		if signed, err := sg.signHash(rec.RecordHash); err == nil {
-			rec.Signature = signed.Signature
-			rec.PublicKey = signed.PublicKey
+	if sg.dedup != nil {
+		flush, dup, summary := sg.dedup.track(rec, eval.ShouldBlock)
+		if flush != nil {
+			if err := sg.persistRecord(flush); err != nil {
+				return eval, rec, err
+			}
+		}
+		if dup != nil {
+			// Repeat of the current violation streak: no new record or anchor,
+			// except for the periodic summary.
+			if summary != nil {
+				if err := sg.persistRecord(summary); err != nil {
+					return eval, dup, err
+				}
+			}
+			return eval, dup, nil
 		}
 	}
 
-	materializeRecord()
-
+	seq := sg.reserveRecord(rec)
+	var anchorErr error
 	if sg.cfg.AnchorEnabled {
-		anchor := sg.anchorToSui
-		if sg.anchorFn != nil {
-			anchor = sg.anchorFn
-		}
-		tx, err := anchor(rec)
-		if err != nil {
-			log.Printf("[ANCHOR] error: %v", err)
-			rec.AnchorError = err.Error()
-			if sg.cfg.AnchorFailClosed && !eval.ShouldBlock {
-				eval.ShouldBlock = true
-				eval.Score = maxInt(eval.Score, sg.cfg.RiskThreshold)
-				eval.Tags = dedupe(append(eval.Tags, "anchor_failure"))
-				eval.Reason = eval.Reason + "; on-chain anchor failed (fail-closed)"
-
-				rec.Score = eval.Score
-				rec.Tags = eval.Tags
-				rec.Reason = eval.Reason
-				rec.Decision = "blocked"
-				materializeRecord()
-			}
-		} else {
-			rec.TxDigest = tx
-		}
+		anchorErr = sg.anchorRecord(rec)
+	}
+	if anchorErr != nil && sg.cfg.AnchorFailClosed && !eval.ShouldBlock {
+		eval.ShouldBlock = true
+		eval.Score = maxInt(eval.Score, sg.actionThreshold(action))
+		eval.Tags = dedupe(append(eval.Tags, "anchor_failure"))
+		eval.Reason = eval.Reason + "; on-chain anchor failed (fail-closed)"
+
+		// Nothing was anchored under rec's hash, so the record itself
+		// becomes the block before it is written.
+		rec.Decision = "blocked"
+		rec.Score, rec.Tags, rec.Reason = eval.Score, eval.Tags, eval.Reason
+		sg.resealReserved(seq, rec)
 	}
+	sg.mirrorRecord(rec)
 
-	if err := sg.appendAudit(rec); err != nil {
+	if err := sg.appendReserved(seq, rec); err != nil {
 		return eval, rec, err
 	}
+	sg.queueAnchorRetry(rec)
+	if sg.dedup != nil {
+		sg.dedup.remember(rec)
+	}
 
 	return eval, rec, nil
 }
 
+// onchainApproved reports whether the action's template hash was approved
+// on-chain by the registry admin. A failed lookup approves nothing.
+func (sg *SentinelGuard) onchainApproved(action, prompt string) (string, bool) {
+	if sg.allowlist == nil {
+		return "", false
+	}
+	hash := actionTemplateHash(action, prompt)
+	approved, err := sg.allowlist.Approved(hash)
+	if err != nil {
+		log.Printf("[ALLOWLIST] lookup failed: %v", err)
+		return "", false
+	}
+	return hash, approved
+}
+
+func (sg *SentinelGuard) materializeRecord(rec *AuditRecord) {
+	if !sg.chainLoaded {
+		head, err := lastAuditRecordHash(sg.cfg.AuditLogPath)
+		if err != nil {
+			log.Printf("[SENTINEL] cannot read audit log head, starting a new hash chain: %v", err)
+		}
+		sg.lastHash, sg.written, sg.chainLoaded = head, head, true
+	}
+	sg.sealRecord(rec, sg.lastHash)
+}
+
+// sealRecord links rec to prev, then hashes and signs it.
+func (sg *SentinelGuard) sealRecord(rec *AuditRecord, prev string) {
+	rec.PrevHash = prev
+	rec.PromptSHA256 = promptCommitment(rec.Prompt)
+	rec.RecordHash = sg.computeHash(hashedAuditForm(rec))
+	rec.Signature = ""
+	rec.PublicKey = ""
+	if signed, err := sg.signHash(rec.RecordHash); err == nil {
+		rec.Signature = signed.Signature
+		rec.PublicKey = signed.PublicKey
+	}
+}
+
+// anchorRecord submits rec on-chain and records the digest or error on it.
+func (sg *SentinelGuard) anchorRecord(rec *AuditRecord) error {
+	primary := sg.primaryBackend()
+	tx, err := primary.Anchor(rec)
+	sg.metrics.observeAnchor(err)
+	if err != nil {
+		log.Printf("[ANCHOR] error: %v", err)
+		sg.alertAnchorFailure(primary.Name(), rec, err)
+		rec.AnchorError = err.Error()
+		sg.capabilities.set(capAnchor, false, err.Error())
+		return err
+	}
+	sg.capabilities.set(capAnchor, true, "")
+	rec.TxDigest = tx
+	sg.publishAnchorConfirmed(primary.Name(), rec, tx)
+	if sg.sui != nil && tx != "" {
+		// Best effort; verify-anchors re-checks inclusion later.
+		rec.Checkpoint, _ = sg.sui.TransactionCheckpoint(tx)
+	}
+	return nil
+}
+
+func (sg *SentinelGuard) primaryBackend() ChainBackend {
+	if sg.anchorFn != nil {
+		return suiBackend{anchor: sg.anchorFn}
+	}
+	return suiBackend{anchor: sg.anchorToSui}
+}
+
+// persistRecord hashes, signs, anchors and appends a record that is not the
+// result of an evaluation (violation streak summaries, config changes).
+// Anchor failures are recorded on rec but do not fail the call.
+func (sg *SentinelGuard) persistRecord(rec *AuditRecord) error {
+	return sg.commitRecord(sg.reserveRecord(rec), rec)
+}
+
+// commitRecord anchors and appends a reserved record. The caller must not
+// hold chainMu.
+func (sg *SentinelGuard) commitRecord(seq uint64, rec *AuditRecord) error {
+	if sg.cfg.AnchorEnabled {
+		_ = sg.anchorRecord(rec)
+	}
+	sg.mirrorRecord(rec)
+	if err := sg.appendReserved(seq, rec); err != nil {
+		return err
+	}
+	sg.queueAnchorRetry(rec)
+	return nil
+}
+
+// reserveRecord hashes rec onto the head of the chain and returns its
+// append position.
+func (sg *SentinelGuard) reserveRecord(rec *AuditRecord) uint64 {
+	sg.chainMu.Lock()
+	defer sg.chainMu.Unlock()
+	return sg.reserveRecordLocked(rec)
+}
+
+// reserveRecordLocked is reserveRecord for callers already holding chainMu.
+func (sg *SentinelGuard) reserveRecordLocked(rec *AuditRecord) uint64 {
+	sg.materializeRecord(rec)
+	sg.lastHash = rec.RecordHash
+	seq := sg.reserved
+	sg.reserved++
+	return seq
+}
+
+// resealReserved rehashes rec after its contents changed between
+// reservation and append. Records reserved after it are re-chained when
+// they are appended.
+func (sg *SentinelGuard) resealReserved(seq uint64, rec *AuditRecord) {
+	sg.chainMu.Lock()
+	defer sg.chainMu.Unlock()
+	sg.sealRecord(rec, rec.PrevHash)
+	if sg.reserved == seq+1 {
+		sg.lastHash = rec.RecordHash
+	}
+}
+
+// appendReserved appends rec once every record reserved before it has been
+// appended. The position is released even if the write fails. A record
+// whose link is not the last one written (an earlier write failed, or its
+// predecessor was resealed) is re-chained first; an anchor it already has
+// names the old hash, so it goes back to the retry queue.
+func (sg *SentinelGuard) appendReserved(seq uint64, rec *AuditRecord) error {
+	sg.chainMu.Lock()
+	defer sg.chainMu.Unlock()
+	cond := sg.chainCondLocked()
+	for sg.appended != seq {
+		cond.Wait()
+	}
+	if rec.PrevHash != sg.written {
+		sg.sealRecord(rec, sg.written)
+		if rec.TxDigest != "" {
+			rec.TxDigest, rec.Checkpoint = "", ""
+			rec.AnchorError = "re-chained before it was written; anchored hash is stale"
+		}
+	}
+	err := sg.appendAudit(rec)
+	if err == nil {
+		sg.written = rec.RecordHash
+	}
+	if sg.reserved == seq+1 {
+		// Nothing reserved links past rec, so the head is what was written.
+		sg.lastHash = sg.written
+	}
+	sg.appended++
+	cond.Broadcast()
+	return err
+}
+
+// drainChainLocked waits until every reserved record has been appended, so
+// the caller, holding chainMu, sees the whole chain in the log.
+func (sg *SentinelGuard) drainChainLocked() {
+	cond := sg.chainCondLocked()
+	for sg.appended != sg.reserved {
+		cond.Wait()
+	}
+}
+
+func (sg *SentinelGuard) chainCondLocked() *sync.Cond {
+	if sg.chainCond == nil {
+		sg.chainCond = sync.NewCond(&sg.chainMu)
+	}
+	return sg.chainCond
+}
+
 func (sg *SentinelGuard) appendAudit(rec *AuditRecord) error {
 	path := sg.cfg.AuditLogPath
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	if err := sg.lockAuditLog(false); err != nil {
 		return err
 	}
 
@@ -239,14 +765,28 @@ func (sg *SentinelGuard) appendAudit(rec *AuditRecord) error {
 	if _, err := w.WriteString(string(b) + "\n"); err != nil {
 		return err
 	}
-	return w.Flush()
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (sg *SentinelGuard) computeHash(rec *AuditRecord) string {
-	if out, err := sg.hashViaRust(rec); err == nil && out.RecordHash != "" {
+	out, err := sg.hashViaRust(rec)
+	if err == nil && out.RecordHash != "" {
+		sg.capabilities.set(capRustHash, true, "")
 		return out.RecordHash
 	}
+	if err == nil {
+		err = fmt.Errorf("hash-audit returned no record_hash")
+	}
+	sg.capabilities.set(capRustHash, false, err.Error())
+	return jcsAuditHash(rec)
+}
 
+// fallbackAuditHash is the pipe-delimited hash older versions wrote when the
+// Rust CLI was unavailable. It is only used to verify those records.
+func fallbackAuditHash(rec *AuditRecord) string {
 	base := fmt.Sprintf("%s|%s|%s|%d|%s|%s|%s",
 		rec.Timestamp.Format(time.RFC3339Nano),
 		rec.Action,
@@ -256,6 +796,9 @@ func (sg *SentinelGuard) computeHash(rec *AuditRecord) string {
 		rec.Decision,
 		rec.Reason,
 	)
+	if rec.PrevHash != "" {
+		base += "|" + rec.PrevHash
+	}
 	sum := sha256.Sum256([]byte(base))
 	return "0x" + hex.EncodeToString(sum[:])
 }
@@ -269,28 +812,42 @@ func (sg *SentinelGuard) anchorToSui(rec *AuditRecord) (string, error) {
 	}
 
 	actionTag := actionToTag(rec.Action)
-	riskScore := rec.Score
-	if riskScore < 0 {
-		riskScore = 0
-	}
-	if riskScore > 100 {
-		riskScore = 100
-	}
-	blocked := "false"
-	if rec.Decision == "blocked" {
-		blocked = "true"
+	riskScore := anchorRiskScore(rec.Score)
+	blocked := rec.Decision == "blocked"
+
+	args := anchorMoveArgs(&sg.cfg, rec, actionTag, riskScore, blocked)
+
+	if sg.sui != nil {
+		rpcArgs, err := args.rpc()
+		if err != nil {
+			return "", err
+		}
+		tx, err := sg.sui.MoveCallClass(txClassAnchor, sg.cfg.AnchorPackage, sg.cfg.AnchorModule, sg.cfg.AnchorFunc, rpcArgs)
+		if err == nil || !sg.cfg.SuiRPC.CLIFallback {
+			return tx, err
+		}
+		log.Printf("[ANCHOR] sui rpc failed, falling back to sui CLI: %v", err)
 	}
+	return sg.anchorViaCLI(args)
+}
 
-	cmd := exec.Command(
-		"sui", "client", "call",
+// anchorViaCLI submits the anchor call with `sui client call` and parses the
+// digest from its output.
+func (sg *SentinelGuard) anchorViaCLI(moveArgs *suiMoveArgs) (string, error) {
+	cliArgs, err := moveArgs.cli()
+	if err != nil {
+		return "", err
+	}
+	args := append([]string{"client"}, sg.cfg.SuiCLI.clientArgs()...)
+	args = append(args, "call",
 		"--package", sg.cfg.AnchorPackage,
 		"--module", sg.cfg.AnchorModule,
 		"--function", sg.cfg.AnchorFunc,
-		"--args", sg.cfg.AnchorRegistry, rec.RecordHash, fmt.Sprintf("%d", actionTag), fmt.Sprintf("%d", riskScore), blocked, "0x6",
-		"--gas-budget", "10000000",
-		"--json",
-	)
-	out, err := cmd.CombinedOutput()
+		"--args")
+	args = append(args, cliArgs...)
+	args = append(args, "--gas-budget", "10000000", "--json")
+	args = append(args, sg.cfg.SuiCLI.txArgs()...)
+	out, err := runSubprocess(sg.cfg.Subprocess.policyFor(procSui), false, "sui", args...)
 	if err != nil {
 		return "", fmt.Errorf("sui call failed: %v, output: %s", err, string(out))
 	}
@@ -345,9 +902,12 @@ func (sg *SentinelGuard) hashViaRust(rec *AuditRecord) (*hashCLIOutput, error) {
 	if sg.cfg.HashCLIPath == "" {
 		return nil, fmt.Errorf("hash_cli_path not configured")
 	}
+	cliPath, err := sg.rustCLI.command(sg.cfg.HashCLIPath, "hash-audit")
+	if err != nil {
+		return nil, err
+	}
 
-	cmd := exec.Command(
-		sg.cfg.HashCLIPath,
+	args := []string{
 		"hash-audit",
 		"--action", rec.Action,
 		"--prompt", rec.Prompt,
@@ -356,8 +916,17 @@ func (sg *SentinelGuard) hashViaRust(rec *AuditRecord) (*hashCLIOutput, error) {
 		"--decision", rec.Decision,
 		"--reason", rec.Reason,
 		"--timestamp", rec.Timestamp.Format(time.RFC3339Nano),
-	)
-	out, err := cmd.CombinedOutput()
+	}
+	if rec.PrevHash != "" {
+		args = append(args, "--prev-hash", rec.PrevHash)
+	}
+	if rec.Occurrences != 0 {
+		args = append(args, "--occurrences", fmt.Sprintf("%d", rec.Occurrences))
+	}
+	if rec.DedupOf != "" {
+		args = append(args, "--dedup-of", rec.DedupOf)
+	}
+	out, err := runSubprocess(sg.cfg.Subprocess.policyFor(procRustCLI), false, cliPath, args...)
 	if err != nil {
 		return nil, fmt.Errorf("hash-audit failed: %v, output: %s", err, string(out))
 	}
@@ -369,18 +938,37 @@ func (sg *SentinelGuard) hashViaRust(rec *AuditRecord) (*hashCLIOutput, error) {
 	return &parsed, nil
 }
 
+func (sg *SentinelGuard) signingConfigured() bool {
+	return sg.signKey != nil
+}
+
+// rustSigningEnabled reports whether records are signed through the Rust
+// CLI rather than in Go.
+func (sg *SentinelGuard) rustSigningEnabled() bool {
+	return sg.signingConfigured() && sg.cfg.SignWithRustCLI && sg.cfg.SignCLIPath != ""
+}
+
 func (sg *SentinelGuard) signHash(recordHash string) (*signCLIOutput, error) {
-	if sg.cfg.SignCLIPath == "" || strings.TrimSpace(sg.cfg.SignPrivKey) == "" {
+	if !sg.signingConfigured() {
 		return nil, fmt.Errorf("signing not configured")
 	}
+	if !sg.rustSigningEnabled() {
+		return signHashNative(recordHash, sg.signKey)
+	}
+	cliPath, err := sg.rustCLI.command(sg.cfg.SignCLIPath, "sign-audit")
+	if err != nil {
+		// Degrade to the Go implementation, which yields identical signatures.
+		sg.capabilities.set(capRustSign, false, err.Error())
+		return signHashNative(recordHash, sg.signKey)
+	}
+	sg.capabilities.set(capRustSign, true, "")
 
-	cmd := exec.Command(
-		sg.cfg.SignCLIPath,
+	out, err := runSubprocess(sg.cfg.Subprocess.policyFor(procRustCLI), false,
+		cliPath,
 		"sign-audit",
 		"--record-hash", recordHash,
-		"--private-key", sg.cfg.SignPrivKey,
+		"--private-key", hex.EncodeToString(sg.signKey.Seed()),
 	)
-	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("sign-audit failed: %v, output: %s", err, string(out))
 	}
@@ -430,6 +1018,17 @@ func truncate(s string, max int) string {
 	return s[:max]
 }
 
+// anchorRiskScore clamps a score to the 0-100 range anchored on-chain.
+func anchorRiskScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
 func actionToTag(action string) int {
 	switch strings.ToUpper(strings.TrimSpace(action)) {
 	case "WAKE_UP":
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// sentinelHookKinds are the git hooks --sentinel-hook can generate.
+var sentinelHookKinds = []string{"pre-push", "pre-commit"}
+
+// sentinelHookDiffLimit caps the staged diff sent by the pre-commit hook.
+const sentinelHookDiffLimit = 256 * 1024
+
+// runSentinelHookMode prints a git hook script that asks the local Sentinel
+// proxy (POST /sentinel/gate) to approve each push or commit. Install it with
+//
+//	sentinel --sentinel-hook pre-push > .git/hooks/pre-push && chmod +x .git/hooks/pre-push
+func runSentinelHookMode(kind, gateURL string, out io.Writer) error {
+	script, err := renderSentinelHook(kind, gateURL)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, script)
+	return err
+}
+
+func renderSentinelHook(kind, gateURL string) (string, error) {
+	kind = strings.TrimSpace(kind)
+	if !containsTag(sentinelHookKinds, kind) {
+		return "", fmt.Errorf("unknown hook %q (want one of %s)", kind, strings.Join(sentinelHookKinds, ", "))
+	}
+	gateURL, err := cleanSentinelHookURL(gateURL)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = sentinelHookTemplate.Execute(&b, map[string]any{
+		"Kind":      kind,
+		"GateURL":   gateURL,
+		"DiffLimit": sentinelHookDiffLimit,
+	})
+	return b.String(), err
+}
+
+// cleanSentinelHookURL validates a proxy base URL before it is embedded in
+// a generated script.
+func cleanSentinelHookURL(gateURL string) (string, error) {
+	gateURL = strings.TrimRight(strings.TrimSpace(gateURL), "/")
+	if gateURL == "" {
+		return "", fmt.Errorf("gate URL is required")
+	}
+	if strings.ContainsAny(gateURL, "'\n") {
+		return "", fmt.Errorf("gate URL must not contain quotes or newlines")
+	}
+	return gateURL, nil
+}
+
+// The hook is POSIX sh with curl as its only dependency. Each push refspec is
+// rewritten as the equivalent `git push` command (with --force for
+// non-fast-forward updates and ":branch" for deletions) so the server-side
+// git rules apply unchanged. Set SENTINEL_HOOK_FAIL_OPEN=1 to let git proceed
+// when the proxy is unreachable.
+var sentinelHookTemplate = template.Must(template.New("hook").Parse(`#!/bin/sh
+# Sentinel {{.Kind}} hook, generated by: sentinel --sentinel-hook {{.Kind}}
+# Checks the operation against the local Sentinel proxy before git runs it.
+
+SENTINEL_GATE_URL="${SENTINEL_GATE_URL:-{{.GateURL}}}/sentinel/gate"
+SENTINEL_AGENT_ID="${SENTINEL_AGENT_ID:-git-hook}"
+
+json_str() {
+	printf '%s' "$1" | tr -d '\000-\010\013\014\016-\037' |
+		sed -e 's/\\/\\\\/g' -e 's/"/\\"/g' -e 's/	/\\t/g' |
+		awk 'BEGIN { ORS = "" } NR > 1 { print "\\n" } { print }'
+}
+
+sentinel_check() {
+	body="{\"action\":\"$1\",\"agent_id\":\"$(json_str "$SENTINEL_AGENT_ID")\",\"prompt\":\"$(json_str "$2")\"}"
+	resp=$(printf '%s' "$body" | curl -sS --max-time 15 -H 'Content-Type: application/json' \
+		--data-binary @- "$SENTINEL_GATE_URL" 2>&1)
+	if [ $? -ne 0 ]; then
+		echo "sentinel: gate unreachable: $resp" >&2
+		[ "${SENTINEL_HOOK_FAIL_OPEN:-0}" = "1" ] && return 0
+		return 1
+	fi
+	case "$resp" in
+	*'"decision":"ALLOW"'*) return 0 ;;
+	esac
+	decision=$(printf '%s' "$resp" | sed -n 's/.*"decision":"\([A-Z_]*\)".*/\1/p')
+	reason=$(printf '%s' "$resp" | sed -n 's/.*"reason":"\([^"]*\)".*/\1/p')
+	challenge=$(printf '%s' "$resp" | sed -n 's/.*"challenge_id":"\([^"]*\)".*/\1/p')
+	echo "sentinel: ${decision:-ERROR}: ${reason:-$resp}" >&2
+	[ -n "$challenge" ] && echo "sentinel: approval challenge $challenge" >&2
+	return 1
+}
+{{if eq .Kind "pre-push"}}
+remote="$1"
+status=0
+while read -r local_ref local_sha remote_ref remote_sha; do
+	branch=${remote_ref#refs/heads/}
+	case "$local_sha" in
+	*[!0]*)
+		force=""
+		case "$remote_sha" in
+		*[!0]*)
+			git merge-base --is-ancestor "$remote_sha" "$local_sha" 2>/dev/null || force="--force "
+			;;
+		esac
+		cmd="git push ${force}${remote} ${local_ref#refs/heads/}:${branch}"
+		;;
+	*)
+		cmd="git push ${remote} :${branch}"
+		;;
+	esac
+	sentinel_check "GIT_PUSH" "$cmd" || status=1
+done
+exit $status
+{{else}}
+diff=$(git diff --cached --no-color --no-ext-diff | head -c {{.DiffLimit}})
+[ -z "$diff" ] && exit 0
+sentinel_check "GIT_COMMIT" "git commit
+$diff" || exit 1
+exit 0
+{{end}}`))
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderSentinelHookRejectsUnknownKind(t *testing.T) {
+	if _, err := renderSentinelHook("post-merge", "http://127.0.0.1:18080"); err == nil {
+		t.Fatal("expected error for unsupported hook")
+	}
+	if _, err := renderSentinelHook("pre-push", "http://x'; rm -rf /"); err == nil {
+		t.Fatal("expected error for quoted gate URL")
+	}
+}
+
+func TestSentinelPrePushHookAgainstGateway(t *testing.T) {
+	for _, bin := range []string{"sh", "curl", "git"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not available", bin)
+		}
+	}
+
+	gw := newTestGateway()
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	script, err := renderSentinelHook("pre-push", srv.URL)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if out, err := exec.Command("sh", "-n", "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("hook is not valid sh: %v\n%s", err, out)
+	}
+	hook := filepath.Join(t.TempDir(), "pre-push")
+	if err := os.WriteFile(hook, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	sha := strings.Repeat("a", 40)
+	zero := strings.Repeat("0", 40)
+	cases := []struct {
+		name  string
+		stdin string
+		ok    bool
+	}{
+		{"new feature branch", "refs/heads/feature/x " + sha + " refs/heads/feature/x " + zero + "\n", true},
+		{"delete main", "(delete) " + zero + " refs/heads/main " + sha + "\n", false},
+	}
+	for _, tc := range cases {
+		cmd := exec.Command("sh", hook, "origin", "git@example.com:acme/app.git")
+		cmd.Stdin = strings.NewReader(tc.stdin)
+		out, err := cmd.CombinedOutput()
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: ok=%v, want %v\n%s", tc.name, err == nil, tc.ok, out)
+		}
+	}
+}
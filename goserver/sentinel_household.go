@@ -0,0 +1,442 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Household notification events.
+const (
+	notifyVaultDeadline       = "vault_deadline_near"
+	notifyPartnerDeadline     = "partner_vault_deadline_near"
+	notifyVaultOverdue        = "vault_heartbeat_overdue"
+	notifyPartnerOverdue      = "partner_vault_heartbeat_overdue"
+	notifyBeneficiaryMismatch = "vault_beneficiary_mismatch"
+)
+
+// HouseholdConfig lets one daemon watch the vaults of several owners, e.g.
+// both partners in a household. Each owner gets their own channels and
+// warning windows, and partners are told when the other's vault is
+// close to its deadline.
+type HouseholdConfig struct {
+	Enabled bool             `json:"enabled"`
+	Owners  []HouseholdOwner `json:"owners"`
+	// RPCURL is the Sui JSON-RPC endpoint scanned for heartbeats.
+	RPCURL string `json:"rpc_url"`
+	// IntervalSec is how often vaults are checked; default 3600.
+	IntervalSec int `json:"interval_sec,omitempty"`
+}
+
+// HouseholdOwner is one member of the household.
+type HouseholdOwner struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"` // shown to partners; default ID
+	// Address is the Sui address that sends the owner's heartbeats.
+	Address string `json:"address"`
+	// Vaults limits the check to these vault IDs; empty means every vault
+	// the address has sent heartbeats to.
+	Vaults []string `json:"vaults,omitempty"`
+	// VaultSettings overrides the owner's settings for individual vaults,
+	// e.g. a personal and a business vault. It does not limit which vaults
+	// are watched.
+	VaultSettings []HouseholdVaultConfig `json:"vault_settings,omitempty"`
+	// Notifications are the owner's own channels. Without them the
+	// owner's alerts go to the gateway's notification channels.
+	Notifications *NotificationsConfig `json:"notifications,omitempty"`
+	// WarnDays is how long before a deadline the owner is warned;
+	// default 7.
+	WarnDays int `json:"warn_days,omitempty"`
+	// Partners are the owner IDs told when this owner's vault is near its
+	// deadline; default every other owner. Use ["none"] to tell no one.
+	Partners []string `json:"partners,omitempty"`
+}
+
+// HouseholdVaultConfig holds the settings of one of an owner's vaults.
+type HouseholdVaultConfig struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"` // e.g. "business"; shown in alerts
+	// WarnDays replaces the owner's warn_days for this vault.
+	WarnDays int `json:"warn_days,omitempty"`
+	// HeartbeatIntervalDays is how often the owner means to send this
+	// vault a heartbeat. A longer gap warns even outside warn_days.
+	HeartbeatIntervalDays int `json:"heartbeat_interval_days,omitempty"`
+	// Beneficiary is the address the vault should release to. A vault
+	// whose on-chain beneficiary differs is reported in its status.
+	Beneficiary string `json:"beneficiary,omitempty"`
+}
+
+// HouseholdVault is the deadline view of one vault.
+type HouseholdVault struct {
+	VaultID   string    `json:"vault_id"`
+	Label     string    `json:"label,omitempty"`
+	Deadline  time.Time `json:"deadline"`
+	HoursLeft float64   `json:"hours_left"`
+	Risk      string    `json:"risk"`
+	Warned    bool      `json:"warned"`
+	// Overdue is set when the last heartbeat is older than the vault's
+	// heartbeat_interval_days.
+	Overdue bool   `json:"overdue,omitempty"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// HouseholdOwnerStatus is served per owner in /sentinel/status.
+type HouseholdOwnerStatus struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	LastActivity *time.Time       `json:"last_activity,omitempty"`
+	Vaults       []HouseholdVault `json:"vaults"`
+	LastCheck    *time.Time       `json:"last_check,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	Channels     []ChannelStatus  `json:"channels,omitempty"`
+}
+
+type householdOwner struct {
+	cfg      HouseholdOwner
+	notify   *sentinelNotifier
+	vaults   map[string]bool
+	settings map[string]HouseholdVaultConfig
+	warn     time.Duration
+	partners []string
+}
+
+// household checks every owner's vault deadlines on an interval and sends
+// each warning once per deadline: to the owner, and to their partners.
+type household struct {
+	owners   []*householdOwner
+	byID     map[string]*householdOwner
+	fallback *sentinelNotifier
+	activity *ActivityMonitor
+	events   *eventHub
+	interval time.Duration
+	fetch    func(address string) (map[string]*heartbeatHistory, error)
+	vault    func(id string) (*vaultObject, error)
+	now      func() time.Time
+
+	mu     sync.Mutex
+	status map[string]*HouseholdOwnerStatus
+	warned map[string]bool // event + vault ID + deadline or beneficiary
+}
+
+// newHousehold returns nil when household mode is disabled. fallback
+// receives the alerts of owners without their own channels.
+func newHousehold(cfg *HouseholdConfig, chain *ChainReadConfig, fallback *sentinelNotifier, activity *ActivityMonitor) (*household, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	if len(cfg.Owners) == 0 {
+		return nil, fmt.Errorf("household.owners is empty")
+	}
+	if strings.TrimSpace(cfg.RPCURL) == "" {
+		return nil, fmt.Errorf("household.rpc_url is required")
+	}
+	reader := newChainReader(cfg.RPCURL, chain)
+	h := &household{
+		byID:     map[string]*householdOwner{},
+		fallback: fallback,
+		activity: activity,
+		interval: time.Duration(cfg.IntervalSec) * time.Second,
+		fetch: func(address string) (map[string]*heartbeatHistory, error) {
+			return collectHeartbeatHistory(reader, address)
+		},
+		vault: func(id string) (*vaultObject, error) {
+			return fetchVaultObject(reader, id)
+		},
+		now:    func() time.Time { return time.Now().UTC() },
+		status: map[string]*HouseholdOwnerStatus{},
+		warned: map[string]bool{},
+	}
+	if h.interval <= 0 {
+		h.interval = time.Hour
+	}
+	for i, oc := range cfg.Owners {
+		oc.ID = strings.TrimSpace(oc.ID)
+		if oc.ID == "" || strings.TrimSpace(oc.Address) == "" {
+			return nil, fmt.Errorf("household.owners[%d]: id and address are required", i)
+		}
+		if h.byID[oc.ID] != nil {
+			return nil, fmt.Errorf("household.owners[%d]: duplicate id %q", i, oc.ID)
+		}
+		if oc.Name == "" {
+			oc.Name = oc.ID
+		}
+		oc.Address = "0x" + normalizeKeyHex(oc.Address)
+		notify, err := newSentinelNotifier(oc.Notifications)
+		if err != nil {
+			return nil, fmt.Errorf("household.owners[%d]: %w", i, err)
+		}
+		if notify != nil && fallback != nil {
+			notify.journal = fallback.journal
+		}
+		o := &householdOwner{
+			cfg:    oc,
+			notify: notify,
+			warn:   7 * 24 * time.Hour,
+		}
+		if oc.WarnDays > 0 {
+			o.warn = time.Duration(oc.WarnDays) * 24 * time.Hour
+		}
+		if len(oc.Vaults) > 0 {
+			o.vaults = map[string]bool{}
+			for _, v := range oc.Vaults {
+				o.vaults[strings.ToLower(v)] = true
+			}
+		}
+		o.settings = map[string]HouseholdVaultConfig{}
+		for j, vc := range oc.VaultSettings {
+			key := strings.ToLower(strings.TrimSpace(vc.ID))
+			switch {
+			case key == "":
+				return nil, fmt.Errorf("household.owners[%d].vault_settings[%d]: id is required", i, j)
+			case o.settings[key].ID != "":
+				return nil, fmt.Errorf("household.owners[%d].vault_settings[%d]: duplicate vault %s", i, j, vc.ID)
+			case vc.WarnDays < 0 || vc.HeartbeatIntervalDays < 0:
+				return nil, fmt.Errorf("household.owners[%d].vault_settings[%d]: day counts cannot be negative", i, j)
+			}
+			if vc.Beneficiary != "" {
+				vc.Beneficiary = "0x" + normalizeKeyHex(vc.Beneficiary)
+			}
+			o.settings[key] = vc
+		}
+		h.owners = append(h.owners, o)
+		h.byID[oc.ID] = o
+	}
+	for _, o := range h.owners {
+		if len(o.cfg.Partners) == 0 {
+			for _, p := range h.owners {
+				if p != o {
+					o.partners = append(o.partners, p.cfg.ID)
+				}
+			}
+			continue
+		}
+		for _, id := range o.cfg.Partners {
+			if id == "none" {
+				continue
+			}
+			if h.byID[id] == nil || id == o.cfg.ID {
+				return nil, fmt.Errorf("household owner %q: unknown partner %q", o.cfg.ID, id)
+			}
+			o.partners = append(o.partners, id)
+		}
+	}
+	return h, nil
+}
+
+// Check refreshes every owner's vaults and sends the warnings that are due.
+func (h *household) Check() {
+	now := h.now()
+	for _, o := range h.owners {
+		st := &HouseholdOwnerStatus{ID: o.cfg.ID, Name: o.cfg.Name, Vaults: []HouseholdVault{}, LastCheck: &now}
+		histories, err := h.fetch(o.cfg.Address)
+		if err != nil {
+			st.Error = err.Error()
+		}
+		for _, id := range sortedKeys(histories) {
+			if o.vaults != nil && !o.vaults[strings.ToLower(id)] {
+				continue
+			}
+			// The contract fixes every vault's deadline at 30 days.
+			stats := heartbeatStats(id, histories[id], defaultHeartbeatThreshold, now)
+			if stats.Forecast == nil {
+				continue // executed, or no heartbeat yet
+			}
+			set := o.settings[strings.ToLower(id)]
+			v := HouseholdVault{
+				VaultID:   id,
+				Label:     set.Label,
+				Deadline:  stats.Forecast.Deadline,
+				HoursLeft: roundHours(stats.Forecast.Deadline.Sub(now).Milliseconds()),
+				Risk:      stats.Forecast.Risk,
+			}
+			warn := o.warn
+			if set.WarnDays > 0 {
+				warn = time.Duration(set.WarnDays) * 24 * time.Hour
+			}
+			if set.HeartbeatIntervalDays > 0 && stats.CurrentGapHours > float64(24*set.HeartbeatIntervalDays) {
+				v.Overdue = true
+			}
+			if set.Beneficiary != "" {
+				v.Problem = h.checkBeneficiary(o, v, set.Beneficiary)
+			}
+			// An overdue vault is announced on its own; once its deadline
+			// is near, the deadline warning covers it.
+			switch {
+			case stats.Forecast.Deadline.Sub(now) <= warn:
+				v.Warned = true
+				h.warnOnce(o, v, notifyVaultDeadline, notifyPartnerDeadline)
+			case v.Overdue:
+				v.Warned = true
+				h.warnOnce(o, v, notifyVaultOverdue, notifyPartnerOverdue)
+			}
+			st.Vaults = append(st.Vaults, v)
+		}
+		h.mu.Lock()
+		h.status[o.cfg.ID] = st
+		h.mu.Unlock()
+	}
+}
+
+// checkBeneficiary describes how the vault's on-chain beneficiary differs
+// from the configured one; it is empty when they match. The owner is told
+// once about each beneficiary seen on chain.
+func (h *household) checkBeneficiary(o *householdOwner, v HouseholdVault, want string) string {
+	vault, err := h.vault(v.VaultID)
+	if err != nil {
+		return "cannot read the vault: " + err.Error()
+	}
+	got := "0x" + normalizeKeyHex(vault.Beneficiary)
+	if got == want {
+		return ""
+	}
+	problem := fmt.Sprintf("beneficiary is %s on chain, expected %s", got, want)
+	if h.firstTime(notifyBeneficiaryMismatch + ":" + v.VaultID + "@" + got) {
+		h.channel(o).Send(beneficiaryNotification(o, v, problem))
+		h.events.Publish(notifyBeneficiaryMismatch, map[string]interface{}{"owner": o.cfg.ID, "vault_id": v.VaultID, "beneficiary": got, "expected": want})
+	}
+	return problem
+}
+
+// warnOnce sends event to the owner and partnerEvent to their partners
+// the first time it is due for the vault's current deadline.
+func (h *household) warnOnce(o *householdOwner, v HouseholdVault, event, partnerEvent string) {
+	if !h.firstTime(event + ":" + v.VaultID + "@" + v.Deadline.Format(time.RFC3339)) {
+		return
+	}
+	h.channel(o).Send(householdNotification(event, o, v, h.idle(o)))
+	h.events.Publish(event, map[string]interface{}{"owner": o.cfg.ID, "vault_id": v.VaultID, "deadline": v.Deadline, "risk": v.Risk})
+	for _, id := range o.partners {
+		h.channel(h.byID[id]).Send(householdNotification(partnerEvent, o, v, h.idle(o)))
+	}
+}
+
+// firstTime reports whether key has not been announced yet, and marks it.
+func (h *household) firstTime(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.warned[key] {
+		return false
+	}
+	h.warned[key] = true
+	return true
+}
+
+func (h *household) channel(o *householdOwner) *sentinelNotifier {
+	if o.notify != nil {
+		return o.notify
+	}
+	return h.fallback
+}
+
+// idle is how long the owner has been inactive, if any activity was seen.
+func (h *household) idle(o *householdOwner) time.Duration {
+	if h.activity == nil {
+		return 0
+	}
+	if last, ok := h.activity.OwnerLastSeen(o.cfg.ID); ok {
+		return h.now().Sub(last)
+	}
+	return 0
+}
+
+// Status returns the last check per owner, in config order.
+func (h *household) Status() []HouseholdOwnerStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]HouseholdOwnerStatus, 0, len(h.owners))
+	for _, o := range h.owners {
+		st := HouseholdOwnerStatus{ID: o.cfg.ID, Name: o.cfg.Name, Vaults: []HouseholdVault{}}
+		if s := h.status[o.cfg.ID]; s != nil {
+			st = *s
+		}
+		if h.activity != nil {
+			if last, ok := h.activity.OwnerLastSeen(o.cfg.ID); ok {
+				st.LastActivity = &last
+			}
+		}
+		st.Channels = o.notify.Channels()
+		out = append(out, st)
+	}
+	return out
+}
+
+// Start checks once at startup and then every interval.
+func (h *household) Start() {
+	go func() {
+		h.Check()
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			h.Check()
+		}
+	}()
+}
+
+func householdNotification(event string, o *householdOwner, v HouseholdVault, idle time.Duration) SentinelNotification {
+	left := time.Duration(v.HoursLeft * float64(time.Hour))
+	deadline := v.Deadline.Format("Mon 2 Jan 2006 15:04 MST")
+	n := SentinelNotification{
+		Event: event,
+		Fields: []notifyField{
+			{"Owner", o.cfg.Name},
+			{"Vault", householdVaultName(v)},
+			{"Risk", v.Risk},
+		},
+	}
+	switch event {
+	case notifyVaultDeadline:
+		n.Title = "Your vault is nearing its deadline"
+		n.Summary = fmt.Sprintf("Send a heartbeat before %s (%s left).", deadline, formatDaysLeft(left))
+	case notifyPartnerDeadline:
+		n.Title = fmt.Sprintf("%s's vault is nearing its deadline", o.cfg.Name)
+		n.Summary = fmt.Sprintf("%s has not sent a heartbeat; the deadline is %s (%s left). Consider checking in with them.",
+			o.cfg.Name, deadline, formatDaysLeft(left))
+	case notifyVaultOverdue:
+		n.Title = "Your vault has missed its heartbeat"
+		n.Summary = fmt.Sprintf("This vault has gone longer than its heartbeat interval without one. The deadline is %s (%s left).", deadline, formatDaysLeft(left))
+	case notifyPartnerOverdue:
+		n.Title = fmt.Sprintf("%s's vault has missed its heartbeat", o.cfg.Name)
+		n.Summary = fmt.Sprintf("%s has gone longer than the vault's heartbeat interval without one; the deadline is %s (%s left).",
+			o.cfg.Name, deadline, formatDaysLeft(left))
+	}
+	if v.Overdue && (event == notifyVaultDeadline || event == notifyPartnerDeadline) {
+		n.Fields = append(n.Fields, notifyField{"Heartbeat", "overdue for this vault's interval"})
+	}
+	if idle > 0 {
+		n.Fields = append(n.Fields, notifyField{"Last activity", formatDaysLeft(idle) + " ago"})
+	}
+	return n
+}
+
+func beneficiaryNotification(o *householdOwner, v HouseholdVault, problem string) SentinelNotification {
+	return SentinelNotification{
+		Event:   notifyBeneficiaryMismatch,
+		Title:   "Your vault's beneficiary is not the one you configured",
+		Summary: "Check the vault before its deadline: it would release to a different address.",
+		Fields: []notifyField{
+			{"Owner", o.cfg.Name},
+			{"Vault", householdVaultName(v)},
+			{"Problem", problem},
+		},
+	}
+}
+
+// householdVaultName is the vault ID, after its label when it has one.
+func householdVaultName(v HouseholdVault) string {
+	if v.Label != "" {
+		return fmt.Sprintf("%s (%s)", v.Label, v.VaultID)
+	}
+	return v.VaultID
+}
+
+// formatDaysLeft renders a duration as days, or hours under two days.
+func formatDaysLeft(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%.0f hours", d.Hours())
+	}
+	return fmt.Sprintf("%.0f days", d.Hours()/24)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookInbox is a fake Discord webhook that keeps the embed titles.
+type webhookInbox struct {
+	mu     sync.Mutex
+	titles []string
+	srv    *httptest.Server
+}
+
+func newWebhookInbox(t *testing.T) *webhookInbox {
+	in := &webhookInbox{}
+	in.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Embeds []struct {
+				Title string `json:"title"`
+			} `json:"embeds"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		in.mu.Lock()
+		for _, e := range body.Embeds {
+			in.titles = append(in.titles, e.Title)
+		}
+		in.mu.Unlock()
+	}))
+	t.Cleanup(in.srv.Close)
+	return in
+}
+
+func (in *webhookInbox) config() *NotificationsConfig {
+	return &NotificationsConfig{Webhooks: []WebhookNotifierConfig{{Kind: "discord", URL: in.srv.URL}}}
+}
+
+func (in *webhookInbox) wait(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		in.mu.Lock()
+		titles := append([]string{}, in.titles...)
+		in.mu.Unlock()
+		if len(titles) >= n || time.Now().After(deadline) {
+			return titles
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHouseholdCrossNotification(t *testing.T) {
+	alex, sam := newWebhookInbox(t), newWebhookInbox(t)
+	activity := NewActivityMonitor()
+	h, err := newHousehold(&HouseholdConfig{
+		Enabled: true,
+		RPCURL:  "http://127.0.0.1:0",
+		Owners: []HouseholdOwner{
+			{ID: "alex", Name: "Alex", Address: "0xa1", Notifications: alex.config(), WarnDays: 5},
+			{ID: "sam", Name: "Sam", Address: "0xb2", Notifications: sam.config()},
+		},
+	}, nil, nil, activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	h.now = func() time.Time { return now }
+	activity.now = func() time.Time { return now.Add(-10 * day) }
+	activity.Record("shell", "alex", false)
+	h.fetch = func(address string) (map[string]*heartbeatHistory, error) {
+		switch address {
+		case "0xa1": // 30-day deadline, 3 days left
+			return map[string]*heartbeatHistory{"0xvault_a": {beatsMs: []int64{now.Add(-27 * day).UnixMilli()}}}, nil
+		case "0xb2": // 10 days left
+			return map[string]*heartbeatHistory{"0xvault_b": {beatsMs: []int64{now.Add(-20 * day).UnixMilli()}}}, nil
+		}
+		return nil, fmt.Errorf("unexpected address %s", address)
+	}
+
+	h.Check()
+	h.Check() // the same deadline is only announced once
+	if got := alex.wait(t, 1); len(got) != 1 || got[0] != "Your vault is nearing its deadline" {
+		t.Fatalf("alex should be warned once: %q", got)
+	}
+	if got := sam.wait(t, 1); len(got) != 1 || got[0] != "Alex's vault is nearing its deadline" {
+		t.Fatalf("sam should hear about alex's vault: %q", got)
+	}
+
+	st := h.Status()
+	if len(st) != 2 || st[0].ID != "alex" || st[0].LastActivity == nil || len(st[0].Vaults) != 1 || !st[0].Vaults[0].Warned || st[0].Vaults[0].HoursLeft != 72 {
+		b, _ := json.Marshal(st)
+		t.Fatalf("unexpected status: %s", b)
+	}
+	if st[1].LastActivity != nil || len(st[1].Vaults) != 1 || st[1].Vaults[0].Warned {
+		t.Fatalf("sam's vault is outside the warning window: %+v", st[1])
+	}
+}
+
+func TestHouseholdVaultSettings(t *testing.T) {
+	inbox := newWebhookInbox(t)
+	h, err := newHousehold(&HouseholdConfig{
+		Enabled: true,
+		RPCURL:  "http://127.0.0.1:0",
+		Owners: []HouseholdOwner{{ID: "alex", Address: "0xa1", Notifications: inbox.config(), VaultSettings: []HouseholdVaultConfig{
+			{ID: "0xPersonal", Label: "personal", HeartbeatIntervalDays: 7},
+			{ID: "0xbusiness", Label: "business", WarnDays: 2, Beneficiary: "0xB0B"},
+		}}},
+	}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	h.now = func() time.Time { return now }
+	h.fetch = func(string) (map[string]*heartbeatHistory, error) {
+		return map[string]*heartbeatHistory{
+			"0xpersonal": {beatsMs: []int64{now.Add(-10 * day).UnixMilli()}}, // 20 days left, but 10 days quiet
+			"0xbusiness": {beatsMs: []int64{now.Add(-26 * day).UnixMilli()}}, // 4 days left
+		}, nil
+	}
+	h.vault = func(id string) (*vaultObject, error) {
+		return &vaultObject{ID: id, Beneficiary: "0xEVE"}, nil
+	}
+	h.Check()
+	h.Check() // each alert is sent once
+
+	got := inbox.wait(t, 2)
+	sort.Strings(got)
+	if strings.Join(got, "|") != "Your vault has missed its heartbeat|Your vault's beneficiary is not the one you configured" {
+		t.Fatalf("expected the overdue and beneficiary alerts once each: %q", got)
+	}
+	n := householdNotification(notifyPartnerOverdue, h.owners[0], h.Status()[0].Vaults[1], 0)
+	if n.Title != "alex's vault has missed its heartbeat" || strings.Contains(n.Summary, "nearing") || n.Fields[1].Value != "personal (0xpersonal)" {
+		t.Fatalf("unexpected partner alert: %+v", n)
+	}
+	vaults := h.Status()[0].Vaults
+	if len(vaults) != 2 {
+		t.Fatalf("unexpected vaults: %+v", vaults)
+	}
+	business, personal := vaults[0], vaults[1]
+	if personal.Label != "personal" || !personal.Overdue || !personal.Warned || personal.Problem != "" {
+		t.Fatalf("personal vault: %+v", personal)
+	}
+	if business.Label != "business" || business.Warned || !strings.Contains(business.Problem, "beneficiary is 0xeve on chain, expected 0xb0b") {
+		t.Fatalf("business vault: %+v", business)
+	}
+}
+
+func TestHouseholdConfigValidation(t *testing.T) {
+	for name, cfg := range map[string]*HouseholdConfig{
+		"no owners":       {Enabled: true, RPCURL: "http://rpc"},
+		"no rpc":          {Enabled: true, Owners: []HouseholdOwner{{ID: "a", Address: "0x1"}}},
+		"duplicate owner": {Enabled: true, RPCURL: "http://rpc", Owners: []HouseholdOwner{{ID: "a", Address: "0x1"}, {ID: "a", Address: "0x2"}}},
+		"unknown partner": {Enabled: true, RPCURL: "http://rpc", Owners: []HouseholdOwner{{ID: "a", Address: "0x1", Partners: []string{"b"}}}},
+		"duplicate vault": {Enabled: true, RPCURL: "http://rpc", Owners: []HouseholdOwner{{ID: "a", Address: "0x1",
+			VaultSettings: []HouseholdVaultConfig{{ID: "0xV"}, {ID: "0xv"}}}}},
+	} {
+		if h, err := newHousehold(cfg, nil, nil, nil); err == nil || h != nil {
+			t.Errorf("%s: want an error, got %v", name, h)
+		}
+	}
+	if h, err := newHousehold(&HouseholdConfig{}, nil, nil, nil); err != nil || h != nil {
+		t.Fatalf("disabled: %v %v", h, err)
+	}
+}
+
+func TestActivityReportsPerOwner(t *testing.T) {
+	gw := newTestGateway()
+	postJSON(t, gw.handleActivity, ActivityReport{Command: "ls", Owner: "sam"})
+	postJSON(t, gw.handleActivity, ActivityReport{Command: "ls"})
+	if _, ok := gw.activity.OwnerLastSeen("sam"); !ok {
+		t.Fatal("sam's activity should be tracked")
+	}
+	if _, ok := gw.activity.OwnerLastSeen("alex"); ok {
+		t.Fatal("alex reported nothing")
+	}
+	st := gw.activity.Status()
+	if len(st.Sources) != 2 || st.Sources[0].Owner != "" || st.Sources[1].Owner != "sam" {
+		t.Fatalf("sources should be split by owner: %+v", st.Sources)
+	}
+	for _, shell := range sentinelShellHookKinds {
+		if script, _ := renderSentinelShellHook(shell, "http://127.0.0.1:9"); !strings.Contains(script, "SENTINEL_OWNER") {
+			t.Fatalf("the %s hook should report SENTINEL_OWNER", shell)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemActivitySource is the ActivityMonitor source for OS input.
+const systemActivitySource = "system"
+
+// SystemActivityConfig polls the OS for the time since the last keyboard or
+// mouse input, so liveness does not depend on shell or extension reports.
+type SystemActivityConfig struct {
+	Enabled bool `json:"enabled"`
+	// IntervalSec is the time between polls; default 60.
+	IntervalSec int `json:"interval_sec,omitempty"`
+	// Owner is the household member who uses this machine.
+	Owner string `json:"owner,omitempty"`
+}
+
+// systemActivity feeds the OS idle time into an ActivityMonitor. idle is
+// systemIdleTime, implemented per platform in sentinel_idle_<os>.go.
+type systemActivity struct {
+	owner    string
+	interval time.Duration
+	idle     func() (time.Duration, error)
+	now      func() time.Time
+	activity *ActivityMonitor
+}
+
+// newSystemActivity returns nil when polling is disabled. It fails when the
+// platform offers no idle time, so the gateway can log why.
+func newSystemActivity(cfg *SystemActivityConfig, activity *ActivityMonitor) (*systemActivity, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	sa := &systemActivity{
+		owner:    strings.TrimSpace(cfg.Owner),
+		interval: time.Duration(cfg.IntervalSec) * time.Second,
+		idle:     systemIdleTime,
+		now:      time.Now,
+		activity: activity,
+	}
+	if sa.interval <= 0 {
+		sa.interval = time.Minute
+	}
+	if err := sa.Poll(); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
+
+// Poll records the last input the OS saw.
+func (sa *systemActivity) Poll() error {
+	idle, err := sa.idle()
+	if err != nil {
+		return err
+	}
+	sa.activity.Observe(systemActivitySource, sa.owner, sa.now().Add(-idle))
+	return nil
+}
+
+// Start polls every interval until the process exits. A failing poll is
+// logged when its error changes.
+func (sa *systemActivity) Start() {
+	go func() {
+		ticker := time.NewTicker(sa.interval)
+		defer ticker.Stop()
+		var last string
+		for range ticker.C {
+			msg := ""
+			if err := sa.Poll(); err != nil {
+				msg = err.Error()
+			}
+			if msg != last && msg != "" {
+				log.Printf("[ACTIVITY] system idle time unavailable: %s", msg)
+			}
+			last = msg
+		}
+	}()
+}
+
+// parseIdleMillis reads the idle time printed by xprintidle (milliseconds)
+// or GNOME Mutter's GetIdletime over gdbus ("(uint64 1234,)").
+func parseIdleMillis(out string) (time.Duration, error) {
+	s := strings.TrimSpace(out)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "(uint64 "), ",)")
+	ms, err := strconv.ParseUint(s, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected idle time %q", strings.TrimSpace(out))
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+var ioregIdleTime = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+// parseIoregIdle reads HIDIdleTime (nanoseconds) from `ioreg -c IOHIDSystem`.
+func parseIoregIdle(out string) (time.Duration, error) {
+	m := ioregIdleTime.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("ioreg printed no HIDIdleTime")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ns), nil
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+
+package main
+
+import "time"
+
+// systemIdleTime reads HIDIdleTime from the IOHIDSystem registry entry.
+func systemIdleTime() (time.Duration, error) {
+	out, err := runSubprocess((*SubprocessConfig)(nil).policyFor(procIdle), true, "ioreg", "-c", "IOHIDSystem", "-d", "4")
+	if err != nil {
+		return 0, err
+	}
+	return parseIoregIdle(string(out))
+}
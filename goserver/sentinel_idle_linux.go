@@ -0,0 +1,56 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// systemIdleTime asks X11 (xprintidle), then GNOME on Wayland (Mutter's
+// IdleMonitor), and finally falls back to the access times of the
+// terminals, which is how w(1) computes idle time on consoles and SSH.
+func systemIdleTime() (time.Duration, error) {
+	policy := (*SubprocessConfig)(nil).policyFor(procIdle)
+	if os.Getenv("DISPLAY") != "" {
+		if out, err := runSubprocess(policy, true, "xprintidle"); err == nil {
+			return parseIdleMillis(string(out))
+		}
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		out, err := runSubprocess(policy, true, "gdbus", "call", "--session",
+			"--dest", "org.gnome.Mutter.IdleMonitor",
+			"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+			"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime")
+		if err == nil {
+			return parseIdleMillis(string(out))
+		}
+	}
+	return terminalIdleTime()
+}
+
+// terminalIdleTime is the time since any terminal was last read from.
+func terminalIdleTime() (time.Duration, error) {
+	var latest time.Time
+	for _, pattern := range []string{"/dev/pts/[0-9]*", "/dev/tty[0-9]*"} {
+		paths, _ := filepath.Glob(pattern)
+		for _, p := range paths {
+			fi, err := os.Stat(p)
+			if err != nil {
+				continue
+			}
+			if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+				if at := time.Unix(st.Atim.Sec, st.Atim.Nsec); at.After(latest) {
+					latest = at
+				}
+			}
+		}
+	}
+	if latest.IsZero() {
+		return 0, fmt.Errorf("no X11 or GNOME idle monitor and no terminals to inspect")
+	}
+	return time.Since(latest), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func systemIdleTime() (time.Duration, error) {
+	return 0, fmt.Errorf("system idle time is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIdleTimes(t *testing.T) {
+	for out, want := range map[string]time.Duration{
+		"1500\n":          1500 * time.Millisecond,
+		"(uint64 61000,)": 61 * time.Second,
+	} {
+		if got, err := parseIdleMillis(out); err != nil || got != want {
+			t.Errorf("parseIdleMillis(%q) = %v, %v", out, got, err)
+		}
+	}
+	if _, err := parseIdleMillis("Error: no display"); err == nil {
+		t.Error("garbage should not parse")
+	}
+
+	ioreg := `    | |   "HIDIdleTime" = 2500000000
+    | |   "HIDKeyboardModifierMappingPairs" = ()`
+	if got, err := parseIoregIdle(ioreg); err != nil || got != 2500*time.Millisecond {
+		t.Fatalf("parseIoregIdle = %v, %v", got, err)
+	}
+	if _, err := parseIoregIdle("no such key"); err == nil {
+		t.Fatal("missing HIDIdleTime should fail")
+	}
+}
+
+func TestSystemActivityFeedsMonitor(t *testing.T) {
+	if sa, err := newSystemActivity(&SystemActivityConfig{}, NewActivityMonitor()); sa != nil || err != nil {
+		t.Fatalf("disabled: %v %v", sa, err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	activity := NewActivityMonitor()
+	idle := 5 * time.Minute
+	sa := &systemActivity{owner: "alex", activity: activity, now: func() time.Time { return now },
+		idle: func() (time.Duration, error) { return idle, nil }}
+	if err := sa.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if last, ok := activity.OwnerLastSeen("alex"); !ok || !last.Equal(now.Add(-5*time.Minute)) {
+		t.Fatalf("last input should be five minutes ago, got %v", last)
+	}
+
+	// A later poll with a longer idle time describes the same input.
+	now, idle = now.Add(time.Minute), 6*time.Minute
+	sa.Poll()
+	if last, _ := activity.OwnerLastSeen("alex"); !last.Equal(now.Add(-6 * time.Minute)) {
+		t.Fatalf("last input moved: %v", last)
+	}
+	st := activity.Status()
+	if len(st.Sources) != 1 || st.Sources[0].Name != systemActivitySource || st.Sources[0].Commands != 0 {
+		t.Fatalf("unexpected sources: %+v", st.Sources)
+	}
+}
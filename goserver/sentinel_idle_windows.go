@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	procGetLastInputInfo = syscall.NewLazyDLL("user32.dll").NewProc("GetLastInputInfo")
+	procGetTickCount     = syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount")
+)
+
+// systemIdleTime compares GetLastInputInfo with the tick count. Both are
+// 32-bit milliseconds, so the subtraction survives the 49-day wrap.
+func systemIdleTime() (time.Duration, error) {
+	info := struct {
+		size uint32
+		time uint32
+	}{size: 8}
+	if ok, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, err
+	}
+	now, _, _ := procGetTickCount.Call()
+	return time.Duration(uint32(now)-info.time) * time.Millisecond, nil
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IncidentEvent is one entry of a reconstructed incident timeline.
+type IncidentEvent struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Source     string    `json:"source"` // sentinel | openclaw | alert | chain | heartbeat | activity
+	Kind       string    `json:"kind"`
+	Summary    string    `json:"summary"`
+	Action     string    `json:"action,omitempty"`
+	RecordHash string    `json:"record_hash,omitempty"`
+	TxDigest   string    `json:"tx_digest,omitempty"`
+}
+
+// IncidentReport is a chronological timeline assembled from every local
+// evidence source, suitable for post-mortems or handing to a third party.
+type IncidentReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Since       time.Time `json:"since"`
+	Sources     []string  `json:"sources"`
+	// Degraded lists the capabilities running on a fallback as of the
+	// latest config snapshot in the window.
+	Degraded []string `json:"degraded,omitempty"`
+	// Unavailable lists the sources that could not be read, with why.
+	Unavailable []string        `json:"unavailable,omitempty"`
+	Events      []IncidentEvent `json:"events"`
+}
+
+// readAuditRecords loads every record from a Sentinel JSONL audit log.
+// A missing file is treated as an empty log.
+func readAuditRecords(path string) ([]AuditRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	records, err := parseAuditJSONL(data)
+	if err != nil {
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// parseAuditJSONL decodes one AuditRecord per non-empty line.
+func parseAuditJSONL(data []byte) ([]AuditRecord, error) {
+	var records []AuditRecord
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// IncidentSources says where BuildIncidentReport looks for events. The
+// audit log, and the OpenClaw session transcripts it seals, are always
+// read; every other source is skipped when unset.
+type IncidentSources struct {
+	AuditPath string
+	// AlertJournal is the gateway's alerts.jsonl.
+	AlertJournal string
+	// Chain is scanned for the transactions Owners sent: heartbeats and
+	// other vault calls.
+	Chain  *chainReader
+	Owners []string
+	// ProxyURL is a running Sentinel proxy, asked for the activity it only
+	// holds in memory.
+	ProxyURL string
+}
+
+// BuildIncidentReport merges the events newer than since from every source
+// into a single chronological timeline. A source that cannot be read is
+// listed in Unavailable rather than failing the report; only an unreadable
+// audit log is an error.
+func BuildIncidentReport(src IncidentSources, since time.Time) (*IncidentReport, error) {
+	records, err := readAuditRecords(src.AuditPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IncidentReport{
+		GeneratedAt: time.Now().UTC(),
+		Since:       since.UTC(),
+		Sources:     []string{"sentinel_audit:" + src.AuditPath},
+		Events:      []IncidentEvent{},
+	}
+	add := func(ev IncidentEvent) {
+		if !ev.Timestamp.Before(since) {
+			report.Events = append(report.Events, ev)
+		}
+	}
+	unavailable := func(source string, err error) {
+		report.Unavailable = append(report.Unavailable, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	for _, rec := range records {
+		if rec.Timestamp.Before(since) {
+			continue
+		}
+		kind := "decision_" + rec.Decision
+		switch {
+		case containsTag(rec.Tags, "config_snapshot"):
+			kind = "config_snapshot"
+			var snap ConfigSnapshot
+			if json.Unmarshal([]byte(rec.Prompt), &snap) == nil {
+				report.Degraded = snap.Degraded
+			}
+		case containsTag(rec.Tags, "config_change"):
+			kind = "config_change_" + rec.Decision
+		case rec.Action == sessionAuditAction:
+			kind = "openclaw_session"
+			if err := addSessionEvents(rec, add); err != nil {
+				unavailable("openclaw_session:"+rec.RecordHash, err)
+			}
+		}
+		add(IncidentEvent{
+			Timestamp:  rec.Timestamp,
+			Source:     "sentinel",
+			Kind:       kind,
+			Summary:    fmt.Sprintf("score=%d tags=%s: %s", rec.Score, strings.Join(rec.Tags, ","), rec.Reason),
+			Action:     rec.Action,
+			RecordHash: rec.RecordHash,
+		})
+
+		switch {
+		case rec.TxDigest != "":
+			add(IncidentEvent{
+				Timestamp:  rec.Timestamp,
+				Source:     "chain",
+				Kind:       "anchor_submitted",
+				Summary:    "audit record anchored on Sui",
+				Action:     rec.Action,
+				RecordHash: rec.RecordHash,
+				TxDigest:   rec.TxDigest,
+			})
+		case rec.AnchorError != "":
+			add(IncidentEvent{
+				Timestamp:  rec.Timestamp,
+				Source:     "chain",
+				Kind:       "anchor_failed",
+				Summary:    rec.AnchorError,
+				Action:     rec.Action,
+				RecordHash: rec.RecordHash,
+			})
+		}
+	}
+
+	if src.AlertJournal != "" {
+		report.Sources = append(report.Sources, "alerts:"+src.AlertJournal)
+		if err := addAlertEvents(src.AlertJournal, add); err != nil {
+			unavailable("alerts", err)
+		}
+	}
+
+	if src.Chain != nil {
+		anchored := map[string]bool{}
+		for _, ev := range report.Events {
+			if ev.TxDigest != "" {
+				anchored[ev.TxDigest] = true
+			}
+		}
+		for _, owner := range src.Owners {
+			report.Sources = append(report.Sources, "chain:"+owner)
+			if err := addChainEvents(src.Chain, owner, anchored, add); err != nil {
+				unavailable("chain:"+owner, err)
+			}
+		}
+	}
+
+	if src.ProxyURL != "" {
+		report.Sources = append(report.Sources, "proxy:"+src.ProxyURL)
+		if err := addActivityEvents(src.ProxyURL, add); err != nil {
+			unavailable("proxy", err)
+		}
+	}
+
+	sort.SliceStable(report.Events, func(i, j int) bool {
+		return report.Events[i].Timestamp.Before(report.Events[j].Timestamp)
+	})
+	return report, nil
+}
+
+// addSessionEvents adds the transcript of the OpenClaw task sealed by rec.
+// Entries read before a broken link are still added.
+func addSessionEvents(rec AuditRecord, add func(IncidentEvent)) error {
+	var summary SessionSummary
+	if err := json.Unmarshal([]byte(rec.Prompt), &summary); err != nil {
+		return err
+	}
+	entries, err := readSession(filepath.Dir(summary.Path), summary.SessionID, summary.TriggerRecord)
+	for _, e := range entries {
+		add(IncidentEvent{
+			Timestamp:  e.Timestamp,
+			Source:     "openclaw",
+			Kind:       "task_" + e.Kind,
+			Summary:    fmt.Sprintf("session %s entry %d of %d", summary.SessionID, e.Seq, summary.Entries),
+			RecordHash: e.EntryHash,
+		})
+	}
+	return err
+}
+
+// addAlertEvents adds every notification recorded in the alert journal. A
+// missing journal means no alert was sent.
+func addAlertEvents(path string, add func(IncidentEvent)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var entry AlertJournalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		add(IncidentEvent{
+			Timestamp:  entry.Time,
+			Source:     "alert",
+			Kind:       entry.Event,
+			Summary:    entry.Title + ": " + entry.Summary,
+			Action:     entry.Fields["Action"],
+			RecordHash: entry.Fields["Record"],
+			TxDigest:   entry.Fields["Anchor tx"],
+		})
+	}
+	return nil
+}
+
+// addChainEvents adds the Move calls owner sent. keep_alive calls form the
+// heartbeat history; audit anchors already in the timeline are skipped.
+func addChainEvents(reader *chainReader, owner string, anchored map[string]bool, add func(IncidentEvent)) error {
+	options := map[string]interface{}{"showInput": true, "showEffects": true}
+	return scanOwnerTransactions(reader, owner, options, func(tx suiTxBlock) {
+		if anchored[tx.Digest] {
+			return
+		}
+		at := time.UnixMilli(jsonInt(tx.TimestampMs)).UTC()
+		status := tx.Effects.Status.Status
+		ptx := tx.Transaction.Data.Transaction
+		for _, call := range ptx.Transactions {
+			mc := call.MoveCall
+			if mc == nil {
+				continue
+			}
+			ev := IncidentEvent{
+				Timestamp: at,
+				Source:    "chain",
+				Kind:      "tx_" + mc.Function,
+				Summary:   fmt.Sprintf("%s::%s from %s", mc.Module, mc.Function, owner),
+				TxDigest:  tx.Digest,
+			}
+			if mc.Function == "keep_alive" {
+				ev.Source, ev.Kind = "heartbeat", "heartbeat"
+				if len(ptx.Inputs) > 0 {
+					ev.Summary = fmt.Sprintf("keep_alive for vault %s from %s", ptx.Inputs[0].ObjectID, owner)
+				}
+			}
+			if status != "" && status != "success" {
+				ev.Kind += "_failed"
+			}
+			add(ev)
+		}
+	})
+}
+
+// addActivityEvents asks a running proxy when each activity source last
+// reported. The monitor keeps no history, so each source contributes only
+// its latest activity.
+func addActivityEvents(proxyURL string, add func(IncidentEvent)) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(proxyURL, "/") + "/sentinel/status")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status endpoint returned %s", resp.Status)
+	}
+	var status struct {
+		Activity ActivityStatus `json:"activity"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&status); err != nil {
+		return err
+	}
+	for _, s := range status.Activity.Sources {
+		who := s.Name
+		if s.Owner != "" {
+			who += " (" + s.Owner + ")"
+		}
+		add(IncidentEvent{
+			Timestamp: s.LastSeen,
+			Source:    "activity",
+			Kind:      "last_seen",
+			Summary:   fmt.Sprintf("latest activity from %s; %d commands since the proxy started", who, s.Commands),
+		})
+	}
+	return nil
+}
+
+// writeIncidentMarkdown renders the report as a Markdown table.
+func writeIncidentMarkdown(out io.Writer, report *IncidentReport) error {
+	var b strings.Builder
+	b.WriteString("# Sentinel Incident Timeline\n\n")
+	fmt.Fprintf(&b, "- Generated: %s\n", report.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Since: %s\n", report.Since.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Sources: %s\n", strings.Join(report.Sources, ", "))
+	if len(report.Degraded) > 0 {
+		fmt.Fprintf(&b, "- Degraded: %s\n", strings.Join(report.Degraded, ", "))
+	}
+	for _, u := range report.Unavailable {
+		fmt.Fprintf(&b, "- Unavailable: %s\n", markdownCell(u))
+	}
+	fmt.Fprintf(&b, "- Events: %d\n\n", len(report.Events))
+
+	if len(report.Events) == 0 {
+		b.WriteString("_No events in the selected window._\n")
+		_, err := io.WriteString(out, b.String())
+		return err
+	}
+
+	b.WriteString("| Time (UTC) | Source | Kind | Action | Summary | Record / Tx |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, ev := range report.Events {
+		ref := ev.RecordHash
+		if ev.TxDigest != "" {
+			ref = ev.TxDigest
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			ev.Timestamp.UTC().Format(time.RFC3339),
+			ev.Source,
+			ev.Kind,
+			markdownCell(ev.Action),
+			markdownCell(ev.Summary),
+			ref,
+		)
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// runIncidentCommand implements `goserver incident report`.
+func runIncidentCommand(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "report" {
+		return fmt.Errorf("usage: incident report [--since 72h] [--format markdown|json] [--config <path>] [--owner <address>] [--rpc <url>] [--url <proxy>]")
+	}
+	fs := flag.NewFlagSet("incident report", flag.ContinueOnError)
+	configPath := fs.String("config", "configs/config.json", "Path to configuration file")
+	since := fs.String("since", "72h", "Start of the timeline: an RFC 3339 time or a duration ago")
+	format := fs.String("format", "markdown", "Output format: markdown or json")
+	owners := fs.String("owner", "", "Comma-separated Sui addresses whose transactions to include; default the household owners")
+	rpcURL := fs.String("rpc", "", "Sui JSON-RPC endpoint to scan; default household.rpc_url or testnet")
+	proxyURL := fs.String("url", "http://127.0.0.1:18080", "Sentinel proxy to ask for current activity; empty to skip")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	from, err := parseAuditQueryTime("--since", strings.TrimSpace(*since), time.Now())
+	if err != nil || from.IsZero() {
+		return fmt.Errorf("--since must be an RFC 3339 time or a duration like 72h, got %q", *since)
+	}
+
+	sentinelCfg, err := loadSentinelConfigOnly(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load sentinel config: %w", err)
+	}
+	guard := NewSentinelGuard(resolveSentinelConfig(sentinelCfg))
+	src := IncidentSources{
+		AuditPath:    guard.cfg.AuditLogPath,
+		AlertJournal: alertJournalPath(guard.cfg.AuditLogPath),
+		ProxyURL:     strings.TrimSpace(*proxyURL),
+	}
+
+	rpc := *rpcURL
+	for _, o := range strings.Split(*owners, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			src.Owners = append(src.Owners, "0x"+normalizeKeyHex(o))
+		}
+	}
+	if hh := guard.cfg.Household; hh != nil {
+		if len(src.Owners) == 0 {
+			for _, o := range hh.Owners {
+				src.Owners = append(src.Owners, "0x"+normalizeKeyHex(o.Address))
+			}
+		}
+		if rpc == "" {
+			rpc = hh.RPCURL
+		}
+	}
+	if len(src.Owners) > 0 {
+		if rpc == "" {
+			rpc = "https://fullnode.testnet.sui.io:443"
+		}
+		src.Chain = newChainReader(rpc, guard.cfg.ChainRead)
+	}
+
+	report, err := BuildIncidentReport(src, from)
+	if err != nil {
+		return fmt.Errorf("failed to build incident report: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(*format)) {
+	case "", "markdown", "md":
+		return writeIncidentMarkdown(out, report)
+	case "json":
+		return encodeSentinelOutput(out, report)
+	default:
+		return fmt.Errorf("unsupported --format %q (use markdown or json)", *format)
+	}
+}
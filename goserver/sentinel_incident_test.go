@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeAuditLog(t *testing.T, path string, records ...AuditRecord) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			t.Fatalf("marshal record: %v", err)
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write audit log: %v", err)
+	}
+}
+
+func TestBuildIncidentReportOrdersAndFiltersEvents(t *testing.T) {
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeAuditLog(t, path,
+		AuditRecord{Timestamp: now.Add(-1 * time.Hour), Action: "WALLET", Decision: "blocked", RecordHash: "0xb", TxDigest: "DIGEST1"},
+		AuditRecord{Timestamp: now.Add(-100 * time.Hour), Action: "EXEC", Decision: "blocked", RecordHash: "0xold"},
+		AuditRecord{Timestamp: now.Add(-2 * time.Hour), Action: "STATUS", Decision: "allowed", RecordHash: "0xa", AnchorError: "rpc timeout"},
+	)
+
+	report, err := BuildIncidentReport(IncidentSources{AuditPath: path}, now.Add(-72*time.Hour))
+	if err != nil {
+		t.Fatalf("BuildIncidentReport failed: %v", err)
+	}
+
+	var kinds []string
+	for _, ev := range report.Events {
+		if ev.RecordHash == "0xold" {
+			t.Fatalf("event outside the window was included: %+v", ev)
+		}
+		kinds = append(kinds, ev.Kind)
+	}
+	want := []string{"decision_allowed", "anchor_failed", "decision_blocked", "anchor_submitted"}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected timeline order: got %v, want %v", kinds, want)
+	}
+}
+
+func TestBuildIncidentReportMergesSources(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+
+	var sealed []AuditRecord
+	rec := newSessionRecorder(&SessionRecordingConfig{Enabled: true}, auditPath, func(r *AuditRecord) error {
+		sealed = append(sealed, *r)
+		return nil
+	})
+	if err := rec.Start("task-1", "0xgate"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Append("task-1", "request", map[string]string{"action": "EXEC"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Finish("task-1"); err != nil {
+		t.Fatal(err)
+	}
+	writeAuditLog(t, auditPath,
+		AuditRecord{Timestamp: now.Add(-3 * time.Hour), Action: "WALLET", Decision: "blocked", RecordHash: "0xgate", TxDigest: "ANCHOR"},
+		sealed[0],
+	)
+
+	journal := alertJournalPath(auditPath)
+	n := &sentinelNotifier{journal: journal}
+	note := gateNotification(notifyGateBlock, "WALLET", RiskEvaluation{Score: 90, Reason: "seed phrase"}, &AuditRecord{RecordHash: "0xgate"})
+	note.Time = now.Add(-3 * time.Hour).Add(time.Second)
+	if err := n.writeJournal(note); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := func(digest, function string, at time.Time) string {
+		return fmt.Sprintf(`{"digest":%q,"timestampMs":"%d",
+			"transaction":{"data":{"transaction":{"inputs":[{"objectId":"0xv1"}],
+				"transactions":[{"MoveCall":{"package":"0xv","module":"lazarus_protocol","function":%q,"arguments":[{"Input":0}]}}]}}},
+			"effects":{"status":{"status":"success"}}}`, digest, at.UnixMilli(), function)
+	}
+	page := `{"data":[` + tx("ANCHOR", "anchor_audit", now.Add(-3*time.Hour)) + "," +
+		tx("BEAT", "keep_alive", now.Add(-2*time.Hour)) + "," +
+		tx("OLD", "keep_alive", now.Add(-100*time.Hour)) +
+		`],"nextCursor":null,"hasNextPage":false}`
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + page + `}`))
+	}))
+	defer node.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"activity": ActivityStatus{
+			Sources: []ActivitySource{{Name: "shell", Owner: "alice", LastSeen: now.Add(-time.Hour), Commands: 4}},
+		}})
+	}))
+	defer proxy.Close()
+
+	report, err := BuildIncidentReport(IncidentSources{
+		AuditPath:    auditPath,
+		AlertJournal: journal,
+		Chain:        newChainReader(node.URL, nil),
+		Owners:       []string{"0xowner"},
+		ProxyURL:     proxy.URL,
+	}, now.Add(-72*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Unavailable) != 0 {
+		t.Fatalf("unexpected unavailable sources: %v", report.Unavailable)
+	}
+	var got []string
+	for _, ev := range report.Events {
+		got = append(got, ev.Source+"/"+ev.Kind)
+	}
+	// The anchor transaction is already in the timeline from the audit log,
+	// and the old heartbeat is outside the window.
+	want := []string{
+		"sentinel/decision_blocked", "chain/anchor_submitted", "alert/gate_block",
+		"heartbeat/heartbeat", "activity/last_seen", "openclaw/task_request", "sentinel/openclaw_session",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected timeline: got %v, want %v", got, want)
+	}
+	if ev := report.Events[2]; ev.RecordHash != "0xgate" || ev.Action != "WALLET" {
+		t.Fatalf("alert lost its record reference: %+v", ev)
+	}
+	if ev := report.Events[3]; ev.TxDigest != "BEAT" || !strings.Contains(ev.Summary, "0xv1") {
+		t.Fatalf("unexpected heartbeat event: %+v", ev)
+	}
+}
+
+func TestBuildIncidentReportListsUnavailableSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeAuditLog(t, path, AuditRecord{Timestamp: time.Now().UTC(), Action: "EXEC", Decision: "allowed", RecordHash: "0xa"})
+
+	report, err := BuildIncidentReport(IncidentSources{AuditPath: path, ProxyURL: "http://127.0.0.1:1"}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("an unreachable proxy must not fail the report: %v", err)
+	}
+	if len(report.Unavailable) != 1 || !strings.HasPrefix(report.Unavailable[0], "proxy: ") || len(report.Events) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunIncidentCommandMarkdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath, auditPath := writeSentinelEvalConfig(t, tmpDir)
+	writeAuditLog(t, auditPath, AuditRecord{
+		Timestamp:  time.Now().UTC(),
+		Action:     "EXEC",
+		Decision:   "blocked",
+		Reason:     "pipe | in reason",
+		RecordHash: "0xabc",
+	})
+
+	var out bytes.Buffer
+	if err := runIncidentCommand([]string{"report", "--config", configPath, "--since", "24h", "--url", ""}, &out); err != nil {
+		t.Fatalf("incident report failed: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "decision_blocked") || !strings.Contains(got, "0xabc") {
+		t.Fatalf("expected blocked event in markdown, got:\n%s", got)
+	}
+	if !strings.Contains(got, `pipe \| in reason`) {
+		t.Fatalf("expected pipe characters to be escaped, got:\n%s", got)
+	}
+
+	if err := runIncidentCommand([]string{"report", "--config", configPath, "--since", "soon"}, &out); err == nil {
+		t.Fatalf("expected invalid duration error")
+	}
+	if err := runIncidentCommand([]string{"summary"}, &out); err == nil {
+		t.Fatalf("expected usage error for an unknown subcommand")
+	}
+}
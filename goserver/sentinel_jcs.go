@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// jcsAuditHash is the record hash: sha256 over the RFC 8785 (JCS) canonical
+// JSON of the hashed fields. `lazarus-vault hash-audit` hashes the same
+// bytes. Tags are split, trimmed and sorted exactly as the CLI receives
+// them (one comma-joined --tags argument). prev_hash, occurrences and
+// dedup_of are omitted when empty, so records without them keep a stable
+// form.
+func jcsAuditHash(rec *AuditRecord) string {
+	tags := []string{}
+	for _, t := range strings.Split(strings.Join(rec.Tags, ","), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	fields := map[string]interface{}{
+		"action":    rec.Action,
+		"prompt":    rec.Prompt,
+		"score":     rec.Score,
+		"tags":      tags,
+		"decision":  rec.Decision,
+		"reason":    rec.Reason,
+		"timestamp": rec.Timestamp.Format(time.RFC3339Nano),
+	}
+	if rec.PrevHash != "" {
+		fields["prev_hash"] = rec.PrevHash
+	}
+	if rec.Occurrences != 0 {
+		fields["occurrences"] = rec.Occurrences
+	}
+	if rec.DedupOf != "" {
+		fields["dedup_of"] = rec.DedupOf
+	}
+	var buf bytes.Buffer
+	if err := appendJCS(&buf, fields); err != nil {
+		panic(err) // only the types above are ever encoded
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// appendJCS writes v in RFC 8785 canonical form. It covers the value types
+// audit records hash: objects, string arrays, strings, integers and bools.
+func appendJCS(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		// Keys sort by their UTF-16 code units.
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendJCSString(buf, k)
+			buf.WriteByte(':')
+			if err := appendJCS(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []string:
+		buf.WriteByte('[')
+		for i, s := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendJCSString(buf, s)
+		}
+		buf.WriteByte(']')
+	case string:
+		appendJCSString(buf, v)
+	case int:
+		buf.WriteString(strconv.Itoa(v))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		return fmt.Errorf("jcs: unsupported type %T", v)
+	}
+	return nil
+}
+
+// appendJCSString escapes only what RFC 8785 requires: quote, backslash
+// and control characters. Invalid UTF-8 bytes become U+FFFD.
+func appendJCSString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r) // utf8.RuneError for invalid bytes
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
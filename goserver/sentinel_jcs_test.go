@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The vector is shared with rustcli's test_hash_audit_is_jcs.
+func TestJCSAuditHashVector(t *testing.T) {
+	ts, _ := time.Parse(time.RFC3339Nano, "2026-10-16T08:00:00.123456789Z")
+	rec := &AuditRecord{
+		Timestamp: ts,
+		Action:    "EXEC",
+		Prompt:    "rm -rf / é \"q\" \\ <x>&\n\x01 ",
+		Score:     95,
+		Tags:      []string{"shell", "destructive, "},
+		Decision:  "blocked",
+		Reason:    "a\tb",
+		PrevHash:  "0x" + strings.Repeat("ab", 32),
+	}
+	if got := jcsAuditHash(rec); got != "0xc73a111cf934b0e0d02476fb51d7392e2a9ef6f0ba442efc94361ddcef112b83" {
+		t.Fatalf("chained hash %s", got)
+	}
+	rec.PrevHash = ""
+	if got := jcsAuditHash(rec); got != "0xa2effd527a227c3d39e6cc8ab08528d8d474462d39c6232cbec6a47103e6a32f" {
+		t.Fatalf("unchained hash %s", got)
+	}
+	rec.PrevHash = "0x" + strings.Repeat("ab", 32)
+	rec.Occurrences = 12
+	rec.DedupOf = "0x" + strings.Repeat("cd", 32)
+	if got := jcsAuditHash(rec); got != "0xd560797799a6eb6d8fba719824be6241ef65401dcd9e2f082481132bd5655db1" {
+		t.Fatalf("collapsed hash %s", got)
+	}
+}
+
+func TestJCSKeyOrderAndEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	err := appendJCS(&buf, map[string]interface{}{"דּ": 1, "\U0001f600": true, "a": []string{" \x1f"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// UTF-16 order puts the surrogate pair of U+1F600 before U+FB33.
+	if want := "{\"a\":[\" \\u001f\"],\"\U0001f600\":true,\"דּ\":1}"; buf.String() != want {
+		t.Fatalf("got %s, want %s", buf.String(), want)
+	}
+}
+
+func TestGuardHashesRecordsWithJCS(t *testing.T) {
+	path := t.TempDir() + "/audit.jsonl"
+	guard := newTestGuard(t, SentinelConfig{AuditLogPath: path, HashCLIPath: "/nonexistent/lazarus-vault"})
+	_, rec, err := guard.Enforce("CODE_EDITING", "git status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.PromptSHA256 != promptCommitment("git status") || rec.RecordHash != jcsAuditHash(hashedAuditForm(rec)) {
+		t.Fatalf("record hash %s is not the JCS hash over the prompt commitment", rec.RecordHash)
+	}
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := verifyAuditChain(records); !v.Valid {
+		t.Fatalf("chain should verify: %+v", v)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// sentinelLaunchAgentLabel names the LaunchAgent and its plist file.
+const sentinelLaunchAgentLabel = "io.lazarus.sentinel"
+
+// runInstallLaunchAgentMode writes a macOS LaunchAgent that starts the
+// Sentinel proxy at login and restarts it if it exits. The agent runs from
+// the current directory, so relative paths in the config resolve as they
+// do now. It prints the launchctl command that loads the agent.
+func runInstallLaunchAgentMode(configPath, proxyAddr string, out io.Writer) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("LaunchAgents are only supported on macOS")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if !isConfigURL(configPath) {
+		if configPath, err = filepath.Abs(configPath); err != nil {
+			return err
+		}
+	}
+
+	plist := renderLaunchAgentPlist(
+		[]string{exe, "-config", configPath, "--sentinel-proxy", "--sentinel-proxy-addr", proxyAddr},
+		workDir,
+		filepath.Join(home, "Library", "Logs", "sentinel.log"),
+	)
+	path := filepath.Join(home, "Library", "LaunchAgents", sentinelLaunchAgentLabel+".plist")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(plist), 0o644); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Wrote %s\nLoad it now with:\n  launchctl bootstrap gui/%d %s\n", path, os.Getuid(), path)
+	return err
+}
+
+func renderLaunchAgentPlist(args []string, workDir, logPath string) string {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + sentinelLaunchAgentLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, a := range args {
+		b.WriteString("\t\t<string>" + esc(a) + "</string>\n")
+	}
+	b.WriteString(`	</array>
+	<key>WorkingDirectory</key>
+	<string>` + esc(workDir) + `</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>` + esc(logPath) + `</string>
+	<key>StandardErrorPath</key>
+	<string>` + esc(logPath) + `</string>
+</dict>
+</plist>
+`)
+	return b.String()
+}
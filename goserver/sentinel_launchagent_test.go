@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderLaunchAgentPlist(t *testing.T) {
+	plist := renderLaunchAgentPlist([]string{"/opt/sentinel", "-config", "/Users/me/R&D/config.json", "--sentinel-proxy"}, "/Users/me/R&D", "/Users/me/Library/Logs/sentinel.log")
+	for _, want := range []string{
+		"<string>" + sentinelLaunchAgentLabel + "</string>",
+		"<string>/Users/me/R&amp;D/config.json</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>KeepAlive</key>\n\t<true/>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Fatalf("plist is missing %q:\n%s", want, plist)
+		}
+	}
+	dec := xml.NewDecoder(strings.NewReader(plist))
+	dec.Strict = true
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("plist is not well-formed XML: %v", err)
+			}
+			break
+		}
+	}
+}
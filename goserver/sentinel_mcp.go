@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision implemented.
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpRequest is a JSON-RPC 2.0 request or notification (no id).
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// mcpTool is one entry of tools/list.
+type mcpTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// mcpToolResult is the tools/call result; the payload is JSON text.
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func mcpSchema(required []string, props map[string]string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, desc := range props {
+		properties[name] = map[string]string{"type": "string", "description": desc}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// sentinelMCPTools are the guard tools offered to MCP clients.
+var sentinelMCPTools = []mcpTool{
+	{
+		Name: "evaluate_risk",
+		Description: "Evaluate an action before performing it. The decision is written to the Sentinel audit log. " +
+			"Do not perform the action when should_block is true; ask the user instead.",
+		InputSchema: mcpSchema([]string{"action", "prompt"}, map[string]string{
+			"action": "Action type, e.g. EXEC, CODE_EDITING, MESSAGE, WAKE_UP",
+			"prompt": "The exact command or instruction about to be carried out",
+		}),
+	},
+	{
+		Name:        "check_command",
+		Description: "Check a shell command against the learned behavioral profile. Returns ALLOW, REQUIRE_APPROVAL or BLOCK.",
+		InputSchema: mcpSchema([]string{"command"}, map[string]string{
+			"command": "Shell command to check",
+		}),
+	},
+	{
+		Name: "record_operation",
+		Description: "Record a command that completed successfully so the behavioral profile learns it. " +
+			"Only a command evaluate_risk allowed in this session is learned, once per evaluation.",
+		InputSchema: mcpSchema([]string{"command"}, map[string]string{
+			"command": "Shell command that ran successfully",
+		}),
+	},
+}
+
+// mcpServer serves SentinelGuard and its PolicyGate over the Model Context
+// Protocol (newline-delimited JSON-RPC on stdio), so MCP-capable agents can
+// gate their own actions.
+type mcpServer struct {
+	guard *SentinelGuard
+	// allowed counts the prompts evaluate_risk allowed that
+	// record_operation has not yet learned. The agent reports its own
+	// operations, so it may only teach the profile what the guard allowed.
+	allowed map[string]int
+}
+
+// mcpAllowedLimit bounds the prompts waiting to be recorded.
+const mcpAllowedLimit = 256
+
+// takeAllowed consumes one allowed evaluation of command.
+func (s *mcpServer) takeAllowed(command string) bool {
+	if s.allowed[command] == 0 {
+		return false
+	}
+	if s.allowed[command]--; s.allowed[command] == 0 {
+		delete(s.allowed, command)
+	}
+	return true
+}
+
+// Serve answers requests from in until it is closed.
+func (s *mcpServer) Serve(in io.Reader, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req mcpRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := enc.Encode(mcpResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &mcpError{-32700, "parse error"}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue // notifications, e.g. notifications/initialized
+		}
+		result, rpcErr := s.handle(req)
+		if err := enc.Encode(mcpResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *mcpServer) handle(req mcpRequest) (interface{}, *mcpError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "sentinel", "version": "1"},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": sentinelMCPTools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &mcpError{-32602, "invalid tools/call params: " + err.Error()}
+		}
+		out, err := s.callTool(p.Name, p.Arguments)
+		if err != nil {
+			return mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		b, _ := json.Marshal(out)
+		return mcpToolResult{Content: []mcpContent{{Type: "text", Text: string(b)}}}, nil
+	default:
+		return nil, &mcpError{-32601, "method not found: " + req.Method}
+	}
+}
+
+func (s *mcpServer) callTool(name string, args map[string]string) (interface{}, error) {
+	arg := func(key string) (string, error) {
+		v := strings.TrimSpace(args[key])
+		if v == "" {
+			return "", fmt.Errorf("%s is required", key)
+		}
+		return v, nil
+	}
+	switch name {
+	case "evaluate_risk":
+		action, err := arg("action")
+		if err != nil {
+			return nil, err
+		}
+		prompt, err := arg("prompt")
+		if err != nil {
+			return nil, err
+		}
+		eval, rec, err := s.guard.Enforce(action, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if !eval.ShouldBlock && (s.allowed[prompt] > 0 || len(s.allowed) < mcpAllowedLimit) {
+			if s.allowed == nil {
+				s.allowed = map[string]int{}
+			}
+			s.allowed[prompt]++
+		}
+		return map[string]interface{}{
+			"decision":     rec.Decision,
+			"score":        eval.Score,
+			"tags":         eval.Tags,
+			"reason":       eval.Reason,
+			"should_block": eval.ShouldBlock,
+			"record_hash":  rec.RecordHash,
+		}, nil
+
+	case "check_command":
+		command, err := arg("command")
+		if err != nil {
+			return nil, err
+		}
+		if s.guard.policyGate == nil {
+			return nil, fmt.Errorf("behavioral detection is disabled")
+		}
+		res := s.guard.policyGate.CheckCommand(command)
+		return map[string]interface{}{
+			"action":         res.Action,
+			"reason":         res.Reason,
+			"risk_score":     res.RiskScore,
+			"anomaly_type":   res.AnomalyType,
+			"needs_approval": res.NeedsApproval,
+		}, nil
+
+	case "record_operation":
+		command, err := arg("command")
+		if err != nil {
+			return nil, err
+		}
+		learned := s.takeAllowed(command) && s.guard.learnActivity(ActivityReport{Command: command})
+		return map[string]interface{}{"learned": learned}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// runSentinelMCPMode serves the guard tools over stdio. Logs go to stderr,
+// which MCP clients keep separate from the protocol stream.
+func runSentinelMCPMode(configPath string, in io.Reader, out io.Writer) error {
+	cfg, err := loadSentinelConfigOnly(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load sentinel config: %w", err)
+	}
+	guard := NewSentinelGuard(resolveSentinelConfig(cfg))
+	if guard == nil {
+		return fmt.Errorf("sentinel guard is not configured")
+	}
+	return (&mcpServer{guard: guard}).Serve(in, out)
+}
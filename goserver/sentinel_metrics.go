@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openclawLatencyBuckets are the histogram bounds (seconds) for OpenClaw
+// dispatch latency.
+var openclawLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// sentinelMetrics collects the counters exported on /metrics in the
+// Prometheus text format.
+type sentinelMetrics struct {
+	mu            sync.Mutex
+	evaluations   map[string]uint64 // by decision
+	tags          map[string]uint64 // by tag
+	gateDecisions map[string]uint64 // by gateway decision
+	anchors       map[string]uint64 // success | failure
+
+	mirrors map[string]map[string]uint64 // by backend, then result
+
+	openclawCount   map[string]uint64 // by result
+	openclawSum     map[string]float64
+	openclawBuckets map[string][]uint64
+}
+
+func newSentinelMetrics() *sentinelMetrics {
+	return &sentinelMetrics{
+		evaluations:     map[string]uint64{},
+		tags:            map[string]uint64{},
+		gateDecisions:   map[string]uint64{},
+		anchors:         map[string]uint64{},
+		mirrors:         map[string]map[string]uint64{},
+		openclawCount:   map[string]uint64{},
+		openclawSum:     map[string]float64{},
+		openclawBuckets: map[string][]uint64{},
+	}
+}
+
+func (m *sentinelMetrics) observeEvaluation(decision string, tags []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluations[decision]++
+	for _, t := range tags {
+		m.tags[t]++
+	}
+}
+
+func (m *sentinelMetrics) observeGateDecision(decision string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.gateDecisions[decision]++
+	m.mu.Unlock()
+}
+
+func (m *sentinelMetrics) observeAnchor(err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.mu.Lock()
+	m.anchors[result]++
+	m.mu.Unlock()
+}
+
+func (m *sentinelMetrics) observeMirror(backend string, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.mu.Lock()
+	if m.mirrors[backend] == nil {
+		m.mirrors[backend] = map[string]uint64{}
+	}
+	m.mirrors[backend][result]++
+	m.mu.Unlock()
+}
+
+func (m *sentinelMetrics) observeOpenClaw(d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	secs := d.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	buckets := m.openclawBuckets[result]
+	if buckets == nil {
+		buckets = make([]uint64, len(openclawLatencyBuckets))
+		m.openclawBuckets[result] = buckets
+	}
+	for i, le := range openclawLatencyBuckets {
+		if secs <= le {
+			buckets[i]++
+		}
+	}
+	m.openclawCount[result]++
+	m.openclawSum[result] += secs
+}
+
+// metricGauge is a value sampled at scrape time. kind defaults to gauge;
+// counters kept by other components set it to counter.
+type metricGauge struct {
+	name, help string
+	kind       string
+	labels     map[string]string
+	value      float64
+}
+
+// write renders the counters plus the scrape-time gauges.
+func (m *sentinelMetrics) write(out io.Writer, gauges []metricGauge) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	counter := func(name, help, label string, values map[string]uint64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, k := range sortedKeys(values) {
+			fmt.Fprintf(&b, "%s{%s=%q} %d\n", name, label, k, values[k])
+		}
+	}
+	counter("sentinel_evaluations_total", "Sentinel evaluations by audit decision.", "decision", m.evaluations)
+	counter("sentinel_evaluation_tags_total", "Risk tags raised by Sentinel evaluations.", "tag", m.tags)
+	counter("sentinel_gate_decisions_total", "Gateway decisions returned by /sentinel/gate.", "decision", m.gateDecisions)
+	counter("sentinel_anchor_transactions_total", "On-chain anchor transactions by result.", "result", m.anchors)
+	if len(m.mirrors) > 0 {
+		name := "sentinel_anchor_mirror_total"
+		fmt.Fprintf(&b, "# HELP %s Mirror anchors by backend and result.\n# TYPE %s counter\n", name, name)
+		for _, backend := range sortedKeys(m.mirrors) {
+			for _, result := range sortedKeys(m.mirrors[backend]) {
+				fmt.Fprintf(&b, "%s{backend=%q,result=%q} %d\n", name, backend, result, m.mirrors[backend][result])
+			}
+		}
+	}
+
+	name := "sentinel_openclaw_dispatch_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Latency of OpenClaw task dispatch.\n# TYPE %s histogram\n", name, name)
+	for _, result := range sortedKeys(m.openclawCount) {
+		for i, le := range openclawLatencyBuckets {
+			fmt.Fprintf(&b, "%s_bucket{result=%q,le=\"%g\"} %d\n", name, result, le, m.openclawBuckets[result][i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{result=%q,le=\"+Inf\"} %d\n", name, result, m.openclawCount[result])
+		fmt.Fprintf(&b, "%s_sum{result=%q} %g\n", name, result, m.openclawSum[result])
+		fmt.Fprintf(&b, "%s_count{result=%q} %d\n", name, result, m.openclawCount[result])
+	}
+
+	described := map[string]bool{}
+	for _, g := range gauges {
+		if !described[g.name] {
+			kind := g.kind
+			if kind == "" {
+				kind = "gauge"
+			}
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", g.name, g.help, g.name, kind)
+			described[g.name] = true
+		}
+		labels := make([]string, 0, len(g.labels))
+		for _, k := range sortedKeys(g.labels) {
+			labels = append(labels, fmt.Sprintf("%s=%q", k, g.labels[k]))
+		}
+		if len(labels) > 0 {
+			fmt.Fprintf(&b, "%s{%s} %g\n", g.name, strings.Join(labels, ","), g.value)
+		} else {
+			fmt.Fprintf(&b, "%s %g\n", g.name, g.value)
+		}
+	}
+	io.WriteString(out, b.String())
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func boolGauge(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// handleMetrics serves GET /metrics in the Prometheus text exposition format.
+func (gw *SentinelGateway) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gauges := []metricGauge{
+		{name: "sentinel_kill_switch_armed", help: "1 when the kill switch is armed.", value: boolGauge(gw.kill.IsArmed())},
+		{name: "sentinel_pending_approvals", help: "Approval challenges awaiting a decision.", value: float64(len(gw.approval.ListPending()))},
+		{name: "sentinel_pending_tokens", help: "Unredeemed execute tokens.", value: float64(gw.executor.PendingCount())},
+		{name: "sentinel_risk_threshold", help: "Score at which evaluations block.", value: float64(gw.guard.riskThreshold())},
+	}
+	for _, c := range gw.guard.capabilities.Status() {
+		gauges = append(gauges, metricGauge{
+			name:   "sentinel_capability_available",
+			help:   "1 when the capability is available, 0 while running on a fallback.",
+			labels: map[string]string{"capability": c.Name},
+			value:  boolGauge(c.Available),
+		})
+	}
+	if gw.canary != nil {
+		if last := gw.canary.Last(); last != nil {
+			gauges = append(gauges,
+				metricGauge{name: "sentinel_canary_accuracy", help: "Accuracy of the latest canary run.", value: last.Accuracy},
+				metricGauge{name: "sentinel_canary_passing", help: "1 when the latest canary run met min_accuracy.", value: boolGauge(last.Passed)},
+				metricGauge{name: "sentinel_canary_last_run_timestamp_seconds", help: "Unix time of the latest canary run.", value: float64(last.RanAt.Unix())},
+			)
+		}
+	}
+	for _, c := range gw.notify.Channels() {
+		gauges = append(gauges, metricGauge{
+			name:   "sentinel_notification_channel_dead",
+			help:   "1 when a notification webhook has failed repeatedly.",
+			labels: map[string]string{"channel": fmt.Sprintf("%s#%d", c.Kind, c.Index)},
+			value:  boolGauge(c.Dead),
+		})
+	}
+	if gw.guard.retry != nil {
+		pending, dead := gw.guard.retry.Counts()
+		for _, g := range []struct {
+			state string
+			n     int
+		}{{"pending", pending}, {"dead", dead}} {
+			gauges = append(gauges, metricGauge{
+				name:   "sentinel_anchor_retry_queued",
+				help:   "Records in the anchor retry queue, by state.",
+				labels: map[string]string{"state": g.state},
+				value:  float64(g.n),
+			})
+		}
+	}
+	if gw.guard.sui != nil {
+		// Families are emitted one after another so samples stay grouped.
+		stats := gw.guard.sui.seq.QueueStats()
+		families := []struct {
+			name, help, kind string
+			value            func(TxQueueStats) float64
+		}{
+			{"sentinel_sui_tx_submissions_total", "Sui transactions started, by priority class.", "counter",
+				func(q TxQueueStats) float64 { return float64(q.Submissions) }},
+			{"sentinel_sui_tx_queue_wait_seconds_total", "Time Sui transactions spent queued for the signer, by priority class.", "counter",
+				func(q TxQueueStats) float64 { return q.WaitSecondsTotal }},
+			{"sentinel_sui_tx_queue_wait_seconds_max", "Longest queue wait for the signer, by priority class.", "",
+				func(q TxQueueStats) float64 { return q.MaxWaitSeconds }},
+			{"sentinel_sui_tx_queued", "Sui transactions waiting for the signer, by priority class.", "",
+				func(q TxQueueStats) float64 { return float64(q.Queued) }},
+		}
+		for _, f := range families {
+			for _, q := range stats {
+				gauges = append(gauges, metricGauge{name: f.name, help: f.help, kind: f.kind, labels: map[string]string{"class": q.Class}, value: f.value(q)})
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	gw.guard.metrics.write(w, gauges)
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notification events.
+const (
+	notifyGateBlock       = "gate_block"
+	notifyApproval        = "approval_required"
+	notifyKillSwitchArmed = "kill_switch_armed"
+	notifyKillSwitchOff   = "kill_switch_disarmed"
+	notifyAnchorFailed    = "anchor_failed"
+	notifyCanaryFailed    = "canary_failed"
+	notifyCanaryRecovered = "canary_recovered"
+	notifyChannelDead     = "channel_dead"
+)
+
+// deadChannelFailures is how many deliveries in a row must fail before a
+// channel is flagged dead.
+const deadChannelFailures = 3
+
+// NotificationsConfig posts Sentinel events to chat webhooks so an ops
+// channel sees blocks and kill-switch transitions as they happen.
+type NotificationsConfig struct {
+	Webhooks []WebhookNotifierConfig `json:"webhooks"`
+}
+
+// WebhookNotifierConfig is one Discord or Slack incoming webhook.
+type WebhookNotifierConfig struct {
+	Kind string `json:"kind"` // discord | slack
+	URL  string `json:"url"`
+	// Events limits which events are posted; empty means all.
+	Events []string `json:"events,omitempty"`
+}
+
+// alertJournalName is the file next to the audit log where the gateway
+// records every alert it sends, so incident reports can list them later.
+const alertJournalName = "alerts.jsonl"
+
+// AlertJournalEntry is one line of the alert journal.
+type AlertJournalEntry struct {
+	Time    time.Time         `json:"time"`
+	Event   string            `json:"event"`
+	Title   string            `json:"title"`
+	Summary string            `json:"summary"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func alertJournalPath(auditLogPath string) string {
+	return filepath.Join(filepath.Dir(auditLogPath), alertJournalName)
+}
+
+// notifyField is one labelled value in a notification.
+type notifyField struct {
+	Name, Value string
+}
+
+// SentinelNotification is rendered into an embed (Discord) or blocks (Slack).
+// Prompts are never included; the record hash points at the audit entry.
+type SentinelNotification struct {
+	Event   string
+	Title   string
+	Summary string
+	Fields  []notifyField
+	Time    time.Time
+}
+
+// notifier delivers one notification.
+type notifier interface {
+	Notify(n SentinelNotification) error
+}
+
+type webhookTarget struct {
+	notifier
+	events map[string]bool
+	url    string
+	health ChannelStatus
+}
+
+// ChannelStatus is the delivery record of one webhook. The webhook URL is a
+// credential, so only its host is shown.
+type ChannelStatus struct {
+	Index               int        `json:"index"`
+	Kind                string     `json:"kind"`
+	Host                string     `json:"host"`
+	Delivered           int        `json:"delivered"`
+	Failed              int        `json:"failed"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	// Dead is set after deadChannelFailures failures in a row and cleared
+	// by the next successful delivery.
+	Dead bool `json:"dead"`
+}
+
+// sentinelNotifier fans notifications out to the configured webhooks from a
+// background worker so the gate never waits on chat delivery. It tracks
+// each webhook's delivery results so a channel that stopped working is
+// noticed before an emergency depends on it.
+type sentinelNotifier struct {
+	targets []*webhookTarget
+	queue   chan SentinelNotification
+	// journal, when set, is the JSONL file each alert is appended to
+	// before delivery.
+	journal string
+
+	mu sync.Mutex // guards targets[].health
+}
+
+func newSentinelNotifier(cfg *NotificationsConfig) (*sentinelNotifier, error) {
+	if cfg == nil || len(cfg.Webhooks) == 0 {
+		return nil, nil
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	n := &sentinelNotifier{queue: make(chan SentinelNotification, 100)}
+	for i, wh := range cfg.Webhooks {
+		if strings.TrimSpace(wh.URL) == "" {
+			return nil, fmt.Errorf("notifications.webhooks[%d].url is required", i)
+		}
+		var target notifier
+		switch strings.ToLower(wh.Kind) {
+		case "discord":
+			target = &discordNotifier{url: wh.URL, client: client}
+		case "slack":
+			target = &slackNotifier{url: wh.URL, client: client}
+		default:
+			return nil, fmt.Errorf("notifications.webhooks[%d]: unknown kind %q (use discord or slack)", i, wh.Kind)
+		}
+		var events map[string]bool
+		if len(wh.Events) > 0 {
+			events = map[string]bool{}
+			for _, e := range wh.Events {
+				events[e] = true
+			}
+		}
+		host := wh.URL
+		if u, err := url.Parse(wh.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		n.targets = append(n.targets, &webhookTarget{
+			notifier: target,
+			events:   events,
+			url:      wh.URL,
+			health:   ChannelStatus{Index: i, Kind: strings.ToLower(wh.Kind), Host: host},
+		})
+	}
+	go n.run()
+	return n, nil
+}
+
+// Send queues a notification. It drops (and logs) when the queue is full
+// rather than block the caller. A nil notifier is a no-op.
+func (n *sentinelNotifier) Send(note SentinelNotification) {
+	if n == nil {
+		return
+	}
+	if note.Time.IsZero() {
+		note.Time = time.Now().UTC()
+	}
+	select {
+	case n.queue <- note:
+	default:
+		log.Printf("[NOTIFY] queue full, dropping %s notification", note.Event)
+	}
+}
+
+func (n *sentinelNotifier) run() {
+	for note := range n.queue {
+		if err := n.writeJournal(note); err != nil {
+			log.Printf("[NOTIFY] journal %s: %v", note.Event, err)
+		}
+		for _, t := range n.targets {
+			if note.Event == notifyChannelDead && t.health.Dead {
+				continue
+			}
+			if t.events != nil && !t.events[note.Event] && note.Event != notifyChannelDead {
+				continue
+			}
+			err := t.Notify(note)
+			if err != nil {
+				log.Printf("[NOTIFY] %s: %v", note.Event, strings.ReplaceAll(err.Error(), t.url, t.health.Host))
+			}
+			if died := n.record(t, err); died {
+				n.Send(deadChannelNotification(t.health))
+			}
+		}
+	}
+}
+
+// writeJournal appends note to the alert journal, if there is one.
+func (n *sentinelNotifier) writeJournal(note SentinelNotification) error {
+	if n.journal == "" {
+		return nil
+	}
+	entry := AlertJournalEntry{Time: note.Time, Event: note.Event, Title: note.Title, Summary: note.Summary}
+	if len(note.Fields) > 0 {
+		entry.Fields = map[string]string{}
+		for _, f := range note.Fields {
+			entry.Fields[f.Name] = f.Value
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(n.journal), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(n.journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// record updates a channel's delivery stats and reports whether this
+// failure just made it dead.
+func (n *sentinelNotifier) record(t *webhookTarget, err error) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now().UTC()
+	h := &t.health
+	if err == nil {
+		if h.Dead {
+			log.Printf("[NOTIFY] %s channel %d (%s) is delivering again", h.Kind, h.Index, h.Host)
+		}
+		h.Delivered++
+		h.ConsecutiveFailures = 0
+		h.LastSuccess = &now
+		h.Dead = false
+		return false
+	}
+	h.Failed++
+	h.ConsecutiveFailures++
+	h.LastFailure = &now
+	h.LastError = strings.ReplaceAll(err.Error(), t.url, h.Host)
+	if h.Dead || h.ConsecutiveFailures < deadChannelFailures {
+		return false
+	}
+	h.Dead = true
+	log.Printf("[NOTIFY] %s channel %d (%s) flagged dead after %d failed deliveries", h.Kind, h.Index, h.Host, h.ConsecutiveFailures)
+	return true
+}
+
+// Channels returns the delivery record of every webhook. A nil notifier
+// has none.
+func (n *sentinelNotifier) Channels() []ChannelStatus {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]ChannelStatus, 0, len(n.targets))
+	for _, t := range n.targets {
+		out = append(out, t.health)
+	}
+	return out
+}
+
+// notificationColor is the embed/attachment colour per event.
+func notificationColor(event string) int {
+	switch event {
+	case notifyGateBlock, notifyKillSwitchArmed, notifyAnchorFailed, notifyCanaryFailed, notifyChannelDead, notifyBeneficiaryMismatch:
+		return 0xd93025 // red
+	case notifyApproval, notifyVaultDeadline, notifyPartnerDeadline, notifyVaultOverdue, notifyPartnerOverdue:
+		return 0xf9ab00 // amber
+	default:
+		return 0x1e8e3e // green
+	}
+}
+
+func postWebhook(client *http.Client, url string, payload interface{}) error {
+	body, _ := json.Marshal(payload)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type discordNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (d *discordNotifier) Notify(n SentinelNotification) error {
+	fields := make([]map[string]interface{}, 0, len(n.Fields))
+	for _, f := range n.Fields {
+		fields = append(fields, map[string]interface{}{"name": f.Name, "value": f.Value, "inline": len(f.Value) < 40})
+	}
+	return postWebhook(d.client, d.url, map[string]interface{}{
+		"username": "Sentinel",
+		"embeds": []map[string]interface{}{{
+			"title":       n.Title,
+			"description": n.Summary,
+			"color":       notificationColor(n.Event),
+			"fields":      fields,
+			"timestamp":   n.Time.Format(time.RFC3339),
+		}},
+	})
+}
+
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackNotifier) Notify(n SentinelNotification) error {
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]string{"type": "plain_text", "text": n.Title}},
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": n.Summary}},
+	}
+	if len(n.Fields) > 0 {
+		fields := make([]map[string]string, 0, len(n.Fields))
+		for _, f := range n.Fields {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": "*" + f.Name + "*\n" + f.Value})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	return postWebhook(s.client, s.url, map[string]interface{}{
+		"text": n.Title + ": " + n.Summary,
+		"attachments": []map[string]interface{}{{
+			"color":  fmt.Sprintf("#%06x", notificationColor(n.Event)),
+			"blocks": blocks,
+			"ts":     n.Time.Unix(),
+		}},
+	})
+}
+
+// gateNotification describes a gate decision for the ops channel.
+func gateNotification(event, action string, eval RiskEvaluation, rec *AuditRecord, extra ...notifyField) SentinelNotification {
+	titles := map[string]string{
+		notifyGateBlock: "Sentinel blocked an action",
+		notifyApproval:  "Sentinel action needs approval",
+	}
+	tags := "none"
+	if len(eval.Tags) > 0 {
+		tags = strings.Join(eval.Tags, ", ")
+	}
+	fields := []notifyField{
+		{"Action", action},
+		{"Score", fmt.Sprintf("%d", eval.Score)},
+		{"Tags", tags},
+		{"Record", rec.RecordHash},
+	}
+	if rec.TxDigest != "" {
+		fields = append(fields, notifyField{"Anchor tx", rec.TxDigest})
+	}
+	return SentinelNotification{
+		Event:   event,
+		Title:   titles[event],
+		Summary: eval.Reason,
+		Fields:  append(fields, extra...),
+	}
+}
+
+func killSwitchNotification(armed bool, reason string) SentinelNotification {
+	if armed {
+		return SentinelNotification{
+			Event:   notifyKillSwitchArmed,
+			Title:   "Sentinel kill switch armed",
+			Summary: reason + ". All agent actions are rejected until an operator disarms it.",
+		}
+	}
+	return SentinelNotification{
+		Event:   notifyKillSwitchOff,
+		Title:   "Sentinel kill switch disarmed",
+		Summary: "Agent actions are being evaluated again.",
+	}
+}
+
+// anchorFailureNotification reports one backend failing to anchor a record.
+func anchorFailureNotification(backend string, rec *AuditRecord, err error) SentinelNotification {
+	return SentinelNotification{
+		Event:   notifyAnchorFailed,
+		Title:   fmt.Sprintf("Sentinel %s anchor failed", backend),
+		Summary: err.Error(),
+		Fields: []notifyField{
+			{"Backend", backend},
+			{"Action", rec.Action},
+			{"Record", rec.RecordHash},
+		},
+	}
+}
+
+// deadChannelNotification warns the remaining channels that one stopped
+// delivering.
+func deadChannelNotification(c ChannelStatus) SentinelNotification {
+	return SentinelNotification{
+		Event:   notifyChannelDead,
+		Title:   "Sentinel notification channel is failing",
+		Summary: fmt.Sprintf("The %s webhook on %s failed %d deliveries in a row. Alerts sent to it are being lost; check the webhook before relying on it.", c.Kind, c.Host, c.ConsecutiveFailures),
+		Fields: []notifyField{
+			{"Channel", fmt.Sprintf("%s #%d", c.Kind, c.Index)},
+			{"Last error", truncate(c.LastError, 200)},
+		},
+	}
+}
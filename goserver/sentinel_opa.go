@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OPAConfig hands the final allow/deny decision to user-supplied Rego
+// policies. Policies are evaluated with the opa binary (`opa eval`) rather
+// than the embedded OPA module, whose dependency tree dwarfs the daemon's,
+// and so operators can upgrade opa on its own; the binary runs under the
+// same subprocess limits as the other helpers.
+type OPAConfig struct {
+	Enabled bool `json:"enabled"`
+	// Policies are .rego files or directories passed to opa as --data.
+	Policies []string `json:"policies"`
+	// Query is evaluated against the input; default data.sentinel.decision.
+	Query  string `json:"query,omitempty"`
+	Binary string `json:"binary,omitempty"` // default "opa" on PATH
+	// FailClosed blocks when the policy cannot be evaluated. Otherwise the
+	// heuristic decision stands.
+	FailClosed bool `json:"fail_closed"`
+}
+
+// OPAInput is the document Rego policies see as `input`.
+type OPAInput struct {
+	Action      string   `json:"action"`
+	Prompt      string   `json:"prompt"`
+	Score       int      `json:"score"`
+	Tags        []string `json:"tags"`
+	Reason      string   `json:"reason"`
+	ShouldBlock bool     `json:"should_block"` // the heuristic decision
+	Threshold   int      `json:"threshold"`
+	Agent       OPAAgent `json:"agent"`
+}
+
+// OPAAgent is the agent context: the behavioral profile's identity and its
+// verdict on the prompt.
+type OPAAgent struct {
+	ID          string  `json:"id"`
+	Behavior    string  `json:"behavior,omitempty"` // ALLOW | REQUIRE_APPROVAL | BLOCK
+	RiskScore   float32 `json:"behavior_risk_score"`
+	AnomalyType string  `json:"anomaly_type,omitempty"`
+}
+
+// OPADecision is the value the query must produce. A policy that sets
+// neither allow nor deny leaves the heuristic decision in place; deny wins
+// over allow. Score, when set, replaces the heuristic score.
+type OPADecision struct {
+	Allow  bool     `json:"allow"`
+	Deny   bool     `json:"deny"`
+	Reason string   `json:"reason,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Score  *int     `json:"score,omitempty"`
+}
+
+// opaEngine evaluates the configured policies for one input at a time.
+type opaEngine struct {
+	cfg    OPAConfig
+	policy SubprocessPolicy
+}
+
+// newOPAEngine returns nil when OPA is disabled.
+func newOPAEngine(cfg *OPAConfig, sub *SubprocessConfig) (*opaEngine, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	c := *cfg
+	if len(c.Policies) == 0 {
+		return nil, fmt.Errorf("opa.policies is required")
+	}
+	for _, p := range c.Policies {
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("opa policy: %w", err)
+		}
+	}
+	if c.Query == "" {
+		c.Query = "data.sentinel.decision"
+	}
+	if c.Binary == "" {
+		c.Binary = "opa"
+	}
+	return &opaEngine{cfg: c, policy: sub.policyFor(procOPA)}, nil
+}
+
+// Decide runs `opa eval` and returns nil when the query is undefined.
+func (e *opaEngine) Decide(input OPAInput) (*OPADecision, error) {
+	f, err := os.CreateTemp("", "sentinel-opa-input-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	err = json.NewEncoder(f).Encode(input)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"eval", "--format", "json", "--input", f.Name()}
+	for _, p := range e.cfg.Policies {
+		args = append(args, "--data", p)
+	}
+	args = append(args, e.cfg.Query)
+	out, err := runSubprocess(e.policy, true, e.cfg.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("opa eval failed: %v, output: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	var result struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("decode opa output: %w", err)
+	}
+	if len(result.Result) == 0 || len(result.Result[0].Expressions) == 0 {
+		return nil, nil
+	}
+	var decision OPADecision
+	if err := json.Unmarshal(result.Result[0].Expressions[0].Value, &decision); err != nil {
+		return nil, fmt.Errorf("%s must be an object with allow/deny: %w", e.cfg.Query, err)
+	}
+	if decision.Score != nil && (*decision.Score < 0 || *decision.Score > 100) {
+		return nil, fmt.Errorf("%s returned score %d outside 0-100", e.cfg.Query, *decision.Score)
+	}
+	return &decision, nil
+}
+
+// applyOPA lets the Rego policy override the heuristic evaluation.
+func (sg *SentinelGuard) applyOPA(eval *RiskEvaluation, action, prompt string, behavior *PolicyResult) {
+	input := OPAInput{
+		Action:      action,
+		Prompt:      prompt,
+		Score:       eval.Score,
+		Tags:        eval.Tags,
+		Reason:      eval.Reason,
+		ShouldBlock: eval.ShouldBlock,
+		Threshold:   sg.actionThreshold(action),
+	}
+	if sg.policyGate != nil {
+		input.Agent.ID = sg.policyGate.agentID
+	}
+	if behavior != nil {
+		input.Agent.Behavior = behavior.Action
+		input.Agent.RiskScore = behavior.RiskScore
+		input.Agent.AnomalyType = behavior.AnomalyType
+	}
+
+	decision, err := sg.opa.Decide(input)
+	if err != nil {
+		sg.capabilities.set(capOPA, false, err.Error())
+		eval.Tags = dedupe(append(eval.Tags, "opa_error"))
+		if sg.opa.cfg.FailClosed {
+			eval.ShouldBlock = true
+			eval.Tags = append(eval.Tags, "opa_fail_closed")
+			eval.Reason += "; opa policy could not be evaluated (fail-closed)"
+		}
+		return
+	}
+	sg.capabilities.set(capOPA, true, "")
+	if decision == nil {
+		return
+	}
+
+	if decision.Score != nil {
+		eval.Score = *decision.Score
+	}
+	eval.Tags = dedupe(append(eval.Tags, decision.Tags...))
+	switch {
+	case decision.Deny:
+		eval.ShouldBlock = true
+		eval.Tags = dedupe(append(eval.Tags, "opa_deny"))
+	case decision.Allow:
+		eval.ShouldBlock = false
+		eval.Tags = dedupe(append(eval.Tags, "opa_allow"))
+	}
+	if decision.Reason != "" {
+		eval.Reason += "; opa: " + decision.Reason
+	}
+}
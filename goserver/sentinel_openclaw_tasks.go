@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openClawTaskIdleTTL is how long a task is remembered after its last step.
+const openClawTaskIdleTTL = time.Hour
+
+// OpenClawStepRequest is the input to POST /sentinel/openclaw/step. OpenClaw
+// (through the sentinel-guard plugin's before_tool_call hook) reports every
+// tool call it is about to make while running a task.
+type OpenClawStepRequest struct {
+	TaskID  string `json:"task_id"` // OpenClaw session or run id
+	Tool    string `json:"tool,omitempty"`
+	Action  string `json:"action,omitempty"` // inferred from Tool when empty
+	Prompt  string `json:"prompt"`           // command or tool parameters
+	AgentID string `json:"agent_id,omitempty"`
+}
+
+// OpenClawStepResponse is the gate decision for one step. Abort tells
+// OpenClaw to stop the whole task, not only this tool call.
+type OpenClawStepResponse struct {
+	GateResponse
+	TaskID string `json:"task_id"`
+	Step   int    `json:"step"`
+	Abort  bool   `json:"abort"`
+}
+
+// OpenClawTask tracks the steps Sentinel has seen for one task.
+type OpenClawTask struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Steps     int       `json:"steps"`
+	Status    string    `json:"status"` // running | aborted
+	// AbortRecord is the audit record of the step that aborted the task.
+	AbortRecord string `json:"abort_record,omitempty"`
+	AbortReason string `json:"abort_reason,omitempty"`
+}
+
+// openClawTasks remembers tasks by id so a block aborts every later step of
+// the same task. Idle tasks are forgotten after openClawTaskIdleTTL.
+type openClawTasks struct {
+	mu    sync.Mutex
+	tasks map[string]*OpenClawTask
+	now   func() time.Time
+}
+
+func newOpenClawTasks() *openClawTasks {
+	return &openClawTasks{tasks: map[string]*OpenClawTask{}, now: func() time.Time { return time.Now().UTC() }}
+}
+
+// step registers the next step of a task, creating the task on its first
+// step, and returns a copy of the task after counting the step.
+func (t *openClawTasks) step(id string) OpenClawTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	for tid, task := range t.tasks {
+		if now.Sub(task.UpdatedAt) > openClawTaskIdleTTL {
+			delete(t.tasks, tid)
+		}
+	}
+	task, ok := t.tasks[id]
+	if !ok {
+		task = &OpenClawTask{ID: id, StartedAt: now, Status: "running"}
+		t.tasks[id] = task
+	}
+	task.Steps++
+	task.UpdatedAt = now
+	return *task
+}
+
+func (t *openClawTasks) abort(id, recordHash, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if task, ok := t.tasks[id]; ok && task.Status != "aborted" {
+		task.Status = "aborted"
+		task.AbortRecord = recordHash
+		task.AbortReason = reason
+	}
+}
+
+// Counts returns the number of running and aborted tasks.
+func (t *openClawTasks) Counts() (running, aborted int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, task := range t.tasks {
+		if task.Status == "aborted" {
+			aborted++
+		} else {
+			running++
+		}
+	}
+	return running, aborted
+}
+
+// openClawToolAction maps an OpenClaw tool name to the gate action
+// categories the agent uses with sentinel_gate.
+func openClawToolAction(tool string) string {
+	lower := strings.ToLower(tool)
+	switch {
+	case strings.Contains(lower, "exec") || strings.Contains(lower, "bash") || strings.Contains(lower, "shell") || strings.Contains(lower, "process"):
+		return "EXEC"
+	case strings.Contains(lower, "browser"):
+		return "BROWSER"
+	case strings.Contains(lower, "wallet") || strings.Contains(lower, "transfer") || strings.Contains(lower, "sign"):
+		return "WALLET"
+	case strings.Contains(lower, "fetch") || strings.Contains(lower, "search") || strings.Contains(lower, "http"):
+		return "NETWORK"
+	case strings.Contains(lower, "edit") || strings.Contains(lower, "patch"):
+		return "CODE_EDITING"
+	case strings.Contains(lower, "read") || strings.Contains(lower, "write") || strings.Contains(lower, "file"):
+		return "FS"
+	default:
+		return "EXEC"
+	}
+}
+
+// handleOpenClawStep gates one intra-task step. Steps go through the same
+// pipeline as /sentinel/gate; a BLOCK or kill switch aborts the task, and
+// every later step of an aborted task is refused without evaluation.
+// REQUIRE_APPROVAL holds only the current step.
+func (gw *SentinelGateway) handleOpenClawStep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req OpenClawStepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	req.TaskID = strings.TrimSpace(req.TaskID)
+	if req.TaskID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "task_id is required"})
+		return
+	}
+	if req.Action == "" {
+		req.Action = openClawToolAction(req.Tool)
+	}
+	if req.Prompt == "" {
+		req.Prompt = req.Tool
+	}
+
+	task := gw.tasks.step(req.TaskID)
+	if task.Status == "aborted" {
+		gw.guard.metrics.observeGateDecision("BLOCK")
+		out := OpenClawStepResponse{
+			GateResponse: GateResponse{
+				Decision:   "BLOCK",
+				Tags:       []string{"task_aborted"},
+				Reason:     "task was aborted: " + task.AbortReason,
+				RecordHash: task.AbortRecord,
+			},
+			TaskID: task.ID,
+			Step:   task.Steps,
+			Abort:  true,
+		}
+		gw.recordStep(req, out)
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	resp, status, err := gw.gate(GateRequest{Action: req.Action, Prompt: req.Prompt, AgentID: req.AgentID})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	out := OpenClawStepResponse{GateResponse: resp, TaskID: task.ID, Step: task.Steps}
+	if resp.Decision == "BLOCK" || resp.Decision == "TRIGGER_KILL_SWITCH" {
+		out.Abort = true
+		gw.tasks.abort(task.ID, resp.RecordHash, resp.Reason)
+		log.Printf("[OPENCLAW] task=%s aborted at step %d: %s", task.ID, task.Steps, resp.Reason)
+	}
+	gw.recordStep(req, out)
+	writeJSON(w, status, out)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// policyListChangeAction is the audit action of a runtime list edit.
+const policyListChangeAction = "POLICY_LIST_CHANGE"
+
+// The lists a PolicyListChange can edit.
+const (
+	listAllowPrompts   = "allow_prompts"
+	listDenyPatterns   = "deny_patterns"
+	listTrustedActions = "trusted_actions"
+)
+
+// PolicyListsConfig enables lists that can be edited while the daemon runs.
+type PolicyListsConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path is where the lists are kept; default policy-lists.json next to
+	// the audit log.
+	Path string `json:"path,omitempty"`
+}
+
+// AllowedPrompt allows one action/prompt pair whatever its score. It waives
+// only the score block: blocking keyword rules, the denylist, approval
+// policies and the domain allowlist still apply. Prompts match after
+// lower-casing and collapsing whitespace.
+type AllowedPrompt struct {
+	Action  string    `json:"action"`
+	Prompt  string    `json:"prompt"`
+	Hash    string    `json:"hash"` // actionTemplateHash(action, prompt)
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// DeniedPattern blocks any request matching a case-insensitive regexp.
+type DeniedPattern struct {
+	Pattern string    `json:"pattern"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// TrustedAction is an action type whose score never blocks it. Its
+// threshold is unchanged; a score that reaches it is waived and tagged
+// trusted_action. Everything that blocks regardless of score still does,
+// e.g. blocking rules, the denylist and require_approval policies.
+type TrustedAction struct {
+	Action  string    `json:"action"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// PolicyLists is the persisted state, served by GET /sentinel/lists.
+type PolicyLists struct {
+	AllowPrompts   []AllowedPrompt `json:"allow_prompts"`
+	DenyPatterns   []DeniedPattern `json:"deny_patterns"`
+	TrustedActions []TrustedAction `json:"trusted_actions"`
+}
+
+// PolicyListChange adds or removes one entry.
+type PolicyListChange struct {
+	Op   string `json:"op"`   // add, remove
+	List string `json:"list"` // allow_prompts, deny_patterns, trusted_actions
+	// Value is the prompt, pattern or action. Removing from allow_prompts
+	// also accepts the entry's hash.
+	Value  string `json:"value"`
+	Action string `json:"action,omitempty"` // allow_prompts only
+	Note   string `json:"note,omitempty"`
+	By     string `json:"by,omitempty"`
+}
+
+// policyListStore holds the lists and their compiled form.
+type policyListStore struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	lists   PolicyLists
+	deny    []*regexp.Regexp
+	allow   map[string]bool
+	trusted map[string]bool
+}
+
+// newPolicyListStore returns nil when the lists are disabled.
+func newPolicyListStore(cfg *PolicyListsConfig, auditLogPath string) (*policyListStore, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	path := cfg.Path
+	if path == "" {
+		path = filepath.Join(filepath.Dir(auditLogPath), "policy-lists.json")
+	}
+	s := &policyListStore{path: path, now: func() time.Time { return time.Now().UTC() }}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.lists); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if err := s.compile(s.lists); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// compile rebuilds the lookup tables from lists. Caller holds mu or owns s.
+func (s *policyListStore) compile(lists PolicyLists) error {
+	deny := make([]*regexp.Regexp, 0, len(lists.DenyPatterns))
+	for _, p := range lists.DenyPatterns {
+		re, err := regexp.Compile("(?i)" + p.Pattern)
+		if err != nil {
+			return fmt.Errorf("deny pattern %q: %w", p.Pattern, err)
+		}
+		deny = append(deny, re)
+	}
+	allow := map[string]bool{}
+	for i, p := range lists.AllowPrompts {
+		lists.AllowPrompts[i].Hash = actionTemplateHash(p.Action, p.Prompt)
+		allow[lists.AllowPrompts[i].Hash] = true
+	}
+	trusted := map[string]bool{}
+	for _, a := range lists.TrustedActions {
+		trusted[a.Action] = true
+	}
+	if lists.AllowPrompts == nil {
+		lists.AllowPrompts = []AllowedPrompt{}
+	}
+	if lists.DenyPatterns == nil {
+		lists.DenyPatterns = []DeniedPattern{}
+	}
+	if lists.TrustedActions == nil {
+		lists.TrustedActions = []TrustedAction{}
+	}
+	s.lists, s.deny, s.allow, s.trusted = lists, deny, allow, trusted
+	return nil
+}
+
+// Lists returns a copy of the current lists.
+func (s *policyListStore) Lists() PolicyLists {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.copyLists()
+}
+
+func (s *policyListStore) copyLists() PolicyLists {
+	return PolicyLists{
+		AllowPrompts:   append([]AllowedPrompt{}, s.lists.AllowPrompts...),
+		DenyPatterns:   append([]DeniedPattern{}, s.lists.DenyPatterns...),
+		TrustedActions: append([]TrustedAction{}, s.lists.TrustedActions...),
+	}
+}
+
+// Apply edits the lists and saves them. Nothing changes if the save fails.
+func (s *policyListStore) Apply(c PolicyListChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next, err := s.edit(c)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	return s.compile(next)
+}
+
+// Check reports whether c would apply to the current lists.
+func (s *policyListStore) Check(c PolicyListChange) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, err := s.edit(c)
+	return err
+}
+
+// edit returns the lists with c applied. Caller holds mu.
+func (s *policyListStore) edit(c PolicyListChange) (PolicyLists, error) {
+	value := strings.TrimSpace(c.Value)
+	if value == "" {
+		return PolicyLists{}, fmt.Errorf("value is required")
+	}
+	next := s.copyLists()
+	now := s.now()
+	switch c.List + " " + c.Op {
+	case listAllowPrompts + " add":
+		action := strings.ToUpper(strings.TrimSpace(c.Action))
+		if action == "" {
+			return PolicyLists{}, fmt.Errorf("action is required for allow_prompts")
+		}
+		hash := actionTemplateHash(action, value)
+		if s.allow[hash] {
+			return PolicyLists{}, fmt.Errorf("prompt is already allowlisted for %s", action)
+		}
+		next.AllowPrompts = append(next.AllowPrompts, AllowedPrompt{Action: action, Prompt: value, Hash: hash, Note: c.Note, AddedAt: now})
+	case listAllowPrompts + " remove":
+		hash := value
+		if c.Action != "" {
+			hash = actionTemplateHash(c.Action, value)
+		}
+		kept := next.AllowPrompts[:0]
+		for _, p := range next.AllowPrompts {
+			if p.Hash != hash {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(next.AllowPrompts) {
+			return PolicyLists{}, errPolicyListEntryNotFound
+		}
+		next.AllowPrompts = kept
+	case listDenyPatterns + " add":
+		if _, err := regexp.Compile("(?i)" + value); err != nil {
+			return PolicyLists{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+		for _, p := range next.DenyPatterns {
+			if p.Pattern == value {
+				return PolicyLists{}, fmt.Errorf("pattern is already denylisted")
+			}
+		}
+		next.DenyPatterns = append(next.DenyPatterns, DeniedPattern{Pattern: value, Note: c.Note, AddedAt: now})
+	case listDenyPatterns + " remove":
+		kept := next.DenyPatterns[:0]
+		for _, p := range next.DenyPatterns {
+			if p.Pattern != value {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(next.DenyPatterns) {
+			return PolicyLists{}, errPolicyListEntryNotFound
+		}
+		next.DenyPatterns = kept
+	case listTrustedActions + " add":
+		action := strings.ToUpper(value)
+		if s.trusted[action] {
+			return PolicyLists{}, fmt.Errorf("%s is already trusted", action)
+		}
+		next.TrustedActions = append(next.TrustedActions, TrustedAction{Action: action, Note: c.Note, AddedAt: now})
+	case listTrustedActions + " remove":
+		action := strings.ToUpper(value)
+		kept := next.TrustedActions[:0]
+		for _, a := range next.TrustedActions {
+			if a.Action != action {
+				kept = append(kept, a)
+			}
+		}
+		if len(kept) == len(next.TrustedActions) {
+			return PolicyLists{}, errPolicyListEntryNotFound
+		}
+		next.TrustedActions = kept
+	default:
+		return PolicyLists{}, fmt.Errorf("unknown change %q on list %q (op is add or remove; list is allow_prompts, deny_patterns or trusted_actions)", c.Op, c.List)
+	}
+	return next, nil
+}
+
+var errPolicyListEntryNotFound = errors.New("entry not found")
+
+// denied returns the first deny pattern matching prompt.
+func (s *policyListStore) denied(prompt string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i, re := range s.deny {
+		if re.MatchString(prompt) {
+			return s.lists.DenyPatterns[i].Pattern
+		}
+	}
+	return ""
+}
+
+// allowed returns the hash of the allowlisted prompt matching action and
+// prompt, or "".
+func (s *policyListStore) allowed(action, prompt string) string {
+	if s == nil {
+		return ""
+	}
+	hash := actionTemplateHash(action, prompt)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.allow[hash] {
+		return hash
+	}
+	return ""
+}
+
+func (s *policyListStore) isTrusted(action string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trusted[strings.ToUpper(strings.TrimSpace(action))]
+}
+
+// ChangePolicyList applies c and records it, followed by a config snapshot
+// so the new lists hash is on the audit chain.
+func (sg *SentinelGuard) ChangePolicyList(c PolicyListChange) (*AuditRecord, error) {
+	if err := sg.lists.Apply(c); err != nil {
+		return nil, err
+	}
+	c.Value = strings.TrimSpace(c.Value)
+	b, _ := json.Marshal(c)
+	rec := &AuditRecord{
+		Timestamp: time.Now().UTC(),
+		Action:    policyListChangeAction,
+		Prompt:    string(b),
+		Tags:      []string{"policy_list"},
+		Decision:  "recorded",
+		Reason:    fmt.Sprintf("%s %s: %s", c.Op, c.List, truncate(c.Value, 120)),
+	}
+	if err := sg.persistRecord(rec); err != nil {
+		return nil, err
+	}
+	if _, err := sg.RecordConfigSnapshot("policy list change"); err != nil {
+		log.Printf("[LISTS] snapshot after list change failed: %v", err)
+	}
+	return rec, nil
+}
+
+// handlePolicyLists serves the lists (GET) and edits them (POST). With
+// runtime_config enabled, edits must go through the two-key config API.
+func (gw *SentinelGateway) handlePolicyLists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, gw.guard.lists.Lists())
+	case http.MethodPost:
+		if gw.config != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "runtime_config is enabled: propose list changes through /sentinel/config/propose"})
+			return
+		}
+		var req PolicyListChange
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+		rec, err := gw.guard.ChangePolicyList(req)
+		if err != nil {
+			code := http.StatusBadRequest
+			if err == errPolicyListEntryNotFound {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("[LISTS] %s %s by=%s record=%s", req.Op, req.List, req.By, rec.RecordHash)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"lists": gw.guard.lists.Lists(), "record_hash": rec.RecordHash})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// runListsCommand implements `lists show|add|remove` against a running
+// proxy, so the change takes effect without a restart.
+func runListsCommand(args []string, out io.Writer) error {
+	usage := fmt.Errorf("usage: lists show|add|remove [--url <proxy>] --list allow_prompts|deny_patterns|trusted_actions --value <v> [--action <ACTION>] [--note <text>]")
+	if len(args) == 0 || (args[0] != "show" && args[0] != "add" && args[0] != "remove") {
+		return usage
+	}
+	fs := flag.NewFlagSet("lists "+args[0], flag.ContinueOnError)
+	proxyURL := fs.String("url", "http://127.0.0.1:18080", "Sentinel proxy base URL")
+	list := fs.String("list", "", "allow_prompts, deny_patterns or trusted_actions")
+	value := fs.String("value", "", "Prompt, pattern or action to add or remove")
+	action := fs.String("action", "", "Action of an allow_prompts entry")
+	note := fs.String("note", "", "Why the entry was added")
+	tokenEnv := fs.String("token-env", "SENTINEL_OPERATOR_TOKEN", "Environment variable holding the proxy's bearer token")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(*proxyURL, "/") + "/sentinel/lists"
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var resp *http.Response
+	var err error
+	if args[0] == "show" {
+		resp, err = client.Get(endpoint)
+	} else {
+		if *list == "" || *value == "" {
+			return usage
+		}
+		by := os.Getenv("USER")
+		body, _ := json.Marshal(PolicyListChange{Op: args[0], List: *list, Value: *value, Action: *action, Note: *note, By: by})
+		req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(os.Getenv(*tokenEnv)))
+		resp, err = client.Do(req)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return encodeSentinelOutput(out, v)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// PolicyProxyConfig tells the standalone policy proxy which action the
+// requests it intercepts are evaluated as. The action comes from the
+// operator's config, never from the payload, so a caller cannot pick a
+// laxer one.
+type PolicyProxyConfig struct {
+	// RouteActions maps URL path prefixes to actions, e.g.
+	// {"/v1/exec": "EXEC"}. Prefixes match whole path segments and the
+	// longest matching prefix wins.
+	RouteActions map[string]string `json:"route_actions,omitempty"`
+	// DefaultAction is used when no route matches; default TOOL_CALL.
+	DefaultAction string `json:"default_action,omitempty"`
+	// MaxBodyBytes caps the payload read for evaluation; default 1 MiB.
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+}
+
+// PolicyProxyBlock is the 403 body returned for a blocked payload.
+type PolicyProxyBlock struct {
+	Error      string         `json:"error"`
+	Action     string         `json:"action"`
+	Evaluation RiskEvaluation `json:"evaluation"`
+	Decision   string         `json:"decision"`
+	RecordHash string         `json:"record_hash"`
+}
+
+// policyProxy sits in front of an agent or LLM tool server. Every request
+// is enforced through the guard; allowed requests are forwarded unchanged
+// and blocked ones never reach the upstream.
+type policyProxy struct {
+	guard    *SentinelGuard
+	cfg      PolicyProxyConfig
+	upstream *httputil.ReverseProxy
+}
+
+func newPolicyProxy(guard *SentinelGuard, upstream string, cfg *PolicyProxyConfig) (*policyProxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+	p := &policyProxy{guard: guard, upstream: httputil.NewSingleHostReverseProxy(u)}
+	if cfg != nil {
+		p.cfg = *cfg
+	}
+	if p.cfg.DefaultAction == "" {
+		p.cfg.DefaultAction = "TOOL_CALL"
+	}
+	if p.cfg.MaxBodyBytes <= 0 {
+		p.cfg.MaxBodyBytes = 1 << 20
+	}
+	return p, nil
+}
+
+func (p *policyProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The upstream may resolve dot segments and repeated slashes itself, so
+	// a path that does not match its cleaned form could be evaluated as one
+	// route and served as another.
+	if clean := cleanURLPath(r.URL.Path); clean != r.URL.Path {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "request path is not canonical; use " + clean})
+		return
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, p.cfg.MaxBodyBytes+1))
+		r.Body.Close()
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+			return
+		}
+		if int64(len(body)) > p.cfg.MaxBodyBytes {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "request body is too large to evaluate"})
+			return
+		}
+	}
+
+	// Nothing is forwarded unevaluated: the path and query are always part
+	// of the evaluated text, followed by every key and value of the payload.
+	action := p.action(r.URL.Path)
+	prompt, err := p.evaluatedText(r, body)
+	if err != nil {
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": err.Error()})
+		return
+	}
+
+	eval, rec, err := p.guard.Enforce(action, prompt)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if eval.ShouldBlock {
+		log.Printf("[POLICY-PROXY] blocked %s %s action=%s score=%d record=%s", r.Method, r.URL.Path, action, eval.Score, rec.RecordHash)
+		writeJSON(w, http.StatusForbidden, PolicyProxyBlock{
+			Error:      "blocked by Sentinel policy",
+			Action:     action,
+			Evaluation: eval,
+			Decision:   rec.Decision,
+			RecordHash: rec.RecordHash,
+		})
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("X-Sentinel-Record-Hash", rec.RecordHash)
+	p.upstream.ServeHTTP(w, r)
+}
+
+// action returns the configured action for path.
+func (p *policyProxy) action(path string) string {
+	action, longest := p.cfg.DefaultAction, -1
+	for prefix, a := range p.cfg.RouteActions {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if !routeMatches(path, prefix) || len(prefix) <= longest || strings.TrimSpace(a) == "" {
+			continue
+		}
+		action, longest = strings.TrimSpace(a), len(prefix)
+	}
+	return action
+}
+
+// routeMatches reports whether prefix, without a trailing slash, covers
+// path on a segment boundary: /v1/exec covers /v1/exec/run, not /v1/executor.
+func routeMatches(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// cleanURLPath is path.Clean keeping a trailing slash.
+func cleanURLPath(p string) string {
+	clean := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return clean
+}
+
+// evaluatedText is what the guard evaluates for a request: its path and
+// unescaped query, then the normalized JSON payload. A request without a
+// body is evaluated as its method, path and query. Bodies the proxy cannot
+// read as plain JSON are refused rather than forwarded unread.
+func (p *policyProxy) evaluatedText(r *http.Request, body []byte) (string, error) {
+	text := r.URL.Path
+	if q := r.URL.RawQuery; q != "" {
+		if unescaped, err := url.QueryUnescape(q); err == nil {
+			q = unescaped
+		}
+		text += "?" + q
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return r.Method + " " + text, nil
+	}
+	if enc := strings.TrimSpace(r.Header.Get("Content-Encoding")); enc != "" && !strings.EqualFold(enc, "identity") {
+		return "", fmt.Errorf("encoded request bodies (%s) cannot be evaluated", enc)
+	}
+	if !strings.Contains(r.Header.Get("Content-Type"), "json") {
+		return "", fmt.Errorf("only JSON request bodies can be evaluated")
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if err := rejectDuplicateKeys(body); err != nil {
+		return "", fmt.Errorf("invalid JSON body: %v", err)
+	}
+	var lines []string
+	flattenJSON("", doc, &lines)
+	return text + "\n" + strings.Join(lines, "\n"), nil
+}
+
+// rejectDuplicateKeys fails on an object that repeats a key. The guard
+// would evaluate the last value while the upstream may act on the first.
+func rejectDuplicateKeys(body []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			seen := map[string]bool{}
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := tok.(string)
+				child := key
+				if path != "" {
+					child = path + "." + key
+				}
+				if seen[key] {
+					return fmt.Errorf("duplicate key %s", child)
+				}
+				seen[key] = true
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		_, err = dec.Token() // the closing delimiter
+		return err
+	}
+	return walk("")
+}
+
+// flattenJSON appends one "path: value" line per leaf of doc, with object
+// keys sorted, so escapes are decoded and key order cannot hide anything.
+func flattenJSON(path string, doc interface{}, lines *[]string) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			flattenJSON(child, v[k], lines)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", path, i), item, lines)
+		}
+	default:
+		b, _ := json.Marshal(v)
+		if s, ok := v.(string); ok {
+			b = []byte(s)
+		}
+		*lines = append(*lines, path+": "+string(b))
+	}
+}
+
+// runSentinelPolicyProxyMode serves the policy proxy on listenAddr in front
+// of upstream until interrupted.
+func runSentinelPolicyProxyMode(configPath, upstream, listenAddr string) error {
+	cfg, err := loadSentinelConfigOnly(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load sentinel config: %w", err)
+	}
+	guard := NewSentinelGuard(resolveSentinelConfig(cfg))
+	if guard == nil {
+		return fmt.Errorf("sentinel guard is not configured")
+	}
+	proxy, err := newPolicyProxy(guard, upstream, guard.cfg.PolicyProxy)
+	if err != nil {
+		return err
+	}
+	guard.StartAnchorRetryWorker()
+	if rec, err := guard.RecordConfigSnapshot("startup"); err != nil {
+		log.Printf("  Config snapshot: not recorded: %v", err)
+	} else {
+		log.Printf("  Config snapshot: %s", rec.Reason)
+	}
+
+	log.Println("=== Sentinel Policy Proxy ===")
+	log.Printf("  Listen:   %s", listenAddr)
+	log.Printf("  Upstream: %s", upstream)
+	log.Printf("  Actions:  %d route(s), default %s", len(proxy.cfg.RouteActions), proxy.cfg.DefaultAction)
+
+	srv := &http.Server{Addr: listenAddr, Handler: proxy}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Println("\nShutting down Sentinel policy proxy...")
+		srv.Close()
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -151,67 +149,77 @@ func computeMerkleRoot(entries []ProofEntry) string {
 	return "0x" + hex.EncodeToString(hashes[0])
 }
 
-// uploadToWalrus POSTs the JSON-marshaled batch to the Walrus publisher and returns
-// the blob ID / CID from the response.
-func uploadToWalrus(walrusURL string, batch *MerkleBatch) (string, error) {
-	body, err := json.Marshal(batch)
-	if err != nil {
-		return "", fmt.Errorf("marshal batch: %w", err)
-	}
-
-	url := walrusURL + "/v1/blobs?epochs=5"
-	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("walrus upload: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read walrus response: %w", err)
+// computeMerkleProof returns the sibling hashes needed to recompute the Merkle
+// root for the entry at index, ordered from leaf to root. It mirrors the tree
+// shape of computeMerkleRoot, including duplication of odd trailing nodes.
+func computeMerkleProof(entries []ProofEntry, index int) ([]string, error) {
+	if index < 0 || index >= len(entries) {
+		return nil, fmt.Errorf("merkle proof index %d out of range (%d entries)", index, len(entries))
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("walrus returned status %d: %s", resp.StatusCode, string(respBody))
+	hashes := make([][]byte, len(entries))
+	for i, e := range entries {
+		h := sha256.Sum256([]byte(e.ChainHash))
+		hashes[i] = h[:]
 	}
 
-	// The Walrus publisher may return the blob ID in various envelope shapes.
-	// Try the known response formats.
-	var newlyCreated struct {
-		NewlyCreated struct {
-			BlobObject struct {
-				BlobID string `json:"blobId"`
-			} `json:"blobObject"`
-		} `json:"newlyCreated"`
-	}
-	if err := json.Unmarshal(respBody, &newlyCreated); err == nil && newlyCreated.NewlyCreated.BlobObject.BlobID != "" {
-		return newlyCreated.NewlyCreated.BlobObject.BlobID, nil
-	}
+	proof := []string{}
+	pos := index
+	for len(hashes) > 1 {
+		if len(hashes)%2 != 0 {
+			hashes = append(hashes, hashes[len(hashes)-1])
+		}
+		sibling := pos ^ 1
+		proof = append(proof, hex.EncodeToString(hashes[sibling]))
 
-	var alreadyCertified struct {
-		AlreadyCertified struct {
-			BlobID string `json:"blobId"`
-		} `json:"alreadyCertified"`
-	}
-	if err := json.Unmarshal(respBody, &alreadyCertified); err == nil && alreadyCertified.AlreadyCertified.BlobID != "" {
-		return alreadyCertified.AlreadyCertified.BlobID, nil
+		next := make([][]byte, 0, len(hashes)/2)
+		for i := 0; i < len(hashes); i += 2 {
+			combined := append(append([]byte{}, hashes[i]...), hashes[i+1]...)
+			h := sha256.Sum256(combined)
+			next = append(next, h[:])
+		}
+		hashes = next
+		pos /= 2
 	}
+	return proof, nil
+}
 
-	// Fallback: try a flat blob_id or cid field.
-	var flat struct {
-		BlobID string `json:"blob_id"`
-		CID    string `json:"cid"`
-	}
-	if err := json.Unmarshal(respBody, &flat); err == nil {
-		if flat.BlobID != "" {
-			return flat.BlobID, nil
+// verifyMerkleProof recomputes the root from a chain hash, its index, and the
+// sibling path produced by computeMerkleProof.
+func verifyMerkleProof(chainHash string, index int, proof []string, root string) bool {
+	leaf := sha256.Sum256([]byte(chainHash))
+	current := leaf[:]
+	pos := index
+	for _, siblingHex := range proof {
+		sibling, err := hex.DecodeString(siblingHex)
+		if err != nil {
+			return false
 		}
-		if flat.CID != "" {
-			return flat.CID, nil
+		var combined []byte
+		if pos%2 == 0 {
+			combined = append(append([]byte{}, current...), sibling...)
+		} else {
+			combined = append(append([]byte{}, sibling...), current...)
 		}
+		h := sha256.Sum256(combined)
+		current = h[:]
+		pos /= 2
 	}
+	return "0x"+hex.EncodeToString(current) == root
+}
 
-	return "", fmt.Errorf("could not extract blob ID from walrus response: %s", string(respBody))
+// uploadToWalrus stores the JSON-marshaled batch on Walrus and returns its
+// blob ID.
+func uploadToWalrus(walrusURL string, batch *MerkleBatch) (string, error) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return "", fmt.Errorf("marshal batch: %w", err)
+	}
+	blob, err := newWalrusClient(walrusURL, http.DefaultClient).Store(body, WalrusStoreOptions{Epochs: 5})
+	if err != nil {
+		return "", err
+	}
+	return blob.BlobID, nil
 }
 
 // GetLatestProof returns the most recent proof entry, or nil if the chain is empty.
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retentionPurgeAction is the audit action of the record that documents a
+// retention pass: which records lost their prompt and which session
+// transcripts were deleted.
+const retentionPurgeAction = "RETENTION_PURGE"
+
+// retentionExemptActions carry Sentinel's own JSON in the prompt field
+// rather than user input, and later verification depends on it.
+var retentionExemptActions = map[string]bool{
+	"CONFIG_SNAPSHOT":      true,
+	"CONFIG_CHANGE":        true,
+	anchorRetryAction:      true,
+	auditCheckpointAction:  true,
+	sessionAuditAction:     true,
+	retentionPurgeAction:   true,
+	policyListChangeAction: true,
+}
+
+// RetentionConfig redacts prompt bodies from the audit log once they are
+// older than RedactAfterDays.
+type RetentionConfig struct {
+	Enabled         bool `json:"enabled"`
+	RedactAfterDays int  `json:"redact_after_days"`
+	IntervalSec     int  `json:"interval_seconds"` // worker tick, default 3600
+}
+
+// AuditRedaction marks a record whose prompt was removed. The record keeps
+// its prompt_sha256, so RecordHash still recomputes. Records written before
+// prompt commitments are verified through the hash chain and their anchor.
+type AuditRedaction struct {
+	At          time.Time `json:"at"`
+	PromptBytes int       `json:"prompt_bytes"`
+}
+
+// PurgedRecord is one record a retention pass redacted.
+type PurgedRecord struct {
+	Line        int       `json:"line"`
+	RecordHash  string    `json:"record_hash"`
+	Timestamp   time.Time `json:"timestamp"`
+	Action      string    `json:"action"`
+	PromptBytes int       `json:"prompt_bytes"`
+}
+
+// PurgeReport documents exactly what a retention pass removed.
+type PurgeReport struct {
+	Log             string         `json:"log"`
+	RanAt           time.Time      `json:"ran_at"`
+	Cutoff          time.Time      `json:"cutoff"`
+	DryRun          bool           `json:"dry_run,omitempty"`
+	Redacted        []PurgedRecord `json:"redacted"`
+	SessionsDeleted []string       `json:"sessions_deleted"`
+	// RecordHash is the RETENTION_PURGE record appended for this pass.
+	RecordHash string `json:"record_hash,omitempty"`
+}
+
+// redactAuditLog rewrites the log with the prompts of user records older
+// than cutoff removed. Untouched lines are kept byte for byte, and the file
+// is replaced atomically. With dryRun the log is left alone.
+func redactAuditLog(path string, cutoff, now time.Time, dryRun bool) ([]PurgedRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	var purged []PurgedRecord
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("audit log %s line %d: %w", path, i+1, err)
+		}
+		if rec.Redacted != nil || rec.Prompt == "" || retentionExemptActions[rec.Action] || !rec.Timestamp.Before(cutoff) {
+			continue
+		}
+		purged = append(purged, PurgedRecord{Line: i + 1, RecordHash: rec.RecordHash, Timestamp: rec.Timestamp, Action: rec.Action, PromptBytes: len(rec.Prompt)})
+		rec.Redacted = &AuditRedaction{At: now, PromptBytes: len(rec.Prompt)}
+		rec.Prompt = ""
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = string(b)
+	}
+	if len(purged) == 0 || dryRun {
+		return purged, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// retentionPurges indexes RETENTION_PURGE records: the hashes of the
+// records they redacted and the sessions whose transcripts they deleted.
+func retentionPurges(records []AuditRecord) (redacted, sessions map[string]bool) {
+	redacted, sessions = map[string]bool{}, map[string]bool{}
+	for _, rec := range records {
+		if rec.Action != retentionPurgeAction {
+			continue
+		}
+		var report PurgeReport
+		if json.Unmarshal([]byte(rec.Prompt), &report) != nil {
+			continue
+		}
+		for _, r := range report.Redacted {
+			redacted[r.RecordHash] = true
+		}
+		for _, id := range report.SessionsDeleted {
+			sessions[id] = true
+		}
+	}
+	return redacted, sessions
+}
+
+// errAuditLogBusy is returned by purge while another process appends to
+// the log.
+var errAuditLogBusy = errors.New("the audit log is in use by another process; stop the proxy or enable sentinel.retention")
+
+// lockAuditLog takes the advisory lock on <audit log>.lock. Every process
+// that appends to the log holds it shared until it exits. A purge needs it
+// exclusively, so it cannot rewrite the log under a running proxy. The
+// caller holds chainMu.
+func (sg *SentinelGuard) lockAuditLog(exclusive bool) error {
+	if sg.auditLock != nil && !exclusive {
+		return nil
+	}
+	if sg.auditLock == nil {
+		if err := os.MkdirAll(filepath.Dir(sg.cfg.AuditLogPath), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(sg.cfg.AuditLogPath+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		sg.auditLock = f
+	}
+	if err := flockAuditLog(sg.auditLock, exclusive); err != nil {
+		if exclusive {
+			return errAuditLogBusy
+		}
+		return err
+	}
+	return nil
+}
+
+// PurgeBefore redacts the prompts of records older than cutoff, deletes the
+// transcripts of sessions sealed before it, and appends a RETENTION_PURGE
+// record listing both. Nothing is appended when there was nothing to purge.
+// It fails with errAuditLogBusy while another process holds the log.
+func (sg *SentinelGuard) PurgeBefore(cutoff time.Time, dryRun bool) (*PurgeReport, error) {
+	sg.chainMu.Lock()
+	report, rec, seq, err := sg.purgeLocked(cutoff, dryRun)
+	sg.chainMu.Unlock()
+	if err != nil || rec == nil {
+		return report, err
+	}
+	if err := sg.commitRecord(seq, rec); err != nil {
+		return nil, err
+	}
+	report.RecordHash = rec.RecordHash
+	return report, nil
+}
+
+// purgeLocked rewrites the log and reserves the RETENTION_PURGE record,
+// which is nil when nothing was purged. The caller holds chainMu.
+func (sg *SentinelGuard) purgeLocked(cutoff time.Time, dryRun bool) (*PurgeReport, *AuditRecord, uint64, error) {
+	sg.drainChainLocked()
+	if !dryRun {
+		if err := sg.lockAuditLog(true); err != nil {
+			return nil, nil, 0, err
+		}
+		defer flockAuditLog(sg.auditLock, false)
+	}
+
+	now := time.Now().UTC()
+	report := &PurgeReport{Log: sg.cfg.AuditLogPath, RanAt: now, Cutoff: cutoff, DryRun: dryRun, SessionsDeleted: []string{}}
+	purged, err := redactAuditLog(sg.cfg.AuditLogPath, cutoff, now, dryRun)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	report.Redacted = append([]PurgedRecord{}, purged...)
+
+	if sg.sessions != nil {
+		records, err := readAuditRecords(sg.cfg.AuditLogPath)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		_, gone := retentionPurges(records)
+		for _, rec := range records {
+			if rec.Action != sessionAuditAction || !rec.Timestamp.Before(cutoff) {
+				continue
+			}
+			var s SessionSummary
+			if json.Unmarshal([]byte(rec.Prompt), &s) != nil || s.SessionID == "" || gone[s.SessionID] {
+				continue
+			}
+			if !dryRun {
+				if err := os.Remove(filepath.Join(sg.sessions.dir, sessionFileName(s.SessionID))); err != nil && !os.IsNotExist(err) {
+					return nil, nil, 0, err
+				}
+			}
+			report.SessionsDeleted = append(report.SessionsDeleted, s.SessionID)
+		}
+	}
+
+	if dryRun || len(report.Redacted)+len(report.SessionsDeleted) == 0 {
+		return report, nil, 0, nil
+	}
+	b, _ := json.Marshal(report)
+	rec := &AuditRecord{
+		Timestamp: now,
+		Action:    retentionPurgeAction,
+		Prompt:    string(b),
+		Tags:      []string{"retention"},
+		Decision:  "recorded",
+		Reason:    fmt.Sprintf("redacted %d prompts and deleted %d session transcripts older than %s", len(report.Redacted), len(report.SessionsDeleted), cutoff.Format(time.RFC3339)),
+	}
+	return report, rec, sg.reserveRecordLocked(rec), nil
+}
+
+// StartRetentionWorker purges expired prompts at the configured interval.
+// The goroutine runs until the process exits.
+func (sg *SentinelGuard) StartRetentionWorker() {
+	cfg := sg.cfg.Retention
+	if cfg == nil || !cfg.Enabled || cfg.RedactAfterDays <= 0 {
+		return
+	}
+	interval := time.Duration(cfg.IntervalSec) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	keep := time.Duration(cfg.RedactAfterDays) * 24 * time.Hour
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			report, err := sg.PurgeBefore(time.Now().UTC().Add(-keep), false)
+			switch {
+			case err != nil:
+				log.Printf("[RETENTION] purge failed: %v", err)
+			case report.RecordHash != "":
+				log.Printf("[RETENTION] redacted %d prompts, deleted %d transcripts (record %s)", len(report.Redacted), len(report.SessionsDeleted), report.RecordHash)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// parseRetentionAge accepts a Go duration or a whole number of days ("30d").
+func parseRetentionAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use a duration like 720h or days like 30d)", s)
+	}
+	return d, nil
+}
+
+// runPurgeCommand implements `purge`. It rewrites the audit log, so it
+// refuses to run while a proxy holds the log; a running proxy purges on its
+// own when sentinel.retention is enabled.
+func runPurgeCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	configPath := fs.String("config", "configs/config.json", "Path to configuration file")
+	olderThan := fs.String("older-than", "", "Redact prompts older than this (e.g. 30d or 720h); default sentinel.retention.redact_after_days")
+	dryRun := fs.Bool("dry-run", false, "Report what would be removed without changing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	sentinelCfg, err := loadSentinelConfigOnly(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load sentinel config: %w", err)
+	}
+	guard := NewSentinelGuard(resolveSentinelConfig(sentinelCfg))
+
+	age := time.Duration(0)
+	switch {
+	case *olderThan != "":
+		if age, err = parseRetentionAge(*olderThan); err != nil {
+			return fmt.Errorf("--older-than: %w", err)
+		}
+	case guard.cfg.Retention != nil && guard.cfg.Retention.RedactAfterDays > 0:
+		age = time.Duration(guard.cfg.Retention.RedactAfterDays) * 24 * time.Hour
+	default:
+		return fmt.Errorf("--older-than is required when sentinel.retention.redact_after_days is not set")
+	}
+
+	report, err := guard.PurgeBefore(time.Now().UTC().Add(-age), *dryRun)
+	if err != nil {
+		return err
+	}
+	return encodeSentinelOutput(out, report)
+}
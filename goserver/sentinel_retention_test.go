@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetentionRedactsPromptsAndKeepsTheChainVerifiable(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{SessionRecording: &SessionRecordingConfig{Enabled: true, MaxPayloadBytes: 256}})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+
+	_, old, err := guard.Enforce("MESSAGE", "email alice@example.com her salary slip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := guard.startSession("task-1", old.RecordHash, map[string]string{"prompt": "salary slip"})
+	guard.finishSession(id, &OpenClawResponse{Status: "ok"}, nil)
+	cutoff := time.Now().UTC()
+	_, fresh, err := guard.Enforce("FS", "ls -la")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dry, err := guard.PurgeBefore(cutoff, true)
+	if err != nil || len(dry.Redacted) != 1 || dry.RecordHash != "" {
+		t.Fatalf("dry run should only report: %+v %v", dry, err)
+	}
+	if records, _ := readAuditRecords(guard.cfg.AuditLogPath); records[0].Prompt == "" {
+		t.Fatal("dry run must not change the log")
+	}
+
+	report, err := guard.PurgeBefore(cutoff, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Redacted) != 1 || report.Redacted[0].RecordHash != old.RecordHash || report.Redacted[0].PromptBytes != len(old.Prompt) {
+		t.Fatalf("only the old user prompt should be redacted: %+v", report.Redacted)
+	}
+	if len(report.SessionsDeleted) != 1 || report.SessionsDeleted[0] != id || report.RecordHash == "" {
+		t.Fatalf("old transcript should be deleted and the pass recorded: %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(guard.sessions.dir, sessionFileName(id))); !os.IsNotExist(err) {
+		t.Fatalf("transcript still on disk: %v", err)
+	}
+
+	records, err := readAuditRecords(guard.cfg.AuditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Prompt != "" || records[0].Redacted == nil || records[0].RecordHash != old.RecordHash {
+		t.Fatalf("old record should keep its hash without the prompt: %+v", records[0])
+	}
+	if records[2].RecordHash != fresh.RecordHash || records[2].Prompt != "ls -la" {
+		t.Fatalf("newer records are untouched: %+v", records[2])
+	}
+	last := records[len(records)-1]
+	if last.Action != retentionPurgeAction || strings.Contains(last.Prompt, "alice") {
+		t.Fatalf("purge record should list hashes, not content: %+v", last)
+	}
+	if v := verifyAuditChain(records); !v.Valid || v.Redacted != 1 {
+		t.Fatalf("redacted log should still verify: %+v", v)
+	}
+	if !auditHashMatches(&records[0]) {
+		t.Fatal("a redacted record's hash should recompute from its prompt commitment")
+	}
+	tampered := append([]AuditRecord{}, records...)
+	tampered[0].Score++
+	if v := verifyAuditChain(tampered); v.Valid || v.FirstBroken != 1 {
+		t.Fatalf("an edit to a redacted record should fail verification: %+v", v)
+	}
+	if code, body := getSession(t, gw, id); code != http.StatusGone {
+		t.Fatalf("purged transcript should be reported gone: %d %v", code, body)
+	}
+
+	if again, err := guard.PurgeBefore(cutoff, false); err != nil || again.RecordHash != "" {
+		t.Fatalf("nothing left to purge: %+v %v", again, err)
+	}
+
+	// Marking a record redacted without a purge listing it hides an edit.
+	records[2].Redacted = &AuditRedaction{At: cutoff}
+	records[2].Score = 0
+	if v := verifyAuditChain(records); v.Valid || v.FirstBroken != 3 || !strings.Contains(v.Problem, "RETENTION_PURGE") {
+		t.Fatalf("unlisted redaction should fail verification: %+v", v)
+	}
+}
+
+func TestPurgeCommand(t *testing.T) {
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	cfg, _ := json.Marshal(map[string]interface{}{"sentinel": map[string]interface{}{
+		"enabled":        true,
+		"audit_log_path": auditPath,
+		"retention":      map[string]interface{}{"enabled": true, "redact_after_days": 30},
+	}})
+	configPath := filepath.Join(dir, "config.json")
+	os.WriteFile(configPath, cfg, 0o600)
+
+	guard := newTestGuard(t, SentinelConfig{AuditLogPath: auditPath})
+	if _, _, err := guard.Enforce("EXEC", "cat /home/bob/notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The guard above holds the log like a running proxy would.
+	var out bytes.Buffer
+	if err := runPurgeCommand([]string{"--config", configPath}, &out); !errors.Is(err, errAuditLogBusy) {
+		t.Fatalf("purge should refuse a log in use: %v", err)
+	}
+	guard.auditLock.Close()
+	if err := runPurgeCommand([]string{"--config", configPath}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var report PurgeReport
+	json.Unmarshal(out.Bytes(), &report)
+	if len(report.Redacted) != 0 {
+		t.Fatalf("a fresh record is within the 30 day retention: %s", out.String())
+	}
+
+	out.Reset()
+	if err := runPurgeCommand([]string{"--config", configPath, "--older-than", "-1h"}, &out); err == nil {
+		t.Fatal("a negative age should be rejected")
+	}
+	for in, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := parseRetentionAge(in); err != nil || got != want {
+			t.Fatalf("%s: got %s %v", in, got, err)
+		}
+	}
+}
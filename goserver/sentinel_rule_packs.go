@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// builtinRulePackName is the pack compiled into the binary. Operator packs
+// override its rules by ID or disable them.
+const builtinRulePackName = "builtin"
+
+// RulePack is a named set of keyword rules loaded from sentinel.rule_packs.
+// Packs are merged in ascending priority, so a higher priority pack wins
+// when two define the same rule ID.
+type RulePack struct {
+	Name     string        `json:"name"`
+	Priority int           `json:"priority"`
+	Rules    []KeywordRule `json:"rules"`
+}
+
+// KeywordRule adds Points and Tag to an evaluation when any of its patterns
+// matches the action or prompt, case-insensitively. Patterns are substrings
+// unless Match selects regular expressions or globs. Rules sharing a tag
+// score once, at the highest points among those that matched.
+type KeywordRule struct {
+	ID       string   `json:"id"`
+	Match    string   `json:"match,omitempty"` // keyword (default) | regex | glob
+	Pattern  string   `json:"pattern,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	// TimeoutMs is accepted for older packs and ignored: rules always scan
+	// the whole input.
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+	Points    int    `json:"points"`
+	Tag       string `json:"tag"`
+	Reason    string `json:"reason"`
+	// Block forces the evaluation to block whatever the total score.
+	Block bool `json:"block,omitempty"`
+	// ExemptRoutineTransfers skips the rule for transfers that
+	// rules.transfers classifies as routine.
+	ExemptRoutineTransfers bool `json:"exempt_routine_transfers,omitempty"`
+	// Disabled removes a rule of the same ID from lower priority packs.
+	Disabled bool `json:"disabled,omitempty"`
+
+	matcher func(lower string) bool // compiled by normalize
+}
+
+// builtinRulePack holds the guard's default detection rules: the keyword
+// lists it has always used plus regexes for their common variants. Its tags are relied on elsewhere (the gateway's violation categories,
+// the injection + exec combination), so overrides should keep them.
+var builtinRulePack = mustRulePack(RulePack{
+	Name: builtinRulePackName,
+	Rules: []KeywordRule{
+		{ID: "prompt_injection", Patterns: []string{"ignore previous", "ignore all", "system prompt", "developer message", "bypass"},
+			Points: 35, Tag: "prompt_injection", Reason: "detected instruction override pattern"},
+		{ID: "wallet_credentials", Patterns: []string{"private key", "seed phrase", "mnemonic", "sign transaction"},
+			Points: 30, Tag: "wallet_risk", Reason: "wallet/credential operation requested", Block: true},
+		{ID: "wallet_transfer", Patterns: []string{"wallet", "transfer usdc"},
+			Points: 30, Tag: "wallet_risk", Reason: "wallet/credential operation requested", Block: true, ExemptRoutineTransfers: true},
+		{ID: "dangerous_exec", Patterns: []string{"curl", "wget", "bash -c", "rm -rf", "chmod 777", "sudo",
+			"invoke-expression", "iex(", "downloadstring", "remove-item -recurse", "powershell -enc"},
+			Points: 30, Tag: "dangerous_exec", Reason: "high-risk shell behavior requested"},
+		{ID: "data_exfiltration", Patterns: []string{"send to", "post to", "email", "telegram", "discord", "whatsapp", "x.com"},
+			Points: 15, Tag: "data_exfiltration", Reason: "external outbound channel detected"},
+		{ID: "policy_bypass", Patterns: []string{"disable safety", "turn off security", "no confirmation"},
+			Points: 25, Tag: "policy_bypass", Reason: "explicit security bypass attempt", Block: true},
+		// Spacing and split-letter variants the substring rules miss.
+		{ID: "prompt_injection_variants", Match: ruleMatchRegex, Patterns: []string{
+			`ignore\s+(all\s+)?(previous|prior)\s+instructions`, `by\s*-?\s*pass\s+(the\s+)?(rules|safety|guard)`},
+			Points: 35, Tag: "prompt_injection", Reason: "detected instruction override pattern"},
+		{ID: "dangerous_exec_variants", Match: ruleMatchRegex, Patterns: []string{
+			`\br\s*m\s+(-[a-z]*\s+)*-[a-z]*(r[a-z]*f|f[a-z]*r)`, `\bchmod\s+(-r\s+)?0?777\b`, `\bs\s+u\s+d\s+o\b`},
+			Points: 30, Tag: "dangerous_exec", Reason: "high-risk shell behavior requested"},
+	},
+})
+
+func mustRulePack(pack RulePack) RulePack {
+	for i := range pack.Rules {
+		if err := pack.Rules[i].normalize(); err != nil {
+			panic("rule pack " + pack.Name + ": " + err.Error())
+		}
+	}
+	return pack
+}
+
+// loadRulePacks reads every file matched by the configured paths or globs.
+// Files ending in .yaml or .yml are read as YAML, anything else as JSON; a
+// pack without a name is named after its file.
+func loadRulePacks(patterns []string) ([]RulePack, error) {
+	var packs []RulePack
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule pack pattern %q: %w", pattern, err)
+		}
+		if len(paths) == 0 {
+			if _, statErr := os.Stat(pattern); statErr != nil {
+				return nil, statErr
+			}
+			paths = []string{pattern}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			pack, err := loadRulePack(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			packs = append(packs, pack)
+		}
+	}
+	return packs, nil
+}
+
+func loadRulePack(path string) (RulePack, error) {
+	var pack RulePack
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pack, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return pack, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&pack); err != nil {
+		return pack, err
+	}
+	if pack.Name == "" {
+		pack.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	for i := range pack.Rules {
+		if err := pack.Rules[i].normalize(); err != nil {
+			return pack, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return pack, nil
+}
+
+// normalize folds Pattern into Patterns, compiles them for matching and
+// rejects rules that could never fire or would skew the score.
+func (r *KeywordRule) normalize() error {
+	if r.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if r.Disabled {
+		return nil
+	}
+	if r.Pattern != "" {
+		r.Patterns = append([]string{r.Pattern}, r.Patterns...)
+		r.Pattern = ""
+	}
+	if r.Match == "" {
+		r.Match = ruleMatchKeyword
+	}
+	patterns := r.Patterns[:0]
+	for _, p := range r.Patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			if r.Match != ruleMatchRegex {
+				p = strings.ToLower(p)
+			}
+			patterns = append(patterns, p)
+		}
+	}
+	r.Patterns = patterns
+	switch {
+	case len(r.Patterns) == 0:
+		return fmt.Errorf("%s: at least one pattern is required", r.ID)
+	case r.Tag == "":
+		return fmt.Errorf("%s: tag is required", r.ID)
+	case r.Points < 0 || r.Points > 100:
+		return fmt.Errorf("%s: points must be between 0 and 100", r.ID)
+	}
+	if r.Reason == "" {
+		r.Reason = "matched rule " + r.ID
+	}
+	matcher, err := compileRulePatterns(r.Match, r.Patterns)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.ID, err)
+	}
+	r.matcher = matcher
+	return nil
+}
+
+// mergeRulePacks combines the built-in pack with the operator's packs. Packs
+// apply in ascending priority (the built-in pack first among equals); a
+// rule replaces an earlier one with the same ID in place, and a disabled
+// rule removes it.
+func mergeRulePacks(packs []RulePack) []KeywordRule {
+	ordered := append([]RulePack{builtinRulePack}, packs...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority < ordered[j].Priority })
+
+	var merged []KeywordRule
+	index := map[string]int{}
+	for _, pack := range ordered {
+		for _, r := range pack.Rules {
+			if i, ok := index[r.ID]; ok {
+				merged[i] = r
+				continue
+			}
+			index[r.ID] = len(merged)
+			merged = append(merged, r)
+		}
+	}
+	rules := merged[:0]
+	for _, r := range merged {
+		if !r.Disabled {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// keywordRules returns the merged rules in force; a guard built without
+// NewSentinelGuard uses the built-in pack.
+func (sg *SentinelGuard) keywordRules() []KeywordRule {
+	if sg.keywords == nil {
+		return builtinRulePack.Rules
+	}
+	return sg.keywords
+}
+
+// keywordMatch is one tag contributed by the keyword rules.
+type keywordMatch struct {
+	Tag    string
+	Points int
+	Reason string
+	Block  bool
+}
+
+// matchKeywordRules returns the tags whose rules matched lower, in rule
+// order. routineTransfer exempts rules flagged ExemptRoutineTransfers.
+func matchKeywordRules(rules []KeywordRule, lower string, routineTransfer bool) []keywordMatch {
+	var matches []keywordMatch
+	byTag := map[string]int{}
+	for _, r := range rules {
+		if r.ExemptRoutineTransfers && routineTransfer {
+			continue
+		}
+		if !r.matches(lower) {
+			continue
+		}
+		i, ok := byTag[r.Tag]
+		if !ok {
+			byTag[r.Tag] = len(matches)
+			matches = append(matches, keywordMatch{Tag: r.Tag, Points: r.Points, Reason: r.Reason, Block: r.Block})
+			continue
+		}
+		m := &matches[i]
+		if r.Points > m.Points {
+			m.Points, m.Reason = r.Points, r.Reason
+		}
+		m.Block = m.Block || r.Block
+	}
+	return matches
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule pattern kinds.
+const (
+	ruleMatchKeyword = "keyword"
+	ruleMatchRegex   = "regex"
+	ruleMatchGlob    = "glob"
+)
+
+// maxRulePatternLen rejects patterns too large to review or compile cheaply.
+const maxRulePatternLen = 512
+
+// compileRulePatterns returns a matcher over the lower-cased action and
+// prompt. Regexes use Go's RE2 engine, which runs in linear time, so no
+// pattern can backtrack catastrophically and the whole input is always
+// scanned. Globs match whole whitespace-separated words,
+// where * and ? also match "/", so `/etc/*shadow*` and `*.pem` do what an
+// operator expects.
+func compileRulePatterns(kind string, patterns []string) (func(string) bool, error) {
+	switch kind {
+	case ruleMatchKeyword:
+		return func(lower string) bool { return hasAny(lower, patterns...) }, nil
+	case ruleMatchRegex, ruleMatchGlob:
+	default:
+		return nil, fmt.Errorf("match must be %s, %s or %s", ruleMatchKeyword, ruleMatchRegex, ruleMatchGlob)
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		if len(p) > maxRulePatternLen {
+			return nil, fmt.Errorf("pattern longer than %d bytes", maxRulePatternLen)
+		}
+		expr := p
+		if kind == ruleMatchGlob {
+			expr = globToRegexp(p)
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	if kind == ruleMatchGlob {
+		return func(lower string) bool {
+			for _, word := range strings.Fields(lower) {
+				for _, re := range compiled {
+					if re.MatchString(word) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(lower string) bool {
+		for _, re := range compiled {
+			if re.MatchString(lower) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// globToRegexp translates *, ? and [...] to an anchored regular expression
+// and escapes everything else.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// matches reports whether the rule fires on lower.
+func (r *KeywordRule) matches(lower string) bool {
+	return r.matcher != nil && r.matcher(lower)
+}
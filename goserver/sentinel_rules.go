@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SentinelRules holds operator-maintained classification rules. They can be
+// inlined under sentinel.rules or kept in a separate file referenced by
+// sentinel.rules_file; the file takes precedence.
+type SentinelRules struct {
+	Infra     *InfraRules    `json:"infra,omitempty"`
+	Git       *GitRules      `json:"git,omitempty"`
+	Transfers *TransferRules `json:"transfers,omitempty"`
+}
+
+// InfraRules allowlists destructive container/Kubernetes operations. Each
+// configured list must match; an empty list is not consulted.
+type InfraRules struct {
+	AllowedNamespaces []string `json:"allowed_namespaces"`
+	AllowedClusters   []string `json:"allowed_clusters"` // kube/docker context or cluster names
+}
+
+func loadSentinelRules(path string) (*SentinelRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules SentinelRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse rules file %s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// resolveSentinelRules returns the rules in force for cfg.
+func resolveSentinelRules(cfg *SentinelConfig) (*SentinelRules, error) {
+	if cfg.RulesFile == "" {
+		return cfg.Rules, nil
+	}
+	return loadSentinelRules(cfg.RulesFile)
+}
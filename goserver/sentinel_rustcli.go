@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RustCLICapabilities is the output of `lazarus-vault --capabilities`.
+type RustCLICapabilities struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Commands []string `json:"commands"`
+}
+
+// Supports reports whether the binary advertises the given subcommand.
+func (c *RustCLICapabilities) Supports(command string) bool {
+	for _, cmd := range c.Commands {
+		if cmd == command {
+			return true
+		}
+	}
+	return false
+}
+
+// rustCLIProbe caches the negotiation result for one binary path.
+type rustCLIProbe struct {
+	path string
+	caps *RustCLICapabilities // nil for legacy binaries without --capabilities
+	err  error
+}
+
+// rustCLIResolver discovers the Rust CLI, pins its checksum and negotiates
+// which subcommands it supports. Results are cached per configured path.
+type rustCLIResolver struct {
+	mu     sync.Mutex
+	pin    string // expected sha256 hex, empty = no pinning
+	policy SubprocessPolicy
+	probes map[string]*rustCLIProbe
+}
+
+func newRustCLIResolver(pinnedSHA256 string, policy SubprocessPolicy) *rustCLIResolver {
+	return &rustCLIResolver{
+		pin:    strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pinnedSHA256), "0x")),
+		policy: policy,
+		probes: make(map[string]*rustCLIProbe),
+	}
+}
+
+// command returns the binary path to invoke for subcommand, or an error if the
+// binary is missing, fails its checksum pin, or does not advertise it.
+func (r *rustCLIResolver) command(configured, subcommand string) (string, error) {
+	if r == nil {
+		return configured, nil
+	}
+
+	probe := r.probe(configured)
+	if probe.err != nil {
+		return "", probe.err
+	}
+	if probe.caps != nil && len(probe.caps.Commands) > 0 && !probe.caps.Supports(subcommand) {
+		return "", fmt.Errorf("rust cli %s (version %s) does not support %s", probe.path, probe.caps.Version, subcommand)
+	}
+	return probe.path, nil
+}
+
+// capabilities returns the negotiated capabilities for a configured path.
+func (r *rustCLIResolver) capabilities(configured string) (*RustCLICapabilities, string, error) {
+	if r == nil {
+		return nil, configured, nil
+	}
+	probe := r.probe(configured)
+	return probe.caps, probe.path, probe.err
+}
+
+func (r *rustCLIResolver) probe(configured string) *rustCLIProbe {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.probes[configured]; ok {
+		return p
+	}
+
+	p := &rustCLIProbe{}
+	p.path, p.err = resolveRustCLIPath(configured)
+	if p.err == nil && r.pin != "" {
+		p.err = verifyBinaryChecksum(p.path, r.pin)
+	}
+	if p.err == nil {
+		p.caps = queryRustCLICapabilities(p.path, r.policy)
+	}
+	r.probes[configured] = p
+	return p
+}
+
+// resolveRustCLIPath returns the configured binary if it exists, otherwise
+// falls back to looking up the same binary name on PATH.
+func resolveRustCLIPath(configured string) (string, error) {
+	if configured == "" {
+		return "", fmt.Errorf("rust cli path not configured")
+	}
+	if info, err := os.Stat(configured); err == nil && !info.IsDir() {
+		return configured, nil
+	}
+	if found, err := exec.LookPath(filepath.Base(configured)); err == nil {
+		return found, nil
+	}
+	return "", fmt.Errorf("rust cli not found at %s or on PATH", configured)
+}
+
+func verifyBinaryChecksum(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != expected {
+		return fmt.Errorf("rust cli %s checksum mismatch: expected %s, got %s", path, expected, got)
+	}
+	return nil
+}
+
+// queryRustCLICapabilities runs `--capabilities`. Binaries that predate the
+// flag return nil and are assumed to support the original command set.
+func queryRustCLICapabilities(path string, policy SubprocessPolicy) *RustCLICapabilities {
+	out, err := runSubprocess(policy, true, path, "--capabilities")
+	if err != nil {
+		return nil
+	}
+	var caps RustCLICapabilities
+	if err := json.Unmarshal(out, &caps); err != nil || len(caps.Commands) == 0 {
+		return nil
+	}
+	return &caps
+}
+
+// signHashNative produces the same Ed25519 signature as `sign-audit` using the
+// Go standard library. It is the default signer; the Rust CLI is opt-in.
+func signHashNative(recordHash string, priv ed25519.PrivateKey) (*signCLIOutput, error) {
+	msg, err := hex.DecodeString(strings.TrimPrefix(recordHash, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("record_hash must be a hex string: %w", err)
+	}
+	return &signCLIOutput{
+		RecordHash: recordHash,
+		Signature:  hex.EncodeToString(ed25519.Sign(priv, msg)),
+		PublicKey:  hex.EncodeToString(priv.Public().(ed25519.PublicKey)),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCapabilitiesCLI(t *testing.T, dir, commands string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "lazarus-vault")
+	script := `#!/bin/sh
+if [ "$1" = "--capabilities" ]; then
+  echo '{"name":"lazarus-vault","version":"9.9.9","commands":[` + commands + `]}'
+  exit 0
+fi
+echo '{"record_hash":"0xfromcli"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake cli: %v", err)
+	}
+	return path
+}
+
+func TestRustCLIResolverHonoursAdvertisedCommands(t *testing.T) {
+	path := writeCapabilitiesCLI(t, t.TempDir(), `"hash-audit"`)
+	r := newRustCLIResolver("", (*SubprocessConfig)(nil).policyFor(procRustCLI))
+
+	if got, err := r.command(path, "hash-audit"); err != nil || got != path {
+		t.Fatalf("expected hash-audit supported, got %q, %v", got, err)
+	}
+	if _, err := r.command(path, "sign-audit"); err == nil || !strings.Contains(err.Error(), "does not support sign-audit") {
+		t.Fatalf("expected sign-audit unsupported error, got %v", err)
+	}
+}
+
+func TestRustCLIResolverChecksumPin(t *testing.T) {
+	path := writeCapabilitiesCLI(t, t.TempDir(), `"hash-audit"`)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fake cli: %v", err)
+	}
+	sum := sha256.Sum256(data)
+
+	if _, err := newRustCLIResolver(hex.EncodeToString(sum[:]), (*SubprocessConfig)(nil).policyFor(procRustCLI)).command(path, "hash-audit"); err != nil {
+		t.Fatalf("expected matching pin to pass, got %v", err)
+	}
+	if _, err := newRustCLIResolver(strings.Repeat("0", 64), (*SubprocessConfig)(nil).policyFor(procRustCLI)).command(path, "hash-audit"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestRustCLIResolverMissingBinary(t *testing.T) {
+	if _, err := newRustCLIResolver("", (*SubprocessConfig)(nil).policyFor(procRustCLI)).command(filepath.Join(t.TempDir(), "no-such-cli"), "hash-audit"); err == nil {
+		t.Fatalf("expected missing binary error")
+	}
+}
+
+func TestSignHashFallsBackToNativeWhenCLILacksSignAudit(t *testing.T) {
+	path := writeCapabilitiesCLI(t, t.TempDir(), `"hash-audit"`)
+	guard := newTestGuard(t, SentinelConfig{
+		HashCLIPath:     path,
+		SignPrivKey:     testSignSeed,
+		SignWithRustCLI: true,
+	})
+
+	recordHash := "0x" + strings.Repeat("ab", 32)
+	out, err := guard.signHash(recordHash)
+	if err != nil {
+		t.Fatalf("signHash failed: %v", err)
+	}
+
+	pub, _ := hex.DecodeString(out.PublicKey)
+	sig, _ := hex.DecodeString(out.Signature)
+	msg, _ := hex.DecodeString(strings.Repeat("ab", 32))
+	if !ed25519.Verify(ed25519.PublicKey(pub), msg, sig) {
+		t.Fatalf("native signature does not verify")
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionAuditAction is the audit action that closes a recorded session.
+// Its prompt is a SessionSummary, so the audit chain commits to the head of
+// the session's own hash chain.
+const sessionAuditAction = "OPENCLAW_SESSION"
+
+// SessionRecordingConfig records what OpenClaw did with each task Sentinel
+// let through.
+type SessionRecordingConfig struct {
+	Enabled bool `json:"enabled"`
+	// Dir defaults to sessions/ next to the audit log.
+	Dir string `json:"dir"`
+	// MaxPayloadBytes truncates large requests, responses and steps;
+	// default 65536.
+	MaxPayloadBytes int `json:"max_payload_bytes"`
+}
+
+// SessionEntry is one line of a session file. The first entry's PrevHash
+// is the record hash of the gate decision that allowed the task; every
+// later entry links to the one before it.
+type SessionEntry struct {
+	Seq       int             `json:"seq"`
+	Timestamp time.Time       `json:"timestamp"`
+	Kind      string          `json:"kind"` // request | step | response | error
+	Payload   json.RawMessage `json:"payload"`
+	PrevHash  string          `json:"prev_hash"`
+	EntryHash string          `json:"entry_hash"`
+}
+
+// SessionSummary is the prompt of the OPENCLAW_SESSION audit record.
+type SessionSummary struct {
+	SessionID     string `json:"session_id"`
+	TriggerRecord string `json:"trigger_record"`
+	Entries       int    `json:"entries"`
+	HeadHash      string `json:"head_hash"`
+	Path          string `json:"path"`
+}
+
+type openSession struct {
+	trigger string
+	path    string
+	seq     int
+	head    string
+}
+
+// sessionRecorder appends hash-linked transcripts to one JSONL file per
+// session and seals each with an audit record when the session finishes.
+type sessionRecorder struct {
+	dir        string
+	maxPayload int
+	persist    func(*AuditRecord) error
+	now        func() time.Time
+
+	mu   sync.Mutex
+	open map[string]*openSession
+}
+
+// newSessionRecorder returns nil when recording is disabled.
+func newSessionRecorder(cfg *SessionRecordingConfig, auditLogPath string, persist func(*AuditRecord) error) *sessionRecorder {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	r := &sessionRecorder{
+		dir:        cfg.Dir,
+		maxPayload: cfg.MaxPayloadBytes,
+		persist:    persist,
+		now:        func() time.Time { return time.Now().UTC() },
+		open:       map[string]*openSession{},
+	}
+	if r.dir == "" {
+		r.dir = filepath.Join(filepath.Dir(auditLogPath), "sessions")
+	}
+	if r.maxPayload <= 0 {
+		r.maxPayload = 64 << 10
+	}
+	return r
+}
+
+func generateSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "sess-" + hex.EncodeToString(b)
+}
+
+// sessionFileName maps a session id (OpenClaw session keys contain ':')
+// to a safe file name.
+func sessionFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, id) + ".jsonl"
+}
+
+// Start opens a session linked to the audit record that allowed it. A
+// session id can be recorded only once.
+func (r *sessionRecorder) Start(id, triggerRecord string) error {
+	if id == "" || strings.Trim(id, ".") == "" {
+		return fmt.Errorf("session id is required")
+	}
+	path := filepath.Join(r.dir, sessionFileName(id))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.open[id]; ok {
+		return fmt.Errorf("session %s is already being recorded", id)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("session %s was already recorded", id)
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	r.open[id] = &openSession{trigger: triggerRecord, path: path, head: triggerRecord}
+	return nil
+}
+
+// Append adds an entry to an open session. It reports false without error
+// when no session with that id is open, so callers can record steps of
+// tasks Sentinel did not dispatch without checking first.
+func (r *sessionRecorder) Append(id, kind string, payload interface{}) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.open[id]
+	if !ok {
+		return false, nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return true, err
+	}
+	if len(raw) > r.maxPayload {
+		raw, _ = json.Marshal(map[string]interface{}{
+			"truncated":      true,
+			"original_bytes": len(raw),
+			"prefix":         string(raw[:r.maxPayload]),
+		})
+	}
+	entry := SessionEntry{
+		Seq:       s.seq + 1,
+		Timestamp: r.now(),
+		Kind:      kind,
+		Payload:   raw,
+		PrevHash:  s.head,
+	}
+	entry.EntryHash = sessionEntryHash(entry)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return true, err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return true, err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return true, err
+	}
+	s.seq, s.head = entry.Seq, entry.EntryHash
+	return true, nil
+}
+
+// Finish closes a session and writes its OPENCLAW_SESSION audit record.
+func (r *sessionRecorder) Finish(id string) (*SessionSummary, error) {
+	r.mu.Lock()
+	s, ok := r.open[id]
+	delete(r.open, id)
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("session %s is not open", id)
+	}
+	summary := &SessionSummary{SessionID: id, TriggerRecord: s.trigger, Entries: s.seq, HeadHash: s.head, Path: s.path}
+	b, _ := json.Marshal(summary)
+	rec := &AuditRecord{
+		Timestamp: r.now(),
+		Action:    sessionAuditAction,
+		Prompt:    string(b),
+		Tags:      []string{"session_recording"},
+		Decision:  "recorded",
+		Reason:    fmt.Sprintf("session %s: %d entries after record %s", id, s.seq, s.trigger),
+	}
+	return summary, r.persist(rec)
+}
+
+// sessionEntryHash is the sha256 of the entry's JSON with EntryHash unset.
+func sessionEntryHash(e SessionEntry) string {
+	e.EntryHash = ""
+	b, _ := json.Marshal(e)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// readSession loads a session file and checks its hash links, starting
+// from the trigger record. It returns the entries read so far together
+// with the first broken link.
+func readSession(dir, id, triggerRecord string) ([]SessionEntry, error) {
+	f, err := os.Open(filepath.Join(dir, sessionFileName(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []SessionEntry
+	prev := triggerRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	for sc.Scan() {
+		var e SessionEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("entry %d: %w", len(entries)+1, err)
+		}
+		switch {
+		case e.Seq != len(entries)+1:
+			return entries, fmt.Errorf("entry %d: sequence %d out of order", len(entries)+1, e.Seq)
+		case e.PrevHash != prev:
+			return entries, fmt.Errorf("entry %d: prev_hash does not link to %s", e.Seq, prev)
+		case sessionEntryHash(e) != e.EntryHash:
+			return entries, fmt.Errorf("entry %d: entry_hash does not match its contents", e.Seq)
+		}
+		entries = append(entries, e)
+		prev = e.EntryHash
+	}
+	return entries, sc.Err()
+}
+
+// sessionSummaries indexes OPENCLAW_SESSION records by session id.
+func sessionSummaries(records []AuditRecord) map[string]SessionSummary {
+	out := map[string]SessionSummary{}
+	for _, rec := range records {
+		if rec.Action != sessionAuditAction {
+			continue
+		}
+		var s SessionSummary
+		if json.Unmarshal([]byte(rec.Prompt), &s) == nil && s.SessionID != "" {
+			out[s.SessionID] = s
+		}
+	}
+	return out
+}
+
+// startSession opens the transcript of a task about to be dispatched and
+// records its request. It returns the session id, or "" when recording is
+// off or failed; recording problems never hold up the task.
+func (sg *SentinelGuard) startSession(taskID, triggerRecord string, request interface{}) string {
+	rec := sg.sessions
+	if rec == nil {
+		return ""
+	}
+	id := strings.TrimSpace(taskID)
+	if id == "" {
+		id = generateSessionID()
+	}
+	if err := rec.Start(id, triggerRecord); err != nil {
+		log.Printf("[SESSION] not recording %s: %v", id, err)
+		return ""
+	}
+	if _, err := rec.Append(id, "request", request); err != nil {
+		log.Printf("[SESSION] %s: %v", id, err)
+	}
+	return id
+}
+
+// finishSession records the dispatch outcome and seals the session.
+func (sg *SentinelGuard) finishSession(id string, resp *OpenClawResponse, dispatchErr error) {
+	if id == "" {
+		return
+	}
+	rec := sg.sessions
+	var err error
+	if dispatchErr != nil {
+		_, err = rec.Append(id, "error", map[string]string{"error": dispatchErr.Error()})
+	} else {
+		_, err = rec.Append(id, "response", resp)
+	}
+	if err != nil {
+		log.Printf("[SESSION] %s: %v", id, err)
+	}
+	if _, err := rec.Finish(id); err != nil {
+		log.Printf("[SESSION] seal %s: %v", id, err)
+	}
+}
+
+// recordStep adds a gated OpenClaw step to the task's session, if one is
+// open under the same id.
+func (gw *SentinelGateway) recordStep(req OpenClawStepRequest, resp OpenClawStepResponse) {
+	_, err := gw.guard.sessions.Append(req.TaskID, "step", map[string]interface{}{
+		"step":        resp.Step,
+		"tool":        req.Tool,
+		"action":      req.Action,
+		"prompt":      req.Prompt,
+		"decision":    resp.Decision,
+		"record_hash": resp.RecordHash,
+		"abort":       resp.Abort,
+	})
+	if err != nil {
+		log.Printf("[SESSION] %s: %v", req.TaskID, err)
+	}
+}
+
+// handleSession serves GET /sentinel/openclaw/sessions?id=... with the
+// transcript and whether its hash chain is intact and matches the head
+// sealed in the audit log.
+func (gw *SentinelGateway) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rec := gw.guard.sessions
+	if rec == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session recording is disabled"})
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id is required"})
+		return
+	}
+
+	resp := map[string]interface{}{"session_id": id}
+	rec.mu.Lock()
+	s, open := rec.open[id]
+	trigger := ""
+	if open {
+		trigger = s.trigger
+	}
+	rec.mu.Unlock()
+
+	var sealed *SessionSummary
+	if !open {
+		records, err := readAuditRecords(gw.guard.cfg.AuditLogPath)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		summary, ok := sessionSummaries(records)[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no recorded session " + id})
+			return
+		}
+		if _, purged := retentionPurges(records); purged[id] {
+			writeJSON(w, http.StatusGone, map[string]string{"error": "transcript of session " + id + " was deleted by retention"})
+			return
+		}
+		sealed, trigger = &summary, summary.TriggerRecord
+	}
+
+	entries, err := readSession(rec.dir, id, trigger)
+	if entries == nil {
+		entries = []SessionEntry{}
+	}
+	if err == nil && sealed != nil {
+		switch {
+		case len(entries) != sealed.Entries:
+			err = fmt.Errorf("audit log sealed %d entries, file has %d", sealed.Entries, len(entries))
+		case len(entries) > 0 && entries[len(entries)-1].EntryHash != sealed.HeadHash:
+			err = fmt.Errorf("last entry does not match the head sealed in the audit log")
+		}
+	}
+	resp["trigger_record"] = trigger
+	resp["open"] = open
+	resp["entries"] = entries
+	resp["valid"] = err == nil
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func getSession(t *testing.T, gw *SentinelGateway, id string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/sentinel/openclaw/sessions?id="+id, nil)
+	rr := httptest.NewRecorder()
+	gw.handleSession(rr, req)
+	var body map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	return rr.Code, body
+}
+
+func TestSessionRecordingLinksTranscriptToTriggerRecord(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{SessionRecording: &SessionRecordingConfig{Enabled: true, MaxPayloadBytes: 256}})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+
+	var gate GateResponse
+	json.Unmarshal(postJSON(t, gw.handleGate, GateRequest{Action: "CODE_EDITING", Prompt: "git status"}).Body.Bytes(), &gate)
+	if gate.Decision != "ALLOW" || gate.Token == nil || gate.Token.RecordHash != gate.RecordHash {
+		t.Fatalf("token should carry the allowing record: %+v", gate)
+	}
+
+	id := gw.guard.startSession("agent:main:s1", gate.Token.RecordHash, map[string]string{"prompt": "git status"})
+	if id != "agent:main:s1" {
+		t.Fatalf("session id = %q", id)
+	}
+	postStep(t, gw, OpenClawStepRequest{TaskID: id, Tool: "exec", Prompt: "git status"})
+	postStep(t, gw, OpenClawStepRequest{TaskID: id, Tool: "write", Prompt: strings.Repeat("x", 1000)})
+	postStep(t, gw, OpenClawStepRequest{TaskID: "someone-else", Tool: "exec", Prompt: "ls"})
+
+	if code, body := getSession(t, gw, id); code != http.StatusOK || body["open"] != true || body["valid"] != true {
+		t.Fatalf("open session should be readable: %d %v", code, body)
+	}
+	gw.guard.finishSession(id, &OpenClawResponse{Status: "ok", Message: "done"}, nil)
+
+	code, body := getSession(t, gw, id)
+	if code != http.StatusOK || body["valid"] != true || body["open"] != false || body["trigger_record"] != gate.RecordHash {
+		t.Fatalf("sealed session should verify: %d %v", code, body)
+	}
+	entries := body["entries"].([]interface{})
+	kinds := []string{}
+	for _, e := range entries {
+		kinds = append(kinds, e.(map[string]interface{})["kind"].(string))
+	}
+	if strings.Join(kinds, ",") != "request,step,step,response" {
+		t.Fatalf("unexpected transcript: %v", kinds)
+	}
+	if first := entries[0].(map[string]interface{}); first["prev_hash"] != gate.RecordHash {
+		t.Fatalf("first entry should link to the trigger record: %v", first)
+	}
+	if payload := entries[2].(map[string]interface{})["payload"].(map[string]interface{}); payload["truncated"] != true {
+		t.Fatalf("oversized step should be truncated: %v", payload)
+	}
+
+	records, err := readAuditRecords(gw.guard.cfg.AuditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, ok := sessionSummaries(records)[id]
+	if !ok || summary.Entries != 4 || summary.TriggerRecord != gate.RecordHash {
+		t.Fatalf("audit log should seal the session: %+v", summary)
+	}
+	if gw.guard.startSession(id, gate.RecordHash, nil) != "" {
+		t.Fatal("a recorded session id must not be reused")
+	}
+
+	path := filepath.Join(gw.guard.sessions.dir, sessionFileName(id))
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), "git status", "git push -f", 1)), 0o600)
+	if _, body := getSession(t, gw, id); body["valid"] != false || !strings.Contains(body["error"].(string), "entry 1") {
+		t.Fatalf("tampering should be detected: %v", body)
+	}
+}
+
+func TestSessionRecordingDisabled(t *testing.T) {
+	gw := newTestGateway()
+	if id := gw.guard.startSession("", "abc", nil); id != "" {
+		t.Fatalf("recording is off, got session %q", id)
+	}
+	if code, _ := getSession(t, gw, "x"); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// sentinelShellHookKinds are the shells --sentinel-shell-hook supports.
+var sentinelShellHookKinds = []string{"zsh", "bash", "fish", "powershell"}
+
+// runSentinelShellHookMode prints a shell integration that reports every
+// command the user runs to the local Sentinel proxy (POST
+// /sentinel/activity), which counts it as liveness and learns it into the
+// behavioral profile. Load it from the shell's startup file, e.g.
+//
+//	eval "$(sentinel --sentinel-shell-hook zsh)"
+func runSentinelShellHookMode(shell, proxyURL string, out io.Writer) error {
+	script, err := renderSentinelShellHook(shell, proxyURL)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, script)
+	return err
+}
+
+func renderSentinelShellHook(shell, proxyURL string) (string, error) {
+	shell = strings.ToLower(strings.TrimSpace(shell))
+	if shell == "pwsh" {
+		shell = "powershell"
+	}
+	if !containsTag(sentinelShellHookKinds, shell) {
+		return "", fmt.Errorf("unknown shell %q (want one of %s)", shell, strings.Join(sentinelShellHookKinds, ", "))
+	}
+	proxyURL, err := cleanSentinelHookURL(proxyURL)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = sentinelShellHookTemplates.ExecuteTemplate(&b, shell, map[string]any{
+		"Shell":       shell,
+		"ActivityURL": proxyURL + "/sentinel/activity",
+	})
+	return b.String(), err
+}
+
+// Reports are sent in the background with a short timeout and all output
+// discarded, so a stopped proxy never slows the prompt. Commands are reported
+// after they finish so the exit status is known; only successful commands
+// are learned. The proxy's token is read from SENTINEL_OPERATOR_TOKEN at
+// run time and never baked into the script.
+var sentinelShellHookTemplates = template.Must(template.New("shell").Parse(`
+{{define "posix"}}
+SENTINEL_ACTIVITY_URL="${SENTINEL_ACTIVITY_URL:-{{.ActivityURL}}}"
+
+__sentinel_json_str() {
+	printf '%s' "$1" | tr -d '\000-\010\013\014\016-\037' |
+		sed -e 's/\\/\\\\/g' -e 's/"/\\"/g' -e 's/	/\\t/g' |
+		awk 'BEGIN { ORS = "" } NR > 1 { print "\\n" } { print }'
+}
+
+__sentinel_report() {
+	__sentinel_body="{\"source\":\"shell\",\"shell\":\"$1\",\"agent_id\":\"$(__sentinel_json_str "${SENTINEL_AGENT_ID:-shell}")\",\"owner\":\"$(__sentinel_json_str "${SENTINEL_OWNER:-}")\",\"command\":\"$(__sentinel_json_str "$2")\",\"exit_code\":${3:-0}}"
+	(printf '%s' "$__sentinel_body" | curl -s -o /dev/null --max-time 2 -H 'Content-Type: application/json' \
+		-H "Authorization: Bearer ${SENTINEL_OPERATOR_TOKEN:-}" --data-binary @- "$SENTINEL_ACTIVITY_URL" >/dev/null 2>&1 &)
+}
+{{end}}
+
+{{define "zsh"}}# Sentinel zsh integration, generated by: sentinel --sentinel-shell-hook zsh
+# Add to ~/.zshrc:  eval "$(sentinel --sentinel-shell-hook zsh)"
+{{template "posix" .}}
+__sentinel_preexec() {
+	__sentinel_cmd=$1
+}
+
+__sentinel_precmd() {
+	local ret=$?
+	[ -n "$__sentinel_cmd" ] || return 0
+	__sentinel_report zsh "$__sentinel_cmd" "$ret"
+	__sentinel_cmd=
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __sentinel_preexec
+add-zsh-hook precmd __sentinel_precmd
+{{end}}
+
+{{define "bash"}}# Sentinel bash integration, generated by: sentinel --sentinel-shell-hook bash
+# Add to ~/.bashrc:  eval "$(sentinel --sentinel-shell-hook bash)"
+{{template "posix" .}}
+__sentinel_last=$(HISTTIMEFORMAT= history 1)
+
+__sentinel_precmd() {
+	local ret=$? line
+	line=$(HISTTIMEFORMAT= history 1)
+	if [ -n "$line" ] && [ "$line" != "$__sentinel_last" ]; then
+		__sentinel_last=$line
+		__sentinel_report bash "$(printf '%s' "$line" | sed 's/^ *[0-9]*[* ] *//')" "$ret"
+	fi
+	return $ret
+}
+
+case ";${PROMPT_COMMAND:-};" in
+*";__sentinel_precmd;"*) ;;
+*) PROMPT_COMMAND="__sentinel_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+{{end}}
+
+{{define "fish"}}# Sentinel fish integration, generated by: sentinel --sentinel-shell-hook fish
+# Add to ~/.config/fish/config.fish:  sentinel --sentinel-shell-hook fish | source
+
+set -q SENTINEL_ACTIVITY_URL; or set -g SENTINEL_ACTIVITY_URL '{{.ActivityURL}}'
+
+function __sentinel_json_str
+	string join \n -- $argv | string replace -ra '[\x00-\x08\x0b\x0c\x0e-\x1f]' '' |
+		string replace -a '\\' '\\\\' | string replace -a '"' '\\"' |
+		string replace -a \t '\\t' | string join '\\n'
+end
+
+function __sentinel_postexec --on-event fish_postexec
+	set -l ret $status
+	test -n "$argv[1]"; or return
+	set -l agent shell
+	set -q SENTINEL_AGENT_ID; and set agent $SENTINEL_AGENT_ID
+	set -l owner ""
+	set -q SENTINEL_OWNER; and set owner $SENTINEL_OWNER
+	set -l body '{"source":"shell","shell":"fish","agent_id":"'(__sentinel_json_str $agent)'","owner":"'(__sentinel_json_str $owner)'","command":"'(__sentinel_json_str $argv[1])'","exit_code":'$ret'}'
+	printf '%s' $body | command curl -s -o /dev/null --max-time 2 -H 'Content-Type: application/json' \
+		-H "Authorization: Bearer $SENTINEL_OPERATOR_TOKEN" --data-binary @- $SENTINEL_ACTIVITY_URL >/dev/null 2>&1 &
+	disown 2>/dev/null
+end
+{{end}}
+
+{{define "powershell"}}# Sentinel PowerShell integration, generated by: sentinel --sentinel-shell-hook powershell
+# Add to $PROFILE:  sentinel --sentinel-shell-hook powershell | Out-String | Invoke-Expression
+
+if (-not $env:SENTINEL_ACTIVITY_URL) { $env:SENTINEL_ACTIVITY_URL = '{{.ActivityURL}}' }
+$global:__SentinelHttp = [System.Net.Http.HttpClient]::new()
+$global:__SentinelHttp.Timeout = [TimeSpan]::FromSeconds(2)
+$global:__SentinelLastId = (Get-History -Count 1).Id
+$global:__SentinelPrompt = $function:prompt
+
+function global:prompt {
+	$ok = $?
+	$code = $global:LASTEXITCODE
+	$last = Get-History -Count 1
+	if ($last -and $last.Id -ne $global:__SentinelLastId) {
+		$global:__SentinelLastId = $last.Id
+		$exit = if ($ok) { 0 } elseif ($code) { $code } else { 1 }
+		$agent = if ($env:SENTINEL_AGENT_ID) { $env:SENTINEL_AGENT_ID } else { 'shell' }
+		$body = @{ source = 'shell'; shell = 'powershell'; agent_id = $agent; owner = "$env:SENTINEL_OWNER"; command = $last.CommandLine; exit_code = $exit } | ConvertTo-Json -Compress
+		$req = [System.Net.Http.HttpRequestMessage]::new('POST', $env:SENTINEL_ACTIVITY_URL)
+		$req.Content = [System.Net.Http.StringContent]::new($body, [System.Text.Encoding]::UTF8, 'application/json')
+		$req.Headers.TryAddWithoutValidation('Authorization', "Bearer $env:SENTINEL_OPERATOR_TOKEN") | Out-Null
+		$null = $global:__SentinelHttp.SendAsync($req)
+	}
+	$global:LASTEXITCODE = $code
+	& $global:__SentinelPrompt
+}
+{{end}}
+`))
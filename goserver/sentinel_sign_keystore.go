@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A signing keystore holds the audit signing seed sealed with the config
+// bundle key (SENTINEL_CONFIG_KEY or SENTINEL_CONFIG_KEY_FILE), so the seed
+// is neither in the config file nor on any command line.
+const (
+	signKeystoreVersion = 1
+	signKeystoreAAD     = "sentinel-sign-keystore-v1"
+)
+
+type signKeystore struct {
+	Version    int    `json:"sentinel_sign_keystore"`
+	PublicKey  string `json:"public_key"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func sealSignKeystore(priv ed25519.PrivateKey, key []byte) (*signKeystore, error) {
+	aead, err := configBundleAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &signKeystore{
+		Version:    signKeystoreVersion,
+		PublicKey:  hex.EncodeToString(priv.Public().(ed25519.PublicKey)),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, priv.Seed(), []byte(signKeystoreAAD))),
+	}, nil
+}
+
+func openSignKeystore(ks *signKeystore, key []byte) (ed25519.PrivateKey, error) {
+	if ks.Version != signKeystoreVersion {
+		return nil, fmt.Errorf("unsupported signing keystore version %d", ks.Version)
+	}
+	aead, err := configBundleAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(ks.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("signing keystore has a malformed nonce")
+	}
+	ct, err := base64.StdEncoding.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("signing keystore has malformed ciphertext")
+	}
+	seed, err := aead.Open(nil, nonce, ct, []byte(signKeystoreAAD))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing keystore does not decrypt with this key")
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	if !strings.EqualFold(ks.PublicKey, hex.EncodeToString(priv.Public().(ed25519.PublicKey))) {
+		return nil, fmt.Errorf("signing keystore public key does not match its seed")
+	}
+	return priv, nil
+}
+
+// loadSigningKey returns the audit signing key: the keystore when
+// sign_keystore is set, else the plaintext sign_private_key. It returns nil
+// when neither is configured.
+func loadSigningKey(cfg *SentinelConfig) (ed25519.PrivateKey, error) {
+	if cfg.SignKeystore != "" {
+		data, err := os.ReadFile(cfg.SignKeystore)
+		if err != nil {
+			return nil, err
+		}
+		var ks signKeystore
+		if err := json.Unmarshal(data, &ks); err != nil || ks.Version == 0 {
+			return nil, fmt.Errorf("%s is not a signing keystore", cfg.SignKeystore)
+		}
+		key, err := configBundleKey()
+		if err != nil {
+			return nil, err
+		}
+		return openSignKeystore(&ks, key)
+	}
+	if strings.TrimSpace(cfg.SignPrivKey) == "" {
+		return nil, nil
+	}
+	return ed25519KeyFromSeedHex(cfg.SignPrivKey)
+}
+
+// writeSignKeystore implements `goserver key keystore`: it seals the hex
+// seed in seedFile, or a freshly generated key, into a keystore at outPath.
+func writeSignKeystore(seedFile, outPath string) (*signKeystore, error) {
+	if outPath == "" {
+		return nil, fmt.Errorf("--out is required")
+	}
+	var priv ed25519.PrivateKey
+	if seedFile != "" {
+		raw, err := os.ReadFile(seedFile)
+		if err != nil {
+			return nil, err
+		}
+		if priv, err = ed25519KeyFromSeedHex(string(raw)); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if _, priv, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	key, err := configBundleKey()
+	if err != nil {
+		return nil, err
+	}
+	ks, err := sealSignKeystore(priv, key)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.MarshalIndent(ks, "", "  ")
+	if err := os.WriteFile(outPath, append(data, '\n'), 0o600); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
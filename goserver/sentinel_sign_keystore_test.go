@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSignKeystore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SENTINEL_CONFIG_KEY", hex.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	seedFile := filepath.Join(dir, "seed.hex")
+	if err := os.WriteFile(seedFile, []byte(testSignSeed+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keystore := filepath.Join(dir, "sign.keystore.json")
+	var out bytes.Buffer
+	if err := runKeyCommand([]string{"keystore", "--key-file", seedFile, "--out", keystore}, &out); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(keystore)
+	if bytes.Contains(data, []byte(testSignSeed)) {
+		t.Fatal("keystore must not hold the seed in plaintext")
+	}
+
+	guard := newTestGuard(t, SentinelConfig{
+		AuditLogPath: filepath.Join(dir, "audit.jsonl"),
+		SignKeystore: keystore,
+	})
+	want, _ := ed25519KeyFromSeedHex(testSignSeed)
+	if !strings.Contains(out.String(), hex.EncodeToString(want.Public().(ed25519.PublicKey))) {
+		t.Fatalf("keystore command should print the public key: %s", out.String())
+	}
+	_, rec, err := guard.Enforce("CODE_EDITING", "git status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, _ := hex.DecodeString(rec.PublicKey)
+	sig, _ := hex.DecodeString(rec.Signature)
+	msg, _ := hex.DecodeString(strings.TrimPrefix(rec.RecordHash, "0x"))
+	if !want.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(pub)) || !ed25519.Verify(pub, msg, sig) {
+		t.Fatalf("record should be signed with the keystore key: %+v", rec)
+	}
+
+	t.Setenv("SENTINEL_CONFIG_KEY", hex.EncodeToString(bytes.Repeat([]byte{8}, 32)))
+	if _, err := loadSigningKey(&SentinelConfig{SignKeystore: keystore}); err == nil {
+		t.Fatal("a keystore must not open with the wrong key")
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Helper process classes. Each class gets its own timeout.
+const (
+	procSui      = "sui"
+	procRustCLI  = "rustcli"
+	procOpenClaw = "openclaw"
+	procOPA      = "opa"
+	procIdle     = "idle"
+)
+
+// SubprocessConfig bounds every helper process the daemon spawns (sui,
+// lazarus-vault, openclaw, opa). Zero values fall back to the built-in defaults.
+type SubprocessConfig struct {
+	SuiTimeoutSec      int      `json:"sui_timeout_seconds"`
+	RustCLITimeoutSec  int      `json:"rust_cli_timeout_seconds"`
+	OpenClawTimeoutSec int      `json:"openclaw_timeout_seconds"`
+	OPATimeoutSec      int      `json:"opa_timeout_seconds"`
+	MaxOutputBytes     int      `json:"max_output_bytes"`
+	EnvPassthrough     []string `json:"env_passthrough"` // extra variables (or PREFIX_*) to keep
+	// IsolateRustCLI runs the hash/sign helpers in fresh user and network
+	// namespaces on Linux so a hijacked binary cannot reach the network.
+	IsolateRustCLI bool `json:"isolate_rust_cli"`
+}
+
+// SubprocessPolicy is the resolved limit set for one helper invocation.
+type SubprocessPolicy struct {
+	Timeout        time.Duration
+	MaxOutputBytes int
+	EnvAllowlist   []string
+	Isolate        bool
+}
+
+// baseEnvAllowlist is what every helper may see. Anything else (API keys,
+// cloud credentials, tokens) is scrubbed from the child environment.
+var baseEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "LANG", "LC_ALL", "TZ", "TMPDIR",
+	"SYSTEMROOT", "SystemRoot", "WINDIR", "APPDATA", "LOCALAPPDATA", "USERPROFILE",
+}
+
+var defaultProcTimeouts = map[string]time.Duration{
+	procSui:      60 * time.Second,
+	procRustCLI:  10 * time.Second,
+	procOpenClaw: 120 * time.Second,
+	procOPA:      5 * time.Second,
+	procIdle:     5 * time.Second,
+}
+
+const defaultMaxOutputBytes = 1 << 20
+
+// policyFor resolves the limits for a helper class. A nil config yields the
+// defaults.
+func (c *SubprocessConfig) policyFor(class string) SubprocessPolicy {
+	p := SubprocessPolicy{
+		Timeout:        defaultProcTimeouts[class],
+		MaxOutputBytes: defaultMaxOutputBytes,
+		EnvAllowlist:   append([]string{}, baseEnvAllowlist...),
+	}
+	switch class {
+	case procSui:
+		p.EnvAllowlist = append(p.EnvAllowlist, "SUI_*")
+	case procOpenClaw:
+		p.EnvAllowlist = append(p.EnvAllowlist, "OPENCLAW_*")
+	case procIdle:
+		p.EnvAllowlist = append(p.EnvAllowlist, "DISPLAY", "XAUTHORITY", "WAYLAND_DISPLAY", "DBUS_SESSION_BUS_ADDRESS", "XDG_RUNTIME_DIR")
+	}
+	if c == nil {
+		return p
+	}
+
+	var secs int
+	switch class {
+	case procSui:
+		secs = c.SuiTimeoutSec
+	case procRustCLI:
+		secs = c.RustCLITimeoutSec
+		p.Isolate = c.IsolateRustCLI
+	case procOpenClaw:
+		secs = c.OpenClawTimeoutSec
+	case procOPA:
+		secs = c.OPATimeoutSec
+	}
+	if secs > 0 {
+		p.Timeout = time.Duration(secs) * time.Second
+	}
+	if c.MaxOutputBytes > 0 {
+		p.MaxOutputBytes = c.MaxOutputBytes
+	}
+	p.EnvAllowlist = append(p.EnvAllowlist, c.EnvPassthrough...)
+	return p
+}
+
+// errOutputLimit is returned when a helper writes more than MaxOutputBytes.
+var errOutputLimit = errors.New("subprocess output limit exceeded")
+
+// cappedBuffer keeps at most limit bytes and remembers whether more arrived.
+type cappedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.limit - b.buf.Len()
+	if room <= 0 {
+		b.truncated = true
+		return len(p), nil
+	}
+	if len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// runSubprocess executes name with args under the given policy and returns
+// the combined stdout+stderr. When stdoutOnly is set, stderr is discarded.
+func runSubprocess(policy SubprocessPolicy, stdoutOnly bool, name string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = scrubEnv(os.Environ(), policy.EnvAllowlist)
+	cmd.WaitDelay = 2 * time.Second
+	applyProcessSandbox(cmd, policy)
+
+	limit := policy.MaxOutputBytes
+	if limit <= 0 {
+		limit = defaultMaxOutputBytes
+	}
+	out := &cappedBuffer{limit: limit}
+	cmd.Stdout = out
+	if !stdoutOnly {
+		cmd.Stderr = out
+	}
+
+	err := cmd.Run()
+	data := out.buf.Bytes()
+	if ctx.Err() == context.DeadlineExceeded {
+		return data, fmt.Errorf("%s timed out after %s", name, policy.Timeout)
+	}
+	if err != nil {
+		return data, err
+	}
+	if out.truncated {
+		return data, fmt.Errorf("%s: %w (%d bytes)", name, errOutputLimit, limit)
+	}
+	return data, nil
+}
+
+// scrubEnv keeps only allowlisted variables. Entries ending in "*" match by
+// prefix.
+func scrubEnv(environ, allow []string) []string {
+	kept := make([]string, 0, len(allow))
+	for _, kv := range environ {
+		key := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			key = kv[:i]
+		}
+		for _, a := range allow {
+			if key == a || (strings.HasSuffix(a, "*") && strings.HasPrefix(key, strings.TrimSuffix(a, "*"))) {
+				kept = append(kept, kv)
+				break
+			}
+		}
+	}
+	return kept
+}
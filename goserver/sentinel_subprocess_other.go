@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "os/exec"
+
+// applyProcessSandbox is a no-op outside Linux; timeouts, environment
+// scrubbing and output caps still apply.
+func applyProcessSandbox(cmd *exec.Cmd, policy SubprocessPolicy) {}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSubprocessTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := runSubprocess(SubprocessPolicy{Timeout: 200 * time.Millisecond}, false, "sh", "-c", "sleep 5")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Fatalf("timeout did not stop the helper promptly")
+	}
+}
+
+func TestRunSubprocessOutputCap(t *testing.T) {
+	out, err := runSubprocess(SubprocessPolicy{Timeout: 5 * time.Second, MaxOutputBytes: 16}, false,
+		"sh", "-c", "printf '%0100d' 0")
+	if !errors.Is(err, errOutputLimit) {
+		t.Fatalf("expected output limit error, got %v", err)
+	}
+	if len(out) != 16 {
+		t.Fatalf("expected output truncated to 16 bytes, got %d", len(out))
+	}
+}
+
+func TestRunSubprocessScrubsEnvironment(t *testing.T) {
+	t.Setenv("SENTINEL_TEST_SECRET", "leak")
+	t.Setenv("SUI_TEST_CONFIG", "keep")
+
+	policy := (*SubprocessConfig)(nil).policyFor(procSui)
+	out, err := runSubprocess(policy, false, "sh", "-c", "echo secret=$SENTINEL_TEST_SECRET sui=$SUI_TEST_CONFIG")
+	if err != nil {
+		t.Fatalf("runSubprocess failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "secret= sui=keep" {
+		t.Fatalf("unexpected environment seen by helper: %q", got)
+	}
+}
+
+func TestSubprocessConfigOverrides(t *testing.T) {
+	cfg := &SubprocessConfig{RustCLITimeoutSec: 3, MaxOutputBytes: 42, EnvPassthrough: []string{"EXTRA"}, IsolateRustCLI: true}
+
+	p := cfg.policyFor(procRustCLI)
+	if p.Timeout != 3*time.Second || p.MaxOutputBytes != 42 || !p.Isolate {
+		t.Fatalf("unexpected rustcli policy: %+v", p)
+	}
+	if !containsTag(p.EnvAllowlist, "EXTRA") {
+		t.Fatalf("expected passthrough variable in allowlist: %v", p.EnvAllowlist)
+	}
+	if sui := cfg.policyFor(procSui); sui.Timeout != defaultProcTimeouts[procSui] || sui.Isolate {
+		t.Fatalf("unexpected sui policy: %+v", sui)
+	}
+}
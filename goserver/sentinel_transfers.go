@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ruleTransferRoutine is a transfer whose every amount is within its cap.
+var ruleTransferRoutine = BinaryRule{Category: "FINANCIAL", Risk: 0.30}
+
+// TransferRules caps single transfers per asset so routine payments pass
+// while large ones need approval.
+type TransferRules struct {
+	// Caps maps an asset symbol (case-insensitive) to the largest amount a
+	// transfer may move without approval. "USD" also covers "$50".
+	Caps map[string]float64 `json:"caps"`
+}
+
+type transferAmount struct {
+	Amount float64
+	Asset  string
+}
+
+var (
+	transferVerbPattern   = regexp.MustCompile(`(?i)\b(transfer|send|pay|withdraw|swap|bridge|move)\b`)
+	transferAmountPattern = regexp.MustCompile(`(?i)(\$\s*)?\b(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?\s*(k|m|thousand|million)?\b\s*([a-z][a-z0-9]{1,9})?`)
+)
+
+// transferAmounts extracts "<amount> <ASSET>" and "$<amount>" pairs from
+// text. A word after the number is an asset if it has a cap or is written in
+// upper case ("50 USDC"); other numbers ("2 tabs") are ignored.
+func (r *TransferRules) transferAmounts(text string) []transferAmount {
+	var out []transferAmount
+	for _, m := range transferAmountPattern.FindAllStringSubmatch(text, -1) {
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", "")+m[3], 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(m[4]) {
+		case "k", "thousand":
+			amount *= 1e3
+		case "m", "million":
+			amount *= 1e6
+		}
+		asset := m[5]
+		switch {
+		case strings.TrimSpace(m[1]) == "$":
+			asset = "USD"
+		case asset == "":
+			continue
+		case r.capFor(asset) < 0 && asset != strings.ToUpper(asset):
+			continue
+		}
+		out = append(out, transferAmount{Amount: amount, Asset: strings.ToUpper(asset)})
+	}
+	return out
+}
+
+// capFor returns the cap for asset, or -1 if it has none.
+func (r *TransferRules) capFor(asset string) float64 {
+	for k, v := range r.Caps {
+		if strings.EqualFold(k, asset) {
+			return v
+		}
+	}
+	return -1
+}
+
+// check classifies the transfers requested in text. routine is true when
+// text requests a transfer and every amount is of a capped asset and within
+// its cap; over lists the amounts above their cap.
+func (r *TransferRules) check(text string) (routine bool, over []string) {
+	if r == nil || len(r.Caps) == 0 || !transferVerbPattern.MatchString(text) {
+		return false, nil
+	}
+	amounts := r.transferAmounts(text)
+	if len(amounts) == 0 {
+		return false, nil
+	}
+	routine = true
+	for _, a := range amounts {
+		limit := r.capFor(a.Asset)
+		switch {
+		case limit < 0:
+			routine = false // no cap to compare against
+		case a.Amount > limit:
+			routine = false
+			over = append(over, fmt.Sprintf("%s %s (cap %s)", formatAmount(a.Amount), a.Asset, formatAmount(limit)))
+		}
+	}
+	return routine, over
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
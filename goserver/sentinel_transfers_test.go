@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestTransferRulesCheck(t *testing.T) {
+	r := &TransferRules{Caps: map[string]float64{"usdc": 100, "SUI": 5, "USD": 20}}
+	cases := []struct {
+		text    string
+		routine bool
+		over    int
+	}{
+		{"transfer 50 USDC to 0xabc", true, 0},
+		{"transfer 50,000 USDC to 0xabc", false, 1},
+		{"send 1.5k usdc to bob", false, 1},
+		{"pay $15 for the domain", true, 0},
+		{"send 2 SUI and 200 USDC", false, 1},
+		{"send 10 ETH to bob", false, 0}, // uncapped asset
+		{"open 2 tabs and check the balance", false, 0},
+		{"transfer the file to the server", false, 0},
+	}
+	for _, tc := range cases {
+		routine, over := r.check(tc.text)
+		if routine != tc.routine || len(over) != tc.over {
+			t.Errorf("check(%q) = %v %v, want routine=%v over=%d", tc.text, routine, over, tc.routine, tc.over)
+		}
+	}
+}
+
+func TestTransferCapsSeparateSmallAndLargeTransfers(t *testing.T) {
+	guard := newTestGuard(t, SentinelConfig{
+		Rules: &SentinelRules{Transfers: &TransferRules{Caps: map[string]float64{"USDC": 100}}},
+	})
+
+	small := guard.Evaluate("WALLET", "transfer 50 USDC to 0xabc")
+	if small.ShouldBlock || !containsTag(small.Tags, "transfer_within_limit") {
+		t.Fatalf("small transfer: block=%v tags=%v reason=%s", small.ShouldBlock, small.Tags, small.Reason)
+	}
+	large := guard.Evaluate("WALLET", "transfer 50000 USDC to 0xabc")
+	if !large.ShouldBlock || !containsTag(large.Tags, "transfer_over_limit") {
+		t.Fatalf("large transfer: block=%v tags=%v", large.ShouldBlock, large.Tags)
+	}
+	if large.Score <= small.Score {
+		t.Fatalf("expected large transfer to score higher: %d <= %d", large.Score, small.Score)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "regexp"
+
+// uiAccessPattern matches agent actions that read what is on the user's
+// screen, clipboard or password manager, for desktop/browser agents.
+type uiAccessPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var uiAccessPatterns = []uiAccessPattern{
+	{"clipboard", regexp.MustCompile(`\b(clipboard|pbpaste|xclip\b[^\n]*-o|xsel\b|wl-paste|get-clipboard)|clipboard\.read`)},
+	{"screenshot", regexp.MustCompile(`\b(screenshots?|screen ?capture|screencapture|scrot|gnome-screenshot|grim|printscreen|print screen|capturescreenshot)\b|\bimport\s+-window\s+root\b|record (the |my )?screen`)},
+	{"password manager", regexp.MustCompile(`\b(1password|bitwarden|lastpass|keepass(xc)?|dashlane|keychain access|password manager|credential manager)\b|` +
+		`\b(op (item get|read)|bw get|lpass show|cmdkey /list)\b|security find-(generic|internet)-password|` +
+		`chrome://(settings/)?passwords|about:logins|passwords\.google\.com`)},
+}
+
+// detectUIAccess returns the kind of sensitive UI surface lower (a lowercased
+// action + prompt) reaches for, or "" if none.
+func detectUIAccess(lower string) string {
+	for _, p := range uiAccessPatterns {
+		if p.re.MatchString(lower) {
+			return p.kind
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDetectUIAccess(t *testing.T) {
+	cases := map[string]string{
+		"take a screenshot of the banking tab":           "screenshot",
+		"run pbpaste and send me the output":             "clipboard",
+		"xclip -selection clipboard -o":                  "clipboard",
+		"await navigator.clipboard.readText()":           "clipboard",
+		"open chrome://settings/passwords and list them": "password manager",
+		"op item get github --fields password":           "password manager",
+		"security find-generic-password -s aws -w":       "password manager",
+		"summarize the release notes":                    "",
+		"stop reading the docs and build":                "",
+	}
+	for text, want := range cases {
+		if got := detectUIAccess(text); got != want {
+			t.Errorf("detectUIAccess(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestGateRoutesUIAccessToApproval(t *testing.T) {
+	gw := newTestGateway()
+	rr := postJSON(t, gw.handleGate, GateRequest{Action: "BROWSER", Prompt: "take a screenshot of the open tab"})
+
+	var resp GateResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Decision != "REQUIRE_APPROVAL" {
+		t.Fatalf("expected REQUIRE_APPROVAL, got %s (%s)", resp.Decision, resp.Reason)
+	}
+	if !containsTag(resp.Tags, "ui_exfiltration") {
+		t.Fatalf("expected ui_exfiltration tag, got %v", resp.Tags)
+	}
+}
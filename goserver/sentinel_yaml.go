@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlToJSON converts the block-style YAML subset used for operator-written
+// files (benchmark corpora, rule packs) to JSON, so they decode into the
+// same structs as their JSON equivalents. Supported: nested block mappings
+// and sequences, flow sequences of scalars ([a, b]), plain and quoted
+// scalars, and comments. Anchors, tags, flow mappings and block scalars are
+// rejected rather than misread.
+func yamlToJSON(data []byte) ([]byte, error) {
+	p := &yamlParser{}
+	for n, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n+1)
+		}
+		trimmed = strings.TrimRight(stripYAMLComment(trimmed), " \t")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{num: n + 1, indent: len(line) - len(strings.TrimLeft(line, " ")), text: trimmed})
+	}
+	if len(p.lines) == 0 {
+		return []byte("null"), nil
+	}
+	v, err := p.node(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return json.Marshal(v)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// node parses the block starting at the current line, which must be at
+// indent.
+func (p *yamlParser) node(indent int) (interface{}, error) {
+	l := p.lines[p.pos]
+	if l.indent != indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+	}
+	if isYAMLSeqItem(l.text) {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(l.text); ok {
+		return p.mapping(indent)
+	}
+	p.pos++
+	return yamlScalarValue(l.text, l.num)
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent || !isYAMLSeqItem(l.text) {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			v, err := p.node(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		// "- key: value" opens a mapping whose keys line up with "key".
+		col := indent + len(l.text) - len(rest)
+		p.lines[p.pos] = yamlLine{num: l.num, indent: col, text: rest}
+		v, err := p.node(col)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || (l.indent == indent && isYAMLSeqItem(l.text)) {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", l.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.num, key)
+		}
+		p.pos++
+		if rest != "" {
+			v, err := yamlScalarValue(rest, l.num)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			continue
+		}
+		switch {
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			v, err := p.node(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSeqItem(p.lines[p.pos].text):
+			// A sequence may sit at its key's indentation.
+			v, err := p.sequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		default:
+			m[key] = nil
+		}
+	}
+	return m, nil
+}
+
+// splitYAMLKey splits "key: value" (or "key:") at the first colon outside
+// quotes that is followed by a space or ends the line.
+func splitYAMLKey(text string) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			key := strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false
+			}
+			if key[0] == '"' || key[0] == '\'' {
+				unquoted, err := yamlScalarValue(key, 0)
+				s, isString := unquoted.(string)
+				if err != nil || !isString {
+					return "", "", false
+				}
+				key = s
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a trailing comment: a # at the start or after
+// whitespace, outside quotes.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '[' || text[i-1] == ',' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func yamlScalarValue(raw string, line int) (interface{}, error) {
+	fail := func(format string, args ...interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("line %d: "+format, append([]interface{}{line}, args...)...)
+	}
+	switch {
+	case raw == "":
+		return nil, nil
+	case raw[0] == '"':
+		if len(raw) < 2 || raw[len(raw)-1] != '"' {
+			return fail("unterminated or trailing text after double-quoted string")
+		}
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return fail("invalid double-quoted string %s", raw)
+		}
+		return s, nil
+	case raw[0] == '\'':
+		if len(raw) < 2 || raw[len(raw)-1] != '\'' {
+			return fail("unterminated or trailing text after single-quoted string")
+		}
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), nil
+	case raw[0] == '[':
+		if raw[len(raw)-1] != ']' {
+			return fail("unterminated flow sequence")
+		}
+		items := []interface{}{}
+		for _, part := range splitYAMLFlow(raw[1 : len(raw)-1]) {
+			if strings.HasPrefix(part, "[") || strings.HasPrefix(part, "{") {
+				return fail("nested flow collections are not supported")
+			}
+			v, err := yamlScalarValue(part, line)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case strings.ContainsRune("{|>&*!%@`", rune(raw[0])):
+		return fail("unsupported YAML value %q (quote it, or use block mappings and sequences)", raw)
+	}
+	switch raw {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil && !strings.ContainsAny(raw, "xXnN") {
+		return f, nil
+	}
+	return raw, nil
+}
+
+// splitYAMLFlow splits the inside of a flow sequence at commas outside
+// quotes.
+func splitYAMLFlow(s string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
@@ -0,0 +1,347 @@
+// Package sentinelclient is a Go client for the Sentinel proxy's HTTP API
+// (goserver --sentinel-proxy): gate decisions, activity reports, approvals
+// and status.
+//
+//	c := sentinelclient.New("http://127.0.0.1:18080")
+//	resp, err := c.Gate(ctx, sentinelclient.GateRequest{Action: "EXEC", Prompt: cmd})
+//	if err == nil && resp.Allowed() {
+//		// run cmd
+//	}
+//
+// Reads are retried on transport errors and 429/502/503/504. Writes are
+// retried only when the connection was refused, so a gate decision is never
+// recorded twice.
+package sentinelclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Gate decisions.
+const (
+	DecisionAllow           = "ALLOW"
+	DecisionRequireApproval = "REQUIRE_APPROVAL"
+	DecisionBlock           = "BLOCK"
+	DecisionKillSwitch      = "TRIGGER_KILL_SWITCH"
+)
+
+// Client talks to one Sentinel proxy. The zero values of the optional
+// fields are usable defaults.
+type Client struct {
+	BaseURL string
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+	// Token is the proxy's operator token, sent as a bearer token;
+	// ReportActivity and ConfirmApproval require it.
+	Token string
+	// ExtensionToken is the browser extension token that PendingApprovals
+	// is sent with instead of Token.
+	ExtensionToken string
+	// Retries is how often a failed request is retried; default 2.
+	// Negative disables retries.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further
+	// one; default 200ms.
+	Backoff time.Duration
+}
+
+// New returns a client for the proxy at baseURL, e.g.
+// http://127.0.0.1:18080.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// APIError is a non-2xx answer from the proxy.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("sentinel: %d %s", e.StatusCode, e.Message)
+}
+
+// GateRequest is the input to Gate.
+type GateRequest struct {
+	Action  string `json:"action"`
+	Prompt  string `json:"prompt"`
+	AgentID string `json:"agent_id,omitempty"`
+}
+
+// GateResponse is the proxy's decision.
+type GateResponse struct {
+	Decision    string        `json:"decision"`
+	Score       int           `json:"score"`
+	Tags        []string      `json:"tags"`
+	Reason      string        `json:"reason"`
+	RecordHash  string        `json:"record_hash"`
+	Token       *ExecuteToken `json:"token,omitempty"`
+	ChallengeID string        `json:"challenge_id,omitempty"`
+	ProofIndex  int           `json:"proof_index"`
+	TxDigest    string        `json:"tx_digest,omitempty"`
+	AnchorError string        `json:"anchor_error,omitempty"`
+}
+
+// Allowed reports whether the action may proceed now.
+func (r *GateResponse) Allowed() bool { return r.Decision == DecisionAllow }
+
+// ExecuteToken is a one-time execution token.
+type ExecuteToken struct {
+	ID         string    `json:"id"`
+	Action     string    `json:"action"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Redeemed   bool      `json:"redeemed"`
+	RecordHash string    `json:"record_hash,omitempty"`
+}
+
+// ActivityReport is a command the user ran, reported as liveness.
+type ActivityReport struct {
+	Source   string `json:"source,omitempty"`
+	Shell    string `json:"shell,omitempty"`
+	AgentID  string `json:"agent_id,omitempty"`
+	Command  string `json:"command"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+}
+
+// ActivityResult tells whether the behavioral profile learned the command.
+type ActivityResult struct {
+	Recorded bool `json:"recorded"`
+	Learned  bool `json:"learned"`
+}
+
+// ApprovalChallenge is a human approval request.
+type ApprovalChallenge struct {
+	ID         string     `json:"id"`
+	Action     string     `json:"action"`
+	Prompt     string     `json:"prompt"`
+	RiskScore  int        `json:"risk_score"`
+	Status     string     `json:"status"` // pending, approved, rejected, expired
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	DecidedAt  *time.Time `json:"decided_at,omitempty"`
+	DecisionBy string     `json:"decision_by,omitempty"`
+	RecordHash string     `json:"record_hash,omitempty"`
+}
+
+// ApprovalDecision is the answer to ConfirmApproval. Token is set when the
+// challenge was approved.
+type ApprovalDecision struct {
+	Challenge ApprovalChallenge `json:"challenge"`
+	Token     *ExecuteToken     `json:"token,omitempty"`
+}
+
+// KillSwitchStatus is the state of the kill switch.
+type KillSwitchStatus struct {
+	Armed               bool      `json:"armed"`
+	Reason              string    `json:"reason,omitempty"`
+	ArmedAt             time.Time `json:"armed_at,omitempty"`
+	ConsecutiveHighRisk int       `json:"consecutive_high_risk"`
+	Threshold           int       `json:"threshold"`
+}
+
+// ActivitySource is the liveness of one reporting source.
+type ActivitySource struct {
+	Name     string    `json:"name"`
+	Owner    string    `json:"owner,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+	Commands int       `json:"commands"`
+	Learned  int       `json:"learned"`
+}
+
+// Activity is the proxy's liveness view.
+type Activity struct {
+	LastSeen    *time.Time       `json:"last_seen"`
+	IdleSeconds int64            `json:"idle_seconds,omitempty"`
+	Sources     []ActivitySource `json:"sources"`
+}
+
+// Status is GET /sentinel/status. Raw holds the whole document, including
+// the sections of optional features.
+type Status struct {
+	KillSwitch       KillSwitchStatus           `json:"kill_switch"`
+	PendingApprovals int                        `json:"pending_approvals"`
+	ProofChainLength int                        `json:"proof_chain_length"`
+	ProofChainValid  bool                       `json:"proof_chain_valid"`
+	PendingTokens    int                        `json:"pending_tokens"`
+	RiskThreshold    int                        `json:"risk_threshold"`
+	ConfigHash       string                     `json:"config_hash"`
+	Degraded         []string                   `json:"degraded"`
+	Activity         Activity                   `json:"activity"`
+	Raw              map[string]json.RawMessage `json:"-"`
+}
+
+// Health returns nil when the proxy answers /health.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// Status returns the proxy's status.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var body json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/sentinel/status", nil, &body); err != nil {
+		return nil, err
+	}
+	st := &Status{}
+	if err := json.Unmarshal(body, st); err != nil {
+		return nil, err
+	}
+	return st, json.Unmarshal(body, &st.Raw)
+}
+
+// Gate asks for a decision on an action. Every call is written to the
+// audit log. A kill switch or capability refusal is returned as a decision,
+// not an error.
+func (c *Client) Gate(ctx context.Context, req GateRequest) (*GateResponse, error) {
+	var resp GateResponse
+	err := c.do(ctx, http.MethodPost, "/sentinel/gate", req, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden && resp.Decision != "" {
+		return &resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReportActivity reports a command the user ran. It keeps the owner's
+// liveness current and may teach the behavioral profile.
+func (c *Client) ReportActivity(ctx context.Context, report ActivityReport) (*ActivityResult, error) {
+	var res ActivityResult
+	if err := c.do(ctx, http.MethodPost, "/sentinel/activity", report, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// StartApproval opens an approval challenge by hand.
+func (c *Client) StartApproval(ctx context.Context, action, prompt string, score int) (*ApprovalChallenge, error) {
+	var ch ApprovalChallenge
+	body := map[string]interface{}{"action": action, "prompt": prompt, "score": score}
+	if err := c.do(ctx, http.MethodPost, "/sentinel/approval/start", body, &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// ConfirmApproval approves or rejects a pending challenge.
+func (c *Client) ConfirmApproval(ctx context.Context, challengeID string, approved bool, decidedBy string) (*ApprovalDecision, error) {
+	var d ApprovalDecision
+	body := map[string]interface{}{"challenge_id": challengeID, "approved": approved, "decided_by": decidedBy}
+	if err := c.do(ctx, http.MethodPost, "/sentinel/approval/confirm", body, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// PendingApprovals lists the pending challenges, oldest first. It uses the
+// browser extension endpoint, so the proxy must enable it and
+// ExtensionToken must be set.
+func (c *Client) PendingApprovals(ctx context.Context) ([]ApprovalChallenge, error) {
+	var resp struct {
+		Pending []ApprovalChallenge `json:"pending"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/sentinel/extension/approvals", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Pending, nil
+}
+
+// do sends one request, retrying as described in the package comment, and
+// decodes the JSON answer into out. out is also filled for error answers
+// that carry a JSON body.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	retries, backoff := c.Retries, c.Backoff
+	if retries == 0 {
+		retries = 2
+	}
+	if backoff <= 0 {
+		backoff = 200 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := c.once(ctx, method, path, body, out)
+		if err == nil || !retry || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff << attempt):
+		}
+	}
+}
+
+// once sends a single request and reports whether a failure may be retried.
+func (c *Client) once(ctx context.Context, method, path string, body []byte, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token := c.Token
+	if strings.HasPrefix(path, "/sentinel/extension/") {
+		token = c.ExtensionToken
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		refused := errors.As(err, &opErr) && opErr.Op == "dial"
+		return ctx.Err() == nil && (method == http.MethodGet || refused), err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return method == http.MethodGet, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil {
+			return false, nil
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return false, fmt.Errorf("sentinel: decode %s: %w", path, err)
+		}
+		return false, nil
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	var msg struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &msg) == nil && msg.Error != "" {
+		apiErr.Message = msg.Error
+	} else if out != nil {
+		json.Unmarshal(data, out)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method == http.MethodGet, apiErr
+	}
+	return false, apiErr
+}
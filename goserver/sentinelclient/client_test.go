@@ -0,0 +1,52 @@
+package sentinelclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok","pending_approvals":2}`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+
+	// Reads are retried through two 503s.
+	st, err := c.Status(context.Background())
+	if err != nil || st.PendingApprovals != 2 || calls != 3 {
+		t.Fatalf("status after %d calls: %+v %v", calls, st, err)
+	}
+
+	// A write that reached the server is not retried.
+	_, err = c.Gate(context.Background(), GateRequest{Action: "EXEC", Prompt: "ls"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls != 4 {
+		t.Fatalf("gate after %d calls: %v", calls, err)
+	}
+
+	c.Retries = -1
+	if err := c.Health(context.Background()); err == nil || calls != 5 {
+		t.Fatalf("health with retries disabled after %d calls: %v", calls, err)
+	}
+
+	// A refused connection is retried, even for writes.
+	srv.Close()
+	c.Retries = 1
+	start := time.Now()
+	c.Backoff = 20 * time.Millisecond
+	if _, err := c.Gate(context.Background(), GateRequest{Action: "EXEC"}); err == nil || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("refused gate should be retried once and fail: %v", err)
+	}
+}
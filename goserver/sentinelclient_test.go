@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lazarus-protocol/goserver/sentinelclient"
+)
+
+// The client package is tested against the real gateway so its types stay
+// in step with the handlers.
+func TestSentinelClientAgainstGateway(t *testing.T) {
+	t.Setenv("SENTINEL_OPERATOR_TOKEN", "s3cret")
+	guard := newTestGuard(t, SentinelConfig{})
+	gw := newTestGatewayFor(guard, SentinelGatewayConfig{})
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := sentinelclient.New(srv.URL + "/")
+	c.Token = "s3cret"
+	if err := c.Health(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Gate(ctx, sentinelclient.GateRequest{Action: "CODE_EDITING", Prompt: "git status"})
+	if err != nil || !resp.Allowed() || resp.Token == nil || resp.RecordHash == "" {
+		t.Fatalf("unexpected gate response: %+v %v", resp, err)
+	}
+	if res, err := c.ReportActivity(ctx, sentinelclient.ActivityReport{Shell: "zsh", Command: "make test"}); err != nil || !res.Recorded {
+		t.Fatalf("unexpected activity result: %+v %v", res, err)
+	}
+
+	ch, err := c.StartApproval(ctx, "WALLET", "transfer 5 SUI", 80)
+	if err != nil || ch.Status != "pending" {
+		t.Fatalf("unexpected challenge: %+v %v", ch, err)
+	}
+	st, err := c.Status(ctx)
+	if err != nil || st.PendingApprovals != 1 || st.ProofChainLength != 1 || st.Activity.LastSeen == nil || st.Raw["kill_switch"] == nil {
+		t.Fatalf("unexpected status: %+v %v", st, err)
+	}
+	d, err := c.ConfirmApproval(ctx, ch.ID, true, "ops")
+	if err != nil || d.Challenge.Status != "approved" || d.Token == nil {
+		t.Fatalf("unexpected decision: %+v %v", d, err)
+	}
+	_, err = c.ConfirmApproval(ctx, ch.ID, true, "ops")
+	var apiErr *sentinelclient.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message == "" {
+		t.Fatalf("a decided challenge should fail with an API error: %v", err)
+	}
+
+	// A kill switch refusal is a decision, not an error.
+	gw.kill.Arm("drill")
+	resp, err = c.Gate(ctx, sentinelclient.GateRequest{Action: "EXEC", Prompt: "ls"})
+	if err != nil || resp.Decision != sentinelclient.DecisionKillSwitch {
+		t.Fatalf("unexpected kill switch response: %+v %v", resp, err)
+	}
+}
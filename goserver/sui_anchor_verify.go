@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Anchor verification outcomes.
+const (
+	anchorVerified = "verified" // checkpointed, succeeded, event matches
+	anchorPending  = "pending"  // executed but not yet in a checkpoint
+	anchorFailed   = "failed"   // the transaction aborted
+	anchorMissing  = "missing"  // the node does not know the digest
+	anchorMismatch = "mismatch" // no AuditAnchoredEvent for this record
+)
+
+// AnchorVerification is what the chain says about one anchored record.
+type AnchorVerification struct {
+	RecordHash string `json:"record_hash"`
+	TxDigest   string `json:"tx_digest"`
+	Checkpoint string `json:"checkpoint,omitempty"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// AnchorVerificationReport covers every anchored record in an audit log.
+type AnchorVerificationReport struct {
+	Records  int                  `json:"records"`
+	Anchored int                  `json:"anchored"`
+	Verified int                  `json:"verified"`
+	Pending  int                  `json:"pending"`
+	Problems int                  `json:"problems"`
+	Results  []AnchorVerification `json:"results"`
+}
+
+// verifyAnchors re-checks each record's anchor against the chain rather
+// than trusting the digest the CLI or RPC returned at the time: the
+// transaction must be in a checkpoint (the same one stored on the record,
+// if any), have succeeded, and have emitted AuditAnchoredEvent for the
+// record's hash. Records anchored late by the retry queue are checked
+// against the digest in their ANCHOR_RETRY record.
+func verifyAnchors(reader *chainReader, records []AuditRecord) *AnchorVerificationReport {
+	report := &AnchorVerificationReport{Records: len(records), Results: []AnchorVerification{}}
+	late := lateAnchors(records)
+	for _, rec := range records {
+		if receipt, ok := late[rec.RecordHash]; ok && rec.TxDigest == "" {
+			rec.TxDigest, rec.Checkpoint = receipt.TxDigest, receipt.Checkpoint
+		}
+		if rec.TxDigest == "" {
+			continue
+		}
+		report.Anchored++
+		v := verifyAnchor(reader, rec)
+		switch v.Status {
+		case anchorVerified:
+			report.Verified++
+		case anchorPending:
+			report.Pending++
+		default:
+			report.Problems++
+		}
+		report.Results = append(report.Results, v)
+	}
+	return report
+}
+
+// verifyAnchor looks up rec.TxDigest on the chain and checks it.
+func verifyAnchor(reader *chainReader, rec AuditRecord) AnchorVerification {
+	v := AnchorVerification{RecordHash: rec.RecordHash, TxDigest: rec.TxDigest}
+	options := map[string]bool{"showEffects": true, "showEvents": true}
+	var tx suiTxBlock
+	if err := reader.Read("sui_getTransactionBlock", []interface{}{rec.TxDigest, options}, &tx); err != nil {
+		v.Status, v.Detail = anchorMissing, err.Error()
+		return v
+	}
+	v.Checkpoint = tx.Checkpoint
+	v.Status, v.Detail = checkAnchorTx(rec, tx)
+	return v
+}
+
+func checkAnchorTx(rec AuditRecord, tx suiTxBlock) (string, string) {
+	if s := tx.Effects.Status.Status; s != "" && s != "success" {
+		return anchorFailed, "transaction status " + s
+	}
+	var event map[string]interface{}
+	for _, ev := range tx.Events {
+		if _, name := splitEventType(ev.Type); name == "sentinel_audit::AuditAnchoredEvent" &&
+			normalizeKeyHex(jsonString(ev.ParsedJSON["record_hash"])) == normalizeKeyHex(rec.RecordHash) {
+			event = ev.ParsedJSON
+			break
+		}
+	}
+	if event == nil {
+		return anchorMismatch, "no AuditAnchoredEvent with this record hash"
+	}
+	// The event also carries the score and decision, which must agree with
+	// the record.
+	if v, ok := event["risk_score"]; ok && int(jsonInt(v)) != anchorRiskScore(rec.Score) {
+		return anchorMismatch, fmt.Sprintf("event risk_score %d, record score %d", jsonInt(v), rec.Score)
+	}
+	if v, ok := event["blocked"].(bool); ok && v != (rec.Decision == "blocked") {
+		return anchorMismatch, fmt.Sprintf("event blocked=%v, record decision %q", v, rec.Decision)
+	}
+	switch {
+	case tx.Checkpoint == "":
+		return anchorPending, "not yet included in a checkpoint"
+	case rec.Checkpoint != "" && rec.Checkpoint != tx.Checkpoint:
+		return anchorMismatch, fmt.Sprintf("record says checkpoint %s, chain says %s", rec.Checkpoint, tx.Checkpoint)
+	}
+	return anchorVerified, ""
+}
+
+// runVerifyAnchorsCommand implements `goserver verify-anchors --audit`.
+// It fails when any anchor does not verify, so it can run from cron.
+func runVerifyAnchorsCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("verify-anchors", flag.ContinueOnError)
+	auditPath := fs.String("audit", "./audit/sentinel-audit.jsonl", "Sentinel JSONL audit log to verify")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint to verify against")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	records, err := readAuditRecords(strings.TrimSpace(*auditPath))
+	if err != nil {
+		return err
+	}
+	report := verifyAnchors(newChainReader(*rpcURL, nil), records)
+	if err := encodeSentinelOutput(out, report); err != nil {
+		return err
+	}
+	if report.Problems > 0 {
+		return fmt.Errorf("%d of %d anchors did not verify", report.Problems, report.Anchored)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyAnchors(t *testing.T) {
+	hash := func(b byte) string { return "0x" + strings.Repeat(fmt.Sprintf("%02x", b), 32) }
+	anchored := func(digest, status, checkpoint, recordHash string) string {
+		return fmt.Sprintf(`{"digest":%q,"checkpoint":%q,"effects":{"status":{"status":%q}},
+			"events":[{"type":"0xpkg::sentinel_audit::AuditAnchoredEvent","parsedJson":{"record_hash":%q}}]}`,
+			digest, checkpoint, status, recordHash)
+	}
+	chain := map[string]string{
+		"ok":      anchored("ok", "success", "100", hash(1)),
+		"pending": anchored("pending", "success", "", hash(2)),
+		"aborted": anchored("aborted", "failure", "101", hash(3)),
+		"other":   anchored("other", "success", "102", hash(9)),
+		"moved":   anchored("moved", "success", "103", hash(5)),
+	}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var digest string
+		json.Unmarshal(req.Params[0], &digest)
+		if tx, ok := chain[digest]; ok {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, tx)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"Could not find the referenced transaction"}}`)
+	}))
+	defer node.Close()
+
+	records := []AuditRecord{
+		{RecordHash: hash(1), TxDigest: "ok", Checkpoint: "100"},
+		{RecordHash: hash(2), TxDigest: "pending"},
+		{RecordHash: hash(3), TxDigest: "aborted"},
+		{RecordHash: hash(4), TxDigest: "other"},
+		{RecordHash: hash(5), TxDigest: "moved", Checkpoint: "99"},
+		{RecordHash: hash(6), TxDigest: "forged"},
+		{RecordHash: hash(7), AnchorError: "rpc down"},
+	}
+	report := verifyAnchors(newChainReader(node.URL, nil), records)
+	want := []string{anchorVerified, anchorPending, anchorFailed, anchorMismatch, anchorMismatch, anchorMissing}
+	if len(report.Results) != len(want) {
+		t.Fatalf("expected %d anchored results, got %+v", len(want), report.Results)
+	}
+	for i, w := range want {
+		if report.Results[i].Status != w {
+			t.Fatalf("result %d (%s): status %s, want %s (%s)", i, report.Results[i].TxDigest, report.Results[i].Status, w, report.Results[i].Detail)
+		}
+	}
+	if report.Records != 7 || report.Verified != 1 || report.Pending != 1 || report.Problems != 4 || report.Results[0].Checkpoint != "100" {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	line, _ := json.Marshal(records[0])
+	os.WriteFile(auditPath, append(line, '\n'), 0o644)
+	var buf bytes.Buffer
+	if err := runVerifyAnchorsCommand([]string{"--audit", auditPath, "--rpc", node.URL}, &buf); err != nil {
+		t.Fatalf("a clean log should verify: %v", err)
+	}
+	line, _ = json.Marshal(records[5])
+	f, _ := os.OpenFile(auditPath, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write(append(line, '\n'))
+	f.Close()
+	if err := runVerifyAnchorsCommand([]string{"--audit", auditPath, "--rpc", node.URL}, &buf); err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("a forged digest should fail verification, got %v", err)
+	}
+}
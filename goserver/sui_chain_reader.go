@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ChainReadConfig keeps chain reads polite to public fullnodes: identical
+// reads are shared and cached briefly, reads issued together go out as one
+// JSON-RPC batch, and at most MaxConcurrent HTTP requests are in flight.
+type ChainReadConfig struct {
+	Backend    string `json:"backend"`     // jsonrpc (default) | graphql
+	GraphQLURL string `json:"graphql_url"` // Sui GraphQL endpoint for the graphql backend
+
+	CacheTTLMs    int `json:"cache_ttl_ms"`    // default 2000
+	BatchWindowMs int `json:"batch_window_ms"` // how long to collect a batch; default 5
+	MaxBatchSize  int `json:"max_batch_size"`  // default 20
+	MaxConcurrent int `json:"max_concurrent"`  // default 4
+}
+
+type cachedRead struct {
+	result  json.RawMessage
+	expires time.Time
+}
+
+type pendingRead struct {
+	key    string
+	method string
+	params []interface{}
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+}
+
+// chainReader is the read path to one JSON-RPC endpoint.
+type chainReader struct {
+	url      string
+	client   *http.Client
+	ttl      time.Duration
+	window   time.Duration
+	maxBatch int
+	sem      chan struct{}
+
+	mu       sync.Mutex
+	cache    map[string]cachedRead
+	inflight map[string]*pendingRead
+	queue    []*pendingRead
+	timer    *time.Timer
+}
+
+func newChainReader(url string, cfg *ChainReadConfig) *chainReader {
+	c := ChainReadConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.CacheTTLMs <= 0 {
+		c.CacheTTLMs = 2000
+	}
+	if c.BatchWindowMs <= 0 {
+		c.BatchWindowMs = 5
+	}
+	if c.MaxBatchSize <= 0 {
+		c.MaxBatchSize = 20
+	}
+	if c.MaxConcurrent <= 0 {
+		c.MaxConcurrent = 4
+	}
+	return &chainReader{
+		url:      url,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		ttl:      time.Duration(c.CacheTTLMs) * time.Millisecond,
+		window:   time.Duration(c.BatchWindowMs) * time.Millisecond,
+		maxBatch: c.MaxBatchSize,
+		sem:      make(chan struct{}, c.MaxConcurrent),
+		cache:    map[string]cachedRead{},
+		inflight: map[string]*pendingRead{},
+	}
+}
+
+// Read performs a JSON-RPC read and decodes its result into out.
+func (r *chainReader) Read(method string, params []interface{}, out interface{}) error {
+	keyBytes, _ := json.Marshal(params)
+	key := method + string(keyBytes)
+
+	r.mu.Lock()
+	if c, ok := r.cache[key]; ok && time.Now().Before(c.expires) {
+		r.mu.Unlock()
+		return json.Unmarshal(c.result, out)
+	}
+	p, ok := r.inflight[key]
+	if !ok {
+		p = &pendingRead{key: key, method: method, params: params, done: make(chan struct{})}
+		r.inflight[key] = p
+		r.queue = append(r.queue, p)
+		switch {
+		case len(r.queue) >= r.maxBatch:
+			batch := r.takeQueueLocked()
+			go r.send(batch)
+		case r.timer == nil:
+			r.timer = time.AfterFunc(r.window, r.flush)
+		}
+	}
+	r.mu.Unlock()
+
+	<-p.done
+	if p.err != nil {
+		return p.err
+	}
+	return json.Unmarshal(p.result, out)
+}
+
+func (r *chainReader) takeQueueLocked() []*pendingRead {
+	batch := r.queue
+	r.queue = nil
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	return batch
+}
+
+func (r *chainReader) flush() {
+	r.mu.Lock()
+	batch := r.takeQueueLocked()
+	r.mu.Unlock()
+	if len(batch) > 0 {
+		r.send(batch)
+	}
+}
+
+// send issues one HTTP request for the batch (a plain request when it holds
+// a single read) and completes every pending read.
+func (r *chainReader) send(batch []*pendingRead) {
+	r.sem <- struct{}{}
+	err := r.roundTrip(batch)
+	<-r.sem
+
+	now := time.Now()
+	r.mu.Lock()
+	for _, p := range batch {
+		if p.err == nil && err != nil {
+			p.err = err
+		}
+		if p.err == nil {
+			r.cache[p.key] = cachedRead{result: p.result, expires: now.Add(r.ttl)}
+		}
+		delete(r.inflight, p.key)
+	}
+	r.mu.Unlock()
+	for _, p := range batch {
+		close(p.done)
+	}
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (r *chainReader) roundTrip(batch []*pendingRead) error {
+	reqs := make([]map[string]interface{}, len(batch))
+	for i, p := range batch {
+		reqs[i] = map[string]interface{}{"jsonrpc": "2.0", "id": i + 1, "method": p.method, "params": p.params}
+	}
+	var body []byte
+	if len(reqs) == 1 {
+		body, _ = json.Marshal(reqs[0])
+	} else {
+		body, _ = json.Marshal(reqs)
+	}
+
+	resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var responses []rpcResponse
+	if len(reqs) == 1 {
+		var single rpcResponse
+		if err := json.NewDecoder(resp.Body).Decode(&single); err != nil {
+			return fmt.Errorf("decode rpc response (HTTP %d): %w", resp.StatusCode, err)
+		}
+		single.ID = 1
+		responses = []rpcResponse{single}
+	} else if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return fmt.Errorf("decode rpc batch response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	for _, res := range responses {
+		if res.ID < 1 || res.ID > len(batch) {
+			continue
+		}
+		p := batch[res.ID-1]
+		if res.Error != nil {
+			p.err = fmt.Errorf("%s: rpc error %d: %s", p.method, res.Error.Code, res.Error.Message)
+			continue
+		}
+		p.result = res.Result
+	}
+	for _, p := range batch {
+		if p.err == nil && p.result == nil {
+			p.err = fmt.Errorf("%s: no response in batch", p.method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// echoRPC answers sui_getObject with the requested id and fails any other
+// method; it counts HTTP requests and records whether each was a batch.
+func echoRPC(t *testing.T, requests *int32, batches *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		body, _ := io.ReadAll(r.Body)
+		type req struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		answer := func(q req) map[string]interface{} {
+			if q.Method != "sui_getObject" {
+				return map[string]interface{}{"jsonrpc": "2.0", "id": q.ID, "error": map[string]interface{}{"code": -32601, "message": "method not found"}}
+			}
+			return map[string]interface{}{"jsonrpc": "2.0", "id": q.ID, "result": map[string]interface{}{"objectId": q.Params[0]}}
+		}
+		if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+			atomic.AddInt32(batches, 1)
+			var qs []req
+			json.Unmarshal(body, &qs)
+			out := make([]map[string]interface{}, 0, len(qs))
+			for i := len(qs) - 1; i >= 0; i-- { // reversed to exercise id matching
+				out = append(out, answer(qs[i]))
+			}
+			json.NewEncoder(w).Encode(out)
+			return
+		}
+		var q req
+		json.Unmarshal(body, &q)
+		json.NewEncoder(w).Encode(answer(q))
+	}))
+}
+
+func TestChainReaderBatchesAndCaches(t *testing.T) {
+	var requests, batches int32
+	srv := echoRPC(t, &requests, &batches)
+	defer srv.Close()
+	reader := newChainReader(srv.URL, &ChainReadConfig{BatchWindowMs: 50})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("0x%d", i%5) // five distinct reads, each requested twice
+			var out struct {
+				ObjectID string `json:"objectId"`
+			}
+			if err := reader.Read("sui_getObject", []interface{}{id}, &out); err != nil || out.ObjectID != id {
+				errs <- fmt.Errorf("read %s: got %q, %v", id, out.ObjectID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if requests != 1 || batches != 1 {
+		t.Fatalf("expected one batched request, got %d requests (%d batches)", requests, batches)
+	}
+
+	var out map[string]interface{}
+	if err := reader.Read("sui_getObject", []interface{}{"0x1"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected cached read, got %d requests", requests)
+	}
+	if err := reader.Read("sui_unknown", []interface{}{}, &out); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Fatalf("expected rpc error, got %v", err)
+	}
+}
+
+func TestChainReaderSplitsLargeBatches(t *testing.T) {
+	var requests, batches int32
+	srv := echoRPC(t, &requests, &batches)
+	defer srv.Close()
+	reader := newChainReader(srv.URL, &ChainReadConfig{BatchWindowMs: 1000, MaxBatchSize: 3, MaxConcurrent: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var out map[string]interface{}
+			reader.Read("sui_getObject", []interface{}{fmt.Sprintf("0x%d", i)}, &out)
+		}(i)
+	}
+	wg.Wait()
+	if requests != 2 {
+		t.Fatalf("expected two full batches without waiting for the window, got %d requests", requests)
+	}
+}
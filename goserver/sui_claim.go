@@ -0,0 +1,313 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClaimResult is the output of `goserver claim`.
+type ClaimResult struct {
+	VaultID     string `json:"vault_id"`
+	Owner       string `json:"owner"`
+	Beneficiary string `json:"beneficiary"`
+	BlobID      string `json:"blob_id"`
+	Executed    bool   `json:"executed"`
+	// ExecuteTx is set when this claim executed the will.
+	ExecuteTx string `json:"execute_tx,omitempty"`
+	Output    string `json:"output"`
+	Size      int    `json:"size"`
+	Checksum  string `json:"checksum"`
+	// ChecksumVerified is set when --checksum was given and matched.
+	ChecksumVerified bool     `json:"checksum_verified"`
+	Notes            []string `json:"notes,omitempty"`
+}
+
+// vaultObject is the on-chain state of a lazarus_protocol::Vault.
+type vaultObject struct {
+	ID              string
+	Package         string
+	Owner           string
+	Beneficiary     string
+	BlobID          string
+	LastHeartbeatMs int64
+	Executed        bool
+}
+
+func fetchVaultObject(reader *chainReader, vaultID string) (*vaultObject, error) {
+	var obj struct {
+		Data *struct {
+			Type    string `json:"type"`
+			Content struct {
+				Fields struct {
+					Owner           string `json:"owner"`
+					Beneficiary     string `json:"beneficiary"`
+					EncryptedBlobID string `json:"encrypted_blob_id"`
+					LastHeartbeatMs string `json:"last_heartbeat_ms"`
+					IsExecuted      bool   `json:"is_executed"`
+				} `json:"fields"`
+			} `json:"content"`
+		} `json:"data"`
+	}
+	err := reader.Read("sui_getObject", []interface{}{vaultID, map[string]bool{"showType": true, "showContent": true}}, &obj)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Data == nil {
+		return nil, fmt.Errorf("vault %s not found", vaultID)
+	}
+	parts := strings.Split(obj.Data.Type, "::")
+	if len(parts) != 3 || parts[1] != "lazarus_protocol" || parts[2] != "Vault" {
+		return nil, fmt.Errorf("%s is a %s, not a lazarus_protocol::Vault", vaultID, obj.Data.Type)
+	}
+	f := obj.Data.Content.Fields
+	last, _ := strconv.ParseInt(f.LastHeartbeatMs, 10, 64)
+	return &vaultObject{
+		ID:              vaultID,
+		Package:         parts[0],
+		Owner:           f.Owner,
+		Beneficiary:     f.Beneficiary,
+		BlobID:          f.EncryptedBlobID,
+		LastHeartbeatMs: last,
+		Executed:        f.IsExecuted,
+	}, nil
+}
+
+// claimOptions are the inputs of a claim.
+type claimOptions struct {
+	VaultID       string
+	DecryptionKey string
+	Checksum      string // expected hex sha256 of the plaintext, optional
+	OutPath       string
+	RPCURL        string
+	AggregatorURL string
+	SignerKey     string // executes the will when set
+	Force         bool   // decrypt even though the will cannot be executed yet
+	Now           time.Time
+}
+
+// claimVaultPayload executes the will when it is due and a signer is given,
+// then downloads, decrypts and verifies the vault's blob and writes it to
+// OutPath. It refuses to decrypt a vault whose owner is still within the
+// heartbeat threshold unless Force is set.
+func claimVaultPayload(opts claimOptions) (*ClaimResult, error) {
+	if _, err := os.Stat(opts.OutPath); err == nil {
+		return nil, fmt.Errorf("%s already exists; refusing to overwrite", opts.OutPath)
+	}
+	vault, err := fetchVaultObject(newChainReader(opts.RPCURL, nil), opts.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	res := &ClaimResult{VaultID: vault.ID, Owner: vault.Owner, Beneficiary: vault.Beneficiary, BlobID: vault.BlobID, Executed: vault.Executed}
+
+	deadline := time.UnixMilli(vault.LastHeartbeatMs).Add(defaultHeartbeatThreshold).UTC()
+	switch {
+	case vault.Executed:
+	case opts.Now.After(deadline) && opts.SignerKey != "":
+		sui, err := NewSuiClient(&SuiRPCConfig{Enabled: true, RPCURL: opts.RPCURL, PrivateKey: opts.SignerKey})
+		if err != nil {
+			return nil, err
+		}
+		args, err := new(suiMoveArgs).shared(vault.ID, true).clock("").rpc()
+		if err != nil {
+			return nil, err
+		}
+		tx, err := sui.MoveCallClass(txClassEmergency, vault.Package, "lazarus_protocol", "execute_will", args)
+		if err != nil {
+			return nil, fmt.Errorf("execute_will: %w", err)
+		}
+		res.Executed, res.ExecuteTx = true, tx
+	case opts.Now.After(deadline):
+		res.Notes = append(res.Notes, "the will is executable but was not executed; pass --key-file to execute it on-chain")
+	case opts.Force:
+		res.Notes = append(res.Notes, fmt.Sprintf("the owner's deadline is %s; decrypted early because of --force", deadline.Format(time.RFC3339)))
+	default:
+		return nil, fmt.Errorf("the owner sent a heartbeat %s ago; the will cannot be executed before %s",
+			formatDaysLeft(opts.Now.Sub(time.UnixMilli(vault.LastHeartbeatMs))), deadline.Format(time.RFC3339))
+	}
+
+	plaintext, err := decryptVaultBlob(opts, vault)
+	if err != nil {
+		return nil, err
+	}
+	res.Checksum = vaultChecksum(plaintext)
+	res.ChecksumVerified = opts.Checksum != ""
+	if dir := filepath.Dir(opts.OutPath); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(opts.OutPath, plaintext, 0o600); err != nil {
+		return nil, err
+	}
+	res.Output, res.Size = opts.OutPath, len(plaintext)
+	return res, nil
+}
+
+// decryptVaultBlob downloads and decrypts the vault's blob and checks it
+// against opts.Checksum, when one is given.
+func decryptVaultBlob(opts claimOptions, vault *vaultObject) ([]byte, error) {
+	ciphertext, err := fetchWalrusBlob(&http.Client{Timeout: 2 * time.Minute}, opts.AggregatorURL, vault.BlobID)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openVaultPayload(ciphertext, opts.DecryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if want := strings.ToLower(strings.TrimSpace(opts.Checksum)); want != "" {
+		if got := vaultChecksum(plaintext); want != got {
+			return nil, fmt.Errorf("checksum mismatch: blob decrypts to %s, expected %s", got, want)
+		}
+	}
+	return plaintext, nil
+}
+
+// ClaimSimulation is the output of `goserver simulate-claim`.
+type ClaimSimulation struct {
+	VaultID     string `json:"vault_id"`
+	Owner       string `json:"owner"`
+	Beneficiary string `json:"beneficiary"`
+	BlobID      string `json:"blob_id"`
+	Executed    bool   `json:"executed"`
+	// Deadline is when the will becomes executable; Executable reports
+	// whether it has passed.
+	Deadline   time.Time `json:"deadline"`
+	Executable bool      `json:"executable"`
+	// Recoverable is set when the blob downloaded, decrypted and matched
+	// the checksum. Problem says which step failed otherwise.
+	Recoverable      bool     `json:"recoverable"`
+	Problem          string   `json:"problem,omitempty"`
+	Size             int      `json:"size,omitempty"`
+	Checksum         string   `json:"checksum,omitempty"`
+	ChecksumVerified bool     `json:"checksum_verified"`
+	Notes            []string `json:"notes,omitempty"`
+}
+
+// simulateClaim runs the claim path without its side effects: it reads the
+// vault and downloads, decrypts and checks the blob as a claim would, but
+// never calls execute_will and never writes the plaintext. It ignores the
+// owner's deadline, so owners can check their vault while they are alive.
+func simulateClaim(opts claimOptions) (*ClaimSimulation, error) {
+	vault, err := fetchVaultObject(newChainReader(opts.RPCURL, nil), opts.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.UnixMilli(vault.LastHeartbeatMs).Add(defaultHeartbeatThreshold).UTC()
+	res := &ClaimSimulation{
+		VaultID:     vault.ID,
+		Owner:       vault.Owner,
+		Beneficiary: vault.Beneficiary,
+		BlobID:      vault.BlobID,
+		Executed:    vault.Executed,
+		Deadline:    deadline,
+		Executable:  !vault.Executed && opts.Now.After(deadline),
+	}
+	if !vault.Executed {
+		res.Notes = append(res.Notes, "a claim after the deadline needs a --key-file signer to execute the will")
+	}
+	if opts.Checksum == "" {
+		res.Notes = append(res.Notes, "no --checksum given; the beneficiary cannot tell a wrong file from the right one")
+	}
+
+	plaintext, err := decryptVaultBlob(opts, vault)
+	if err != nil {
+		res.Problem = err.Error()
+		return res, nil
+	}
+	res.Recoverable = true
+	res.Size, res.Checksum = len(plaintext), vaultChecksum(plaintext)
+	res.ChecksumVerified = opts.Checksum != ""
+	return res, nil
+}
+
+// runSimulateClaimCommand implements `goserver simulate-claim`. It exits
+// non-zero when the beneficiary would not recover the file.
+func runSimulateClaimCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("simulate-claim", flag.ContinueOnError)
+	vaultID := fs.String("vault", "", "Vault object ID")
+	keyFile := fs.String("decryption-key-file", "", "File holding the decryption key (hex key||nonce) left for the beneficiary")
+	checksum := fs.String("checksum", "", "Expected sha256 of the plaintext, as left for the beneficiary")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint")
+	aggregator := fs.String("aggregator", "https://aggregator.walrus-testnet.walrus.space", "Walrus aggregator URL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *vaultID == "" || *keyFile == "" {
+		return fmt.Errorf("--vault and --decryption-key-file are required")
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	res, err := simulateClaim(claimOptions{
+		VaultID:       "0x" + normalizeKeyHex(*vaultID),
+		DecryptionKey: string(key),
+		Checksum:      *checksum,
+		RPCURL:        *rpcURL,
+		AggregatorURL: *aggregator,
+		Now:           time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := encodeSentinelOutput(out, res); err != nil {
+		return err
+	}
+	if !res.Recoverable {
+		return fmt.Errorf("the beneficiary could not recover the vault: %s", res.Problem)
+	}
+	return nil
+}
+
+// runClaimCommand implements `goserver claim`, the beneficiary's side of a
+// vault.
+func runClaimCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("claim", flag.ContinueOnError)
+	vaultID := fs.String("vault", "", "Vault object ID")
+	keyFile := fs.String("decryption-key-file", "", "File holding the decryption key (hex key||nonce) the owner left")
+	checksum := fs.String("checksum", "", "Expected sha256 of the plaintext, if the owner left it")
+	outPath := fs.String("out", "", "Write the decrypted file here (must not exist)")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint")
+	aggregator := fs.String("aggregator", "https://aggregator.walrus-testnet.walrus.space", "Walrus aggregator URL")
+	signerFile := fs.String("key-file", "", "Sui key (hex ed25519 seed or base64 keystore entry) that pays for execute_will")
+	force := fs.Bool("force", false, "Decrypt even though the owner's deadline has not passed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *vaultID == "" || *keyFile == "" || *outPath == "" {
+		return fmt.Errorf("--vault, --decryption-key-file and --out are required")
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	opts := claimOptions{
+		VaultID:       "0x" + normalizeKeyHex(*vaultID),
+		DecryptionKey: string(key),
+		Checksum:      *checksum,
+		OutPath:       *outPath,
+		RPCURL:        *rpcURL,
+		AggregatorURL: *aggregator,
+		Force:         *force,
+		Now:           time.Now(),
+	}
+	if *signerFile != "" {
+		signer, err := os.ReadFile(*signerFile)
+		if err != nil {
+			return err
+		}
+		opts.SignerKey = strings.TrimSpace(string(signer))
+	}
+	res, err := claimVaultPayload(opts)
+	if err != nil {
+		return err
+	}
+	return encodeSentinelOutput(out, res)
+}
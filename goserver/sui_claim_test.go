@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaimVault(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	var stored []byte
+	walrus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+			io.WriteString(w, `{"newlyCreated":{"blobObject":{"id":"0xobj","blobId":"blob-7","storage":{"endEpoch":9}}}}`)
+		case r.URL.Path == "/v1/blobs/blob-7":
+			w.Write(stored)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer walrus.Close()
+	sealed, err := encryptAndStoreWalrus(newWalrusClient(walrus.URL, nil), []byte("seed phrase"), WalrusStoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var moveCalls [][]interface{}
+	inner := fakeSuiNode(t, &moveCalls)
+	defer inner.Close()
+	executed := false
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Contains(body, []byte(`"sui_getObject"`)) {
+			resp, err := http.Post(inner.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			io.Copy(w, resp.Body)
+			executed = executed || bytes.Contains(body, []byte(`"sui_executeTransactionBlock"`))
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"data":{"objectId":"0xv1","type":"0xpkg::lazarus_protocol::Vault",
+			"content":{"dataType":"moveObject","fields":{"owner":"0xo","beneficiary":"0xb","encrypted_blob_id":"blob-7",
+			"last_heartbeat_ms":"%d","is_executed":%t}}}}}`, day, executed)
+	}))
+	defer node.Close()
+
+	dir := t.TempDir()
+	opts := claimOptions{
+		VaultID:       "0xv1",
+		DecryptionKey: sealed.DecryptionKey,
+		Checksum:      strings.ToUpper(sealed.Checksum),
+		OutPath:       filepath.Join(dir, "early"),
+		RPCURL:        node.URL,
+		AggregatorURL: walrus.URL,
+		SignerKey:     strings.Repeat("04", 32),
+		Now:           time.UnixMilli(20 * day),
+	}
+	if _, err := claimVaultPayload(opts); err == nil || !strings.Contains(err.Error(), "19 days ago") {
+		t.Fatalf("a claim before the deadline should fail: %v", err)
+	}
+
+	opts.OutPath = filepath.Join(dir, "claimed", "seed.txt")
+	opts.Now = time.UnixMilli(40 * day)
+	res, err := claimVaultPayload(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Executed || res.ExecuteTx != "FakeDigest111" || !res.ChecksumVerified || res.Size != 11 {
+		t.Fatalf("unexpected claim: %+v", res)
+	}
+	if len(moveCalls) != 1 || moveCalls[0][0] != "0xv1" || moveCalls[0][1] != "0x6" {
+		t.Fatalf("unexpected execute_will call: %v", moveCalls)
+	}
+	if got, _ := os.ReadFile(opts.OutPath); string(got) != "seed phrase" {
+		t.Fatalf("claimed file holds %q", got)
+	}
+	if _, err := claimVaultPayload(opts); err == nil {
+		t.Fatal("an existing output file should not be overwritten")
+	}
+
+	// Once executed, the command claims without a signer and does not call
+	// execute_will again.
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(sealed.DecryptionKey+"\n"), 0o600)
+	var buf bytes.Buffer
+	err = runClaimCommand([]string{"--vault", "0xV1", "--decryption-key-file", keyFile, "--checksum", "00",
+		"--out", filepath.Join(dir, "again"), "--rpc", node.URL, "--aggregator", walrus.URL}, &buf)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("a wrong checksum should fail: %v", err)
+	}
+	err = runClaimCommand([]string{"--vault", "0xV1", "--decryption-key-file", keyFile,
+		"--out", filepath.Join(dir, "again"), "--rpc", node.URL, "--aggregator", walrus.URL}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out ClaimResult
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || !out.Executed || out.ExecuteTx != "" || len(moveCalls) != 1 {
+		t.Fatalf("unexpected command output: %s", buf.String())
+	}
+}
+
+func TestSimulateClaim(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	var stored []byte
+	walrus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+			io.WriteString(w, `{"newlyCreated":{"blobObject":{"id":"0xobj","blobId":"blob-7","storage":{"endEpoch":9}}}}`)
+		case r.URL.Path == "/v1/blobs/blob-7":
+			w.Write(stored)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer walrus.Close()
+	sealed, err := encryptAndStoreWalrus(newWalrusClient(walrus.URL, nil), []byte("seed phrase"), WalrusStoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Contains(body, []byte(`"sui_getObject"`)) {
+			t.Errorf("a simulation must only read the chain: %s", body)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"data":{"objectId":"0xv1","type":"0xpkg::lazarus_protocol::Vault",
+			"content":{"dataType":"moveObject","fields":{"owner":"0xo","beneficiary":"0xb","encrypted_blob_id":"blob-7",
+			"last_heartbeat_ms":"%d","is_executed":false}}}}}`, day)
+	}))
+	defer node.Close()
+
+	// The simulation never executes the will and writes nothing.
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(sealed.DecryptionKey+"\n"), 0o600)
+	var buf bytes.Buffer
+	err = runSimulateClaimCommand([]string{"--vault", "0xV1", "--decryption-key-file", keyFile, "--checksum", sealed.Checksum,
+		"--rpc", node.URL, "--aggregator", walrus.URL}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res ClaimSimulation
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Recoverable || !res.ChecksumVerified || res.Size != 11 || res.Executed ||
+		!res.Deadline.Equal(time.UnixMilli(31*day).UTC()) {
+		t.Fatalf("unexpected simulation: %s", buf.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("the simulation must not write the plaintext: %v", entries)
+	}
+
+	// Before the deadline the simulation still checks recovery.
+	for name, tc := range map[string]struct {
+		key, checksum string
+		now           int64
+		recoverable   bool
+		problem       string
+	}{
+		"owner alive":    {sealed.DecryptionKey, sealed.Checksum, 20, true, ""},
+		"wrong key":      {strings.Repeat("ab", 44), "", 40, false, "decrypt"},
+		"wrong checksum": {sealed.DecryptionKey, "00", 40, false, "checksum mismatch"},
+	} {
+		res, err := simulateClaim(claimOptions{VaultID: "0xv1", DecryptionKey: tc.key, Checksum: tc.checksum,
+			RPCURL: node.URL, AggregatorURL: walrus.URL, Now: time.UnixMilli(tc.now * day)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Recoverable != tc.recoverable || res.Executable != (tc.now > 31) || !strings.Contains(res.Problem, tc.problem) {
+			t.Fatalf("%s: %+v", name, res)
+		}
+	}
+}
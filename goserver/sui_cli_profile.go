@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SuiCLIConfig pins the sui CLI profile used for anchoring, instead of
+// whatever `sui client` env and address happen to be active on the host.
+type SuiCLIConfig struct {
+	// ClientConfig is the client.yaml passed as --client.config.
+	ClientConfig string `json:"client_config"`
+	// Env is the environment alias passed as --client.env.
+	Env string `json:"env"`
+	// KeystorePath is the sui.keystore that client.yaml points at. At
+	// startup it must hold the key for Address.
+	KeystorePath string `json:"keystore_path"`
+	// Address is the expected sender. It is passed as --sender and must
+	// match `sui client active-address` at startup.
+	Address string `json:"address"`
+}
+
+// clientArgs returns the global `sui client` flags that select the profile.
+func (c *SuiCLIConfig) clientArgs() []string {
+	if c == nil {
+		return nil
+	}
+	var args []string
+	if c.ClientConfig != "" {
+		args = append(args, "--client.config", c.ClientConfig)
+	}
+	if c.Env != "" {
+		args = append(args, "--client.env", c.Env)
+	}
+	return args
+}
+
+// txArgs returns the transaction flags that pin the sender.
+func (c *SuiCLIConfig) txArgs() []string {
+	if c == nil || c.Address == "" {
+		return nil
+	}
+	return []string{"--sender", c.Address}
+}
+
+var suiAddressPattern = regexp.MustCompile(`0x[0-9a-fA-F]{64}`)
+
+// validateSuiCLIProfile checks that the pinned profile exists and signs as
+// the configured address, so a switched `sui client` env cannot make the
+// daemon anchor on the wrong network or from the wrong account.
+func validateSuiCLIProfile(c *SuiCLIConfig, policy SubprocessPolicy) error {
+	if c == nil {
+		return nil
+	}
+	if c.Address != "" && !suiAddressPattern.MatchString(c.Address) {
+		return fmt.Errorf("sui_cli.address %q is not a 32-byte hex address", c.Address)
+	}
+	if c.ClientConfig != "" {
+		data, err := os.ReadFile(c.ClientConfig)
+		if err != nil {
+			return fmt.Errorf("sui_cli.client_config: %w", err)
+		}
+		if c.Env != "" && !regexp.MustCompile(`(?m)^\s*-?\s*alias:\s*"?`+regexp.QuoteMeta(c.Env)+`"?\s*$`).Match(data) {
+			return fmt.Errorf("sui_cli.env %q is not defined in %s", c.Env, c.ClientConfig)
+		}
+	}
+	if c.KeystorePath != "" {
+		addrs, err := keystoreAddresses(c.KeystorePath)
+		if err != nil {
+			return fmt.Errorf("sui_cli.keystore_path: %w", err)
+		}
+		if c.Address != "" && !containsTag(addrs, strings.ToLower(c.Address)) {
+			return fmt.Errorf("sui_cli.keystore_path %s has no key for %s", c.KeystorePath, c.Address)
+		}
+	}
+	if c.Address == "" {
+		return nil
+	}
+
+	args := append([]string{"client"}, c.clientArgs()...)
+	out, err := runSubprocess(policy, true, "sui", append(args, "active-address")...)
+	if err != nil {
+		return fmt.Errorf("sui client active-address: %v, output: %s", err, strings.TrimSpace(string(out)))
+	}
+	active := suiAddressPattern.FindString(string(out))
+	if !strings.EqualFold(active, c.Address) {
+		return fmt.Errorf("sui CLI active address is %q, expected %s", active, c.Address)
+	}
+	return nil
+}
+
+// keystoreAddresses derives the address of every key in a sui.keystore
+// (a JSON array of base64 flag || secret entries).
+func keystoreAddresses(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s is not a sui keystore: %w", path, err)
+	}
+	addrs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		signer, err := parseSuiSigner(entry, "")
+		if err != nil {
+			continue // schemes this build cannot derive (e.g. secp256r1)
+		}
+		addrs = append(addrs, suiAddressFor(signer.Flag(), signer.PublicKey()))
+	}
+	return addrs, nil
+}
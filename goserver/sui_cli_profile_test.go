@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuiCLIProfile(t *testing.T) {
+	dir := t.TempDir()
+	seed := bytes.Repeat([]byte{7}, 32)
+	addr := suiAddress(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+	keystore := filepath.Join(dir, "sui.keystore")
+	entry := base64.StdEncoding.EncodeToString(append([]byte{suiFlagEd25519}, seed...))
+	os.WriteFile(keystore, []byte(`["`+entry+`"]`), 0o600)
+	clientYAML := filepath.Join(dir, "client.yaml")
+	os.WriteFile(clientYAML, []byte("keystore:\n  File: "+keystore+"\nenvs:\n  - alias: testnet\n    rpc: https://fullnode.testnet.sui.io:443\nactive_env: devnet\n"), 0o600)
+
+	argsLog := filepath.Join(dir, "args.log")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %s
+case "$*" in
+  *active-address*) echo %s ;;
+  *call*) echo '{"effects":{"transactionDigest":"CliDigest"}}' ;;
+esac
+`, argsLog, addr)
+	os.WriteFile(filepath.Join(dir, "sui"), []byte(script), 0o755)
+	t.Setenv("PATH", dir)
+
+	profile := &SuiCLIConfig{ClientConfig: clientYAML, Env: "testnet", KeystorePath: keystore, Address: addr}
+	newGuard := func(p *SuiCLIConfig) *SentinelGuard {
+		g := newTestGuard(t, SentinelConfig{
+			AnchorEnabled:  true,
+			AnchorPackage:  "0xpkg",
+			AnchorRegistry: "0xreg",
+			SuiCLI:         p,
+		})
+		g.probeCapabilities()
+		return g
+	}
+	anchorStatus := func(g *SentinelGuard) CapabilityStatus {
+		for _, st := range g.capabilities.Status() {
+			if st.Name == capAnchor {
+				return st
+			}
+		}
+		t.Fatal("anchor capability not probed")
+		return CapabilityStatus{}
+	}
+
+	guard := newGuard(profile)
+	if st := anchorStatus(guard); !st.Available {
+		t.Fatalf("valid profile should leave anchoring available: %+v", st)
+	}
+	_, rec, err := guard.Enforce("RUN", "git status")
+	if err != nil || rec.TxDigest != "CliDigest" {
+		t.Fatalf("anchor via CLI: %+v %v", rec, err)
+	}
+	logged, _ := os.ReadFile(argsLog)
+	lines := strings.Split(strings.TrimSpace(string(logged)), "\n")
+	want := "client --client.config " + clientYAML + " --client.env testnet call "
+	if !strings.HasPrefix(lines[len(lines)-1], want) || !strings.HasSuffix(lines[len(lines)-1], "--sender "+addr) {
+		t.Fatalf("call did not pin the profile: %s", lines[len(lines)-1])
+	}
+
+	other := "0x" + strings.Repeat("ab", 32)
+	for name, p := range map[string]*SuiCLIConfig{
+		"active address": {ClientConfig: clientYAML, Env: "testnet", Address: other},
+		"has no key":     {KeystorePath: keystore, Address: other},
+		"not defined":    {ClientConfig: clientYAML, Env: "mainnet"},
+	} {
+		if st := anchorStatus(newGuard(p)); st.Available || !strings.Contains(st.Detail, name) {
+			t.Fatalf("expected %q failure, got %+v", name, st)
+		}
+	}
+}
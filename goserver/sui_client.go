@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/block-vision/sui-go-sdk/models"
+	"github.com/block-vision/sui-go-sdk/sui"
+	"golang.org/x/crypto/blake2b"
+)
+
+// SuiRPCConfig submits anchor transactions over Sui JSON-RPC instead of
+// shelling out to the sui CLI.
+type SuiRPCConfig struct {
+	Enabled bool   `json:"enabled"`
+	RPCURL  string `json:"rpc_url"`
+	// PrivateKey is the operator's ed25519 key: a hex seed or a base64
+	// sui.keystore entry (flag byte followed by the seed).
+	PrivateKey string `json:"private_key"`
+	GasBudget  uint64 `json:"gas_budget"` // default 10000000
+	// CLIFallback retries with `sui client call` when the RPC path fails.
+	CLIFallback bool `json:"cli_fallback"`
+	// MaxConflictRetries is how many times a transaction rejected for an
+	// object lock or version conflict is rebuilt and resubmitted (default 3).
+	MaxConflictRetries int `json:"max_conflict_retries"`
+	// KMS signs with a secp256k1 key held in a cloud KMS instead of
+	// PrivateKey, so the key never exists on the host.
+	KMS *KMSSignerConfig `json:"kms,omitempty"`
+}
+
+// SuiClient builds, signs and executes Move calls against a fullnode
+// through the block-vision sui-go-sdk.
+// The node builds the transaction bytes (unsafe_moveCall); signing happens
+// locally, or in a cloud KMS, so the key never leaves the process or the KMS.
+type SuiClient struct {
+	rpcURL    string
+	signer    suiSigner
+	address   string
+	gasBudget uint64
+	api       sui.ISuiAPI
+	seq       *txSequencer
+}
+
+func NewSuiClient(cfg *SuiRPCConfig) (*SuiClient, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	if strings.TrimSpace(cfg.RPCURL) == "" {
+		return nil, fmt.Errorf("sui_rpc.rpc_url is required")
+	}
+	var signer suiSigner
+	if cfg.KMS != nil {
+		kms, err := newKMSSigner(cfg.KMS)
+		if err != nil {
+			return nil, err
+		}
+		signer = kms
+	} else {
+		key, err := parseSuiPrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		signer = ed25519Signer{key}
+	}
+	budget := cfg.GasBudget
+	if budget == 0 {
+		budget = 10000000
+	}
+	return &SuiClient{
+		rpcURL:    cfg.RPCURL,
+		signer:    signer,
+		address:   suiAddressFor(signer.Flag(), signer.PublicKey()),
+		gasBudget: budget,
+		api:       sui.NewSuiClientWithCustomClient(cfg.RPCURL, &http.Client{Timeout: 30 * time.Second}),
+		seq:       newTxSequencer(cfg.MaxConflictRetries),
+	}, nil
+}
+
+func parseSuiPrivateKey(s string) (ed25519.PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("sui_rpc.private_key is required")
+	}
+	if raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x")); err == nil && len(raw) == ed25519.SeedSize {
+		return ed25519.NewKeyFromSeed(raw), nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil && len(raw) == ed25519.SeedSize+1 {
+		if raw[0] != 0x00 {
+			return nil, fmt.Errorf("sui_rpc.private_key: only ed25519 keys are supported (flag %d)", raw[0])
+		}
+		return ed25519.NewKeyFromSeed(raw[1:]), nil
+	}
+	return nil, fmt.Errorf("sui_rpc.private_key must be a hex ed25519 seed or a base64 keystore entry")
+}
+
+// suiAddress derives the Sui address of an ed25519 public key.
+func suiAddress(pub ed25519.PublicKey) string {
+	return suiAddressFor(suiFlagEd25519, pub)
+}
+
+// Address returns the sender address transactions are signed for.
+func (c *SuiClient) Address() string { return c.address }
+
+// signTransaction returns the serialized signature (flag || sig || pubkey)
+// over the intent-prefixed transaction bytes.
+func (c *SuiClient) signTransaction(txBytes []byte) (string, error) {
+	digest := blake2b.Sum256(append([]byte{0, 0, 0}, txBytes...)) // TransactionData intent, v0, Sui app
+	return serializeSuiSignature(c.signer, digest[:])
+}
+
+// MoveCall executes package::module::function with args and returns the
+// transaction digest. A transaction that executes but aborts is an error.
+// Calls are sequenced per signer, so concurrent anchors never race for the
+// same gas coin, and conflicts are retried with fresh object refs.
+func (c *SuiClient) MoveCall(pkg, module, function string, args []interface{}) (string, error) {
+	return c.MoveCallClass(txClassDefault, pkg, module, function, args)
+}
+
+// MoveCallClass is MoveCall in a priority class (txClassEmergency,
+// txClassDefault or txClassAnchor).
+func (c *SuiClient) MoveCallClass(class int, pkg, module, function string, args []interface{}) (string, error) {
+	return c.seq.submit(class, func() (string, error) {
+		return c.moveCallOnce(pkg, module, function, args)
+	})
+}
+
+// TransactionCheckpoint returns the checkpoint that included digest, or ""
+// while the transaction is executed but not yet checkpointed.
+func (c *SuiClient) TransactionCheckpoint(digest string) (string, error) {
+	tx, err := c.api.SuiGetTransactionBlock(context.Background(), models.SuiGetTransactionBlockRequest{Digest: digest})
+	if err != nil {
+		return "", suiSDKError("sui_getTransactionBlock", err)
+	}
+	return tx.Checkpoint, nil
+}
+
+// moveCallOnce builds (picking current object versions), signs and
+// executes one transaction.
+func (c *SuiClient) moveCallOnce(pkg, module, function string, args []interface{}) (string, error) {
+	// The SDK's typed MoveCall sends an empty gas object ID instead of null,
+	// which the node rejects, so the build goes through SuiCall.
+	var built models.TxnMetaData
+	err := c.call("unsafe_moveCall", []interface{}{
+		c.address, pkg, module, function, []string{}, args, nil, strconv.FormatUint(c.gasBudget, 10),
+	}, &built)
+	if err != nil {
+		return "", fmt.Errorf("build transaction: %w", err)
+	}
+	txBytes, err := base64.StdEncoding.DecodeString(built.TxBytes)
+	if err != nil {
+		return "", fmt.Errorf("decode txBytes: %w", err)
+	}
+
+	signature, err := c.signTransaction(txBytes)
+	if err != nil {
+		return "", fmt.Errorf("sign transaction: %w", err)
+	}
+
+	executed, err := c.api.SuiExecuteTransactionBlock(context.Background(), models.SuiExecuteTransactionBlockRequest{
+		TxBytes:     built.TxBytes,
+		Signature:   []string{signature},
+		Options:     models.SuiTransactionBlockOptions{ShowEffects: true},
+		RequestType: "WaitForLocalExecution",
+	})
+	if err != nil {
+		return "", fmt.Errorf("execute transaction: %w", suiSDKError("sui_executeTransactionBlock", err))
+	}
+	if s := executed.Effects.Status; s.Status != "" && s.Status != "success" {
+		return executed.Digest, fmt.Errorf("transaction %s failed: %s", executed.Digest, s.Error)
+	}
+	return executed.Digest, nil
+}
+
+// call sends an untyped JSON-RPC request through the SDK and decodes its
+// result into result.
+func (c *SuiClient) call(method string, params []interface{}, result interface{}) error {
+	raw, err := c.api.SuiCall(context.Background(), method, params...)
+	if err != nil {
+		return suiSDKError(method, err)
+	}
+	var out struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if s, _ := raw.(string); s != "" {
+		if err := json.Unmarshal([]byte(s), &out); err != nil {
+			return fmt.Errorf("decode %s response: %w", method, err)
+		}
+	}
+	if len(out.Result) == 0 {
+		return fmt.Errorf("%s: empty response", method)
+	}
+	return json.Unmarshal(out.Result, result)
+}
+
+// suiSDKError turns the SDK's error, which carries the node's JSON-RPC error
+// object as its text, back into a suiRPCError so conflicts stay
+// distinguishable from transport failures.
+func suiSDKError(method string, err error) error {
+	var rpc struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(err.Error()), &rpc) != nil || rpc.Code == nil {
+		return err
+	}
+	return &suiRPCError{Method: method, Code: *rpc.Code, Message: rpc.Message}
+}
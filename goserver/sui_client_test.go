@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// fakeSuiNode serves unsafe_moveCall and sui_executeTransactionBlock and
+// checks that submitted signatures verify.
+func fakeSuiNode(t *testing.T, moveCalls *[][]interface{}) *httptest.Server {
+	txBytes := []byte("fake-transaction-data")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "unsafe_moveCall":
+			var args []interface{}
+			json.Unmarshal(req.Params[5], &args)
+			*moveCalls = append(*moveCalls, args)
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"txBytes": base64.StdEncoding.EncodeToString(txBytes)}})
+		case "sui_executeTransactionBlock":
+			var sigs []string
+			json.Unmarshal(req.Params[1], &sigs)
+			raw, _ := base64.StdEncoding.DecodeString(sigs[0])
+			digest := blake2b.Sum256(append([]byte{0, 0, 0}, txBytes...))
+			valid := false
+			switch {
+			case len(raw) == 97 && raw[0] == suiFlagEd25519:
+				valid = ed25519.Verify(ed25519.PublicKey(raw[65:]), digest[:], raw[1:65])
+			case len(raw) == 98 && raw[0] == suiFlagSecp256k1:
+				valid = verifySecp256k1(raw[65:], digest[:], raw[1:65])
+			}
+			if !valid {
+				t.Errorf("invalid transaction signature")
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{
+				"digest":  "FakeDigest111",
+				"effects": map[string]interface{}{"status": map[string]string{"status": "success"}},
+			}})
+		case "sui_getTransactionBlock":
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"digest": "FakeDigest111", "checkpoint": "4242"}})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+		}
+	}))
+}
+
+func TestAnchorViaSuiRPC(t *testing.T) {
+	var moveCalls [][]interface{}
+	node := fakeSuiNode(t, &moveCalls)
+	defer node.Close()
+
+	guard := newTestGuard(t, SentinelConfig{
+		AnchorEnabled:  true,
+		AnchorPackage:  "0xpkg",
+		AnchorRegistry: "0xregistry",
+		SuiRPC:         &SuiRPCConfig{Enabled: true, RPCURL: node.URL, PrivateKey: strings.Repeat("01", 32)},
+	})
+	if guard.sui == nil {
+		t.Fatal("expected native sui client")
+	}
+	_, rec, err := guard.Enforce("RUN", "rm -rf / --no-preserve-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.TxDigest != "FakeDigest111" {
+		t.Fatalf("expected digest from rpc, got %q (error %q)", rec.TxDigest, rec.AnchorError)
+	}
+	if rec.Checkpoint != "4242" {
+		t.Fatalf("expected the inclusion checkpoint on the record, got %q", rec.Checkpoint)
+	}
+	if len(moveCalls) != 1 || moveCalls[0][0] != "0xregistry" || moveCalls[0][1] != rec.RecordHash || moveCalls[0][4] != true {
+		t.Fatalf("unexpected move call args: %v", moveCalls)
+	}
+	if guard.effectiveConfig().SuiRPC.PrivateKey != "[redacted]" {
+		t.Fatal("sui private key must be redacted from config snapshots")
+	}
+}
+
+func TestParseSuiPrivateKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 7
+	fromHex, err := parseSuiPrivateKey(hex.EncodeToString(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromKeystore, err := parseSuiPrivateKey(base64.StdEncoding.EncodeToString(append([]byte{0x00}, seed...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromHex.Equal(fromKeystore) {
+		t.Fatal("hex and keystore encodings of the same seed must match")
+	}
+	if _, err := parseSuiPrivateKey(base64.StdEncoding.EncodeToString(append([]byte{0x01}, seed...))); err == nil {
+		t.Fatal("expected secp256k1 keystore entry to be rejected")
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultHeartbeatThreshold mirrors HEARTBEAT_THRESHOLD_MS in
+// lazarus_protocol.move.
+const defaultHeartbeatThreshold = 30 * 24 * time.Hour
+
+// nearMissFraction: a heartbeat that lands with less than this share of the
+// deadline left is a near miss.
+const nearMissFraction = 0.10
+
+// HeartbeatStats summarizes one vault's heartbeat history.
+type HeartbeatStats struct {
+	VaultID          string  `json:"vault_id"`
+	Heartbeats       int     `json:"heartbeats"`
+	FailedAttempts   int     `json:"failed_attempts"`
+	SuccessRate      float64 `json:"success_rate"`
+	AverageGasMist   int64   `json:"average_gas_mist"`
+	LongestGapHours  float64 `json:"longest_gap_hours"`
+	CurrentGapHours  float64 `json:"current_gap_hours"`
+	NearMisses       int     `json:"near_misses"`
+	ReliabilityScore int     `json:"reliability_score"` // 0-100
+	Executed         bool    `json:"executed"`
+
+	Forecast *HeartbeatForecast `json:"forecast,omitempty"`
+}
+
+// HeartbeatForecast warns ahead of a deadline that falls in a period when
+// the owner has historically gone quiet.
+type HeartbeatForecast struct {
+	Deadline   time.Time `json:"deadline"`
+	Risk       string    `json:"risk"` // low | elevated | high
+	Reasons    []string  `json:"reasons,omitempty"`
+	Suggestion string    `json:"suggestion,omitempty"`
+}
+
+// heartbeatHistory is what the owner's transactions say about one vault.
+type heartbeatHistory struct {
+	createdMs int64
+	beatsMs   []int64
+	failed    int
+	gasTotal  int64
+	gasCount  int64
+	executed  bool
+}
+
+// collectHeartbeatHistory folds the owner's keep_alive calls and vault
+// events into per-vault histories.
+func collectHeartbeatHistory(reader *chainReader, owner string) (map[string]*heartbeatHistory, error) {
+	vaults := map[string]*heartbeatHistory{}
+	get := func(id string) *heartbeatHistory {
+		if vaults[id] == nil {
+			vaults[id] = &heartbeatHistory{}
+		}
+		return vaults[id]
+	}
+	options := map[string]interface{}{"showInput": true, "showEvents": true, "showEffects": true}
+	err := scanOwnerTransactions(reader, owner, options, func(tx suiTxBlock) {
+		ptx := tx.Transaction.Data.Transaction
+		for _, call := range ptx.Transactions {
+			mc := call.MoveCall
+			if mc == nil || mc.Function != "keep_alive" || len(mc.Arguments) == 0 {
+				continue
+			}
+			var arg struct {
+				Input *int `json:"Input"`
+			}
+			if json.Unmarshal(mc.Arguments[0], &arg) != nil || arg.Input == nil || *arg.Input >= len(ptx.Inputs) {
+				continue
+			}
+			h := get(ptx.Inputs[*arg.Input].ObjectID)
+			gas := tx.Effects.GasUsed
+			h.gasTotal += jsonInt(gas.ComputationCost) + jsonInt(gas.StorageCost) - jsonInt(gas.StorageRebate)
+			h.gasCount++
+			if tx.Effects.Status.Status != "" && tx.Effects.Status.Status != "success" {
+				h.failed++
+			}
+		}
+		for _, ev := range tx.Events {
+			_, name := splitEventType(ev.Type)
+			id := jsonString(ev.ParsedJSON["vault_id"])
+			switch name {
+			case "lazarus_protocol::VaultCreatedEvent":
+				get(id).createdMs = jsonInt(ev.ParsedJSON["timestamp_ms"])
+			case "lazarus_protocol::HeartbeatEvent":
+				h := get(id)
+				h.beatsMs = append(h.beatsMs, jsonInt(ev.ParsedJSON["timestamp_ms"]))
+			case "lazarus_protocol::WillExecutedEvent":
+				get(id).executed = true
+			}
+		}
+	})
+	return vaults, err
+}
+
+// heartbeatStats computes the statistics for one vault. The reliability
+// score averages the success rate with the worst-case deadline headroom
+// (1 - longest gap / threshold), minus 10 points per near miss.
+func heartbeatStats(id string, h *heartbeatHistory, threshold time.Duration, now time.Time) HeartbeatStats {
+	st := HeartbeatStats{VaultID: id, Heartbeats: len(h.beatsMs), FailedAttempts: h.failed, Executed: h.executed}
+	if attempts := st.Heartbeats + st.FailedAttempts; attempts > 0 {
+		st.SuccessRate = float64(st.Heartbeats) / float64(attempts)
+	} else {
+		st.SuccessRate = 1
+	}
+	if h.gasCount > 0 {
+		st.AverageGasMist = h.gasTotal / h.gasCount
+	}
+
+	beats := append([]int64{}, h.beatsMs...)
+	sort.Slice(beats, func(i, j int) bool { return beats[i] < beats[j] })
+	prev := h.createdMs
+	thresholdMs := threshold.Milliseconds()
+	var longest int64
+	var gaps []int64
+	nearMissMonths := map[time.Month]bool{}
+	for _, b := range beats {
+		if prev > 0 {
+			gap := b - prev
+			gaps = append(gaps, gap)
+			if gap > longest {
+				longest = gap
+			}
+			if gap > thresholdMs-int64(float64(thresholdMs)*nearMissFraction) {
+				st.NearMisses++
+				nearMissMonths[time.UnixMilli(b).UTC().Month()] = true
+			}
+		}
+		prev = b
+	}
+	if prev > 0 && !h.executed {
+		st.CurrentGapHours = roundHours(now.UnixMilli() - prev)
+	}
+	st.LongestGapHours = roundHours(longest)
+
+	headroom := 1 - float64(longest)/float64(thresholdMs)
+	headroom = math.Max(0, math.Min(1, headroom))
+	score := 100*(st.SuccessRate+headroom)/2 - 10*float64(st.NearMisses)
+	st.ReliabilityScore = int(math.Round(math.Max(0, score)))
+
+	if prev > 0 && !h.executed {
+		st.Forecast = forecastHeartbeat(beats, gaps, nearMissMonths, prev, threshold, now)
+	}
+	return st
+}
+
+// forecastHeartbeat looks at where the next deadline falls relative to the
+// owner's habits: the usual long gaps, weekdays without heartbeats, and
+// months with earlier near misses.
+func forecastHeartbeat(beats, gaps []int64, nearMissMonths map[time.Month]bool, lastMs int64, threshold time.Duration, now time.Time) *HeartbeatForecast {
+	deadline := time.UnixMilli(lastMs).UTC().Add(threshold)
+	f := &HeartbeatForecast{Deadline: deadline, Risk: "low"}
+	elevate := func(level, reason string) {
+		f.Reasons = append(f.Reasons, reason)
+		if level == "high" || f.Risk == "low" {
+			f.Risk = level
+		}
+	}
+
+	// A long usual gap starting now would overrun the deadline.
+	var p90 time.Duration
+	if len(gaps) > 0 {
+		sorted := append([]int64{}, gaps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		p90 = time.Duration(sorted[(len(sorted)*9)/10]) * time.Millisecond
+		if now.Add(p90).After(deadline) {
+			elevate("high", fmt.Sprintf("your longer gaps between heartbeats (about %.0f days) would run past the deadline", p90.Hours()/24))
+		}
+	}
+
+	// The deadline lands on a weekday the owner rarely sends heartbeats on.
+	if len(beats) >= 7 {
+		var perDay [7]int
+		for _, b := range beats {
+			perDay[time.UnixMilli(b).UTC().Weekday()]++
+		}
+		mean := float64(len(beats)) / 7
+		if d := deadline.Weekday(); float64(perDay[d]) < mean/3 {
+			elevate("elevated", fmt.Sprintf("you rarely send heartbeats on %ss; your deadline lands on one", d))
+		}
+	}
+
+	if nearMissMonths[deadline.Month()] {
+		elevate("elevated", fmt.Sprintf("you came close to the deadline in %s before", deadline.Month()))
+	}
+
+	if f.Risk != "low" {
+		by := deadline.Add(-3 * 24 * time.Hour)
+		if p90 > 0 && now.Add(p90).After(deadline) {
+			by = now.Add(24 * time.Hour)
+		}
+		f.Suggestion = fmt.Sprintf("send an early heartbeat before %s", by.Format("Mon 2 Jan 2006"))
+	}
+	return f
+}
+
+func roundHours(ms int64) float64 {
+	return math.Round(float64(ms)/float64(time.Hour.Milliseconds())*10) / 10
+}
+
+// runHeartbeatStatsCommand implements `goserver heartbeat-stats --owner`.
+func runHeartbeatStatsCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("heartbeat-stats", flag.ContinueOnError)
+	owner := fs.String("owner", "", "Owner address that sends the heartbeats")
+	vault := fs.String("vault", "", "Only report this vault")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint to scan")
+	threshold := fs.Duration("threshold", defaultHeartbeatThreshold, "Heartbeat deadline of the vault contract")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *owner == "" {
+		return fmt.Errorf("--owner is required")
+	}
+	if *threshold <= 0 {
+		return fmt.Errorf("--threshold must be positive")
+	}
+
+	histories, err := collectHeartbeatHistory(newChainReader(*rpcURL, nil), "0x"+normalizeKeyHex(*owner))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	stats := []HeartbeatStats{}
+	for _, id := range sortedKeys(histories) {
+		if *vault != "" && !strings.EqualFold(id, *vault) {
+			continue
+		}
+		stats = append(stats, heartbeatStats(id, histories[id], *threshold, now))
+	}
+	return encodeSentinelOutput(out, stats)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatStats(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	keepAlive := func(digest, status string, beatMs int64) string {
+		events := "[]"
+		if status == "success" {
+			events = fmt.Sprintf(`[{"type":"0xv::lazarus_protocol::HeartbeatEvent","parsedJson":{"vault_id":"0xv1","timestamp_ms":"%d"}}]`, beatMs)
+		}
+		return fmt.Sprintf(`{"digest":%q,
+			"transaction":{"data":{"transaction":{"inputs":[{"objectId":"0xv1"},{"objectId":"0x6"}],
+				"transactions":[{"MoveCall":{"package":"0xv","module":"lazarus_protocol","function":"keep_alive","arguments":[{"Input":0},{"Input":1}]}}]}}},
+			"effects":{"status":{"status":%q},"gasUsed":{"computationCost":"1000","storageCost":"2000","storageRebate":"1000"}},
+			"events":%s}`, digest, status, events)
+	}
+	created := fmt.Sprintf(`{"digest":"c","events":[{"type":"0xv::lazarus_protocol::VaultCreatedEvent","parsedJson":{"vault_id":"0xv1","timestamp_ms":"%d"}}]}`, day)
+	page := `{"data":[` + created + "," +
+		keepAlive("a", "success", 11*day) + "," + // 10-day gap
+		keepAlive("b", "failure", 0) + "," +
+		keepAlive("c", "success", 39*day) + // 28-day gap: near miss
+		`],"nextCursor":null,"hasNextPage":false}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + page + `}`))
+	}))
+	defer srv.Close()
+
+	histories, err := collectHeartbeatHistory(newChainReader(srv.URL, nil), "0xabc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := heartbeatStats("0xv1", histories["0xv1"], defaultHeartbeatThreshold, time.UnixMilli(41*day))
+	if st.Heartbeats != 2 || st.FailedAttempts != 1 || st.AverageGasMist != 2000 {
+		t.Fatalf("unexpected counts: %+v", st)
+	}
+	if st.LongestGapHours != 28*24 || st.CurrentGapHours != 2*24 || st.NearMisses != 1 {
+		t.Fatalf("unexpected gaps: %+v", st)
+	}
+	// (2/3 + (1 - 28/30)) / 2 * 100 - 10 = 26.67 → 27
+	if st.ReliabilityScore != 27 {
+		t.Fatalf("reliability score = %d", st.ReliabilityScore)
+	}
+	if f := st.Forecast; f == nil || f.Risk != "low" || !f.Deadline.Equal(time.UnixMilli(69*day).UTC()) {
+		t.Fatalf("unexpected forecast: %+v", st.Forecast)
+	}
+	// Four days later a 28-day gap, which this owner has had, runs past the deadline.
+	late := heartbeatStats("0xv1", histories["0xv1"], defaultHeartbeatThreshold, time.UnixMilli(45*day))
+	if f := late.Forecast; f.Risk != "high" || len(f.Reasons) != 1 || !strings.Contains(f.Suggestion, "Mon 16 Feb 1970") {
+		t.Fatalf("unexpected forecast: %+v", f)
+	}
+
+	var buf bytes.Buffer
+	if err := runHeartbeatStatsCommand([]string{"--owner", "0xabc", "--rpc", srv.URL, "--vault", "0xV1"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var out []HeartbeatStats
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || len(out) != 1 || out[0].VaultID != "0xv1" {
+		t.Fatalf("unexpected command output: %s", buf.String())
+	}
+}
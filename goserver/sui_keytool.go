@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Sui signature scheme flags.
+const (
+	suiFlagEd25519   byte = 0x00
+	suiFlagSecp256k1 byte = 0x01
+)
+
+// suiSigner is a private key in one of the supported Sui schemes.
+type suiSigner interface {
+	Flag() byte
+	PublicKey() []byte
+	// SignDigest signs the 32-byte intent message digest.
+	SignDigest(digest []byte) ([]byte, error)
+}
+
+type ed25519Signer struct{ key ed25519.PrivateKey }
+
+func (s ed25519Signer) Flag() byte        { return suiFlagEd25519 }
+func (s ed25519Signer) PublicKey() []byte { return s.key.Public().(ed25519.PublicKey) }
+func (s ed25519Signer) SignDigest(d []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, d), nil
+}
+
+func suiSchemeName(flag byte) string {
+	switch flag {
+	case suiFlagEd25519:
+		return "ed25519"
+	case suiFlagSecp256k1:
+		return "secp256k1"
+	}
+	return fmt.Sprintf("unknown(%d)", flag)
+}
+
+// parseSuiSigner accepts a base64 sui.keystore entry (flag || secret), or a
+// hex secret together with scheme.
+func parseSuiSigner(key, scheme string) (suiSigner, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("--key-file or --key-env is required")
+	}
+	keyFlag := suiFlagEd25519
+	var secret []byte
+	if raw, err := hex.DecodeString(strings.TrimPrefix(key, "0x")); err == nil && len(raw) == 32 {
+		secret = raw
+		switch strings.ToLower(strings.TrimSpace(scheme)) {
+		case "", "ed25519":
+		case "secp256k1":
+			keyFlag = suiFlagSecp256k1
+		default:
+			return nil, fmt.Errorf("unsupported --scheme %q (use ed25519 or secp256k1)", scheme)
+		}
+	} else if raw, err := base64.StdEncoding.DecodeString(key); err == nil && len(raw) == 33 {
+		keyFlag, secret = raw[0], raw[1:]
+	} else {
+		return nil, fmt.Errorf("the key must be a hex secret or a base64 sui.keystore entry")
+	}
+
+	switch keyFlag {
+	case suiFlagEd25519:
+		return ed25519Signer{ed25519.NewKeyFromSeed(secret)}, nil
+	case suiFlagSecp256k1:
+		key, err := newSecp256k1Key(secret)
+		if err != nil {
+			return nil, err
+		}
+		return secp256k1Signer{key}, nil
+	}
+	return nil, fmt.Errorf("unsupported key scheme %s", suiSchemeName(keyFlag))
+}
+
+// suiAddressFor derives the Sui address for a scheme flag and public key.
+func suiAddressFor(flag byte, pub []byte) string {
+	sum := blake2b.Sum256(append([]byte{flag}, pub...))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// personalMessageDigest is the digest Sui signs for a personal message:
+// blake2b-256 over the PersonalMessage intent and the BCS-encoded bytes.
+func personalMessageDigest(msg []byte) []byte {
+	buf := []byte{3, 0, 0} // intent scope PersonalMessage, version 0, app Sui
+	n := uint64(len(msg))
+	for n >= 0x80 {
+		buf = append(buf, byte(n)|0x80)
+		n >>= 7
+	}
+	buf = append(buf, byte(n))
+	buf = append(buf, msg...)
+	sum := blake2b.Sum256(buf)
+	return sum[:]
+}
+
+// serializeSuiSignature signs digest and returns the serialized Sui
+// signature (flag || signature || public key), base64-encoded.
+func serializeSuiSignature(s suiSigner, digest []byte) (string, error) {
+	sig, err := s.SignDigest(digest)
+	if err != nil {
+		return "", err
+	}
+	out := append(append([]byte{s.Flag()}, sig...), s.PublicKey()...)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// signPersonalMessage signs msg in the Sui personal-message format.
+func signPersonalMessage(s suiSigner, msg []byte) (string, error) {
+	return serializeSuiSignature(s, personalMessageDigest(msg))
+}
+
+// verifyPersonalMessage checks a serialized Sui signature over msg and
+// returns the signer's scheme flag and address.
+func verifyPersonalMessage(serialized string, msg []byte) (byte, string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(serialized))
+	if err != nil || len(raw) == 0 {
+		return 0, "", fmt.Errorf("signature must be base64")
+	}
+	flag, body := raw[0], raw[1:]
+	digest := personalMessageDigest(msg)
+	var pub []byte
+	var ok bool
+	switch flag {
+	case suiFlagEd25519:
+		if len(body) != ed25519.SignatureSize+ed25519.PublicKeySize {
+			return flag, "", fmt.Errorf("malformed ed25519 signature")
+		}
+		pub = body[ed25519.SignatureSize:]
+		ok = ed25519.Verify(ed25519.PublicKey(pub), digest, body[:ed25519.SignatureSize])
+	case suiFlagSecp256k1:
+		if len(body) != 64+33 {
+			return flag, "", fmt.Errorf("malformed secp256k1 signature")
+		}
+		pub = body[64:]
+		ok = verifySecp256k1(pub, digest, body[:64])
+	default:
+		return flag, "", fmt.Errorf("unsupported signature scheme %s", suiSchemeName(flag))
+	}
+	addr := suiAddressFor(flag, pub)
+	if !ok {
+		return flag, addr, fmt.Errorf("signature does not verify")
+	}
+	return flag, addr, nil
+}
+
+// KeySignOutput is printed by `key sign` and `key address`.
+type KeySignOutput struct {
+	Scheme    string `json:"scheme"`
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"` // base64, flag-prefixed as in Sui
+	Message   string `json:"message,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// KeyVerifyOutput is printed by `key verify`.
+type KeyVerifyOutput struct {
+	Valid   bool   `json:"valid"`
+	Scheme  string `json:"scheme,omitempty"`
+	Address string `json:"address,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// readSignerKey reads a private key from keyFile ("-" for stdin) or from
+// the environment variable keyEnv. Keys are never taken on the command
+// line, where they would show up in ps and shell history.
+func readSignerKey(keyFile, keyEnv string, stdin io.Reader) (string, error) {
+	switch {
+	case keyFile != "" && keyEnv != "":
+		return "", fmt.Errorf("use either --key-file or --key-env, not both")
+	case keyFile == "-":
+		raw, err := io.ReadAll(io.LimitReader(stdin, 4096))
+		return strings.TrimSpace(string(raw)), err
+	case keyFile != "":
+		raw, err := os.ReadFile(keyFile)
+		return strings.TrimSpace(string(raw)), err
+	case keyEnv != "":
+		key := strings.TrimSpace(os.Getenv(keyEnv))
+		if key == "" {
+			return "", fmt.Errorf("%s is not set", keyEnv)
+		}
+		return key, nil
+	}
+	return "", nil
+}
+
+// runKeyCommand implements `goserver key sign|verify|address`, the companion
+// tooling guardians and beneficiaries use to produce and check Sui
+// personal-message attestations.
+func runKeyCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: key sign|verify|address|keystore [flags]")
+	}
+	fs := flag.NewFlagSet("key "+args[0], flag.ContinueOnError)
+	keyEnv := fs.String("key-env", "", "Environment variable holding the private key (hex secret or base64 sui.keystore entry)")
+	scheme := fs.String("scheme", "ed25519", "Scheme for hex keys: ed25519 or secp256k1")
+	message := fs.String("message", "", "Message to sign or verify")
+	messageFile := fs.String("message-file", "", "Read the message from a file instead of --message")
+	signature := fs.String("signature", "", "Serialized Sui signature (base64) to verify")
+	address := fs.String("address", "", "Expected signer address for verify")
+	keyFile := fs.String("key-file", "", "File holding the private key, - for stdin; for keystore, the hex ed25519 seed to seal (default: generate a key)")
+	outPath := fs.String("out", "", "Where keystore writes the encrypted audit signing keystore")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	readMessage := func() ([]byte, error) {
+		if *messageFile != "" {
+			return os.ReadFile(*messageFile)
+		}
+		if *message == "" {
+			return nil, fmt.Errorf("--message or --message-file is required")
+		}
+		return []byte(*message), nil
+	}
+
+	switch args[0] {
+	case "address", "sign":
+		key, err := readSignerKey(*keyFile, *keyEnv, os.Stdin)
+		if err != nil {
+			return err
+		}
+		signer, err := parseSuiSigner(key, *scheme)
+		if err != nil {
+			return err
+		}
+		result := KeySignOutput{
+			Scheme:    suiSchemeName(signer.Flag()),
+			Address:   suiAddressFor(signer.Flag(), signer.PublicKey()),
+			PublicKey: base64.StdEncoding.EncodeToString(append([]byte{signer.Flag()}, signer.PublicKey()...)),
+		}
+		if args[0] == "sign" {
+			msg, err := readMessage()
+			if err != nil {
+				return err
+			}
+			result.Message = string(msg)
+			if result.Signature, err = signPersonalMessage(signer, msg); err != nil {
+				return err
+			}
+		}
+		return encodeSentinelOutput(out, result)
+
+	case "keystore":
+		ks, err := writeSignKeystore(*keyFile, *outPath)
+		if err != nil {
+			return err
+		}
+		return encodeSentinelOutput(out, map[string]string{"keystore": *outPath, "public_key": ks.PublicKey})
+
+	case "verify":
+		if *signature == "" {
+			return fmt.Errorf("--signature is required")
+		}
+		msg, err := readMessage()
+		if err != nil {
+			return err
+		}
+		sigFlag, addr, err := verifyPersonalMessage(*signature, msg)
+		result := KeyVerifyOutput{Valid: err == nil, Scheme: suiSchemeName(sigFlag), Address: addr}
+		if err == nil && *address != "" && !strings.EqualFold(normalizeKeyHex(*address), strings.TrimPrefix(addr, "0x")) {
+			err = fmt.Errorf("signed by %s, expected %s", addr, *address)
+			result.Valid = false
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if encErr := encodeSentinelOutput(out, result); encErr != nil {
+			return encErr
+		}
+		if err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown key command %q (use sign, verify, address or keystore)", args[0])
+}
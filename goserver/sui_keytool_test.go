@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPersonalMessageSignVerify(t *testing.T) {
+	seed := strings.Repeat("42", 32)
+	ed, err := parseSuiSigner(seed, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secp, err := newSecp256k1Key(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for scheme, signer := range map[string]suiSigner{"ed25519": ed, "secp256k1": secp256k1Signer{secp}} {
+		msg := []byte("release vault 0xabc to beneficiary")
+		sig, err := signPersonalMessage(signer, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flag, addr, err := verifyPersonalMessage(sig, msg)
+		if err != nil {
+			t.Fatalf("%s: verify: %v", scheme, err)
+		}
+		if flag != signer.Flag() || addr != suiAddressFor(signer.Flag(), signer.PublicKey()) {
+			t.Fatalf("%s: verify returned flag %d address %s", scheme, flag, addr)
+		}
+		if _, _, err := verifyPersonalMessage(sig, []byte("release vault 0xabc to attacker")); err == nil {
+			t.Fatalf("%s: tampered message should not verify", scheme)
+		}
+	}
+}
+
+func TestParseSuiSignerKeystoreEntry(t *testing.T) {
+	secret := bytes.Repeat([]byte{7}, 32)
+	entry := base64.StdEncoding.EncodeToString(append([]byte{suiFlagEd25519}, secret...))
+	fromEntry, err := parseSuiSigner(entry, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromHex, _ := parseSuiSigner(hex.EncodeToString(secret), "ed25519")
+	if fromEntry.Flag() != suiFlagEd25519 || !bytes.Equal(fromEntry.PublicKey(), fromHex.PublicKey()) {
+		t.Fatal("keystore entry and hex key should give the same ed25519 key")
+	}
+	if _, err := parseSuiSigner(hex.EncodeToString(secret), "rsa"); err == nil {
+		t.Fatal("unknown scheme should be rejected")
+	}
+
+	secpEntry := base64.StdEncoding.EncodeToString(append([]byte{suiFlagSecp256k1}, secret...))
+	secpFromEntry, err := parseSuiSigner(secpEntry, "")
+	if err != nil {
+		t.Fatalf("secp256k1 keystore entry: %v", err)
+	}
+	secpFromHex, err := parseSuiSigner(hex.EncodeToString(secret), "secp256k1")
+	if err != nil {
+		t.Fatalf("secp256k1 hex key: %v", err)
+	}
+	if secpFromEntry.Flag() != suiFlagSecp256k1 || !bytes.Equal(secpFromEntry.PublicKey(), secpFromHex.PublicKey()) {
+		t.Fatal("keystore entry and hex key should give the same secp256k1 key")
+	}
+}
+
+func TestRunKeyCommand(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.hex")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("11", 32)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SUI_KEY", strings.Repeat("11", 32))
+	var out bytes.Buffer
+
+	// A secp256k1 signature from the env var verifies against its key.
+	if err := runKeyCommand([]string{"sign", "--key-env", "TEST_SUI_KEY", "--scheme", "secp256k1", "--message", "hello"}, &out); err != nil {
+		t.Fatalf("secp256k1 sign: %v", err)
+	}
+	var secpSigned KeySignOutput
+	if err := json.Unmarshal(out.Bytes(), &secpSigned); err != nil || secpSigned.Scheme != "secp256k1" {
+		t.Fatalf("unexpected secp256k1 sign output: %s", out.String())
+	}
+	raw, _ := base64.StdEncoding.DecodeString(secpSigned.Signature)
+	if len(raw) != 1+64+33 || !verifySecp256k1(raw[65:], personalMessageDigest([]byte("hello")), raw[1:65]) {
+		t.Fatalf("secp256k1 signature should verify: %s", secpSigned.Signature)
+	}
+
+	for _, args := range [][]string{
+		{"sign", "--message", "hello"},
+		{"sign", "--key", strings.Repeat("11", 32), "--message", "hello"},
+		{"sign", "--key-file", keyFile, "--key-env", "TEST_SUI_KEY", "--message", "hello"},
+		{"sign", "--key-env", "TEST_SUI_KEY_UNSET", "--message", "hello"},
+	} {
+		out.Reset()
+		if err := runKeyCommand(args, &out); err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+
+	out.Reset()
+	if err := runKeyCommand([]string{"sign", "--key-file", keyFile, "--message", "hello"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var signed KeySignOutput
+	if err := json.Unmarshal(out.Bytes(), &signed); err != nil {
+		t.Fatal(err)
+	}
+	if signed.Scheme != "ed25519" || signed.Signature == "" {
+		t.Fatalf("unexpected sign output: %+v", signed)
+	}
+
+	out.Reset()
+	if err := runKeyCommand([]string{"verify", "--signature", signed.Signature, "--message", "hello", "--address", signed.Address}, &out); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	var verified KeyVerifyOutput
+	json.Unmarshal(out.Bytes(), &verified)
+	if !verified.Valid || verified.Address != signed.Address {
+		t.Fatalf("unexpected verify output: %+v", verified)
+	}
+
+	out.Reset()
+	other := "0x" + strings.Repeat("0", 64)
+	if err := runKeyCommand([]string{"verify", "--signature", signed.Signature, "--message", "hello", "--address", other}, &out); err == nil {
+		t.Fatal("verify against the wrong address should fail")
+	}
+	json.Unmarshal(out.Bytes(), &verified)
+	if verified.Valid {
+		t.Fatal("wrong-address verify should report valid=false")
+	}
+}
+
+func TestReadSignerKeyFromStdin(t *testing.T) {
+	key, err := readSignerKey("-", "", strings.NewReader(strings.Repeat("22", 32)+"\n"))
+	if err != nil || key != strings.Repeat("22", 32) {
+		t.Fatalf("stdin key = %q, %v", key, err)
+	}
+}
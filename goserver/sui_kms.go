@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// KMSSignerConfig selects a cloud KMS key that signs Sui transactions. Only
+// secp256k1 keys are usable: AWS key spec ECC_SECG_P256K1 or GCP algorithm
+// EC_SIGN_SECP256K1_SHA256.
+type KMSSignerConfig struct {
+	Provider string `json:"provider"` // aws | gcp
+	// KeyID is the AWS key id or ARN, or the GCP cryptoKeyVersion resource
+	// name (projects/.../cryptoKeyVersions/N).
+	KeyID    string `json:"key_id"`
+	Region   string `json:"region"`   // AWS region
+	Endpoint string `json:"endpoint"` // optional API endpoint override
+}
+
+// kmsBackend is the two calls needed from a KMS.
+type kmsBackend interface {
+	// PublicKeyDER returns the key's DER SubjectPublicKeyInfo.
+	PublicKeyDER() ([]byte, error)
+	// SignSHA256 returns a DER ECDSA signature over a SHA-256 hash.
+	SignSHA256(hash []byte) ([]byte, error)
+}
+
+// kmsSigner is a suiSigner whose private key stays in the KMS.
+type kmsSigner struct {
+	backend kmsBackend
+	pub     []byte // compressed secp256k1
+}
+
+func newKMSSigner(cfg *KMSSignerConfig) (*kmsSigner, error) {
+	if strings.TrimSpace(cfg.KeyID) == "" {
+		return nil, fmt.Errorf("sui_rpc.kms.key_id is required")
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	var backend kmsBackend
+	switch strings.ToLower(cfg.Provider) {
+	case "aws":
+		if cfg.Region == "" && cfg.Endpoint == "" {
+			return nil, fmt.Errorf("sui_rpc.kms.region is required for aws")
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://kms." + cfg.Region + ".amazonaws.com/"
+		}
+		backend = &awsKMS{keyID: cfg.KeyID, region: cfg.Region, endpoint: endpoint, client: client, now: time.Now}
+	case "gcp":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://cloudkms.googleapis.com"
+		}
+		backend = &gcpKMS{
+			name:        cfg.KeyID,
+			endpoint:    strings.TrimRight(endpoint, "/"),
+			metadataURL: "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token",
+			client:      client,
+		}
+	default:
+		return nil, fmt.Errorf("unknown sui_rpc.kms.provider %q (use aws or gcp)", cfg.Provider)
+	}
+	return newKMSSignerWithBackend(backend)
+}
+
+func newKMSSignerWithBackend(backend kmsBackend) (*kmsSigner, error) {
+	der, err := backend.PublicKeyDER()
+	if err != nil {
+		return nil, fmt.Errorf("kms public key: %w", err)
+	}
+	pub, err := secp256k1FromSPKI(der)
+	if err != nil {
+		return nil, fmt.Errorf("kms public key: %w", err)
+	}
+	return &kmsSigner{backend: backend, pub: pub}, nil
+}
+
+func (k *kmsSigner) Flag() byte        { return suiFlagSecp256k1 }
+func (k *kmsSigner) PublicKey() []byte { return k.pub }
+
+// SignDigest has the KMS sign SHA-256(digest), as Sui secp256k1 expects,
+// and normalizes the result to a low-s r||s signature.
+func (k *kmsSigner) SignDigest(digest []byte) ([]byte, error) {
+	h := sha256.Sum256(digest)
+	der, err := k.backend.SignSHA256(h[:])
+	if err != nil {
+		return nil, fmt.Errorf("kms sign: %w", err)
+	}
+	parsed, err := ecdsa.ParseDERSignature(der)
+	if err != nil {
+		return nil, fmt.Errorf("kms sign: malformed signature: %w", err)
+	}
+	r, s := parsed.R(), parsed.S()
+	if s.IsOverHalfOrder() {
+		s.Negate()
+	}
+	sig := make([]byte, 64)
+	r.PutBytesUnchecked(sig[:32])
+	s.PutBytesUnchecked(sig[32:])
+	if !verifySecp256k1(k.pub, digest, sig) {
+		return nil, fmt.Errorf("kms sign: signature does not verify against the key's public key")
+	}
+	return sig, nil
+}
+
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+// secp256k1FromSPKI returns the compressed key from a DER
+// SubjectPublicKeyInfo, which x509 cannot parse for this curve.
+func secp256k1FromSPKI(der []byte) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidSecp256k1) {
+		return nil, fmt.Errorf("not a secp256k1 key")
+	}
+	point := spki.PublicKey.Bytes
+	if len(point) != 65 || point[0] != 0x04 {
+		return nil, fmt.Errorf("expected an uncompressed secp256k1 point")
+	}
+	key, err := secp256k1.ParsePubKey(point)
+	if err != nil {
+		return nil, fmt.Errorf("public key is not on secp256k1")
+	}
+	return key.SerializeCompressed(), nil
+}
+
+// awsKMS calls AWS KMS with requests signed by the AWS SDK's SigV4 signer.
+// Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+type awsKMS struct {
+	keyID    string
+	region   string
+	endpoint string
+	client   *http.Client
+	now      func() time.Time
+}
+
+func (a *awsKMS) PublicKeyDER() ([]byte, error) {
+	var out struct {
+		PublicKey string `json:"PublicKey"`
+	}
+	if err := a.call("GetPublicKey", map[string]string{"KeyId": a.keyID}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.PublicKey)
+}
+
+func (a *awsKMS) SignSHA256(hash []byte) ([]byte, error) {
+	var out struct {
+		Signature string `json:"Signature"`
+	}
+	err := a.call("Sign", map[string]string{
+		"KeyId":            a.keyID,
+		"Message":          base64.StdEncoding.EncodeToString(hash),
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Signature)
+}
+
+func (a *awsKMS) call(action string, in, out interface{}) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for aws kms")
+	}
+	body, _ := json.Marshal(in)
+	req, err := http.NewRequest(http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	creds := aws.Credentials{AccessKeyID: accessKey, SecretAccessKey: secretKey, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(context.Background(), creds, req, hex.EncodeToString(payloadHash[:]), "kms", a.region, a.now().UTC()); err != nil {
+		return fmt.Errorf("%s: sign request: %w", action, err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("%s: HTTP %d %s %s", action, resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// gcpKMS calls Cloud KMS. The access token comes from GCP_ACCESS_TOKEN or,
+// on GCE, from the metadata server.
+type gcpKMS struct {
+	name        string
+	endpoint    string
+	metadataURL string
+	client      *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func (g *gcpKMS) PublicKeyDER() ([]byte, error) {
+	var out struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := g.call(http.MethodGet, "/v1/"+g.name+"/publicKey", nil, &out); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(out.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("publicKey: no PEM block in response")
+	}
+	return block.Bytes, nil
+}
+
+func (g *gcpKMS) SignSHA256(hash []byte) ([]byte, error) {
+	var out struct {
+		Signature string `json:"signature"`
+	}
+	in := map[string]interface{}{"digest": map[string]string{"sha256": base64.StdEncoding.EncodeToString(hash)}}
+	if err := g.call(http.MethodPost, "/v1/"+g.name+":asymmetricSign", in, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Signature)
+}
+
+func (g *gcpKMS) accessToken() (string, error) {
+	if t := os.Getenv("GCP_ACCESS_TOKEN"); t != "" {
+		return t, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+	req, _ := http.NewRequest(http.MethodGet, g.metadataURL, nil)
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata token (set GCP_ACCESS_TOKEN off GCE): %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata token: HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return "", fmt.Errorf("metadata token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("metadata token: no access_token in response")
+	}
+	g.token = tok.AccessToken
+	g.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
+
+func (g *gcpKMS) call(method, path string, in, out interface{}) error {
+	token, err := g.accessToken()
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if in != nil {
+		b, _ := json.Marshal(in)
+		body = bytes.NewReader(b)
+	}
+	u := g.endpoint + (&url.URL{Path: path}).EscapedPath()
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("%s: HTTP %d %s", path, resp.StatusCode, apiErr.Error.Message)
+	}
+	return json.Unmarshal(data, out)
+}
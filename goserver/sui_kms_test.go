@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// fakeKMSKey is the key a fake KMS signs with. It returns high-s
+// signatures, as real KMSes may, so normalization is exercised.
+func fakeKMSKey(t *testing.T) (*secp256k1Key, []byte, func(hash []byte) []byte) {
+	key, err := newSecp256k1Key(bytes.Repeat([]byte{9}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := key.priv.PubKey().SerializeUncompressed()
+	params, _ := asn1.Marshal(oidSecp256k1)
+	spki, _ := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+	sign := func(hash []byte) []byte {
+		sig := key.signHash(hash)
+		var s secp256k1.ModNScalar
+		s.SetByteSlice(sig[32:])
+		high := s.Negate().Bytes()
+		der, _ := asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(high[:])})
+		return der
+	}
+	return key, spki, sign
+}
+
+func TestAWSKMSSignerAnchorsTransaction(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	key, spki, sign := fakeKMSKey(t)
+	kms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			t.Errorf("request is not SigV4 signed: %q", r.Header.Get("Authorization"))
+		}
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		if in["KeyId"] != "alias/sentinel" {
+			t.Errorf("unexpected key id %q", in["KeyId"])
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			json.NewEncoder(w).Encode(map[string]string{"PublicKey": base64.StdEncoding.EncodeToString(spki)})
+		case "TrentService.Sign":
+			if in["MessageType"] != "DIGEST" {
+				t.Errorf("expected a DIGEST sign request")
+			}
+			hash, _ := base64.StdEncoding.DecodeString(in["Message"])
+			json.NewEncoder(w).Encode(map[string]string{"Signature": base64.StdEncoding.EncodeToString(sign(hash))})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer kms.Close()
+
+	var moveCalls [][]interface{}
+	node := fakeSuiNode(t, &moveCalls)
+	defer node.Close()
+
+	client, err := NewSuiClient(&SuiRPCConfig{
+		Enabled: true,
+		RPCURL:  node.URL,
+		KMS:     &KMSSignerConfig{Provider: "aws", KeyID: "alias/sentinel", Region: "us-east-1", Endpoint: kms.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Address() != suiAddressFor(suiFlagSecp256k1, key.pub) {
+		t.Fatalf("address should derive from the KMS public key, got %s", client.Address())
+	}
+	if tx, err := client.MoveCall("0xpkg", "sentinel_audit", "record_audit", []interface{}{"0xreg"}); err != nil || tx != "FakeDigest111" {
+		t.Fatalf("move call: %q %v", tx, err)
+	}
+}
+
+func TestGCPKMSSigner(t *testing.T) {
+	t.Setenv("GCP_ACCESS_TOKEN", "tok")
+	_, spki, sign := fakeKMSKey(t)
+	name := "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+	kms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("missing bearer token")
+		}
+		switch r.URL.Path {
+		case "/v1/" + name + "/publicKey":
+			pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})
+			json.NewEncoder(w).Encode(map[string]string{"pem": string(pemKey), "algorithm": "EC_SIGN_SECP256K1_SHA256"})
+		case "/v1/" + name + ":asymmetricSign":
+			var in struct {
+				Digest struct {
+					SHA256 string `json:"sha256"`
+				} `json:"digest"`
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			hash, _ := base64.StdEncoding.DecodeString(in.Digest.SHA256)
+			json.NewEncoder(w).Encode(map[string]string{"signature": base64.StdEncoding.EncodeToString(sign(hash))})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer kms.Close()
+
+	signer, err := newKMSSigner(&KMSSignerConfig{Provider: "gcp", KeyID: name, Endpoint: kms.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("guardian attestation")
+	sig, err := signPersonalMessage(signer, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, addr, err := verifyPersonalMessage(sig, msg); err != nil || addr != suiAddressFor(suiFlagSecp256k1, signer.PublicKey()) {
+		t.Fatalf("kms signature should verify: %s %v", addr, err)
+	}
+}
+
+func TestGCPKMSMetadataTokenErrors(t *testing.T) {
+	t.Setenv("GCP_ACCESS_TOKEN", "")
+	status, body := http.StatusForbidden, "Service account has no token scopes"
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			t.Errorf("missing Metadata-Flavor header")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer metadata.Close()
+
+	g := &gcpKMS{metadataURL: metadata.URL, client: metadata.Client()}
+	if _, err := g.accessToken(); err == nil || !strings.Contains(err.Error(), "HTTP 403 Service account has no token scopes") {
+		t.Fatalf("expected the status and body in the error, got %v", err)
+	}
+	status, body = http.StatusOK, `{"access_token":"meta-tok","expires_in":3600}`
+	if tok, err := g.accessToken(); err != nil || tok != "meta-tok" {
+		t.Fatalf("expected the metadata token, got %q %v", tok, err)
+	}
+}
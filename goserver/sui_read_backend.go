@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DynamicFieldName identifies a dynamic field. JSON-RPC takes the decoded
+// value; GraphQL takes its BCS encoding.
+type DynamicFieldName struct {
+	Type string
+	JSON interface{}
+	BCS  []byte
+}
+
+// suiReadBackend is the read API the daemon needs from Sui, so reads keep
+// working as JSON-RPC read endpoints give way to GraphQL.
+type suiReadBackend interface {
+	DynamicFieldExists(parent string, name DynamicFieldName) (bool, error)
+}
+
+// newSuiReadBackend selects the backend configured in chain_read.backend.
+// rpcURL is the JSON-RPC endpoint of the calling feature.
+func newSuiReadBackend(rpcURL string, cfg *ChainReadConfig) (suiReadBackend, error) {
+	backend := ""
+	if cfg != nil {
+		backend = strings.ToLower(strings.TrimSpace(cfg.Backend))
+	}
+	switch backend {
+	case "", "jsonrpc":
+		if rpcURL == "" {
+			return nil, fmt.Errorf("a JSON-RPC url is required for the jsonrpc read backend")
+		}
+		return &jsonRPCReadBackend{reader: newChainReader(rpcURL, cfg)}, nil
+	case "graphql":
+		if cfg.GraphQLURL == "" {
+			return nil, fmt.Errorf("chain_read.graphql_url is required for the graphql read backend")
+		}
+		limit := cfg.MaxConcurrent
+		if limit <= 0 {
+			limit = 4
+		}
+		return &graphQLReadBackend{
+			url:    cfg.GraphQLURL,
+			client: &http.Client{Timeout: 10 * time.Second},
+			sem:    make(chan struct{}, limit),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown chain_read.backend %q (use jsonrpc or graphql)", cfg.Backend)
+	}
+}
+
+// jsonRPCReadBackend reads through the batching, caching chainReader.
+type jsonRPCReadBackend struct {
+	reader *chainReader
+}
+
+func (b *jsonRPCReadBackend) DynamicFieldExists(parent string, name DynamicFieldName) (bool, error) {
+	var result struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	err := b.reader.Read("suix_getDynamicFieldObject", []interface{}{parent, map[string]interface{}{
+		"type":  name.Type,
+		"value": name.JSON,
+	}}, &result)
+	if err != nil {
+		return false, err
+	}
+	if result.Error != nil {
+		if result.Error.Code == "dynamicFieldNotFound" {
+			return false, nil
+		}
+		return false, fmt.Errorf("rpc error: %s", result.Error.Code)
+	}
+	return len(result.Data) > 0 && string(result.Data) != "null", nil
+}
+
+// graphQLReadBackend reads from a Sui GraphQL service.
+type graphQLReadBackend struct {
+	url    string
+	client *http.Client
+	sem    chan struct{}
+}
+
+const dynamicFieldQuery = `query($parent: SuiAddress!, $type: String!, $bcs: Base64!) {
+  owner(address: $parent) { dynamicField(name: {type: $type, bcs: $bcs}) { __typename } }
+}`
+
+func (b *graphQLReadBackend) DynamicFieldExists(parent string, name DynamicFieldName) (bool, error) {
+	var data struct {
+		Owner *struct {
+			DynamicField json.RawMessage `json:"dynamicField"`
+		} `json:"owner"`
+	}
+	err := b.query(dynamicFieldQuery, map[string]interface{}{
+		"parent": parent,
+		"type":   name.Type,
+		"bcs":    base64.StdEncoding.EncodeToString(name.BCS),
+	}, &data)
+	if err != nil {
+		return false, err
+	}
+	if data.Owner == nil {
+		return false, nil
+	}
+	return len(data.Owner.DynamicField) > 0 && string(data.Owner.DynamicField) != "null", nil
+}
+
+func (b *graphQLReadBackend) query(query string, vars map[string]interface{}, data interface{}) error {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	b.sem <- struct{}{}
+	defer func() { <-b.sem }()
+	resp, err := b.client.Post(b.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("decode graphql response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if len(out.Errors) > 0 {
+		return fmt.Errorf("graphql error: %s", out.Errors[0].Message)
+	}
+	return json.Unmarshal(out.Data, data)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOnchainAllowlistGraphQLBackend(t *testing.T) {
+	approved := actionTemplateHash("EXEC", "rotate logs")
+	approvedBCS, _ := hex.DecodeString(strings.TrimPrefix(approved, "0x"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string            `json:"query"`
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Query, "dynamicField") || req.Variables["parent"] != "0xreg" || req.Variables["type"] != "0xpkg::sentinel_audit::ApprovedAction" {
+			t.Errorf("unexpected query: %+v", req)
+		}
+		if req.Variables["bcs"] == base64.StdEncoding.EncodeToString(approvedBCS) {
+			w.Write([]byte(`{"data":{"owner":{"dynamicField":{"__typename":"DynamicField"}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"owner":{"dynamicField":null}}}`))
+	}))
+	defer srv.Close()
+
+	al := newOnchainAllowlist(&SentinelConfig{
+		AnchorPackage:    "0xpkg",
+		AnchorModule:     "sentinel_audit",
+		AnchorRegistry:   "0xreg",
+		OnchainAllowlist: &OnchainAllowlistConfig{Enabled: true},
+		ChainRead:        &ChainReadConfig{Backend: "graphql", GraphQLURL: srv.URL},
+	})
+	if ok, err := al.Approved(approved); err != nil || !ok {
+		t.Fatalf("approved hash: ok=%v err=%v", ok, err)
+	}
+	if ok, err := al.Approved(actionTemplateHash("EXEC", "rm -rf /")); err != nil || ok {
+		t.Fatalf("unknown hash: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestReadBackendSelection(t *testing.T) {
+	if _, err := newSuiReadBackend("", nil); err == nil {
+		t.Fatal("jsonrpc backend needs a url")
+	}
+	if _, err := newSuiReadBackend("http://rpc", &ChainReadConfig{Backend: "graphql"}); err == nil {
+		t.Fatal("graphql backend needs graphql_url")
+	}
+	if _, err := newSuiReadBackend("http://rpc", &ChainReadConfig{Backend: "grpc"}); err == nil {
+		t.Fatal("unknown backends must be rejected")
+	}
+	al := newOnchainAllowlist(&SentinelConfig{AnchorRegistry: "0xreg", OnchainAllowlist: &OnchainAllowlistConfig{Enabled: true}})
+	if ok, err := al.Approved(actionTemplateHash("EXEC", "ls")); err == nil || ok {
+		t.Fatalf("misconfigured backend must fail closed: ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RecoveredVault is a vault reconstructed from the owner's transaction history.
+type RecoveredVault struct {
+	VaultID         string `json:"vault_id"`
+	Package         string `json:"package"`
+	Beneficiary     string `json:"beneficiary"`
+	CreatedMs       int64  `json:"created_ms"`
+	LastHeartbeatMs int64  `json:"last_heartbeat_ms"`
+	Executed        bool   `json:"executed"`
+	CreateTx        string `json:"create_tx"`
+}
+
+// RecoveredAnchor is one audit record anchored by the owner.
+type RecoveredAnchor struct {
+	RecordHash  string `json:"record_hash"`
+	TxDigest    string `json:"tx_digest"`
+	TimestampMs int64  `json:"timestamp_ms"`
+	Blocked     bool   `json:"blocked"`
+}
+
+// RecoveryReport is the output of `goserver recover`.
+type RecoveryReport struct {
+	Owner        string                  `json:"owner"`
+	Transactions int                     `json:"transactions_scanned"`
+	Vaults       []RecoveredVault        `json:"vaults"`
+	Anchors      []RecoveredAnchor       `json:"anchors"`
+	Config       *SentinelOneClickConfig `json:"config"`
+	ConfigPath   string                  `json:"config_path,omitempty"`
+	NextSteps    []string                `json:"next_steps"`
+}
+
+// suiTxBlock is the subset of a suix_queryTransactionBlocks entry recovery
+// reads.
+type suiTxBlock struct {
+	Digest      string `json:"digest"`
+	TimestampMs string `json:"timestampMs"`
+	Checkpoint  string `json:"checkpoint"`
+	Transaction struct {
+		Data struct {
+			Transaction struct {
+				Inputs []struct {
+					ObjectID string `json:"objectId"`
+				} `json:"inputs"`
+				Transactions []struct {
+					MoveCall *struct {
+						Package   string            `json:"package"`
+						Module    string            `json:"module"`
+						Function  string            `json:"function"`
+						Arguments []json.RawMessage `json:"arguments"`
+					} `json:"MoveCall"`
+				} `json:"transactions"`
+			} `json:"transaction"`
+		} `json:"data"`
+	} `json:"transaction"`
+	Effects struct {
+		Status struct {
+			Status string `json:"status"`
+		} `json:"status"`
+		GasUsed struct {
+			ComputationCost string `json:"computationCost"`
+			StorageCost     string `json:"storageCost"`
+			StorageRebate   string `json:"storageRebate"`
+		} `json:"gasUsed"`
+	} `json:"effects"`
+	Events []struct {
+		Type       string                 `json:"type"`
+		ParsedJSON map[string]interface{} `json:"parsedJson"`
+	} `json:"events"`
+}
+
+// scanOwnerTransactions pages through every transaction sent by owner,
+// oldest first, and calls fn for each.
+func scanOwnerTransactions(reader *chainReader, owner string, options map[string]interface{}, fn func(suiTxBlock)) error {
+	var cursor interface{}
+	for {
+		var page struct {
+			Data        []suiTxBlock `json:"data"`
+			NextCursor  *string      `json:"nextCursor"`
+			HasNextPage bool         `json:"hasNextPage"`
+		}
+		query := map[string]interface{}{
+			"filter":  map[string]interface{}{"FromAddress": owner},
+			"options": options,
+		}
+		if err := reader.Read("suix_queryTransactionBlocks", []interface{}{query, cursor, 50, false}, &page); err != nil {
+			return fmt.Errorf("scan transactions: %w", err)
+		}
+		for _, tx := range page.Data {
+			fn(tx)
+		}
+		if !page.HasNextPage || page.NextCursor == nil {
+			return nil
+		}
+		cursor = *page.NextCursor
+	}
+}
+
+// scanOwnerHistory folds the owner's vault and audit-anchor events into a
+// report.
+func scanOwnerHistory(reader *chainReader, owner string) (*RecoveryReport, error) {
+	report := &RecoveryReport{Owner: owner, Vaults: []RecoveredVault{}, Anchors: []RecoveredAnchor{}}
+	vaults := map[string]*RecoveredVault{}
+	sentinel := &SentinelConfig{Enabled: true, RiskThreshold: 70, AuditLogPath: "./audit/sentinel-audit.jsonl"}
+
+	err := scanOwnerTransactions(reader, owner, map[string]interface{}{"showInput": true, "showEvents": true}, func(tx suiTxBlock) {
+		report.Transactions++
+		foldTransaction(tx, vaults, report, sentinel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range vaults {
+		report.Vaults = append(report.Vaults, *v)
+	}
+	sort.Slice(report.Vaults, func(i, j int) bool { return report.Vaults[i].CreatedMs < report.Vaults[j].CreatedMs })
+	report.Config = &SentinelOneClickConfig{Sentinel: sentinel}
+	return report, nil
+}
+
+func foldTransaction(tx suiTxBlock, vaults map[string]*RecoveredVault, report *RecoveryReport, sentinel *SentinelConfig) {
+	ptx := tx.Transaction.Data.Transaction
+	for _, call := range ptx.Transactions {
+		mc := call.MoveCall
+		if mc == nil || mc.Function != "record_audit" || len(mc.Arguments) == 0 {
+			continue
+		}
+		sentinel.AnchorPackage = mc.Package
+		sentinel.AnchorModule = mc.Module
+		sentinel.AnchorFunc = mc.Function
+		var arg struct {
+			Input *int `json:"Input"`
+		}
+		if json.Unmarshal(mc.Arguments[0], &arg) == nil && arg.Input != nil && *arg.Input < len(ptx.Inputs) {
+			sentinel.AnchorRegistry = ptx.Inputs[*arg.Input].ObjectID
+		}
+	}
+
+	for _, ev := range tx.Events {
+		pkg, name := splitEventType(ev.Type)
+		fields := ev.ParsedJSON
+		switch name {
+		case "lazarus_protocol::VaultCreatedEvent":
+			id := jsonString(fields["vault_id"])
+			vaults[id] = &RecoveredVault{
+				VaultID:         id,
+				Package:         pkg,
+				Beneficiary:     jsonString(fields["beneficiary"]),
+				CreatedMs:       jsonInt(fields["timestamp_ms"]),
+				LastHeartbeatMs: jsonInt(fields["timestamp_ms"]),
+				CreateTx:        tx.Digest,
+			}
+		case "lazarus_protocol::HeartbeatEvent":
+			if v := vaults[jsonString(fields["vault_id"])]; v != nil {
+				if ts := jsonInt(fields["timestamp_ms"]); ts > v.LastHeartbeatMs {
+					v.LastHeartbeatMs = ts
+				}
+			}
+		case "lazarus_protocol::WillExecutedEvent":
+			if v := vaults[jsonString(fields["vault_id"])]; v != nil {
+				v.Executed = true
+			}
+		case "sentinel_audit::AuditAnchoredEvent":
+			report.Anchors = append(report.Anchors, RecoveredAnchor{
+				RecordHash:  jsonString(fields["record_hash"]),
+				TxDigest:    tx.Digest,
+				TimestampMs: jsonInt(fields["timestamp_ms"]),
+				Blocked:     fields["blocked"] == true,
+			})
+		}
+	}
+}
+
+// splitEventType splits "0xpkg::module::Event" into the package and
+// "module::Event".
+func splitEventType(t string) (string, string) {
+	i := strings.Index(t, "::")
+	if i < 0 {
+		return "", t
+	}
+	return t[:i], t[i+2:]
+}
+
+// jsonString and jsonInt read Sui parsedJson values; u64 fields arrive as
+// decimal strings.
+func jsonString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func jsonInt(v interface{}) int64 {
+	switch x := v.(type) {
+	case string:
+		n, _ := strconv.ParseInt(x, 10, 64)
+		return n
+	case float64:
+		return int64(x)
+	}
+	return 0
+}
+
+// recoveryNextSteps lists what is left to bring the daemon back on a new
+// machine.
+func recoveryNextSteps(r *RecoveryReport) []string {
+	steps := []string{}
+	if r.ConfigPath != "" {
+		steps = append(steps, fmt.Sprintf("Review %s and fill in hash_cli_path, sign_cli_path and the openclaw section.", r.ConfigPath))
+	} else {
+		steps = append(steps, "Re-run with --out <path> to write the reconstructed config skeleton.")
+	}
+	steps = append(steps, fmt.Sprintf("Import the key for %s into the sui CLI (sui keytool import) or set sentinel.sui_rpc.private_key.", r.Owner))
+	if r.Config.Sentinel.AnchorRegistry != "" {
+		steps = append(steps, "Set sentinel.anchor_enabled=true once the key is imported; anchors continue on the recovered registry.")
+	} else {
+		steps = append(steps, "No audit anchors were found for this address; set anchor_package and anchor_registry by hand if anchoring was done by another operator key.")
+	}
+	for _, v := range r.Vaults {
+		if !v.Executed {
+			steps = append(steps, fmt.Sprintf("Send a heartbeat to vault %s (lazarus_protocol::keep_alive) to confirm the key controls it.", v.VaultID))
+		}
+	}
+	if len(r.Anchors) > 0 {
+		steps = append(steps, "Compare recovered anchors with any exported evidence bundles (--verify-bundle); the local audit log starts empty on this machine.")
+	}
+	return steps
+}
+
+// runRecoverCommand implements `goserver recover --owner <address>`.
+func runRecoverCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("recover", flag.ContinueOnError)
+	owner := fs.String("owner", "", "Owner address whose vaults and anchors to recover")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint to scan")
+	outPath := fs.String("out", "", "Write the reconstructed config skeleton to this path (must not exist)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *owner == "" {
+		return fmt.Errorf("--owner is required")
+	}
+	addr := "0x" + normalizeKeyHex(*owner)
+	if *outPath != "" {
+		if _, err := os.Stat(*outPath); err == nil {
+			return fmt.Errorf("%s already exists; refusing to overwrite", *outPath)
+		}
+	}
+
+	report, err := scanOwnerHistory(newChainReader(*rpcURL, nil), addr)
+	if err != nil {
+		return err
+	}
+	report.Config.SuiRPCURL = *rpcURL
+
+	if *outPath != "" {
+		b, err := json.MarshalIndent(report.Config, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(*outPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(*outPath, append(b, '\n'), 0o600); err != nil {
+			return err
+		}
+		report.ConfigPath = *outPath
+	}
+	report.NextSteps = recoveryNextSteps(report)
+	return encodeSentinelOutput(out, report)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecoverFromOwnerHistory(t *testing.T) {
+	pages := []string{
+		`{"data":[{"digest":"TxCreate","events":[{"type":"0xvault::lazarus_protocol::VaultCreatedEvent",
+			"parsedJson":{"vault_id":"0xv1","owner":"0xabc","beneficiary":"0xben","timestamp_ms":"1000"}}]},
+		{"digest":"TxBeat","events":[{"type":"0xvault::lazarus_protocol::HeartbeatEvent",
+			"parsedJson":{"vault_id":"0xv1","owner":"0xabc","timestamp_ms":"5000"}}]}],
+		"nextCursor":"c1","hasNextPage":true}`,
+		`{"data":[{"digest":"TxAnchor",
+			"transaction":{"data":{"transaction":{"inputs":[{"type":"object","objectId":"0xreg"},{"type":"pure","value":"0xhash"}],
+				"transactions":[{"MoveCall":{"package":"0xpkg","module":"sentinel_audit","function":"record_audit","arguments":[{"Input":0},{"Input":1}]}}]}}},
+			"events":[{"type":"0xpkg::sentinel_audit::AuditAnchoredEvent",
+				"parsedJson":{"record_hash":"0xhash","blocked":true,"timestamp_ms":"7000"}}]}],
+		"nextCursor":null,"hasNextPage":false}`,
+	}
+	var cursors []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "suix_queryTransactionBlocks" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		cursors = append(cursors, req.Params[1])
+		page := pages[0]
+		if req.Params[1] == "c1" {
+			page = pages[1]
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + page + `}`))
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "config.json")
+	var buf bytes.Buffer
+	if err := runRecoverCommand([]string{"--owner", "0xABC", "--rpc", srv.URL, "--out", out}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(cursors) != 2 {
+		t.Fatalf("expected two pages, got cursors %v", cursors)
+	}
+
+	var report RecoveryReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Owner != "0xabc" || report.Transactions != 3 {
+		t.Fatalf("unexpected report header: %+v", report)
+	}
+	if len(report.Vaults) != 1 || report.Vaults[0].LastHeartbeatMs != 5000 || report.Vaults[0].Package != "0xvault" {
+		t.Fatalf("unexpected vaults: %+v", report.Vaults)
+	}
+	if len(report.Anchors) != 1 || !report.Anchors[0].Blocked || report.Anchors[0].TxDigest != "TxAnchor" {
+		t.Fatalf("unexpected anchors: %+v", report.Anchors)
+	}
+
+	cfg, err := loadSentinelOneClickConfig(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SuiRPCURL != srv.URL || cfg.Sentinel.AnchorPackage != "0xpkg" || cfg.Sentinel.AnchorRegistry != "0xreg" || cfg.Sentinel.AnchorFunc != "record_audit" {
+		t.Fatalf("unexpected recovered config: %+v", cfg.Sentinel)
+	}
+	if len(report.NextSteps) == 0 {
+		t.Fatal("expected next steps")
+	}
+
+	// An existing config is never overwritten.
+	if err := runRecoverCommand([]string{"--owner", "0xabc", "--rpc", srv.URL, "--out", out}, &buf); err == nil {
+		t.Fatal("recover must refuse to overwrite an existing config")
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Fatal(err)
+	}
+}
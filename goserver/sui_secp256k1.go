@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// secp256k1 ECDSA as used by Sui (flag 0x01): the signature is the 64-byte
+// r||s with low s over SHA-256 of the message, and public keys are
+// compressed. The standard library has no secp256k1 curve, so the curve
+// arithmetic comes from decred's constant-time implementation.
+
+// secp256k1Key is a private key with its compressed public key.
+type secp256k1Key struct {
+	priv *secp256k1.PrivateKey
+	pub  []byte
+}
+
+func newSecp256k1Key(secret []byte) (*secp256k1Key, error) {
+	var d secp256k1.ModNScalar
+	if len(secret) != 32 || d.SetByteSlice(secret) || d.IsZero() {
+		return nil, fmt.Errorf("invalid secp256k1 private key")
+	}
+	priv := secp256k1.NewPrivateKey(&d)
+	return &secp256k1Key{priv: priv, pub: priv.PubKey().SerializeCompressed()}, nil
+}
+
+// Sign returns r||s over SHA-256(msg) with a deterministic nonce (RFC 6979)
+// and s normalized to the lower half of the order.
+func (k *secp256k1Key) Sign(msg []byte) []byte {
+	h := sha256.Sum256(msg)
+	return k.signHash(h[:])
+}
+
+// signHash signs a precomputed 32-byte hash.
+func (k *secp256k1Key) signHash(h []byte) []byte {
+	sig := ecdsa.Sign(k.priv, h)
+	r, s := sig.R(), sig.S()
+	out := make([]byte, 64)
+	r.PutBytesUnchecked(out[:32])
+	s.PutBytesUnchecked(out[32:])
+	return out
+}
+
+type secp256k1Signer struct{ key *secp256k1Key }
+
+func (s secp256k1Signer) Flag() byte        { return suiFlagSecp256k1 }
+func (s secp256k1Signer) PublicKey() []byte { return s.key.pub }
+func (s secp256k1Signer) SignDigest(d []byte) ([]byte, error) {
+	return s.key.Sign(d), nil
+}
+
+// secp256k1Scalars parses a r||s signature, rejecting out-of-range values.
+func secp256k1Scalars(sig []byte) (r, s secp256k1.ModNScalar, ok bool) {
+	if len(sig) != 64 || r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) || r.IsZero() || s.IsZero() {
+		return r, s, false
+	}
+	return r, s, true
+}
+
+// verifySecp256k1 checks a r||s signature over SHA-256(msg). High-s
+// signatures are rejected, as Sui does.
+func verifySecp256k1(pub, msg, sig []byte) bool {
+	if len(pub) != 33 {
+		return false
+	}
+	key, err := secp256k1.ParsePubKey(pub)
+	if err != nil {
+		return false
+	}
+	r, s, ok := secp256k1Scalars(sig)
+	if !ok || s.IsOverHalfOrder() {
+		return false
+	}
+	h := sha256.Sum256(msg)
+	return ecdsa.NewSignature(&r, &s).Verify(h[:], key)
+}
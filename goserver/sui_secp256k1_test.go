@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+func TestSecp256k1KnownVector(t *testing.T) {
+	secret := make([]byte, 32)
+	secret[31] = 1
+	key, err := newSecp256k1Key(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(key.pub); got != "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" {
+		t.Fatalf("public key = %s", got)
+	}
+
+	msg := []byte("Satoshi Nakamoto")
+	sig := key.Sign(msg)
+	want := "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8" +
+		"2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5"
+	if got := hex.EncodeToString(sig); got != want {
+		t.Fatalf("signature = %s", got)
+	}
+	if !verifySecp256k1(key.pub, msg, sig) {
+		t.Fatal("signature should verify")
+	}
+
+	// The high-s twin of a valid signature is rejected.
+	var s secp256k1.ModNScalar
+	s.SetByteSlice(sig[32:])
+	high := append([]byte{}, sig...)
+	s.Negate().PutBytesUnchecked(high[32:])
+	if verifySecp256k1(key.pub, msg, high) {
+		t.Fatal("high-s signature should be rejected")
+	}
+}
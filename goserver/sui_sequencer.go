@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// suiRPCError is an error answer from the fullnode, as opposed to a
+// transport failure whose outcome is unknown.
+type suiRPCError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *suiRPCError) Error() string {
+	return fmt.Sprintf("%s: rpc error %d: %s", e.Method, e.Code, e.Message)
+}
+
+// objectConflictMarkers identify rejections caused by another transaction
+// from the same signer holding or having consumed an object version (gas
+// coin or shared object). The rejected transaction did not execute, so it
+// is safe to rebuild against fresh versions and resubmit.
+var objectConflictMarkers = []string{
+	"ObjectVersionUnavailableForConsumption",
+	"ObjectLockConflict",
+	"locked objects",
+	"equivocat",
+	"not available for consumption",
+	"TooManyTransactionsPendingOnObject",
+}
+
+func isObjectConflict(err error) bool {
+	var rpcErr *suiRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Method != "sui_executeTransactionBlock" {
+		return false
+	}
+	for _, m := range objectConflictMarkers {
+		if strings.Contains(rpcErr.Message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transaction priority classes, highest first. A queued transaction of a
+// higher class is started before any lower one, so emergency transactions
+// (execute_will, panic-sell) never wait behind a backlog of audit anchors.
+// A lower class passed over txStarvationLimit times in a row gets the next
+// turn, so a steady stream of higher-class work cannot starve it.
+const (
+	txClassEmergency = iota
+	txClassDefault
+	txClassAnchor
+	numTxClasses
+)
+
+var txClassNames = [numTxClasses]string{"emergency", "default", "anchor"}
+
+const txStarvationLimit = 8
+
+// TxQueueStats reports one class's submissions and time spent queued.
+type TxQueueStats struct {
+	Class            string  `json:"class"`
+	Submissions      uint64  `json:"submissions"`
+	Queued           int     `json:"queued"`
+	WaitSecondsTotal float64 `json:"wait_seconds_total"`
+	MaxWaitSeconds   float64 `json:"max_wait_seconds"`
+}
+
+// txSequencer serializes one signer's submissions, hands the signer to the
+// highest-priority waiter, and retries version conflicts. Transport errors
+// and Move aborts are never retried: the first may have executed and the
+// second would fail again.
+type txSequencer struct {
+	maxRetries int
+	backoff    time.Duration
+
+	mu        sync.Mutex
+	busy      bool
+	waiting   [numTxClasses][]chan struct{}
+	skipped   [numTxClasses]int
+	stats     [numTxClasses]TxQueueStats
+	conflicts int
+}
+
+func newTxSequencer(maxRetries int) *txSequencer {
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	s := &txSequencer{maxRetries: maxRetries, backoff: 250 * time.Millisecond}
+	for c := range s.stats {
+		s.stats[c].Class = txClassNames[c]
+	}
+	return s
+}
+
+// acquire blocks until the signer is free for a transaction of class.
+func (s *txSequencer) acquire(class int) {
+	start := time.Now()
+	s.mu.Lock()
+	if s.busy {
+		ch := make(chan struct{})
+		s.waiting[class] = append(s.waiting[class], ch)
+		s.mu.Unlock()
+		<-ch
+		s.mu.Lock()
+	}
+	s.busy = true
+	wait := time.Since(start).Seconds()
+	st := &s.stats[class]
+	st.Submissions++
+	st.WaitSecondsTotal += wait
+	if wait > st.MaxWaitSeconds {
+		st.MaxWaitSeconds = wait
+	}
+	s.mu.Unlock()
+}
+
+// release hands the signer to the oldest waiter of the highest class,
+// unless a lower class has been passed over txStarvationLimit times.
+func (s *txSequencer) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := -1
+	for c := range s.waiting {
+		if len(s.waiting[c]) == 0 {
+			continue
+		}
+		if next < 0 {
+			next = c
+		} else if s.skipped[c] >= txStarvationLimit {
+			next = c
+			break
+		}
+	}
+	if next < 0 {
+		s.busy = false
+		return
+	}
+	for c := next + 1; c < numTxClasses; c++ {
+		if len(s.waiting[c]) > 0 {
+			s.skipped[c]++
+		}
+	}
+	s.skipped[next] = 0
+	ch := s.waiting[next][0]
+	s.waiting[next] = s.waiting[next][1:]
+	close(ch) // busy stays set for the new holder
+}
+
+// submit runs build-sign-execute until it succeeds, fails for a reason
+// other than an object conflict, or runs out of retries. The signer is
+// released between retries, so a conflicting anchor does not hold up a
+// queued emergency transaction.
+func (s *txSequencer) submit(class int, run func() (string, error)) (string, error) {
+	for attempt := 1; ; attempt++ {
+		s.acquire(class)
+		digest, err := run()
+		s.release()
+		if err == nil || !isObjectConflict(err) {
+			return digest, err
+		}
+		s.mu.Lock()
+		s.conflicts++
+		s.mu.Unlock()
+		if attempt > s.maxRetries {
+			return digest, fmt.Errorf("giving up after %d object conflicts: %w", attempt, err)
+		}
+		log.Printf("[SUI] %s transaction hit an object conflict, rebuilding (retry %d/%d): %v",
+			txClassNames[class], attempt, s.maxRetries, err)
+		time.Sleep(s.backoff * time.Duration(attempt))
+	}
+}
+
+// QueueStats returns per-class queue statistics.
+func (s *txSequencer) QueueStats() []TxQueueStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]TxQueueStats, numTxClasses)
+	for c := range out {
+		out[c] = s.stats[c]
+		out[c].Queued = len(s.waiting[c])
+	}
+	return out
+}
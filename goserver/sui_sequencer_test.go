@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// conflictingSuiNode rejects the first `conflicts` executions with a lock
+// conflict and records how many executions overlap.
+func conflictingSuiNode(t *testing.T, conflicts int32, errMessage string) (*httptest.Server, *int32, *int32) {
+	var inFlight, maxInFlight, executes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "unsafe_moveCall":
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"txBytes": "dHg="}})
+		case "sui_executeTransactionBlock":
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			if atomic.AddInt32(&executes, 1) <= conflicts {
+				json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -32002, "message": errMessage}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{
+				"digest":  "Digest",
+				"effects": map[string]interface{}{"status": map[string]string{"status": "success"}},
+			}})
+		}
+	}))
+	return srv, &maxInFlight, &executes
+}
+
+func testSuiClient(t *testing.T, url string) *SuiClient {
+	c, err := NewSuiClient(&SuiRPCConfig{Enabled: true, RPCURL: url, PrivateKey: strings.Repeat("02", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.seq.backoff = time.Millisecond
+	return c
+}
+
+func TestSequencerSerializesAndRetriesConflicts(t *testing.T) {
+	node, maxInFlight, executes := conflictingSuiNode(t, 2,
+		"Failed to sign transaction by a quorum of validators because of locked objects: ObjectLockConflict")
+	defer node.Close()
+	c := testSuiClient(t, node.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.MoveCall("0xpkg", "sentinel_audit", "record_audit", nil); err != nil {
+				t.Errorf("move call: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if *maxInFlight != 1 {
+		t.Fatalf("submissions from one signer must not overlap, saw %d in flight", *maxInFlight)
+	}
+	if *executes != 7 || c.seq.conflicts != 2 {
+		t.Fatalf("expected 5 successes after 2 conflicts, got %d executions and %d conflicts", *executes, c.seq.conflicts)
+	}
+}
+
+func TestSequencerDoesNotRetryOtherErrors(t *testing.T) {
+	node, _, executes := conflictingSuiNode(t, 10, "Insufficient gas")
+	defer node.Close()
+	c := testSuiClient(t, node.URL)
+	if _, err := c.MoveCall("0xpkg", "m", "f", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if *executes != 1 {
+		t.Fatalf("non-conflict errors must not be retried, got %d executions", *executes)
+	}
+
+	node2, _, executes2 := conflictingSuiNode(t, 10, "ObjectVersionUnavailableForConsumption")
+	defer node2.Close()
+	c2 := testSuiClient(t, node2.URL)
+	if _, err := c2.MoveCall("0xpkg", "m", "f", nil); err == nil || !strings.Contains(err.Error(), "giving up") {
+		t.Fatalf("expected to give up after retries, got %v", err)
+	}
+	if *executes2 != 4 {
+		t.Fatalf("expected 1 attempt + 3 retries, got %d", *executes2)
+	}
+}
+
+func TestSequencerPriorityLanes(t *testing.T) {
+	s := newTxSequencer(1)
+	hold := make(chan struct{})
+	go s.submit(txClassAnchor, func() (string, error) { <-hold; return "first", nil })
+
+	queued := func(class, n int) {
+		t.Helper()
+		for i := 0; i < 200; i++ {
+			if s.QueueStats()[class].Queued == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("expected %d queued %s transactions", n, txClassNames[class])
+	}
+	for s.QueueStats()[txClassAnchor].Submissions == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	run := func(class int, name string) {
+		defer wg.Done()
+		s.submit(class, func() (string, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return name, nil
+		})
+	}
+	for i, name := range []string{"anchor-1", "anchor-2"} {
+		wg.Add(1)
+		go run(txClassAnchor, name)
+		queued(txClassAnchor, i+1)
+	}
+	wg.Add(1)
+	go run(txClassEmergency, "execute_will")
+	queued(txClassEmergency, 1)
+
+	close(hold)
+	wg.Wait()
+	if strings.Join(order, ",") != "execute_will,anchor-1,anchor-2" {
+		t.Fatalf("emergency must preempt queued anchors, got order %v", order)
+	}
+	stats := s.QueueStats()
+	if stats[txClassEmergency].Submissions != 1 || stats[txClassEmergency].WaitSecondsTotal <= 0 || stats[txClassAnchor].Submissions != 3 {
+		t.Fatalf("unexpected queue stats: %+v", stats)
+	}
+}
+
+func TestSequencerStarvationBound(t *testing.T) {
+	s := newTxSequencer(1)
+	hold := make(chan struct{})
+	go s.submit(txClassEmergency, func() (string, error) { <-hold; return "first", nil })
+	for s.QueueStats()[txClassEmergency].Submissions == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(class int, name string, n int) {
+		t.Helper()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.submit(class, func() (string, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return name, nil
+			})
+		}()
+		for i := 0; s.QueueStats()[class].Queued != n; i++ {
+			if i == 200 {
+				t.Fatalf("expected %d queued %s transactions", n, txClassNames[class])
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	enqueue(txClassAnchor, "anchor", 1)
+	var want []string
+	for i := 1; i <= txStarvationLimit+2; i++ {
+		name := fmt.Sprintf("emergency-%d", i)
+		enqueue(txClassEmergency, name, i)
+		want = append(want, name)
+	}
+	want = append(want[:txStarvationLimit], append([]string{"anchor"}, want[txStarvationLimit:]...)...)
+
+	close(hold)
+	wg.Wait()
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Fatalf("an anchor passed over %d times must run next, got order %v", txStarvationLimit, order)
+	}
+}
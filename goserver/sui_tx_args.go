@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sui system objects. Both are shared, and Move only accepts them by
+// immutable reference; a transaction that takes either mutably is rejected.
+const (
+	suiClockObjectID  = "0x6"
+	suiRandomObjectID = "0x8"
+)
+
+// suiMoveArg is one Move call argument: a pure value, or a shared object
+// and whether the call takes it by mutable reference.
+type suiMoveArg struct {
+	value   interface{}
+	object  bool
+	mutable bool
+}
+
+// suiMoveArgs builds the argument list of a Move call once, so the RPC and
+// CLI paths pass the same objects in the same order.
+type suiMoveArgs struct {
+	args []suiMoveArg
+}
+
+func (a *suiMoveArgs) pure(v interface{}) *suiMoveArgs {
+	a.args = append(a.args, suiMoveArg{value: v})
+	return a
+}
+
+// shared appends a shared object argument.
+func (a *suiMoveArgs) shared(id string, mutable bool) *suiMoveArgs {
+	a.args = append(a.args, suiMoveArg{value: id, object: true, mutable: mutable})
+	return a
+}
+
+// clock appends the Clock object; id overrides 0x6 for forks and localnets
+// that publish it elsewhere.
+func (a *suiMoveArgs) clock(id string) *suiMoveArgs {
+	if id == "" {
+		id = suiClockObjectID
+	}
+	return a.shared(id, false)
+}
+
+func (a *suiMoveArgs) validate() error {
+	for i, arg := range a.args {
+		if !arg.object {
+			continue
+		}
+		id, _ := arg.value.(string)
+		if strings.TrimSpace(id) == "" {
+			return fmt.Errorf("move call argument %d: object id is empty", i)
+		}
+		if arg.mutable && isSuiSystemObject(id) {
+			return fmt.Errorf("move call argument %d: system object %s cannot be taken mutably", i, id)
+		}
+	}
+	return nil
+}
+
+// rpc returns the arguments for unsafe_moveCall. The node resolves object
+// versions and reference kinds from the function signature.
+func (a *suiMoveArgs) rpc() ([]interface{}, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(a.args))
+	for i, arg := range a.args {
+		out[i] = arg.value
+	}
+	return out, nil
+}
+
+// cli returns the arguments for `sui client call --args`.
+func (a *suiMoveArgs) cli() ([]string, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(a.args))
+	for i, arg := range a.args {
+		out[i] = fmt.Sprint(arg.value)
+	}
+	return out, nil
+}
+
+// isSuiSystemObject reports whether id is 0x6 or 0x8 in any zero-padded form.
+func isSuiSystemObject(id string) bool {
+	short := "0x" + strings.TrimLeft(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "0x"), "0")
+	return short == suiClockObjectID || short == suiRandomObjectID
+}
+
+// anchorMoveArgs are the arguments of record_audit, which only reads the
+// registry, so anchors never contend with each other for it.
+func anchorMoveArgs(cfg *SentinelConfig, rec *AuditRecord, actionTag, riskScore int, blocked bool) *suiMoveArgs {
+	return new(suiMoveArgs).
+		shared(cfg.AnchorRegistry, false).
+		pure(rec.RecordHash).
+		pure(actionTag).
+		pure(riskScore).
+		pure(blocked).
+		clock(cfg.AnchorClock)
+}
+
+// approveActionMoveArgs are the arguments of approve_action, which adds the
+// hash under the registry and so takes it mutably.
+func approveActionMoveArgs(registry, actionHash string) *suiMoveArgs {
+	return new(suiMoveArgs).shared(registry, true).pure(actionHash)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnchorMoveArgs(t *testing.T) {
+	rec := &AuditRecord{RecordHash: "0xabc"}
+	cfg := &SentinelConfig{AnchorRegistry: "0xreg"}
+
+	rpc, err := anchorMoveArgs(cfg, rec, 3, 90, true).rpc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"0xreg", "0xabc", 3, 90, true, suiClockObjectID}; !reflect.DeepEqual(rpc, want) {
+		t.Fatalf("rpc args %v, want %v", rpc, want)
+	}
+
+	cfg.AnchorClock = "0x106"
+	cli, err := anchorMoveArgs(cfg, rec, 3, 90, false).cli()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cli, " "); got != "0xreg 0xabc 3 90 false 0x106" {
+		t.Fatalf("cli args %q", got)
+	}
+
+	if _, err := anchorMoveArgs(&SentinelConfig{}, rec, 3, 90, false).rpc(); err == nil {
+		t.Fatal("an empty registry id should be rejected")
+	}
+	if _, err := new(suiMoveArgs).shared("0x"+strings.Repeat("0", 63)+"8", true).cli(); err == nil {
+		t.Fatal("a padded Random object id taken mutably should be rejected")
+	}
+	if _, err := approveActionMoveArgs("0xreg", "0xhash").cli(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VaultCreateResult is the output of `goserver create`. DecryptionKey is the
+// only copy of the key; the beneficiary needs it to claim.
+type VaultCreateResult struct {
+	WalrusEncryptedStore
+	VaultID     string `json:"vault_id,omitempty"`
+	TxDigest    string `json:"tx_digest"`
+	Beneficiary string `json:"beneficiary"`
+}
+
+// createVault encrypts plaintext natively, stores it on Walrus and creates
+// a vault for it, so no Rust CLI is needed.
+func createVault(sui *SuiClient, walrus *walrusClient, pkg, beneficiary string, plaintext []byte, opts WalrusStoreOptions) (*VaultCreateResult, error) {
+	stored, err := encryptAndStoreWalrus(walrus, plaintext, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := &VaultCreateResult{WalrusEncryptedStore: *stored, Beneficiary: beneficiary}
+	args, err := new(suiMoveArgs).pure(beneficiary).pure(stored.BlobID).clock("").rpc()
+	if err != nil {
+		return nil, err
+	}
+	// The key is printed even when the vault is not created: the blob is
+	// already stored, and a retry can reuse it.
+	if res.TxDigest, err = sui.MoveCall(pkg, "lazarus_protocol", "create_vault", args); err != nil {
+		return res, fmt.Errorf("create_vault: %w", err)
+	}
+	var tx suiTxBlock
+	if err := sui.call("sui_getTransactionBlock", []interface{}{res.TxDigest, map[string]bool{"showEvents": true}}, &tx); err == nil {
+		for _, ev := range tx.Events {
+			if _, name := splitEventType(ev.Type); name == "lazarus_protocol::VaultCreatedEvent" {
+				res.VaultID = jsonString(ev.ParsedJSON["vault_id"])
+			}
+		}
+	}
+	return res, nil
+}
+
+// runCreateCommand implements `goserver create`.
+func runCreateCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	file := fs.String("file", "", "File to protect")
+	beneficiary := fs.String("beneficiary", "", "Beneficiary Sui address")
+	pkg := fs.String("package", "", "Package ID of the deployed lazarus_protocol contract")
+	keyFile := fs.String("key-file", "", "Owner's Sui key (hex ed25519 seed or base64 keystore entry)")
+	rpcURL := fs.String("rpc", "https://fullnode.testnet.sui.io:443", "Sui JSON-RPC endpoint")
+	publisher := fs.String("publisher", "https://publisher.walrus-testnet.walrus.space", "Walrus publisher URL")
+	epochs := fs.Int("epochs", 5, "Storage duration in epochs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || *beneficiary == "" || *pkg == "" || *keyFile == "" {
+		return fmt.Errorf("--file, --beneficiary, --package and --key-file are required")
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	sui, err := NewSuiClient(&SuiRPCConfig{Enabled: true, RPCURL: *rpcURL, PrivateKey: strings.TrimSpace(string(key))})
+	if err != nil {
+		return err
+	}
+	res, err := createVault(sui, newWalrusClient(*publisher, nil), *pkg, "0x"+normalizeKeyHex(*beneficiary), data, WalrusStoreOptions{Epochs: *epochs})
+	if res != nil {
+		if encErr := encodeSentinelOutput(out, res); err == nil {
+			err = encErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateVaultWithoutRust(t *testing.T) {
+	var stored []byte
+	walrus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			stored, _ = io.ReadAll(r.Body)
+			io.WriteString(w, `{"newlyCreated":{"blobObject":{"id":"0xobj","blobId":"blob-3","storage":{"endEpoch":12}}}}`)
+			return
+		}
+		w.Write(stored)
+	}))
+	defer walrus.Close()
+	var moveCalls [][]interface{}
+	node := fakeSuiNode(t, &moveCalls)
+	defer node.Close()
+
+	dir := t.TempDir()
+	file, keyFile := filepath.Join(dir, "will.txt"), filepath.Join(dir, "owner.key")
+	os.WriteFile(file, []byte("to my daughter"), 0o600)
+	os.WriteFile(keyFile, []byte(strings.Repeat("05", 32)+"\n"), 0o600)
+	var buf bytes.Buffer
+	err := runCreateCommand([]string{"--file", file, "--beneficiary", "0xBEEF", "--package", "0xpkg", "--key-file", keyFile,
+		"--rpc", node.URL, "--publisher", walrus.URL}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res VaultCreateResult
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil || res.BlobID != "blob-3" || res.TxDigest != "FakeDigest111" || len(res.DecryptionKey) != 88 {
+		t.Fatalf("unexpected create output: %s", buf.String())
+	}
+	if len(moveCalls) != 1 || len(moveCalls[0]) != 3 || moveCalls[0][0] != "0xbeef" || moveCalls[0][1] != "blob-3" || moveCalls[0][2] != "0x6" {
+		t.Fatalf("unexpected create_vault call: %v", moveCalls)
+	}
+
+	// The stored blob decrypts with the printed key.
+	os.WriteFile(keyFile, []byte(res.DecryptionKey), 0o600)
+	out := filepath.Join(dir, "out.txt")
+	buf.Reset()
+	err = runWalrusCommand([]string{"decrypt", "--blob-id", res.BlobID, "--decryption-key-file", keyFile, "--out", out,
+		"--aggregator", walrus.URL, "--checksum", res.Checksum}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "to my daughter" {
+		t.Fatalf("decrypted %q", got)
+	}
+}
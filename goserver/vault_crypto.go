@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Vault payloads are encrypted as by the Rust CLI's encrypt-and-store, so
+// either side can open what the other sealed:
+//
+//   - ciphertext: AES-256-GCM with a 12-byte nonce and no additional data,
+//     the 16-byte tag appended (Go's Seal and the aes-gcm crate agree)
+//   - decryption_key: hex(key || nonce), 44 bytes or 88 hex characters,
+//     optionally 0x-prefixed
+//   - checksum: hex SHA-256 of the plaintext
+const vaultKeySize = 32 + 12
+
+// sealedVaultPayload is a payload encrypted under a fresh key.
+type sealedVaultPayload struct {
+	Ciphertext    []byte
+	DecryptionKey string
+	Checksum      string
+}
+
+// sealVaultPayload encrypts plaintext under a fresh key and nonce.
+func sealVaultPayload(plaintext []byte) (*sealedVaultPayload, error) {
+	if len(plaintext) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	raw := make([]byte, vaultKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	aead, err := newVaultAEAD(raw[:32])
+	if err != nil {
+		return nil, err
+	}
+	return &sealedVaultPayload{
+		Ciphertext:    aead.Seal(nil, raw[32:], plaintext, nil),
+		DecryptionKey: hex.EncodeToString(raw),
+		Checksum:      vaultChecksum(plaintext),
+	}, nil
+}
+
+// openVaultPayload decrypts a payload sealed by sealVaultPayload or by
+// encrypt-and-store.
+func openVaultPayload(ciphertext []byte, decryptionKey string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(decryptionKey), "0x"))
+	if err != nil || len(raw) != vaultKeySize {
+		return nil, fmt.Errorf("decryption key must be 44 bytes (88 hex chars)")
+	}
+	aead, err := newVaultAEAD(raw[:32])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, raw[32:], ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("blob does not decrypt with this key")
+	}
+	return plaintext, nil
+}
+
+func newVaultAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func vaultChecksum(plaintext []byte) string {
+	sum := sha256.Sum256(plaintext)
+	return hex.EncodeToString(sum[:])
+}
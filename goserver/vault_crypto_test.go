@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The key format is hex(key || nonce), so a published AES-256-GCM vector
+// (GCM spec test case 15, no additional data) opens as any Rust-sealed
+// payload would.
+func TestOpenVaultPayloadVector(t *testing.T) {
+	key := "feffe9928665731c6d6a8f9467308308feffe9928665731c6d6a8f9467308308" + "cafebabefacedbaddecaf888"
+	ciphertext, _ := hex.DecodeString("522dc1f099567d07f47f37a32a84427d643a8cdcbfe5c0c97598a2bd2555d1aa" +
+		"8cb08e48590dbb3da7b08b1056828838c5f61e6393ba7a0abcc9f662898015ad" + "b094dac5d93471bdec1a502270e3cc6c")
+	want, _ := hex.DecodeString("d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a72" +
+		"1c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255")
+	got, err := openVaultPayload(ciphertext, "0x"+key)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("vector did not decrypt: %x %v", got, err)
+	}
+}
+
+func TestSealVaultPayload(t *testing.T) {
+	sealed, err := sealVaultPayload([]byte("last words"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed.DecryptionKey) != 88 || len(sealed.Ciphertext) != 10+16 ||
+		sealed.Checksum != "efb1a0d4329da218e73d793d25285ebe332b9bcc6bd8883478a87893d9090607" {
+		t.Fatalf("unexpected sealed payload: %+v", sealed)
+	}
+	if got, err := openVaultPayload(sealed.Ciphertext, sealed.DecryptionKey+"\n"); err != nil || string(got) != "last words" {
+		t.Fatalf("round trip failed: %q %v", got, err)
+	}
+	sealed.Ciphertext[0] ^= 1
+	if _, err := openVaultPayload(sealed.Ciphertext, sealed.DecryptionKey); err == nil {
+		t.Fatal("a tampered ciphertext should not decrypt")
+	}
+	if _, err := openVaultPayload(sealed.Ciphertext, sealed.DecryptionKey[:64]); err == nil {
+		t.Fatal("a key without a nonce should be rejected")
+	}
+	if _, err := sealVaultPayload(nil); err == nil {
+		t.Fatal("an empty payload should be rejected")
+	}
+}
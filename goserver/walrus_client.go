@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WalrusStoreOptions are the publisher's store parameters.
+type WalrusStoreOptions struct {
+	Epochs int
+	// Deletable blobs can be deleted by their owner before they expire.
+	Deletable bool
+}
+
+// WalrusBlob is the publisher's answer to a store request.
+type WalrusBlob struct {
+	BlobID string `json:"blob_id"`
+	// ObjectID is the Sui Blob object; empty when the blob already existed.
+	ObjectID  string `json:"object_id,omitempty"`
+	EndEpoch  int    `json:"end_epoch,omitempty"`
+	Deletable bool   `json:"deletable,omitempty"`
+	// AlreadyCertified is set when the publisher found the blob already
+	// stored and certified, and stored nothing new.
+	AlreadyCertified bool `json:"already_certified,omitempty"`
+}
+
+// walrusClient talks to a Walrus publisher's HTTP API.
+type walrusClient struct {
+	publisherURL string
+	http         *http.Client
+}
+
+func newWalrusClient(publisherURL string, client *http.Client) *walrusClient {
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &walrusClient{publisherURL: strings.TrimRight(publisherURL, "/"), http: client}
+}
+
+// Store PUTs data to /v1/blobs and returns the stored blob.
+func (c *walrusClient) Store(data []byte, opts WalrusStoreOptions) (*WalrusBlob, error) {
+	q := url.Values{}
+	if opts.Epochs > 0 {
+		q.Set("epochs", strconv.Itoa(opts.Epochs))
+	}
+	if opts.Deletable {
+		q.Set("deletable", "true")
+	}
+	req, err := http.NewRequest(http.MethodPut, c.publisherURL+"/v1/blobs?"+q.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("walrus upload: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("walrus upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read walrus response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("walrus returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return parseWalrusStoreResponse(body)
+}
+
+// parseWalrusStoreResponse reads the newlyCreated or alreadyCertified
+// envelope. Flat blob_id and cid fields from older gateways are accepted.
+func parseWalrusStoreResponse(body []byte) (*WalrusBlob, error) {
+	var resp struct {
+		NewlyCreated *struct {
+			BlobObject struct {
+				ID        string `json:"id"`
+				BlobID    string `json:"blobId"`
+				Deletable bool   `json:"deletable"`
+				Storage   struct {
+					EndEpoch int `json:"endEpoch"`
+				} `json:"storage"`
+			} `json:"blobObject"`
+		} `json:"newlyCreated"`
+		AlreadyCertified *struct {
+			BlobID   string `json:"blobId"`
+			EndEpoch int    `json:"endEpoch"`
+		} `json:"alreadyCertified"`
+		BlobID string `json:"blob_id"`
+		CID    string `json:"cid"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse walrus response: %w", err)
+	}
+	switch {
+	case resp.NewlyCreated != nil && resp.NewlyCreated.BlobObject.BlobID != "":
+		obj := resp.NewlyCreated.BlobObject
+		return &WalrusBlob{BlobID: obj.BlobID, ObjectID: obj.ID, EndEpoch: obj.Storage.EndEpoch, Deletable: obj.Deletable}, nil
+	case resp.AlreadyCertified != nil && resp.AlreadyCertified.BlobID != "":
+		return &WalrusBlob{BlobID: resp.AlreadyCertified.BlobID, EndEpoch: resp.AlreadyCertified.EndEpoch, AlreadyCertified: true}, nil
+	case resp.BlobID != "":
+		return &WalrusBlob{BlobID: resp.BlobID}, nil
+	case resp.CID != "":
+		return &WalrusBlob{BlobID: resp.CID}, nil
+	}
+	return nil, fmt.Errorf("could not extract blob ID from walrus response: %s", string(body))
+}
+
+// WalrusEncryptedStore is the output of `walrus store`, the same shape the
+// Rust CLI's encrypt-and-store prints.
+type WalrusEncryptedStore struct {
+	BlobID string `json:"blob_id"`
+	// DecryptionKey is hex(key || nonce) for the AES-256-GCM ciphertext.
+	DecryptionKey string `json:"decryption_key"`
+	// Checksum is the hex sha256 of the plaintext.
+	Checksum         string `json:"checksum"`
+	OriginalSize     int    `json:"original_size"`
+	EncryptedSize    int    `json:"encrypted_size"`
+	EndEpoch         int    `json:"end_epoch,omitempty"`
+	AlreadyCertified bool   `json:"already_certified,omitempty"`
+}
+
+// encryptAndStoreWalrus encrypts plaintext under a fresh AES-256-GCM key and
+// stores the ciphertext, so the publisher never sees the contents.
+func encryptAndStoreWalrus(c *walrusClient, plaintext []byte, opts WalrusStoreOptions) (*WalrusEncryptedStore, error) {
+	sealed, err := sealVaultPayload(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := c.Store(sealed.Ciphertext, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &WalrusEncryptedStore{
+		BlobID:           blob.BlobID,
+		DecryptionKey:    sealed.DecryptionKey,
+		Checksum:         sealed.Checksum,
+		OriginalSize:     len(plaintext),
+		EncryptedSize:    len(sealed.Ciphertext),
+		EndEpoch:         blob.EndEpoch,
+		AlreadyCertified: blob.AlreadyCertified,
+	}, nil
+}
+
+// runWalrusCommand implements `goserver walrus store` and `walrus decrypt`.
+func runWalrusCommand(args []string, out io.Writer) error {
+	if len(args) > 0 && args[0] == "decrypt" {
+		return runWalrusDecrypt(args[1:], out)
+	}
+	if len(args) == 0 || args[0] != "store" {
+		return fmt.Errorf("usage: walrus store --file <path> [--publisher <url>] [--epochs N] [--deletable] [--plain]\n" +
+			"       walrus decrypt --blob-id <id> --decryption-key-file <path> --out <path> [--aggregator <url>] [--checksum <sha256>]")
+	}
+	fs := flag.NewFlagSet("walrus store", flag.ContinueOnError)
+	file := fs.String("file", "", "File to store")
+	publisher := fs.String("publisher", "https://publisher.walrus-testnet.walrus.space", "Walrus publisher URL")
+	epochs := fs.Int("epochs", 5, "Storage duration in epochs")
+	deletable := fs.Bool("deletable", false, "Store a deletable blob")
+	plain := fs.Bool("plain", false, "Store the file as is instead of encrypting it")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	client := newWalrusClient(*publisher, nil)
+	opts := WalrusStoreOptions{Epochs: *epochs, Deletable: *deletable}
+	if *plain {
+		blob, err := client.Store(data, opts)
+		if err != nil {
+			return err
+		}
+		return encodeSentinelOutput(out, blob)
+	}
+	result, err := encryptAndStoreWalrus(client, data, opts)
+	if err != nil {
+		return err
+	}
+	return encodeSentinelOutput(out, result)
+}
+
+// fetchWalrusBlob downloads a blob from an aggregator.
+func fetchWalrusBlob(client *http.Client, aggregatorURL, blobID string) ([]byte, error) {
+	resp, err := client.Get(strings.TrimRight(aggregatorURL, "/") + "/v1/blobs/" + url.PathEscape(blobID))
+	if err != nil {
+		return nil, fmt.Errorf("walrus download: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("walrus download: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("walrus aggregator returned status %d for blob %s", resp.StatusCode, blobID)
+	}
+	return body, nil
+}
+
+// runWalrusDecrypt downloads a blob and decrypts it with its key, as the
+// Rust CLI's decrypt does.
+func runWalrusDecrypt(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("walrus decrypt", flag.ContinueOnError)
+	blobID := fs.String("blob-id", "", "Blob to decrypt")
+	keyFile := fs.String("decryption-key-file", "", "File holding the decryption key (hex key||nonce)")
+	outPath := fs.String("out", "", "Write the plaintext here (must not exist)")
+	aggregator := fs.String("aggregator", "https://aggregator.walrus-testnet.walrus.space", "Walrus aggregator URL")
+	checksum := fs.String("checksum", "", "Expected sha256 of the plaintext")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *blobID == "" || *keyFile == "" || *outPath == "" {
+		return fmt.Errorf("--blob-id, --decryption-key-file and --out are required")
+	}
+	if _, err := os.Stat(*outPath); err == nil {
+		return fmt.Errorf("%s already exists; refusing to overwrite", *outPath)
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := fetchWalrusBlob(&http.Client{Timeout: 2 * time.Minute}, *aggregator, *blobID)
+	if err != nil {
+		return err
+	}
+	plaintext, err := openVaultPayload(ciphertext, string(key))
+	if err != nil {
+		return err
+	}
+	sum := vaultChecksum(plaintext)
+	if want := strings.ToLower(strings.TrimSpace(*checksum)); want != "" && want != sum {
+		return fmt.Errorf("checksum mismatch: blob decrypts to %s, expected %s", sum, want)
+	}
+	if err := os.WriteFile(*outPath, plaintext, 0o600); err != nil {
+		return err
+	}
+	return encodeSentinelOutput(out, map[string]interface{}{"blob_id": *blobID, "output": *outPath, "size": len(plaintext), "checksum": sum})
+}
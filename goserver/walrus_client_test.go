@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWalrusClientStore(t *testing.T) {
+	var stored []byte
+	publisher := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v1/blobs" || r.URL.Query().Get("epochs") != "7" ||
+			r.URL.Query().Get("deletable") != "true" || r.Header.Get("Content-Type") != "application/octet-stream" {
+			t.Errorf("unexpected store request %s %s", r.Method, r.URL)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Equal(body, stored) {
+			io.WriteString(w, `{"alreadyCertified":{"blobId":"blob-9","event":{"txDigest":"D","eventSeq":"0"},"endEpoch":41}}`)
+			return
+		}
+		stored = body
+		io.WriteString(w, `{"newlyCreated":{"blobObject":{"id":"0xobj","blobId":"blob-9","deletable":true,"storage":{"endEpoch":40}},"cost":100}}`)
+	}))
+	defer publisher.Close()
+
+	client := newWalrusClient(publisher.URL+"/", nil)
+	opts := WalrusStoreOptions{Epochs: 7, Deletable: true}
+	blob, err := client.Store([]byte("attestation"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *blob != (WalrusBlob{BlobID: "blob-9", ObjectID: "0xobj", EndEpoch: 40, Deletable: true}) {
+		t.Fatalf("unexpected newly created blob: %+v", blob)
+	}
+	if blob, err = client.Store([]byte("attestation"), opts); err != nil || !blob.AlreadyCertified || blob.EndEpoch != 41 {
+		t.Fatalf("unexpected already certified blob: %+v %v", blob, err)
+	}
+
+	out, err := encryptAndStoreWalrus(client, []byte("last words"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := hex.DecodeString(out.DecryptionKey)
+	block, _ := aes.NewCipher(raw[:32])
+	aead, _ := cipher.NewGCM(block)
+	plain, err := aead.Open(nil, raw[32:], stored, nil)
+	if err != nil || string(plain) != "last words" || out.OriginalSize != 10 || out.EncryptedSize != len(stored) {
+		t.Fatalf("stored ciphertext does not decrypt with the returned key: %+v %v", out, err)
+	}
+
+	if _, err := parseWalrusStoreResponse([]byte(`{"newlyCreated":{}}`)); err == nil {
+		t.Fatal("a response without a blob id should fail")
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Walrus renewal notification events.
+const (
+	notifyWalrusRenewalFailed    = "walrus_renewal_failed"
+	notifyWalrusRenewalRecovered = "walrus_renewal_recovered"
+)
+
+// WalrusRenewalConfig keeps vault blobs stored on Walrus. A blob lives for
+// a fixed number of epochs; once it expires the vault points at nothing.
+type WalrusRenewalConfig struct {
+	Enabled bool `json:"enabled"`
+	// Vaults are vault IDs whose encrypted_blob_id is kept alive. The blob
+	// ID is read from the chain on every check.
+	Vaults []string `json:"vaults,omitempty"`
+	// BlobIDs are further blobs to keep alive.
+	BlobIDs []string `json:"blob_ids,omitempty"`
+	// RPCURL is the Sui JSON-RPC endpoint; required with Vaults.
+	RPCURL        string `json:"rpc_url,omitempty"`
+	AggregatorURL string `json:"aggregator_url,omitempty"`
+	PublisherURL  string `json:"publisher_url,omitempty"`
+	// Epochs is the lifetime each renewal asks for; default 10.
+	Epochs int `json:"epochs,omitempty"`
+	// RenewEpochsLeft renews a blob once fewer epochs than this are left;
+	// default 2.
+	RenewEpochsLeft int `json:"renew_epochs_left,omitempty"`
+	// EpochHours is the network's epoch length; default 24 (testnet).
+	// Mainnet epochs are 336 hours.
+	EpochHours int `json:"epoch_hours,omitempty"`
+	// IntervalSec is how often blobs are checked; default 3600.
+	IntervalSec int `json:"interval_sec,omitempty"`
+}
+
+// WalrusBlobStatus is served per blob in /sentinel/status.
+type WalrusBlobStatus struct {
+	BlobID  string `json:"blob_id,omitempty"`
+	VaultID string `json:"vault_id,omitempty"`
+	// EndEpoch is the last end epoch the publisher reported.
+	EndEpoch int `json:"end_epoch,omitempty"`
+	// ExpiresAt is a lower bound on the blob's expiry.
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastRenewed *time.Time `json:"last_renewed,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// walrusRenewal re-stores each watched blob before it expires. Storing the
+// same bytes yields the same blob ID, so vaults keep pointing at it; the
+// publisher only buys storage when the blob would not otherwise last the
+// requested epochs.
+type walrusRenewal struct {
+	vaults    []string
+	blobIDs   []string
+	epochs    int
+	renewLeft int
+	epoch     time.Duration
+	interval  time.Duration
+	publisher *walrusClient
+	download  func(blobID string) ([]byte, error)
+	vault     func(vaultID string) (*vaultObject, error)
+	notify    *sentinelNotifier
+	now       func() time.Time
+
+	mu     sync.Mutex
+	status map[string]*WalrusBlobStatus // by vault ID, or blob ID
+	order  []string
+}
+
+// newWalrusRenewal returns nil when renewal is disabled.
+func newWalrusRenewal(cfg *WalrusRenewalConfig, chain *ChainReadConfig, notify *sentinelNotifier) (*walrusRenewal, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	if len(cfg.Vaults) == 0 && len(cfg.BlobIDs) == 0 {
+		return nil, fmt.Errorf("walrus_renewal.vaults and walrus_renewal.blob_ids are empty")
+	}
+	if len(cfg.Vaults) > 0 && strings.TrimSpace(cfg.RPCURL) == "" {
+		return nil, fmt.Errorf("walrus_renewal.rpc_url is required with vaults")
+	}
+	r := &walrusRenewal{
+		blobIDs:   cfg.BlobIDs,
+		epochs:    cfg.Epochs,
+		renewLeft: cfg.RenewEpochsLeft,
+		epoch:     time.Duration(cfg.EpochHours) * time.Hour,
+		interval:  time.Duration(cfg.IntervalSec) * time.Second,
+		notify:    notify,
+		now:       func() time.Time { return time.Now().UTC() },
+		status:    map[string]*WalrusBlobStatus{},
+	}
+	if r.epochs <= 0 {
+		r.epochs = 10
+	}
+	if r.renewLeft <= 0 {
+		r.renewLeft = 2
+	}
+	if r.epoch <= 0 {
+		r.epoch = 24 * time.Hour
+	}
+	if r.interval <= 0 {
+		r.interval = time.Hour
+	}
+	// The current epoch may be nearly over, so a blob stored for N epochs
+	// is only sure to last N-1 of them.
+	if r.epochs-1 <= r.renewLeft {
+		return nil, fmt.Errorf("walrus_renewal.epochs (%d) must exceed renew_epochs_left (%d) by at least 2", r.epochs, r.renewLeft)
+	}
+	aggregator := cfg.AggregatorURL
+	if aggregator == "" {
+		aggregator = "https://aggregator.walrus-testnet.walrus.space"
+	}
+	publisher := cfg.PublisherURL
+	if publisher == "" {
+		publisher = "https://publisher.walrus-testnet.walrus.space"
+	}
+	r.publisher = newWalrusClient(publisher, &http.Client{Timeout: 5 * time.Minute})
+	client := &http.Client{Timeout: 5 * time.Minute}
+	r.download = func(blobID string) ([]byte, error) {
+		return fetchWalrusBlob(client, aggregator, blobID)
+	}
+	for _, id := range cfg.Vaults {
+		r.vaults = append(r.vaults, "0x"+normalizeKeyHex(id))
+	}
+	if len(r.vaults) > 0 {
+		reader := newChainReader(cfg.RPCURL, chain)
+		r.vault = func(vaultID string) (*vaultObject, error) {
+			return fetchVaultObject(reader, vaultID)
+		}
+	}
+	return r, nil
+}
+
+// Check renews every blob that is due, and alerts when a blob cannot be
+// renewed and when it recovers.
+func (r *walrusRenewal) Check() {
+	type target struct{ blobID, vaultID string }
+	var targets []target
+	for _, id := range r.vaults {
+		v, err := r.vault(id)
+		if err != nil {
+			st := &WalrusBlobStatus{VaultID: id}
+			if prev := r.last(id); prev != nil {
+				*st = *prev
+			}
+			r.record(id, st, fmt.Errorf("read vault: %w", err))
+			continue
+		}
+		targets = append(targets, target{v.BlobID, id})
+	}
+	for _, id := range r.blobIDs {
+		targets = append(targets, target{id, ""})
+	}
+	for _, t := range targets {
+		key := t.blobID
+		if t.vaultID != "" {
+			key = t.vaultID
+		}
+		st := &WalrusBlobStatus{BlobID: t.blobID, VaultID: t.vaultID}
+		if prev := r.last(key); prev != nil && prev.BlobID == t.blobID {
+			*st = *prev
+			st.Error = ""
+		}
+		if st.ExpiresAt != nil && r.now().Before(st.ExpiresAt.Add(-time.Duration(r.renewLeft)*r.epoch)) {
+			r.record(key, st, nil)
+			continue
+		}
+		r.record(key, st, r.renew(st))
+	}
+}
+
+func (r *walrusRenewal) last(key string) *WalrusBlobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status[key]
+}
+
+// renew downloads the blob and stores it again for the configured epochs.
+func (r *walrusRenewal) renew(st *WalrusBlobStatus) error {
+	data, err := r.download(st.BlobID)
+	if err != nil {
+		return err
+	}
+	blob, err := r.publisher.Store(data, WalrusStoreOptions{Epochs: r.epochs})
+	if err != nil {
+		return err
+	}
+	if blob.BlobID != st.BlobID {
+		return fmt.Errorf("publisher stored the blob as %s", blob.BlobID)
+	}
+	now := r.now()
+	expires := now.Add(time.Duration(r.epochs-1) * r.epoch)
+	st.EndEpoch, st.ExpiresAt, st.LastRenewed = blob.EndEpoch, &expires, &now
+	return nil
+}
+
+// record stores the blob's status and alerts on a change between failing
+// and healthy.
+func (r *walrusRenewal) record(key string, st *WalrusBlobStatus, err error) {
+	if err != nil {
+		st.Error = err.Error()
+	}
+	r.mu.Lock()
+	prev := r.status[key]
+	if prev == nil {
+		r.order = append(r.order, key)
+	}
+	r.status[key] = st
+	r.mu.Unlock()
+
+	wasFailing := prev != nil && prev.Error != ""
+	switch {
+	case err != nil && !wasFailing:
+		log.Printf("[WALRUS] renewal of %s failed: %v", key, err)
+		r.notify.Send(walrusRenewalNotification(st, r.now()))
+	case err == nil && wasFailing:
+		log.Printf("[WALRUS] renewal of %s recovered", key)
+		r.notify.Send(walrusRenewalNotification(st, r.now()))
+	}
+}
+
+// Status returns every watched blob in the order first seen.
+func (r *walrusRenewal) Status() []WalrusBlobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]WalrusBlobStatus, 0, len(r.order))
+	for _, id := range r.order {
+		out = append(out, *r.status[id])
+	}
+	return out
+}
+
+// Start checks once at startup and then every interval.
+func (r *walrusRenewal) Start() {
+	go func() {
+		r.Check()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			r.Check()
+		}
+	}()
+}
+
+func walrusRenewalNotification(st *WalrusBlobStatus, now time.Time) SentinelNotification {
+	var fields []notifyField
+	if st.BlobID != "" {
+		fields = append(fields, notifyField{"Blob", st.BlobID})
+	}
+	if st.VaultID != "" {
+		fields = append(fields, notifyField{"Vault", st.VaultID})
+	}
+	if st.Error == "" {
+		return SentinelNotification{
+			Event:   notifyWalrusRenewalRecovered,
+			Title:   "Walrus blob renewed",
+			Summary: "The blob is stored for at least " + formatDaysLeft(st.ExpiresAt.Sub(now)) + " again.",
+			Fields:  fields,
+		}
+	}
+	summary := "The blob could not be renewed: " + st.Error + "."
+	if st.ExpiresAt != nil {
+		summary += fmt.Sprintf(" It is stored for at least %s more; after that the vault cannot be recovered.", formatDaysLeft(st.ExpiresAt.Sub(now)))
+	}
+	return SentinelNotification{
+		Event:   notifyWalrusRenewalFailed,
+		Title:   "Walrus blob renewal failed",
+		Summary: summary,
+		Fields:  fields,
+	}
+}
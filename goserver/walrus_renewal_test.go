@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWalrusRenewal(t *testing.T) {
+	var stores, missing int32
+	walrus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			n := atomic.AddInt32(&stores, 1)
+			fmt.Fprintf(w, `{"newlyCreated":{"blobObject":{"id":"0xobj","blobId":%q,"storage":{"endEpoch":%d}}}}`, body, 100+n)
+		case atomic.LoadInt32(&missing) == 1:
+			http.NotFound(w, r)
+		default:
+			io.WriteString(w, r.URL.Path[len("/v1/blobs/"):])
+		}
+	}))
+	defer walrus.Close()
+
+	inbox := newWebhookInbox(t)
+	notify, _ := newSentinelNotifier(inbox.config())
+	r, err := newWalrusRenewal(&WalrusRenewalConfig{
+		Enabled:       true,
+		Vaults:        []string{"0xV1"},
+		BlobIDs:       []string{"extra"},
+		RPCURL:        "http://127.0.0.1:0",
+		AggregatorURL: walrus.URL,
+		PublisherURL:  walrus.URL,
+		Epochs:        5,
+	}, nil, notify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultBlob := "vault-blob"
+	r.vault = func(id string) (*vaultObject, error) {
+		if id != "0xv1" {
+			t.Errorf("unexpected vault %s", id)
+		}
+		return &vaultObject{ID: id, BlobID: vaultBlob}, nil
+	}
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	// The first check confirms both blobs for 5 epochs: 4 full days.
+	r.Check()
+	st := r.Status()
+	if stores != 2 || len(st) != 2 || st[0].VaultID != "0xv1" || st[0].BlobID != "vault-blob" || st[1].BlobID != "extra" {
+		t.Fatalf("unexpected first check (%d stores): %+v", stores, st)
+	}
+	if !st[0].ExpiresAt.Equal(now.Add(96*time.Hour)) || st[0].EndEpoch != 101 || st[0].Error != "" {
+		t.Fatalf("unexpected vault blob status: %+v", st[0])
+	}
+
+	// Nothing is due until two epochs are left.
+	now = now.Add(47 * time.Hour)
+	r.Check()
+	if stores != 2 {
+		t.Fatalf("blobs renewed early: %d stores", stores)
+	}
+	now = now.Add(time.Hour)
+	r.Check()
+	if stores != 4 {
+		t.Fatalf("due blobs were not renewed: %d stores", stores)
+	}
+
+	// A new vault blob is confirmed at once.
+	vaultBlob = "vault-blob-2"
+	r.Check()
+	if st := r.Status(); stores != 5 || st[0].BlobID != "vault-blob-2" {
+		t.Fatalf("new vault blob not renewed (%d stores): %+v", stores, st[0])
+	}
+
+	// A blob the aggregator no longer serves alerts once, then recovers.
+	atomic.StoreInt32(&missing, 1)
+	now = now.Add(72 * time.Hour)
+	r.Check()
+	r.Check()
+	if st := r.Status(); st[0].Error == "" || st[1].Error == "" || st[0].ExpiresAt == nil {
+		t.Fatalf("failures not recorded: %+v", st)
+	}
+	atomic.StoreInt32(&missing, 0)
+	r.Check()
+	if st := r.Status(); st[0].Error != "" || st[1].Error != "" {
+		t.Fatalf("recovery not recorded: %+v", st)
+	}
+	titles := inbox.wait(t, 4)
+	want := map[string]int{"Walrus blob renewal failed": 2, "Walrus blob renewed": 2}
+	for _, title := range titles {
+		want[title]--
+	}
+	if len(titles) != 4 || want["Walrus blob renewal failed"] != 0 || want["Walrus blob renewed"] != 0 {
+		t.Fatalf("unexpected alerts: %v", titles)
+	}
+
+	if _, err := newWalrusRenewal(&WalrusRenewalConfig{Enabled: true, BlobIDs: []string{"b"}, Epochs: 3}, nil, nil); err == nil {
+		t.Fatal("epochs too close to renew_epochs_left should be rejected")
+	}
+	if _, err := newWalrusRenewal(&WalrusRenewalConfig{Enabled: true, Vaults: []string{"0x1"}}, nil, nil); err == nil {
+		t.Fatal("vaults without rpc_url should be rejected")
+	}
+}
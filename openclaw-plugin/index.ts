@@ -2,8 +2,9 @@
  * Sentinel Guard — OpenClaw Plugin
  *
  * Registers a `sentinel_gate` agent tool that calls the Sentinel proxy
- * to evaluate actions before execution. Also injects safety rules via
- * an agent:bootstrap hook.
+ * to evaluate actions before execution, reports every tool call of a
+ * running task through a before_tool_call hook, and injects safety rules
+ * via an agent:bootstrap hook.
  */
 
 import { Type } from "@sinclair/typebox";
@@ -47,6 +48,43 @@ async function callSentinelGate(
   return res.json() as Promise<SentinelGateResponse>;
 }
 
+interface SentinelStepResponse extends SentinelGateResponse {
+  reason: string;
+  task_id: string;
+  step: number;
+  abort: boolean;
+}
+
+async function callSentinelStep(
+  sentinelUrl: string,
+  taskId: string,
+  tool: string,
+  params: unknown,
+  agentId?: string
+): Promise<SentinelStepResponse> {
+  const body: Record<string, string> = {
+    task_id: taskId,
+    tool,
+    prompt: `${tool} ${JSON.stringify(params ?? {})}`,
+  };
+  if (agentId) body.agent_id = agentId;
+
+  const res = await fetch(`${sentinelUrl}/sentinel/openclaw/step`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+    signal: AbortSignal.timeout(FETCH_TIMEOUT_MS),
+  });
+
+  // 403 carries a decision (kill switch, sandbox) like a 200 does.
+  if (!res.ok && res.status !== 403) {
+    const text = await res.text();
+    throw new Error(`Sentinel step returned ${res.status}: ${text}`);
+  }
+
+  return res.json() as Promise<SentinelStepResponse>;
+}
+
 async function callSentinelStatus(sentinelUrl: string): Promise<unknown> {
   const res = await fetch(`${sentinelUrl}/sentinel/status`, {
     signal: AbortSignal.timeout(FETCH_TIMEOUT_MS),
@@ -57,13 +95,16 @@ async function callSentinelStatus(sentinelUrl: string): Promise<unknown> {
 
 async function callSentinelApprovalConfirm(
   sentinelUrl: string,
+  operatorToken: string,
   challengeId: string,
   approved: boolean,
   decidedBy: string
 ): Promise<unknown> {
+  const headers: Record<string, string> = { "Content-Type": "application/json" };
+  if (operatorToken) headers.Authorization = `Bearer ${operatorToken}`;
   const res = await fetch(`${sentinelUrl}/sentinel/approval/confirm`, {
     method: "POST",
-    headers: { "Content-Type": "application/json" },
+    headers,
     body: JSON.stringify({
       challenge_id: challengeId,
       approved,
@@ -81,6 +122,9 @@ async function callSentinelApprovalConfirm(
 export default function register(api: any) {
   const cfg = api.config?.plugins?.entries?.["sentinel-guard"]?.config ?? {};
   const sentinelUrl = cfg.sentinelUrl || DEFAULT_SENTINEL_URL;
+  // Confirming a challenge needs the proxy's operator token. Without it the
+  // agent cannot approve its own challenges.
+  const operatorToken: string = cfg.operatorToken || "";
 
   // ── Tool 1: sentinel_gate ─────────────────────────────────────────
   // The agent calls this BEFORE executing any risky action.
@@ -216,6 +260,7 @@ The gate returns a decision:
       try {
         const result = await callSentinelApprovalConfirm(
           sentinelUrl,
+          operatorToken,
           params.challenge_id,
           params.approved,
           params.decided_by
@@ -239,6 +284,40 @@ The gate returns a decision:
     },
   });
 
+  // ── Hook: before_tool_call — gate every step of a running task ────
+  // The agent is asked to call sentinel_gate itself; this hook does not
+  // rely on that. Each tool call is reported to Sentinel before it runs,
+  // and once a step is blocked every later step of the task is refused.
+  if (api.on) {
+    api.on("before_tool_call", async (event: any, ctx: any) => {
+      const tool: string = event?.toolName ?? "";
+      if (tool.startsWith("sentinel_")) return;
+      const taskId: string = ctx?.sessionKey ?? ctx?.runId ?? "default";
+      try {
+        const result = await callSentinelStep(
+          sentinelUrl,
+          taskId,
+          tool,
+          event?.params,
+          ctx?.agentId
+        );
+        if (result.decision === "ALLOW") return;
+        const verdict = result.abort
+          ? "Sentinel aborted this task"
+          : `Sentinel requires approval (challenge ${result.challenge_id})`;
+        return {
+          block: true,
+          blockReason: `${verdict}: ${result.reason}`,
+        };
+      } catch (err: any) {
+        return {
+          block: true,
+          blockReason: `Sentinel step check failed: ${err.message}. Defaulting to BLOCK.`,
+        };
+      }
+    });
+  }
+
   // ── Hook: agent:bootstrap — inject Sentinel guard rules ───────────
   // This tells the agent about Sentinel before every session.
   if (api.registerPluginHooksFromDir) {
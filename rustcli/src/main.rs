@@ -15,9 +15,13 @@ use std::path::PathBuf;
 #[derive(Parser, Debug)]
 #[command(author, version, about, long_about = None)]
 struct Args {
+    /// Print supported subcommands as JSON and exit
+    #[arg(long)]
+    capabilities: bool,
+
     /// Command to execute
     #[command(subcommand)]
-    command: Commands,
+    command: Option<Commands>,
 }
 
 #[derive(Subcommand, Debug)]
@@ -72,6 +76,15 @@ enum Commands {
         reason: String,
         #[arg(long)]
         timestamp: String,
+        /// Hash of the previous record in the audit log (hash chain)
+        #[arg(long, default_value = "")]
+        prev_hash: String,
+        /// Count of identical violations a collapsed record stands for
+        #[arg(long, default_value_t = 0)]
+        occurrences: u64,
+        /// Hash of the first record of a collapsed violation streak
+        #[arg(long, default_value = "")]
+        dedup_of: String,
     },
 
     /// Sign an audit hash with ed25519 private key (hex 32-byte seed)
@@ -110,14 +123,36 @@ struct AuditSignOutput {
     public_key: String,
 }
 
+/// Capability report consumed by the Go daemon to negotiate features
+#[derive(Serialize, Deserialize, Debug)]
+struct CapabilitiesOutput {
+    name: String,
+    version: String,
+    commands: Vec<String>,
+}
+
+/// Subcommands advertised via --capabilities; keep in sync with `Commands`
+const SUPPORTED_COMMANDS: &[&str] = &["encrypt-and-store", "decrypt", "hash-audit", "sign-audit"];
+
+/// Hashed as RFC 8785 (JCS) canonical JSON. Fields are declared in sorted
+/// key order so serde_json's compact output is already canonical; the Go
+/// guard (jcsAuditHash) produces the same bytes.
 #[derive(Serialize, Deserialize, Debug)]
 struct CanonicalAuditRecord {
     action: String,
+    decision: String,
+    // dedup_of, occurrences and prev_hash are omitted when empty so records
+    // without them keep a stable form.
+    #[serde(skip_serializing_if = "String::is_empty")]
+    dedup_of: String,
+    #[serde(skip_serializing_if = "is_zero")]
+    occurrences: u64,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    prev_hash: String,
     prompt: String,
+    reason: String,
     score: u8,
     tags: Vec<String>,
-    decision: String,
-    reason: String,
     timestamp: String,
 }
 
@@ -157,7 +192,15 @@ impl WalrusResponse {
 fn main() -> Result<()> {
     let args = Args::parse();
 
-    match args.command {
+    if args.capabilities {
+        return print_capabilities();
+    }
+
+    let Some(command) = args.command else {
+        anyhow::bail!("a subcommand is required (see --help)");
+    };
+
+    match command {
         Commands::EncryptAndStore {
             file,
             publisher,
@@ -181,8 +224,22 @@ fn main() -> Result<()> {
             decision,
             reason,
             timestamp,
+            prev_hash,
+            occurrences,
+            dedup_of,
         } => {
-            hash_audit(action, prompt, score, tags, decision, reason, timestamp)?;
+            hash_audit(
+                action,
+                prompt,
+                score,
+                tags,
+                decision,
+                reason,
+                timestamp,
+                prev_hash,
+                occurrences,
+                dedup_of,
+            )?;
         }
         Commands::SignAudit {
             record_hash,
@@ -195,6 +252,16 @@ fn main() -> Result<()> {
     Ok(())
 }
 
+fn print_capabilities() -> Result<()> {
+    let output = CapabilitiesOutput {
+        name: env!("CARGO_PKG_NAME").to_string(),
+        version: env!("CARGO_PKG_VERSION").to_string(),
+        commands: SUPPORTED_COMMANDS.iter().map(|c| c.to_string()).collect(),
+    };
+    println!("{}", serde_json::to_string_pretty(&output)?);
+    Ok(())
+}
+
 /// Download ciphertext from Walrus and decrypt to a local file.
 fn decrypt_blob(
     blob_id: &str,
@@ -421,6 +488,7 @@ async fn upload_to_walrus_async(data: &[u8], publisher_url: &str, epochs: u64) -
         .ok_or_else(|| anyhow::anyhow!("Walrus response missing blob ID"))
 }
 
+#[allow(clippy::too_many_arguments)]
 fn hash_audit(
     action: String,
     prompt: String,
@@ -429,6 +497,9 @@ fn hash_audit(
     decision: String,
     reason: String,
     timestamp: String,
+    prev_hash: String,
+    occurrences: u64,
+    dedup_of: String,
 ) -> Result<()> {
     let mut parsed_tags: Vec<String> = tags
         .split(',')
@@ -439,24 +510,35 @@ fn hash_audit(
 
     let record = CanonicalAuditRecord {
         action,
+        decision,
+        dedup_of,
+        occurrences,
+        prev_hash,
         prompt,
+        reason,
         score,
         tags: parsed_tags,
-        decision,
-        reason,
         timestamp,
     };
 
-    let canonical = serde_json::to_string(&record)?;
-    let mut hasher = Sha256::new();
-    hasher.update(canonical.as_bytes());
-    let record_hash = format!("0x{}", hex::encode(hasher.finalize()));
-
-    let output = AuditHashOutput { record_hash };
+    let output = AuditHashOutput {
+        record_hash: audit_record_hash(&record)?,
+    };
     println!("{}", serde_json::to_string_pretty(&output)?);
     Ok(())
 }
 
+fn is_zero(n: &u64) -> bool {
+    *n == 0
+}
+
+fn audit_record_hash(record: &CanonicalAuditRecord) -> Result<String> {
+    let canonical = serde_json::to_string(record)?;
+    let mut hasher = Sha256::new();
+    hasher.update(canonical.as_bytes());
+    Ok(format!("0x{}", hex::encode(hasher.finalize())))
+}
+
 fn sign_audit(record_hash: &str, private_key_hex: &str) -> Result<()> {
     let hash_bytes = hex::decode(record_hash.trim_start_matches("0x"))
         .context("record_hash must be a hex string")?;
@@ -520,6 +602,54 @@ mod tests {
         assert_eq!(encoded.len(), 88);
     }
 
+    #[test]
+    fn test_capabilities_match_subcommands() {
+        use clap::CommandFactory;
+        let cmd = Args::command();
+        let mut names: Vec<String> = cmd
+            .get_subcommands()
+            .map(|c| c.get_name().to_string())
+            .collect();
+        names.sort();
+        let mut advertised: Vec<String> =
+            SUPPORTED_COMMANDS.iter().map(|c| c.to_string()).collect();
+        advertised.sort();
+        assert_eq!(names, advertised);
+    }
+
+    // The vector is shared with the Go guard's TestJCSAuditHashVector.
+    #[test]
+    fn test_hash_audit_is_jcs() {
+        let mut record = CanonicalAuditRecord {
+            action: "EXEC".to_string(),
+            decision: "blocked".to_string(),
+            dedup_of: String::new(),
+            occurrences: 0,
+            prev_hash: format!("0x{}", "ab".repeat(32)),
+            prompt: "rm -rf / é \"q\" \\ <x>&\n\u{1} ".to_string(),
+            reason: "a\tb".to_string(),
+            score: 95,
+            tags: vec!["destructive".to_string(), "shell".to_string()],
+            timestamp: "2026-10-16T08:00:00.123456789Z".to_string(),
+        };
+        assert_eq!(
+            audit_record_hash(&record).unwrap(),
+            "0xc73a111cf934b0e0d02476fb51d7392e2a9ef6f0ba442efc94361ddcef112b83"
+        );
+        record.prev_hash.clear();
+        assert_eq!(
+            audit_record_hash(&record).unwrap(),
+            "0xa2effd527a227c3d39e6cc8ab08528d8d474462d39c6232cbec6a47103e6a32f"
+        );
+        record.prev_hash = format!("0x{}", "ab".repeat(32));
+        record.occurrences = 12;
+        record.dedup_of = format!("0x{}", "cd".repeat(32));
+        assert_eq!(
+            audit_record_hash(&record).unwrap(),
+            "0xd560797799a6eb6d8fba719824be6241ef65401dcd9e2f082481132bd5655db1"
+        );
+    }
+
     #[test]
     fn test_key_decoding_roundtrip() {
         let key = [7u8; 32];
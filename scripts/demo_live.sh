@@ -11,6 +11,13 @@ GOSERVER="$ROOT/goserver"
 ADDR="127.0.0.1:18080"
 BASE="http://$ADDR"
 PROXY_PID=""
+export SENTINEL_OPERATOR_TOKEN="${SENTINEL_OPERATOR_TOKEN:-demo-$(od -An -N16 -tx1 /dev/urandom | tr -d ' \n')}"
+
+# operator_auth prints the operator token header for curl's -H @file, so the
+# token never appears on a command line.
+operator_auth() {
+  printf 'Authorization: Bearer %s\n' "$SENTINEL_OPERATOR_TOKEN"
+}
 
 # ── Colors ────────────────────────────────────────────────────
 G='\033[0;32m' R='\033[0;31m' Y='\033[0;33m' C='\033[0;36m' B='\033[1m' N='\033[0m'
@@ -63,7 +70,7 @@ fi
 ok "Proxy running (PID $PROXY_PID)"
 
 # Ensure clean state
-curl -sf -X POST "$BASE/sentinel/kill-switch/disarm" > /dev/null 2>&1 || true
+curl -sf -X POST -H @<(operator_auth) "$BASE/sentinel/kill-switch/disarm" > /dev/null 2>&1 || true
 
 # ── 1. ALLOW: Low-Risk Action ─────────────────────────────────
 banner "Scenario 1: Low-Risk Action → ALLOW"
@@ -141,7 +148,7 @@ fi
 # ── 3b. Human Approval Flow ───────────────────────────────────
 if [ -n "$CHALLENGE_ID" ]; then
   step "Human operator approves challenge..."
-  APPROVE_RESP=$(curl -sf -X POST "$BASE/sentinel/approval/confirm" \
+  APPROVE_RESP=$(curl -sf -X POST -H @<(operator_auth) "$BASE/sentinel/approval/confirm" \
     -H 'Content-Type: application/json' \
     -d "{\"challenge_id\":\"$CHALLENGE_ID\",\"approved\":true,\"decided_by\":\"human-operator\"}" 2>/dev/null)
   echo "$APPROVE_RESP" | pretty
@@ -158,7 +165,7 @@ fi
 banner "Scenario 4: Emergency Kill Switch"
 step "Arming kill switch..."
 
-ARM_RESP=$(curl -sf -X POST "$BASE/sentinel/kill-switch/arm" \
+ARM_RESP=$(curl -sf -X POST -H @<(operator_auth) "$BASE/sentinel/kill-switch/arm" \
   -H 'Content-Type: application/json' \
   -d '{"reason":"emergency shutdown demo"}' 2>/dev/null)
 echo "$ARM_RESP" | pretty
@@ -178,7 +185,7 @@ else
 fi
 
 step "Disarming kill switch..."
-curl -sf -X POST "$BASE/sentinel/kill-switch/disarm" > /dev/null 2>&1
+curl -sf -X POST -H @<(operator_auth) "$BASE/sentinel/kill-switch/disarm" > /dev/null 2>&1
 ok "Kill switch disarmed"
 
 # ── 5. Proof Chain Verification ────────────────────────────────
@@ -23,6 +23,13 @@ curl_local() {
     curl -sS "$@"
 }
 
+# operator_auth prints the operator token header for curl's -H @file, so the
+# token never appears on a command line.
+operator_auth() {
+  printf 'Authorization: Bearer %s\n' "$SENTINEL_OPERATOR_TOKEN"
+}
+: "${SENTINEL_OPERATOR_TOKEN:?export the operator token of the proxy as SENTINEL_OPERATOR_TOKEN}"
+
 banner() {
   echo ""
   echo -e "${CYAN}═══════════════════════════════════════════════════════════════${RESET}"
@@ -69,6 +76,7 @@ echo ""
 echo -e "${DIM}  确保 Sentinel proxy 已在另一个终端启动:${RESET}"
 echo -e "${DIM}  cd goserver && go run . --config configs/config.openclaw.json \\${RESET}"
 echo -e "${DIM}    --sentinel-proxy --sentinel-proxy-addr 127.0.0.1:18080${RESET}"
+echo -e "${DIM}  两个终端需 export 同一个 SENTINEL_OPERATOR_TOKEN${RESET}"
 pause
 
 # Check health first
@@ -81,7 +89,7 @@ fi
 success "Sentinel proxy 在线"
 
 # Reset state: disarm kill switch
-curl_local -X POST "$SENTINEL_URL/sentinel/kill-switch/disarm" > /dev/null 2>&1 || true
+curl_local -X POST -H @<(operator_auth) "$SENTINEL_URL/sentinel/kill-switch/disarm" > /dev/null 2>&1 || true
 
 # ════════════════════════════════════════════════════════════════════════════
 # Scene 1: Health Check
@@ -195,7 +203,7 @@ echo -e "${DIM}  操作员审查后决定 approve...${RESET}"
 echo ""
 
 if [ -n "$CHALLENGE_ID" ]; then
-  CONFIRM_RESP=$(curl_local -X POST "$SENTINEL_URL/sentinel/approval/confirm" \
+  CONFIRM_RESP=$(curl_local -X POST -H @<(operator_auth) "$SENTINEL_URL/sentinel/approval/confirm" \
     -H 'Content-Type: application/json' \
     -d "{\"challenge_id\":\"$CHALLENGE_ID\",\"approved\":true,\"decided_by\":\"human-operator\"}")
 
@@ -216,7 +224,7 @@ step "手动激活 Kill Switch"
 echo -e "${DIM}  紧急情况下，操作员可一键封锁所有 Agent 操作${RESET}"
 echo ""
 
-run_cmd "curl_local -X POST $SENTINEL_URL/sentinel/kill-switch/arm \
+run_cmd "curl_local -X POST -H @<(operator_auth) $SENTINEL_URL/sentinel/kill-switch/arm \
   -H 'Content-Type: application/json' \
   -d '{\"reason\":\"emergency shutdown demo\"}' | jq ."
 
@@ -241,7 +249,7 @@ pause
 
 step "解除 Kill Switch，恢复正常"
 echo ""
-run_cmd "curl_local -X POST $SENTINEL_URL/sentinel/kill-switch/disarm | jq ."
+run_cmd "curl_local -X POST -H @<(operator_auth) $SENTINEL_URL/sentinel/kill-switch/disarm | jq ."
 success "Kill Switch 已解除，恢复正常运行"
 pause
 
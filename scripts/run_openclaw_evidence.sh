@@ -17,6 +17,13 @@ curl_local() {
     curl -sS "$@"
 }
 
+# operator_auth prints the operator token header for curl's -H @file, so the
+# token never appears on a command line.
+operator_auth() {
+  printf 'Authorization: Bearer %s\n' "$SENTINEL_OPERATOR_TOKEN"
+}
+: "${SENTINEL_OPERATOR_TOKEN:?export the operator token of the proxy as SENTINEL_OPERATOR_TOKEN}"
+
 run_openclaw_sentinel() {
   env -u http_proxy -u https_proxy -u HTTP_PROXY -u HTTPS_PROXY -u ALL_PROXY -u all_proxy \
     "$OPENCLAW_BIN" --no-color sentinel "$@"
@@ -33,13 +40,14 @@ curl_local --max-time 3 http://127.0.0.1:18080/health > /dev/null || {
   echo "Sentinel proxy is not reachable on 127.0.0.1:18080" >&2
   echo "Start it first:" >&2
   echo "  cd goserver && env -u GOROOT GOCACHE=/tmp/go-build-cache-lazarus go run . --config configs/config.openclaw.json --sentinel-proxy --sentinel-proxy-addr 127.0.0.1:18080" >&2
+  echo "with the same SENTINEL_OPERATOR_TOKEN exported in both shells." >&2
   exit 1
 }
 
 echo "Running OpenClaw evidence flow..."
 
 # Ensure clean starting state.
-curl_local -X POST http://127.0.0.1:18080/sentinel/kill-switch/disarm > "$OUT_DIR/00-disarm-before.json"
+curl_local -X POST -H @<(operator_auth) http://127.0.0.1:18080/sentinel/kill-switch/disarm > "$OUT_DIR/00-disarm-before.json"
 
 run_openclaw_sentinel status > "$OUT_DIR/01-status-before.raw.txt" 2>&1
 extract_json "$OUT_DIR/01-status-before.raw.txt" "$OUT_DIR/01-status-before.json"
@@ -57,12 +65,12 @@ if [ -z "$CHALLENGE_ID" ] || [ "$CHALLENGE_ID" = "null" ]; then
 fi
 echo "$CHALLENGE_ID" > "$OUT_DIR/challenge_id.txt"
 
-curl_local -X POST http://127.0.0.1:18080/sentinel/approval/confirm \
+curl_local -X POST -H @<(operator_auth) http://127.0.0.1:18080/sentinel/approval/confirm \
   -H "Content-Type: application/json" \
   -d "{\"challenge_id\":\"$CHALLENGE_ID\",\"approved\":true,\"decided_by\":\"zihe\"}" \
   > "$OUT_DIR/04-approval-confirm.json"
 
-curl_local -X POST http://127.0.0.1:18080/sentinel/kill-switch/arm \
+curl_local -X POST -H @<(operator_auth) http://127.0.0.1:18080/sentinel/kill-switch/arm \
   -H "Content-Type: application/json" \
   -d "{\"reason\":\"hackathon_evidence\"}" \
   > "$OUT_DIR/05-kill-switch-arm.json"
@@ -72,7 +80,7 @@ extract_json "$OUT_DIR/06-status-after-arm.raw.txt" "$OUT_DIR/06-status-after-ar
 
 curl_local http://127.0.0.1:18080/sentinel/proof/latest > "$OUT_DIR/07-proof-latest.json"
 
-curl_local -X POST http://127.0.0.1:18080/sentinel/kill-switch/disarm > "$OUT_DIR/08-disarm-after.json"
+curl_local -X POST -H @<(operator_auth) http://127.0.0.1:18080/sentinel/kill-switch/disarm > "$OUT_DIR/08-disarm-after.json"
 
 run_openclaw_sentinel status > "$OUT_DIR/09-status-final.raw.txt" 2>&1
 extract_json "$OUT_DIR/09-status-final.raw.txt" "$OUT_DIR/09-status-final.json"